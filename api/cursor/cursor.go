@@ -0,0 +1,181 @@
+// Package cursor provides opaque, tamper-proof pagination cursors.
+//
+// A cursor has the form "v2.<payload>.<signature>", where the payload is the base64url
+// encoded JSON position of the last seen expense together with the sort specification
+// (e.g. "amount.desc,date.asc") and a hash of the filter it was issued for, and the
+// signature is an HMAC-SHA256 of "v2.<payload>" under a server key. A cursor is only
+// accepted for the exact listing it was issued for.
+//
+// Cursors issued before signing was introduced ("v1", base64 of "id,value") can still be
+// decoded while legacy support is enabled, so clients holding one can finish paging. Legacy
+// support is a temporary migration switch, off by default, and even when it is on, legacy
+// cursors are only accepted for the default listing: sorted by date descending, unfiltered.
+package cursor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	errapi "github.com/beka-birhanu/finance-go/api/error"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	// Version is the version of the cursors issued by the codec.
+	Version = 2
+
+	// LegacyVersion is the version of the unsigned cursors.
+	LegacyVersion = 1
+
+	versionPrefix = "v2."
+
+	// legacySort is the sort specification of the only listing legacy cursors are accepted
+	// for: the default sort, by date descending, which legacy cursors were mostly issued for.
+	legacySort = "date.desc"
+)
+
+// legacyFilterHash is the filter hash of the only listing legacy cursors are accepted for:
+// the unfiltered one.
+var legacyFilterHash = HashFilter(irepository.ExpenseFilter{})
+
+// Cursor is the decoded position of the last seen expense of a listing.
+type Cursor struct {
+	Version    int       // Format version the cursor was issued with
+	Sort       string    // Sort specification of the listing, e.g. "amount.desc,date.asc"
+	FilterHash string    // Hash of the filter the listing was issued for
+	LastSeenID uuid.UUID // ID of the last seen expense
+	Values     []string  // Sort key values of the last seen expense, one per sort key
+}
+
+// payload is the signed JSON representation of a Cursor.
+type payload struct {
+	Sort       string    `json:"s"`
+	FilterHash string    `json:"h"`
+	LastSeenID uuid.UUID `json:"id"`
+	Values     []string  `json:"v"`
+}
+
+// Codec encodes and decodes signed cursors.
+type Codec struct {
+	key          []byte
+	acceptLegacy bool
+}
+
+// Config holds the configuration for creating a new Codec.
+type Config struct {
+	Secret       string // Key used to sign cursors
+	AcceptLegacy bool   // Whether unsigned v1 cursors are still accepted; a temporary migration switch
+}
+
+// NewCodec creates a new Codec with the given configuration.
+func NewCodec(config Config) *Codec {
+	return &Codec{
+		key:          []byte(config.Secret),
+		acceptLegacy: config.AcceptLegacy,
+	}
+}
+
+// Encode returns the signed, opaque representation of the cursor.
+func (c *Codec) Encode(cur Cursor) string {
+	data, _ := json.Marshal(payload{
+		Sort:       cur.Sort,
+		FilterHash: cur.FilterHash,
+		LastSeenID: cur.LastSeenID,
+		Values:     cur.Values,
+	})
+
+	signed := versionPrefix + base64.RawURLEncoding.EncodeToString(data)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(c.sign(signed))
+}
+
+// Decode verifies the token and returns the cursor it holds. It returns a bad request
+// error if the token is malformed, was tampered with, or was issued for a different sort
+// or filter than the expected ones.
+func (c *Codec) Decode(token, sort, filterHash string) (*Cursor, error) {
+	if !strings.HasPrefix(token, versionPrefix) {
+		if c.acceptLegacy {
+			return decodeLegacy(token, sort, filterHash)
+		}
+		return nil, errapi.NewBadRequest("invalid cursor: unsupported cursor version")
+	}
+
+	lastDot := strings.LastIndex(token, ".")
+	if lastDot <= len(versionPrefix) {
+		return nil, errapi.NewBadRequest("invalid cursor: malformed cursor")
+	}
+
+	signed, encodedSignature := token[:lastDot], token[lastDot+1:]
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, c.sign(signed)) {
+		return nil, errapi.NewBadRequest("invalid cursor: signature mismatch")
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(signed[len(versionPrefix):])
+	if err != nil {
+		return nil, errapi.NewBadRequest("invalid cursor: malformed cursor")
+	}
+
+	var p payload
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, errapi.NewBadRequest("invalid cursor: malformed cursor")
+	}
+
+	switch {
+	case p.Sort != sort:
+		return nil, errapi.NewBadRequest(fmt.Sprintf("invalid cursor: issued for sort %q, not %q", p.Sort, sort))
+	case p.FilterHash != filterHash:
+		return nil, errapi.NewBadRequest("invalid cursor: issued for a different filter")
+	}
+
+	return &Cursor{
+		Version:    Version,
+		Sort:       p.Sort,
+		FilterHash: p.FilterHash,
+		LastSeenID: p.LastSeenID,
+		Values:     p.Values,
+	}, nil
+}
+
+// sign computes the HMAC-SHA256 of the data under the codec key.
+func (c *Codec) sign(data string) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// decodeLegacy decodes an unsigned v1 cursor. Such cursors carry no listing information and
+// cannot be verified, so they are only trusted for the default listing, sorted by date
+// descending without filters; a bad request error is returned for any other listing.
+func decodeLegacy(token, sort, filterHash string) (*Cursor, error) {
+	if sort != legacySort || filterHash != legacyFilterHash {
+		return nil, errapi.NewBadRequest(fmt.Sprintf("invalid cursor: unsigned cursors are only accepted for sort %q without filters", legacySort))
+	}
+
+	data, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errapi.NewBadRequest("invalid cursor: malformed cursor")
+	}
+
+	parts := strings.Split(string(data), ",")
+	if len(parts) != 2 {
+		return nil, errapi.NewBadRequest("invalid cursor: malformed cursor")
+	}
+
+	lastSeenID, err := uuid.Parse(parts[0])
+	if err != nil {
+		return nil, errapi.NewBadRequest("invalid cursor: malformed cursor")
+	}
+
+	return &Cursor{
+		Version:    LegacyVersion,
+		Sort:       sort,
+		FilterHash: filterHash,
+		LastSeenID: lastSeenID,
+		Values:     []string{parts[1]},
+	}, nil
+}
@@ -0,0 +1,106 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	errapi "github.com/beka-birhanu/finance-go/api/error"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	"github.com/google/uuid"
+)
+
+func TestCodec(t *testing.T) {
+	codec := NewCodec(Config{Secret: "secret", AcceptLegacy: true})
+	strictCodec := NewCodec(Config{Secret: "secret", AcceptLegacy: false})
+
+	lastSeenID := uuid.New()
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	filterHash := HashFilter(irepository.ExpenseFilter{DateFrom: &from, Category: "groceries"})
+
+	token := codec.Encode(Cursor{
+		Sort:       "amount.desc,date.asc",
+		FilterHash: filterHash,
+		LastSeenID: lastSeenID,
+		Values:     []string{"279.70", "2024-06-08T08:00:00Z"},
+	})
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		cur, err := codec.Decode(token, "amount.desc,date.asc", filterHash)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if cur.Version != Version || cur.LastSeenID != lastSeenID || len(cur.Values) != 2 || cur.Values[0] != "279.70" {
+			t.Errorf("unexpected cursor: %+v", cur)
+		}
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		sort := "amount.desc,date.asc"
+		cases := []struct {
+			name       string
+			token      string
+			sort       string
+			filterHash string
+		}{
+			{name: "sort field", token: token, sort: "date.desc", filterHash: filterHash},
+			{name: "sort order", token: token, sort: "amount.asc,date.asc", filterHash: filterHash},
+			{name: "secondary sort key", token: token, sort: "amount.desc", filterHash: filterHash},
+			{name: "filter", token: token, sort: sort, filterHash: HashFilter(irepository.ExpenseFilter{})},
+			{name: "tampered payload", token: tamper(token), sort: sort, filterHash: filterHash},
+			{name: "other key", token: NewCodec(Config{Secret: "other"}).Encode(Cursor{Sort: sort, FilterHash: filterHash}), sort: sort, filterHash: filterHash},
+			{name: "garbage", token: "v2.not-a-cursor", sort: sort, filterHash: filterHash},
+		}
+
+		for _, c := range cases {
+			_, err := codec.Decode(c.token, c.sort, c.filterHash)
+			apiErr, ok := err.(errapi.Error)
+			if !ok || apiErr.StatusCode() != errapi.BadRequest {
+				t.Errorf("%s: expected bad request error, got %v", c.name, err)
+			}
+		}
+	})
+
+	t.Run("Legacy", func(t *testing.T) {
+		legacy := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s,%s", lastSeenID, "2024-06-08T08:00:00Z")))
+		unfiltered := HashFilter(irepository.ExpenseFilter{})
+
+		cur, err := codec.Decode(legacy, "date.desc", unfiltered)
+		if err != nil {
+			t.Fatalf("expected legacy cursor to be accepted, got %v", err)
+		}
+		if cur.Version != LegacyVersion || cur.LastSeenID != lastSeenID {
+			t.Errorf("unexpected legacy cursor: %+v", cur)
+		}
+
+		cases := []struct {
+			name       string
+			codec      *Codec
+			sort       string
+			filterHash string
+		}{
+			{name: "legacy support off", codec: strictCodec, sort: "date.desc", filterHash: unfiltered},
+			{name: "other sort", codec: codec, sort: "amount.desc", filterHash: unfiltered},
+			{name: "secondary sort key", codec: codec, sort: "date.desc,amount.asc", filterHash: unfiltered},
+			{name: "filter", codec: codec, sort: "date.desc", filterHash: filterHash},
+		}
+		for _, c := range cases {
+			_, err := c.codec.Decode(legacy, c.sort, c.filterHash)
+			apiErr, ok := err.(errapi.Error)
+			if !ok || apiErr.StatusCode() != errapi.BadRequest {
+				t.Errorf("%s: expected bad request error, got %v", c.name, err)
+			}
+		}
+	})
+}
+
+// tamper rewrites the payload of a token while keeping its signature.
+func tamper(token string) string {
+	parts := strings.Split(token, ".")
+	data, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	data = []byte(strings.Replace(string(data), `"s":"amount.desc`, `"s":"amount.asc`, 1))
+	parts[1] = base64.RawURLEncoding.EncodeToString(data)
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,44 @@
+package cursor
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+)
+
+// HashFilter returns a short, stable hash of the filter, used to tie a cursor to the
+// listing it was issued for.
+func HashFilter(filter irepository.ExpenseFilter) string {
+	fields := []string{
+		formatTime(filter.DateFrom),
+		formatTime(filter.DateTo),
+		formatFloat(filter.MinAmount),
+		formatFloat(filter.MaxAmount),
+		strconv.Quote(filter.Description),
+		strconv.Quote(filter.Category),
+		strconv.Quote(filter.Account),
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(fields, "|")))
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+// formatTime formats an optional time canonically, ignoring its location.
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// formatFloat formats an optional float canonically.
+func formatFloat(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'g', -1, 64)
+}
@@ -3,6 +3,8 @@
 package errapi
 
 import (
+	"time"
+
 	apperror "github.com/beka-birhanu/finance-go/application/error"
 	ierr "github.com/beka-birhanu/finance-go/domain/common/error"
 	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
@@ -10,18 +12,22 @@ import (
 
 // HTTP status codes used in the Error type.
 const (
-	BadRequest     = 400 // Bad Request
-	Conflict       = 409 // Conflict
-	ServerError    = 500 // Internal Server Error
-	Authentication = 401 // Unauthorized
-	Forbidden      = 403 // Forbidden
-	NotFound       = 404 // Not Found
+	BadRequest         = 400 // Bad Request
+	Conflict           = 409 // Conflict
+	ServerError        = 500 // Internal Server Error
+	Authentication     = 401 // Unauthorized
+	Forbidden          = 403 // Forbidden
+	NotFound           = 404 // Not Found
+	Unprocessable      = 422 // Unprocessable Entity
+	PreconditionFailed = 412 // Precondition Failed
+	TooManyRequests    = 429 // Too Many Requests
 )
 
 // Error represents an API error with an associated HTTP status code and message.
 type Error struct {
-	statusCode int    // HTTP status code for the error
-	message    string // Detailed error message
+	statusCode int           // HTTP status code for the error
+	message    string        // Detailed error message
+	retryAfter time.Duration // How long the client should wait before retrying, if it should
 }
 
 // NewBadRequest creates a new Error with a 400 Bad Request status code
@@ -60,6 +66,24 @@ func NewForbidden(message string) Error {
 	return Error{statusCode: Forbidden, message: message}
 }
 
+// NewUnprocessable creates a new Error with a 422 Unprocessable Entity status code
+// and the provided message.
+func NewUnprocessable(message string) Error {
+	return Error{statusCode: Unprocessable, message: message}
+}
+
+// NewPreconditionFailed creates a new Error with a 412 Precondition Failed status code
+// and the provided message.
+func NewPreconditionFailed(message string) Error {
+	return Error{statusCode: PreconditionFailed, message: message}
+}
+
+// NewTooManyRequests creates a new Error with a 429 Too Many Requests status code,
+// the provided message and how long the client should wait before retrying.
+func NewTooManyRequests(message string, retryAfter time.Duration) Error {
+	return Error{statusCode: TooManyRequests, message: message, retryAfter: retryAfter}
+}
+
 // Error returns the error message as a string.
 func (e Error) Error() string {
 	return e.message
@@ -70,6 +94,12 @@ func (e Error) StatusCode() int {
 	return e.statusCode
 }
 
+// RetryAfter returns how long the client should wait before retrying, or zero if the error
+// does not tell.
+func (e Error) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
 func Map(err ierr.IErr) Error {
 	switch err.Type() {
 	case errdmn.NotFound:
@@ -82,6 +112,18 @@ func Map(err ierr.IErr) Error {
 		return NewServerError(err.Error())
 	case apperror.Authentication:
 		return NewAuthentication(err.Error())
+	case apperror.Unprocessable:
+		return NewUnprocessable(err.Error())
+	case apperror.PreconditionFailed:
+		return NewPreconditionFailed(err.Error())
+	case apperror.TooManyRequests:
+		var retryAfter time.Duration
+		if appErr, ok := err.(apperror.Error); ok {
+			retryAfter = appErr.RetryAfter
+		}
+		return NewTooManyRequests(err.Error(), retryAfter)
+	case apperror.Forbidden:
+		return NewForbidden(err.Error())
 	default:
 		return NewServerError("unknown error occurred while patching expense")
 	}
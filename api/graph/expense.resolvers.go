@@ -6,59 +6,160 @@ package graph
 
 import (
 	"context"
+	"errors"
 
+	"github.com/99designs/gqlgen/graphql"
 	errapi "github.com/beka-birhanu/finance-go/api/error"
 	"github.com/beka-birhanu/finance-go/api/graph/model"
 	"github.com/beka-birhanu/finance-go/api/graph/utils"
 	generalUtil "github.com/beka-birhanu/finance-go/api/utils"
+	auth "github.com/beka-birhanu/finance-go/application/authentication/common"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
 	expensecmd "github.com/beka-birhanu/finance-go/application/expense/command"
+	expensedup "github.com/beka-birhanu/finance-go/application/expense/duplicate"
 	expensqry "github.com/beka-birhanu/finance-go/application/expense/query"
 	ierr "github.com/beka-birhanu/finance-go/domain/common/error"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
 	"github.com/google/uuid"
 )
 
 // CreateExpense is the resolver for the createExpense field.
-func (r *mutationResolver) CreateExpense(ctx context.Context, data model.CreateExpenseInput) (*model.Expense, error) {
-	if err := generalUtil.ConfirmUserID(ctx, data.UserID); err != nil {
+func (r *mutationResolver) CreateExpense(ctx context.Context, data model.CreateExpenseInput, idempotencyKey *string) (*model.Expense, error) {
+	if err := generalUtil.ConfirmUserID(ctx, data.UserID, auth.ScopeExpensesWrite); err != nil {
 		return nil, utils.NewGQLError(err.(errapi.Error))
 	}
 
-	expense, err := r.addExpenseHandler.Handle(&expensecmd.AddCommand{
+	command := &expensecmd.AddCommand{
 		UserId:      data.UserID,
 		Date:        data.Date,
 		Description: data.Description,
 		Amount:      data.Amount,
+		Category:    utils.StringValue(data.Category),
+		Account:     utils.StringValue(data.Account),
+	}
+	if data.OnDuplicate != nil {
+		command.OnDuplicate = expensedup.Policy(*data.OnDuplicate)
+	}
+
+	return idempotent(r.idempotencyService, data.UserID, idempotencyKey, "createExpense", data, func() (*model.Expense, error) {
+		result, err := r.addExpenseHandler.Handle(command)
+		if err != nil {
+			var duplicateErr *apperror.DuplicateError
+			if errors.As(err, &duplicateErr) {
+				return nil, utils.NewDuplicateGQLError(duplicateErr)
+			}
+			return nil, utils.NewGQLError(errapi.Map(err.(ierr.IErr)))
+		}
+
+		if len(result.PossibleDuplicates) > 0 {
+			graphql.AddError(ctx, utils.NewDuplicateWarning(result.PossibleDuplicates))
+		}
+		return utils.NewExpense(result.Expense), nil
 	})
-	if err != nil {
-		return nil, utils.NewGQLError(errapi.Map(err.(ierr.IErr)))
+}
+
+// CreateExpenses is the resolver for the createExpenses field.
+func (r *mutationResolver) CreateExpenses(ctx context.Context, data model.CreateExpensesInput, idempotencyKey *string) ([]*model.Expense, error) {
+	if err := generalUtil.ConfirmUserID(ctx, data.UserID, auth.ScopeExpensesWrite); err != nil {
+		return nil, utils.NewGQLError(err.(errapi.Error))
 	}
 
-	return utils.NewExpense(expense), nil
+	items := make([]expensecmd.BatchAddItem, 0, len(data.Expenses))
+	for _, item := range data.Expenses {
+		items = append(items, expensecmd.BatchAddItem{
+			Date:        item.Date,
+			Description: item.Description,
+			Amount:      item.Amount,
+			Category:    utils.StringValue(item.Category),
+			Account:     utils.StringValue(item.Account),
+		})
+	}
+
+	return idempotent(r.idempotencyService, data.UserID, idempotencyKey, "createExpenses", data, func() ([]*model.Expense, error) {
+		expenses, err := r.batchAddExpenseHandler.Handle(&expensecmd.BatchAddCommand{UserId: data.UserID, Items: items})
+		if err != nil {
+			var batchErr *apperror.BatchError
+			if errors.As(err, &batchErr) {
+				return nil, utils.NewBatchGQLError(batchErr)
+			}
+			return nil, utils.NewGQLError(errapi.Map(err.(ierr.IErr)))
+		}
+
+		result := make([]*model.Expense, 0, len(expenses))
+		for _, expense := range expenses {
+			result = append(result, utils.NewExpense(expense))
+		}
+		return result, nil
+	})
 }
 
 // UpdateExpense is the resolver for the updateExpense field.
 func (r *mutationResolver) UpdateExpense(ctx context.Context, data model.UpdateExpenseInput) (*model.Expense, error) {
-	if err := generalUtil.ConfirmUserID(ctx, data.UserID); err != nil {
+	if err := generalUtil.ConfirmUserID(ctx, data.UserID, auth.ScopeExpensesWrite); err != nil {
 		return nil, utils.NewGQLError(err.(errapi.Error))
 	}
 
 	expense, err := r.patchExpenseHandler.Handle(&expensecmd.PatchCommand{
-		Id:          data.ID,
+		Id:              data.ID,
+		UserId:          data.UserID,
+		Date:            data.Date,
+		Description:     data.Description,
+		Amount:          data.Amount,
+		Category:        data.Category,
+		Account:         data.Account,
+		ExpectedVersion: utils.IntPointer(data.ExpectedVersion),
+	})
+	if err != nil {
+		return nil, utils.NewGQLError(errapi.Map(err.(ierr.IErr)))
+	}
+
+	return utils.NewExpense(expense), nil
+}
+
+// UpdateExpenses is the resolver for the updateExpenses field.
+func (r *mutationResolver) UpdateExpenses(ctx context.Context, data model.UpdateExpensesInput) (*model.BulkResult, error) {
+	if err := generalUtil.ConfirmUserID(ctx, data.UserID, auth.ScopeExpensesWrite); err != nil {
+		return nil, utils.NewGQLError(err.(errapi.Error))
+	}
+
+	result, err := r.bulkPatchExpenseHandler.Handle(&expensecmd.BulkPatchCommand{
 		UserId:      data.UserID,
-		Date:        data.Date,
+		Selection:   utils.NewExpenseSelection(data.Ids, data.Filter),
+		DryRun:      data.DryRun != nil && *data.DryRun,
 		Description: data.Description,
 		Amount:      data.Amount,
+		Date:        data.Date,
+		Category:    data.Category,
+		Account:     data.Account,
 	})
 	if err != nil {
 		return nil, utils.NewGQLError(errapi.Map(err.(ierr.IErr)))
 	}
 
-	return utils.NewExpense(expense), nil
+	return utils.NewBulkResult(result), nil
+}
+
+// DeleteExpenses is the resolver for the deleteExpenses field.
+func (r *mutationResolver) DeleteExpenses(ctx context.Context, data model.DeleteExpensesInput) (*model.BulkResult, error) {
+	if err := generalUtil.ConfirmUserID(ctx, data.UserID, auth.ScopeExpensesWrite); err != nil {
+		return nil, utils.NewGQLError(err.(errapi.Error))
+	}
+
+	result, err := r.bulkDeleteExpenseHandler.Handle(&expensecmd.BulkDeleteCommand{
+		UserId:    data.UserID,
+		Selection: utils.NewExpenseSelection(data.Ids, data.Filter),
+		DryRun:    data.DryRun != nil && *data.DryRun,
+	})
+	if err != nil {
+		return nil, utils.NewGQLError(errapi.Map(err.(ierr.IErr)))
+	}
+
+	return utils.NewBulkResult(result), nil
 }
 
 // Expense is the resolver for the expense field.
 func (r *queryResolver) Expense(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.Expense, error) {
-	if err := generalUtil.ConfirmUserID(ctx, userID); err != nil {
+	if err := generalUtil.ConfirmUserID(ctx, userID, auth.ScopeExpensesRead); err != nil {
 		return nil, utils.NewGQLError(err.(errapi.Error))
 	}
 	expense, err := r.getExpenseHandler.Handle(&expensqry.GetQuery{UserId: userID, ExpenseId: id})
@@ -69,41 +170,35 @@ func (r *queryResolver) Expense(ctx context.Context, userID uuid.UUID, id uuid.U
 }
 
 // Expenses is the resolver for the expenses field.
-func (r *queryResolver) Expenses(ctx context.Context, params model.GetMultipleInput) (*model.PaginatedExpenseResponse, error) {
-	if err := generalUtil.ConfirmUserID(ctx, params.UserID); err != nil {
+func (r *queryResolver) Expenses(ctx context.Context, params model.GetMultipleInput) (*model.ExpenseConnection, error) {
+	if err := generalUtil.ConfirmUserID(ctx, params.UserID, auth.ScopeExpensesRead); err != nil {
 		return nil, utils.NewGQLError(err.(errapi.Error))
 	}
 
-	cursor := ""
-	limit := 0
-	sortField := "date"
-	sortOrder := "desc"
-	if params.SortField != nil {
-		sortField = string(*params.SortField)
-	}
-	if params.SortOrder != nil {
-		sortOrder = string(*params.SortOrder)
-	}
-	if params.Cursor != nil {
-		cursor = *params.Cursor
+	sort, err := utils.NewSort(params)
+	if err != nil {
+		return nil, utils.NewGQLError(err.(errapi.Error))
 	}
 
-	if params.Limit != nil {
-
-		limit = int(*params.Limit)
+	token, limit, backward, err := utils.NewPagingArgs(params).Resolve()
+	if err != nil {
+		return nil, utils.NewGQLError(err.(errapi.Error))
 	}
 
-	query, err := generalUtil.ConstructQueryParams(params.UserID, cursor, limit, sortField, sortOrder)
+	filter := utils.NewExpenseFilter(params)
+	query, err := generalUtil.ConstructQueryParams(r.cursorCodec, params.UserID, token, limit, backward, sort, filter)
 	if err != nil {
 		return nil, utils.NewGQLError(errapi.NewBadRequest(err.Error()))
 	}
 
-	expenses, err := r.getMultipleExpenseHandler.Handle(query)
+	page, err := r.getMultipleExpenseHandler.Handle(query)
 	if err != nil {
-		return nil, utils.NewGQLError(err.(errapi.Error))
+		return nil, utils.NewGQLError(errapi.Map(err.(ierr.IErr)))
 	}
 
-	return utils.NewPaginatedExpenseResponse(expenses, sortField), nil
+	return utils.NewExpenseConnection(page, func(e *expensemodel.Expense) string {
+		return generalUtil.BuildCursor(r.cursorCodec, e, sort, filter)
+	}), nil
 }
 
 // Mutation returns MutationResolver implementation.
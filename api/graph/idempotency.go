@@ -0,0 +1,60 @@
+package graph
+
+import (
+	"encoding/json"
+	"log"
+
+	errapi "github.com/beka-birhanu/finance-go/api/error"
+	"github.com/beka-birhanu/finance-go/api/graph/utils"
+	"github.com/beka-birhanu/finance-go/application/idempotency"
+	ierr "github.com/beka-birhanu/finance-go/domain/common/error"
+	"github.com/google/uuid"
+)
+
+// idempotent runs a mutation at most once per idempotency key of the user. The result of
+// the first successful run is stored and returned again when the mutation is repeated with
+// the same key and input; reusing the key with a different input is an error. Failed runs
+// free the key, so that they can be retried. Without a key the mutation simply runs.
+func idempotent[T any](service *idempotency.Service, userId uuid.UUID, key *string, mutation string, input interface{}, run func() (T, error)) (T, error) {
+	var zero T
+	if key == nil {
+		return run()
+	}
+
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return zero, utils.NewGQLError(errapi.NewServerError(err.Error()))
+	}
+
+	stored, err := service.Begin(userId, *key, "graphql "+mutation, payload)
+	if err != nil {
+		return zero, utils.NewGQLError(errapi.Map(err.(ierr.IErr)))
+	}
+	if stored != nil {
+		var result T
+		if err := json.Unmarshal(stored, &result); err != nil {
+			return zero, utils.NewGQLError(errapi.NewServerError(err.Error()))
+		}
+		return result, nil
+	}
+
+	result, err := run()
+	if err != nil {
+		if releaseErr := service.Release(userId, *key); releaseErr != nil {
+			log.Printf("error releasing idempotency key: %v", releaseErr)
+		}
+		return zero, err
+	}
+
+	// Should storing fail, the key stays claimed until it expires rather than risking that a
+	// retry runs the mutation twice.
+	response, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("error encoding idempotent result: %v", err)
+		return result, nil
+	}
+	if err := service.Complete(userId, *key, response); err != nil {
+		log.Printf("error storing idempotent result: %v", err)
+	}
+	return result, nil
+}
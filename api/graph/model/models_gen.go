@@ -11,65 +11,237 @@ import (
 	"github.com/google/uuid"
 )
 
+type BulkResult struct {
+	Matched int64       `json:"matched"`
+	Ids     []uuid.UUID `json:"ids"`
+	DryRun  bool        `json:"dryRun"`
+}
+
 type CreateExpenseInput struct {
+	Description string           `json:"description"`
+	Amount      float32          `json:"amount"`
+	Date        time.Time        `json:"date"`
+	Category    *string          `json:"category,omitempty"`
+	Account     *string          `json:"account,omitempty"`
+	UserID      uuid.UUID        `json:"userId"`
+	OnDuplicate *DuplicatePolicy `json:"onDuplicate,omitempty"`
+}
+
+type CreateExpensesInput struct {
+	UserID   uuid.UUID                  `json:"userId"`
+	Expenses []*CreateExpensesItemInput `json:"expenses"`
+}
+
+type CreateExpensesItemInput struct {
 	Description string    `json:"description"`
 	Amount      float32   `json:"amount"`
 	Date        time.Time `json:"date"`
-	UserID      uuid.UUID `json:"userId"`
+	Category    *string   `json:"category,omitempty"`
+	Account     *string   `json:"account,omitempty"`
+}
+
+type DeleteExpensesInput struct {
+	UserID uuid.UUID           `json:"userId"`
+	Ids    []uuid.UUID         `json:"ids,omitempty"`
+	Filter *ExpenseFilterInput `json:"filter,omitempty"`
+	DryRun *bool               `json:"dryRun,omitempty"`
 }
 
 type Expense struct {
-	ID          uuid.UUID `json:"id"`
-	Description string    `json:"description"`
-	Amount      float32   `json:"amount"`
-	Date        time.Time `json:"date"`
-	UserID      uuid.UUID `json:"userId"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID          uuid.UUID   `json:"id"`
+	Description string      `json:"description"`
+	Amount      float32     `json:"amount"`
+	Kind        ExpenseKind `json:"kind"`
+	Date        time.Time   `json:"date"`
+	Category    *string     `json:"category,omitempty"`
+	Account     *string     `json:"account,omitempty"`
+	UserID      uuid.UUID   `json:"userId"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	UpdatedAt   time.Time   `json:"updatedAt"`
+	Version     int64       `json:"version"`
+}
+
+type ExpenseConnection struct {
+	Edges      []*ExpenseEdge `json:"edges"`
+	PageInfo   *PageInfo      `json:"pageInfo"`
+	TotalCount int64          `json:"totalCount"`
+}
+
+type ExpenseEdge struct {
+	Cursor string   `json:"cursor"`
+	Node   *Expense `json:"node"`
+}
+
+type ExpenseFilterInput struct {
+	DateFrom    *time.Time `json:"dateFrom,omitempty"`
+	DateTo      *time.Time `json:"dateTo,omitempty"`
+	MinAmount   *float64   `json:"minAmount,omitempty"`
+	MaxAmount   *float64   `json:"maxAmount,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	Category    *string    `json:"category,omitempty"`
+	Account     *string    `json:"account,omitempty"`
 }
 
 type GetMultipleInput struct {
-	Cursor    *string    `json:"cursor,omitempty"`
-	Limit     *int64     `json:"limit,omitempty"`
-	SortField *SortField `json:"sortField,omitempty"`
-	SortOrder *SortOrder `json:"sortOrder,omitempty"`
-	UserID    uuid.UUID  `json:"userId"`
+	First         *int64     `json:"first,omitempty"`
+	After         *string    `json:"after,omitempty"`
+	Last          *int64     `json:"last,omitempty"`
+	Before        *string    `json:"before,omitempty"`
+	Cursor        *string    `json:"cursor,omitempty"`
+	Limit         *int64     `json:"limit,omitempty"`
+	SortField     *SortField `json:"sortField,omitempty"`
+	SortOrder     *SortOrder `json:"sortOrder,omitempty"`
+	ThenSortField *SortField `json:"thenSortField,omitempty"`
+	ThenSortOrder *SortOrder `json:"thenSortOrder,omitempty"`
+	UserID        uuid.UUID  `json:"userId"`
+	DateFrom      *time.Time `json:"dateFrom,omitempty"`
+	DateTo        *time.Time `json:"dateTo,omitempty"`
+	MinAmount     *float64   `json:"minAmount,omitempty"`
+	MaxAmount     *float64   `json:"maxAmount,omitempty"`
+	Description   *string    `json:"description,omitempty"`
+	Category      *string    `json:"category,omitempty"`
+	Account       *string    `json:"account,omitempty"`
 }
 
 type Mutation struct {
 }
 
-type PaginatedExpenseResponse struct {
-	Expenses []*Expense `json:"expenses"`
-	Cursor   *string    `json:"cursor,omitempty"`
+type PageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor,omitempty"`
+	EndCursor       *string `json:"endCursor,omitempty"`
 }
 
 type Query struct {
 }
 
 type UpdateExpenseInput struct {
-	Description *string    `json:"description,omitempty"`
-	Amount      *float32   `json:"amount,omitempty"`
-	Date        *time.Time `json:"date,omitempty"`
-	UserID      uuid.UUID  `json:"userId"`
-	ID          uuid.UUID  `json:"id"`
+	Description     *string    `json:"description,omitempty"`
+	Amount          *float32   `json:"amount,omitempty"`
+	Date            *time.Time `json:"date,omitempty"`
+	Category        *string    `json:"category,omitempty"`
+	Account         *string    `json:"account,omitempty"`
+	ExpectedVersion *int64     `json:"expectedVersion,omitempty"`
+	UserID          uuid.UUID  `json:"userId"`
+	ID              uuid.UUID  `json:"id"`
+}
+
+type UpdateExpensesInput struct {
+	UserID      uuid.UUID           `json:"userId"`
+	Ids         []uuid.UUID         `json:"ids,omitempty"`
+	Filter      *ExpenseFilterInput `json:"filter,omitempty"`
+	DryRun      *bool               `json:"dryRun,omitempty"`
+	Description *string             `json:"description,omitempty"`
+	Amount      *float32            `json:"amount,omitempty"`
+	Date        *time.Time          `json:"date,omitempty"`
+	Category    *string             `json:"category,omitempty"`
+	Account     *string             `json:"account,omitempty"`
+}
+
+type DuplicatePolicy string
+
+const (
+	DuplicatePolicyWarn   DuplicatePolicy = "warn"
+	DuplicatePolicyReject DuplicatePolicy = "reject"
+)
+
+var AllDuplicatePolicy = []DuplicatePolicy{
+	DuplicatePolicyWarn,
+	DuplicatePolicyReject,
+}
+
+func (e DuplicatePolicy) IsValid() bool {
+	switch e {
+	case DuplicatePolicyWarn, DuplicatePolicyReject:
+		return true
+	}
+	return false
+}
+
+func (e DuplicatePolicy) String() string {
+	return string(e)
+}
+
+func (e *DuplicatePolicy) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = DuplicatePolicy(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid DuplicatePolicy", str)
+	}
+	return nil
+}
+
+func (e DuplicatePolicy) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ExpenseKind string
+
+const (
+	ExpenseKindExpense ExpenseKind = "expense"
+	ExpenseKindIncome  ExpenseKind = "income"
+)
+
+var AllExpenseKind = []ExpenseKind{
+	ExpenseKindExpense,
+	ExpenseKindIncome,
+}
+
+func (e ExpenseKind) IsValid() bool {
+	switch e {
+	case ExpenseKindExpense, ExpenseKindIncome:
+		return true
+	}
+	return false
+}
+
+func (e ExpenseKind) String() string {
+	return string(e)
+}
+
+func (e *ExpenseKind) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ExpenseKind(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ExpenseKind", str)
+	}
+	return nil
+}
+
+func (e ExpenseKind) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
 }
 
 type SortField string
 
 const (
-	SortFieldAmount SortField = "amount"
-	SortFieldDate   SortField = "date"
+	SortFieldAmount      SortField = "amount"
+	SortFieldDate        SortField = "date"
+	SortFieldDescription SortField = "description"
+	SortFieldCreatedAt   SortField = "createdAt"
+	SortFieldUpdatedAt   SortField = "updatedAt"
 )
 
 var AllSortField = []SortField{
 	SortFieldAmount,
 	SortFieldDate,
+	SortFieldDescription,
+	SortFieldCreatedAt,
+	SortFieldUpdatedAt,
 }
 
 func (e SortField) IsValid() bool {
 	switch e {
-	case SortFieldAmount, SortFieldDate:
+	case SortFieldAmount, SortFieldDate, SortFieldDescription, SortFieldCreatedAt, SortFieldUpdatedAt:
 		return true
 	}
 	return false
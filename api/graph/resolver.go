@@ -1,25 +1,37 @@
 package graph
 
 import (
+	"github.com/beka-birhanu/finance-go/api/cursor"
 	icmd "github.com/beka-birhanu/finance-go/application/common/cqrs/command"
 	iquery "github.com/beka-birhanu/finance-go/application/common/cqrs/query"
 	expensecmd "github.com/beka-birhanu/finance-go/application/expense/command"
 	expensqry "github.com/beka-birhanu/finance-go/application/expense/query"
+	"github.com/beka-birhanu/finance-go/application/idempotency"
 	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
 )
 
 type Resolver struct {
 	getExpenseHandler         iquery.IHandler[*expensqry.GetQuery, *expensemodel.Expense]
-	getMultipleExpenseHandler iquery.IHandler[*expensqry.GetMultipleQuery, []*expensemodel.Expense]
-	addExpenseHandler         icmd.IHandler[*expensecmd.AddCommand, *expensemodel.Expense]
+	getMultipleExpenseHandler iquery.IHandler[*expensqry.GetMultipleQuery, *expensqry.Page]
+	addExpenseHandler         icmd.IHandler[*expensecmd.AddCommand, *expensecmd.AddResult]
+	batchAddExpenseHandler    icmd.IHandler[*expensecmd.BatchAddCommand, []*expensemodel.Expense]
 	patchExpenseHandler       icmd.IHandler[*expensecmd.PatchCommand, *expensemodel.Expense]
+	bulkPatchExpenseHandler   icmd.IHandler[*expensecmd.BulkPatchCommand, *expensecmd.BulkResult]
+	bulkDeleteExpenseHandler  icmd.IHandler[*expensecmd.BulkDeleteCommand, *expensecmd.BulkResult]
+	cursorCodec               *cursor.Codec
+	idempotencyService        *idempotency.Service
 }
 
 type ResolverConfig struct {
 	GetExpenseHandler         iquery.IHandler[*expensqry.GetQuery, *expensemodel.Expense]
-	GetMultipleExpenseHandler iquery.IHandler[*expensqry.GetMultipleQuery, []*expensemodel.Expense]
-	AddExpenseHandler         icmd.IHandler[*expensecmd.AddCommand, *expensemodel.Expense]
+	GetMultipleExpenseHandler iquery.IHandler[*expensqry.GetMultipleQuery, *expensqry.Page]
+	AddExpenseHandler         icmd.IHandler[*expensecmd.AddCommand, *expensecmd.AddResult]
+	BatchAddExpenseHandler    icmd.IHandler[*expensecmd.BatchAddCommand, []*expensemodel.Expense]
 	PatchExpenseHandler       icmd.IHandler[*expensecmd.PatchCommand, *expensemodel.Expense]
+	BulkPatchExpenseHandler   icmd.IHandler[*expensecmd.BulkPatchCommand, *expensecmd.BulkResult]
+	BulkDeleteExpenseHandler  icmd.IHandler[*expensecmd.BulkDeleteCommand, *expensecmd.BulkResult]
+	CursorCodec               *cursor.Codec
+	IdempotencyService        *idempotency.Service
 }
 
 func NewResolver(c ResolverConfig) *Resolver {
@@ -27,7 +39,12 @@ func NewResolver(c ResolverConfig) *Resolver {
 		getExpenseHandler:         c.GetExpenseHandler,
 		getMultipleExpenseHandler: c.GetMultipleExpenseHandler,
 		addExpenseHandler:         c.AddExpenseHandler,
+		batchAddExpenseHandler:    c.BatchAddExpenseHandler,
 		patchExpenseHandler:       c.PatchExpenseHandler,
+		bulkPatchExpenseHandler:   c.BulkPatchExpenseHandler,
+		bulkDeleteExpenseHandler:  c.BulkDeleteExpenseHandler,
+		cursorCodec:               c.CursorCodec,
+		idempotencyService:        c.IdempotencyService,
 	}
 
 }
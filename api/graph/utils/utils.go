@@ -1,10 +1,17 @@
 package utils
 
 import (
+	"strings"
+
 	errapi "github.com/beka-birhanu/finance-go/api/error"
 	"github.com/beka-birhanu/finance-go/api/graph/model"
 	"github.com/beka-birhanu/finance-go/api/utils"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	expensecmd "github.com/beka-birhanu/finance-go/application/expense/command"
+	expensqry "github.com/beka-birhanu/finance-go/application/expense/query"
 	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+	"github.com/google/uuid"
 	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
@@ -15,7 +22,7 @@ func NewGQLError(err errapi.Error) *gqlerror.Error {
 	extensions["StatusCode"] = err.StatusCode()
 
 	switch err.StatusCode() {
-	case errapi.BadRequest, errapi.Conflict, errapi.NotFound, errapi.Forbidden:
+	case errapi.BadRequest, errapi.Conflict, errapi.NotFound, errapi.Forbidden, errapi.Unprocessable, errapi.PreconditionFailed:
 		errMessage = err.Error()
 	case errapi.Authentication:
 		errMessage = "invalid credentials"
@@ -29,32 +36,213 @@ func NewGQLError(err errapi.Error) *gqlerror.Error {
 	}
 }
 
+// NewBatchGQLError creates a gqlerror for a rejected batch, listing the error of every
+// invalid item by its index under the "items" extension.
+func NewBatchGQLError(err *apperror.BatchError) *gqlerror.Error {
+	items := make([]map[string]interface{}, 0, len(err.Items))
+	for _, item := range err.Items {
+		items = append(items, map[string]interface{}{"index": item.Index, "error": item.Err.Error()})
+	}
+
+	return &gqlerror.Error{
+		Message: "batch rejected: no expenses were created",
+		Extensions: map[string]interface{}{
+			"StatusCode": errapi.BadRequest,
+			"items":      items,
+		},
+	}
+}
+
+// NewDuplicateGQLError creates a gqlerror for an expense rejected because it likely
+// duplicates stored expenses, listing their IDs under the "duplicates" extension.
+func NewDuplicateGQLError(err *apperror.DuplicateError) *gqlerror.Error {
+	return &gqlerror.Error{
+		Message: "expense rejected: it likely duplicates existing expenses",
+		Extensions: map[string]interface{}{
+			"StatusCode": errapi.Conflict,
+			"duplicates": duplicateIDs(err.Duplicates),
+		},
+	}
+}
+
+// NewDuplicateWarning creates a gqlerror warning that a created expense likely duplicates
+// stored expenses, listing their IDs under the "duplicates" extension. Unlike other errors
+// it is reported alongside the data of the field.
+func NewDuplicateWarning(ids []uuid.UUID) *gqlerror.Error {
+	return &gqlerror.Error{
+		Message: "expense likely duplicates existing expenses",
+		Extensions: map[string]interface{}{
+			"warning":    true,
+			"duplicates": ids,
+		},
+	}
+}
+
+// duplicateIDs flattens the IDs of the stored expenses listed by duplicates.
+func duplicateIDs(duplicates []apperror.Duplicate) []uuid.UUID {
+	var ids []uuid.UUID
+	for _, duplicate := range duplicates {
+		ids = append(ids, duplicate.IDs...)
+	}
+	return ids
+}
+
 func NewExpense(e *expensemodel.Expense) *model.Expense {
 	return &model.Expense{
 		ID:          e.ID(),
 		Description: e.Description(),
 		Amount:      e.Amount(),
+		Kind:        model.ExpenseKind(e.Kind()),
 		Date:        e.Date(),
+		Category:    optionalString(e.Category()),
+		Account:     optionalString(e.Account()),
 		UserID:      e.UserID(),
 		CreatedAt:   e.CreatedAt(),
 		UpdatedAt:   e.UpdatedAt(),
+		Version:     int64(e.Version()),
 	}
 }
 
-func NewPaginatedExpenseResponse(es []*expensemodel.Expense, field string) *model.PaginatedExpenseResponse {
+// NewExpenseConnection maps a page of expenses to a Relay connection, encoding a cursor
+// for every edge with buildCursor.
+func NewExpenseConnection(page *expensqry.Page, buildCursor func(*expensemodel.Expense) string) *model.ExpenseConnection {
+	edges := make([]*model.ExpenseEdge, 0, len(page.Expenses))
+	for _, e := range page.Expenses {
+		edges = append(edges, &model.ExpenseEdge{
+			Cursor: buildCursor(e),
+			Node:   NewExpense(e),
+		})
+	}
 
-	expenses := make([]*model.Expense, 0)
-	for _, e := range es {
-		expenses = append(expenses, NewExpense(e))
+	pageInfo := &model.PageInfo{
+		HasNextPage:     page.HasNextPage,
+		HasPreviousPage: page.HasPreviousPage,
+	}
+	if len(edges) > 0 {
+		pageInfo.StartCursor = &edges[0].Cursor
+		pageInfo.EndCursor = &edges[len(edges)-1].Cursor
 	}
 
-	cursor := ""
-	if len(es) > 0 {
-		cursor = utils.BuildCursor(es[len(es)-1], field)
+	return &model.ExpenseConnection{
+		Edges:      edges,
+		PageInfo:   pageInfo,
+		TotalCount: int64(page.TotalCount),
 	}
+}
+
+// NewPagingArgs maps the paging fields of a GetMultipleInput to utils.PagingArgs. The
+// deprecated limit and cursor fields are accepted as aliases of first and after, which take
+// precedence over them.
+func NewPagingArgs(params model.GetMultipleInput) utils.PagingArgs {
+	args := utils.PagingArgs{
+		First:  IntPointer(params.First),
+		After:  params.After,
+		Last:   IntPointer(params.Last),
+		Before: params.Before,
+	}
+	if args.First == nil {
+		args.First = IntPointer(params.Limit)
+	}
+	if args.After == nil {
+		args.After = params.Cursor
+	}
+	return args
+}
+
+// IntPointer narrows an optional GraphQL Int to an optional int.
+func IntPointer(v *int64) *int {
+	if v == nil {
+		return nil
+	}
+	i := int(*v)
+	return &i
+}
+
+// NewSort maps the sort fields of a GetMultipleInput to sort keys. The then fields add a
+// secondary key, which orders expenses that tie on the primary one.
+func NewSort(params model.GetMultipleInput) ([]irepository.SortKey, error) {
+	primary, err := newSortKey(params.SortField, params.SortOrder, model.SortFieldDate)
+	if err != nil {
+		return nil, err
+	}
+	if params.ThenSortField == nil {
+		if params.ThenSortOrder != nil {
+			return nil, errapi.NewBadRequest("thenSortOrder requires thenSortField")
+		}
+		return []irepository.SortKey{primary}, nil
+	}
+
+	secondary, err := newSortKey(params.ThenSortField, params.ThenSortOrder, *params.ThenSortField)
+	if err != nil {
+		return nil, err
+	}
+	return []irepository.SortKey{primary, secondary}, nil
+}
+
+// newSortKey builds a sort key from an optional field and order, defaulting to the given
+// field in descending order.
+func newSortKey(field *model.SortField, order *model.SortOrder, defaultField model.SortField) (irepository.SortKey, error) {
+	f, o := defaultField, model.SortOrderDesc
+	if field != nil {
+		f = *field
+	}
+	if order != nil {
+		o = *order
+	}
+	return utils.NewSortKey(string(f), string(o))
+}
+
+// NewExpenseFilter maps the filter fields of a GetMultipleInput to a repository filter.
+func NewExpenseFilter(params model.GetMultipleInput) irepository.ExpenseFilter {
+	return irepository.ExpenseFilter{
+		DateFrom:    params.DateFrom,
+		DateTo:      params.DateTo,
+		MinAmount:   params.MinAmount,
+		MaxAmount:   params.MaxAmount,
+		Description: strings.TrimSpace(StringValue(params.Description)),
+		Category:    strings.TrimSpace(StringValue(params.Category)),
+		Account:     strings.TrimSpace(StringValue(params.Account)),
+	}
+}
+
+// NewExpenseSelection maps the selection fields of a bulk input to a repository selection.
+func NewExpenseSelection(ids []uuid.UUID, filter *model.ExpenseFilterInput) irepository.ExpenseSelection {
+	selection := irepository.ExpenseSelection{IDs: ids}
+	if filter != nil {
+		selection.Filter = irepository.ExpenseFilter{
+			DateFrom:    filter.DateFrom,
+			DateTo:      filter.DateTo,
+			MinAmount:   filter.MinAmount,
+			MaxAmount:   filter.MaxAmount,
+			Description: strings.TrimSpace(StringValue(filter.Description)),
+			Category:    strings.TrimSpace(StringValue(filter.Category)),
+			Account:     strings.TrimSpace(StringValue(filter.Account)),
+		}
+	}
+	return selection
+}
+
+// NewBulkResult maps the result of a bulk command to its GraphQL representation.
+func NewBulkResult(result *expensecmd.BulkResult) *model.BulkResult {
+	return &model.BulkResult{
+		Matched: int64(result.Matched),
+		Ids:     result.IDs,
+		DryRun:  result.DryRun,
+	}
+}
+
+// StringValue dereferences an optional GraphQL string, treating nil as empty.
+func StringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
 
-	return &model.PaginatedExpenseResponse{
-		Expenses: expenses,
-		Cursor:   &cursor,
+// optionalString maps an empty string to nil so unset fields are returned as null.
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
 	}
+	return &s
 }
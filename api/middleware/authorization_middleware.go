@@ -4,46 +4,126 @@ package middleware
 import (
 	"context"
 	"errors"
+	"log"
 	"net/http"
+	"strings"
 
+	auth "github.com/beka-birhanu/finance-go/application/authentication/common"
+	sessioncmd "github.com/beka-birhanu/finance-go/application/authentication/session"
 	ijwt "github.com/beka-birhanu/finance-go/application/common/interface/jwt"
-	"github.com/dgrijalva/jwt-go"
 )
 
+// errRevokedToken is returned for tokens that were revoked before they expired.
+var errRevokedToken = errors.New("revoked token")
+
 // contextKey is a type for context keys used in this package.
 type contextKey string
 
-// ContextUserClaims is the key for storing user claims in the context.
-const ContextUserClaims contextKey = "userClaims"
+// contextUserClaims is the key for storing user claims in the context.
+const contextUserClaims contextKey = "userClaims"
+
+// WithUserClaims returns a copy of the context carrying the claims of the authenticated user.
+func WithUserClaims(ctx context.Context, claims *ijwt.Claims) context.Context {
+	return context.WithValue(ctx, contextUserClaims, claims)
+}
+
+// UserClaims returns the claims of the authenticated user carried by the context, if any.
+func UserClaims(ctx context.Context) (*ijwt.Claims, bool) {
+	claims, ok := ctx.Value(contextUserClaims).(*ijwt.Claims)
+	return claims, ok && claims != nil
+}
 
-// Authorization is a middleware that validates the JWT token from the request cookie.
-// If the token is valid, the user claims are attached to the request context; otherwise,
-// it returns an HTTP 401 Unauthorized error.
-func Authorization(jwtService ijwt.IService, blockIfInvalid bool) func(http.Handler) http.Handler {
+// Authorization is a middleware that validates the JWT token from the Authorization header
+// or the request cookie.
+// If the token is valid and was not revoked, the user claims are attached to the request
+// context, from which UserClaims reads them; otherwise, it returns an HTTP 401 Unauthorized
+// error, or, when blockIfInvalid is false, passes the request on without claims. Revocation is not checked
+// when revoker is nil. Personal access tokens in the Authorization header are authenticated by
+// accessTokens instead, and are not accepted when it is nil; they are revoked on their own, so
+// the revoker is not consulted for them.
+func Authorization(jwtService ijwt.IService, revoker *sessioncmd.Revoker, accessTokens auth.IAccessTokenAuthenticator, blockIfInvalid bool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			claims, err := extractAndDecodeToken(jwtService, r, "accessToken")
+			claims, isAccessToken, err := authenticate(accessTokens, r)
+			if !isAccessToken {
+				claims, err = extractAndDecodeToken(jwtService, r, "accessToken")
+				if err == nil && revoker != nil {
+					err = checkRevocation(revoker, claims)
+				}
+			}
 			if err != nil && blockIfInvalid {
 				if errors.Is(err, http.ErrNoCookie) {
 					http.Error(w, "Authorization token required", http.StatusUnauthorized)
+				} else if errors.Is(err, errRevokedToken) {
+					http.Error(w, "Token revoked", http.StatusUnauthorized)
 				} else {
 					http.Error(w, "Invalid token", http.StatusUnauthorized)
 				}
 				return
 			}
-			ctx := context.WithValue(r.Context(), ContextUserClaims, claims)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithUserClaims(r.Context(), claims)))
 		})
 	}
 }
 
-func extractAndDecodeToken(jwtService ijwt.IService, r *http.Request, tokenKey string) (jwt.MapClaims, error) {
-	cookie, err := r.Cookie(tokenKey)
-	if err != nil {
-		return nil, err
+// authenticate authenticates the personal access token in the Authorization header of the
+// request, if there is one, and reports whether there is.
+func authenticate(accessTokens auth.IAccessTokenAuthenticator, r *http.Request) (*ijwt.Claims, bool, error) {
+	tokenString, ok := bearerToken(r)
+	if !ok || accessTokens == nil {
+		return nil, false, nil
+	}
+
+	claims, isAccessToken, err := accessTokens.Authenticate(tokenString)
+	if err != nil && isAccessToken {
+		log.Printf("access token rejected: %v", err)
+	}
+	return claims, isAccessToken, err
+}
+
+// extractAndDecodeToken decodes the access token of the request. A token in an
+// "Authorization: Bearer <token>" header takes precedence over the token cookie, so that a
+// client sending both is authenticated by the header it sets explicitly. Authorization
+// headers with other schemes are ignored. Returns http.ErrNoCookie when the request carries
+// neither.
+func extractAndDecodeToken(jwtService ijwt.IService, r *http.Request, tokenKey string) (*ijwt.Claims, error) {
+	tokenString, ok := bearerToken(r)
+	if !ok {
+		cookie, err := r.Cookie(tokenKey)
+		if err != nil {
+			return nil, err
+		}
+		tokenString = cookie.Value
 	}
 
-	tokenString := cookie.Value
 	claims, err := jwtService.Decode(tokenString)
 	return claims, err
 }
+
+// bearerToken returns the token of the Authorization header of the request, if it uses the
+// Bearer scheme.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// checkRevocation returns errRevokedToken if the token with the given claims was revoked.
+// Tokens are rejected when the revocation cannot be checked.
+func checkRevocation(revoker *sessioncmd.Revoker, claims *ijwt.Claims) error {
+	revoked, err := revoker.IsRevoked(claims)
+	if err != nil {
+		log.Printf("error checking token revocation: %v", err)
+		return errRevokedToken
+	}
+	if revoked {
+		return errRevokedToken
+	}
+	return nil
+}
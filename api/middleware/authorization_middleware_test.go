@@ -4,29 +4,92 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	auth "github.com/beka-birhanu/finance-go/application/authentication/common"
+	sessioncmd "github.com/beka-birhanu/finance-go/application/authentication/session"
+	ijwt "github.com/beka-birhanu/finance-go/application/common/interface/jwt"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
 	usermodel "github.com/beka-birhanu/finance-go/domain/model/user"
-	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
 )
 
 type MockJwtService struct {
-	DecodeTokenFunc func(token string) (jwt.MapClaims, error)
+	DecodeTokenFunc func(token string) (*ijwt.Claims, error)
 }
 
 func (m *MockJwtService) Generate(user *usermodel.User) (string, error) {
 	return "", nil
 }
 
-func (m *MockJwtService) Decode(token string) (jwt.MapClaims, error) {
+func (m *MockJwtService) Decode(token string) (*ijwt.Claims, error) {
 	return m.DecodeTokenFunc(token)
 }
 
+type MockTokenRevocationRepository struct {
+	revokedTokens map[string]bool
+	revokedBefore map[uuid.UUID]time.Time
+}
+
+func (m *MockTokenRevocationRepository) RevokeToken(tokenId string, userId uuid.UUID, expiresAt time.Time) error {
+	m.revokedTokens[tokenId] = true
+	return nil
+}
+
+func (m *MockTokenRevocationRepository) IsTokenRevoked(tokenId string) (bool, error) {
+	return m.revokedTokens[tokenId], nil
+}
+
+func (m *MockTokenRevocationRepository) RevokeAllBefore(userId uuid.UUID, at time.Time) error {
+	m.revokedBefore[userId] = at
+	return nil
+}
+
+func (m *MockTokenRevocationRepository) RevokedBefore(userId uuid.UUID) (*time.Time, error) {
+	if at, ok := m.revokedBefore[userId]; ok {
+		return &at, nil
+	}
+	return nil, nil
+}
+
+var _ irepository.ITokenRevocationRepository = &MockTokenRevocationRepository{}
+
+// MockAccessTokenAuthenticator accepts "pat_valid" and rejects the other tokens starting
+// with "pat_".
+type MockAccessTokenAuthenticator struct{}
+
+func (m *MockAccessTokenAuthenticator) Authenticate(token string) (*ijwt.Claims, bool, error) {
+	if !strings.HasPrefix(token, "pat_") {
+		return nil, false, nil
+	}
+	if token != "pat_valid" {
+		return nil, true, errors.New("revoked access token")
+	}
+	return &ijwt.Claims{Subject: uuid.New(), Personal: true, Scopes: []string{"expenses:read"}}, true, nil
+}
+
+var _ auth.IAccessTokenAuthenticator = &MockAccessTokenAuthenticator{}
+
 func TestAuthorizationMiddleware(t *testing.T) {
+	userId := uuid.New()
+	issuedAt := time.Now().UTC().Add(-time.Minute)
+	revoker := sessioncmd.NewRevoker(sessioncmd.RevokerConfig{
+		Revocations: &MockTokenRevocationRepository{
+			revokedTokens: map[string]bool{"revoked": true},
+			revokedBefore: map[uuid.UUID]time.Time{userId: issuedAt.Add(30 * time.Second)},
+		},
+		TimeService: &MockTimeService{},
+	})
+
 	tests := []struct {
 		name                 string
 		setCookie            bool
-		mockDecodeTokenFunc  func(token string) (jwt.MapClaims, error)
+		authorization        string
+		revoker              *sessioncmd.Revoker
+		accessTokens         auth.IAccessTokenAuthenticator
+		mockDecodeTokenFunc  func(token string) (*ijwt.Claims, error)
 		expectedStatusCode   int
 		expectedResponseBody string
 	}{
@@ -40,7 +103,7 @@ func TestAuthorizationMiddleware(t *testing.T) {
 		{
 			name:      "Invalid access token",
 			setCookie: true,
-			mockDecodeTokenFunc: func(token string) (jwt.MapClaims, error) {
+			mockDecodeTokenFunc: func(token string) (*ijwt.Claims, error) {
 				return nil, errors.New("invalid token")
 			},
 			expectedStatusCode:   http.StatusUnauthorized,
@@ -49,8 +112,117 @@ func TestAuthorizationMiddleware(t *testing.T) {
 		{
 			name:      "Valid access token",
 			setCookie: true,
-			mockDecodeTokenFunc: func(token string) (jwt.MapClaims, error) {
-				return jwt.MapClaims{"user_id": "123"}, nil
+			mockDecodeTokenFunc: func(token string) (*ijwt.Claims, error) {
+				return &ijwt.Claims{Subject: uuid.New()}, nil
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: "Hello, authorized user!\n",
+		},
+		{
+			name:          "Bearer access token",
+			authorization: "Bearer bearerToken",
+			mockDecodeTokenFunc: func(token string) (*ijwt.Claims, error) {
+				if token != "bearerToken" {
+					return nil, errors.New("invalid token")
+				}
+				return &ijwt.Claims{Subject: uuid.New()}, nil
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: "Hello, authorized user!\n",
+		},
+		{
+			name:          "Bearer access token takes precedence over the cookie",
+			setCookie:     true,
+			authorization: "bearer bearerToken",
+			mockDecodeTokenFunc: func(token string) (*ijwt.Claims, error) {
+				if token != "bearerToken" {
+					return nil, errors.New("invalid token")
+				}
+				return &ijwt.Claims{Subject: uuid.New()}, nil
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: "Hello, authorized user!\n",
+		},
+		{
+			name:                 "Personal access token",
+			authorization:        "Bearer pat_valid",
+			accessTokens:         &MockAccessTokenAuthenticator{},
+			revoker:              revoker,
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: "Hello, authorized user!\n",
+		},
+		{
+			name:                 "Revoked personal access token",
+			authorization:        "Bearer pat_revoked",
+			accessTokens:         &MockAccessTokenAuthenticator{},
+			expectedStatusCode:   http.StatusUnauthorized,
+			expectedResponseBody: "Invalid token\n",
+		},
+		{
+			name:          "JWT with personal access tokens accepted",
+			authorization: "Bearer bearerToken",
+			accessTokens:  &MockAccessTokenAuthenticator{},
+			mockDecodeTokenFunc: func(token string) (*ijwt.Claims, error) {
+				if token != "bearerToken" {
+					return nil, errors.New("invalid token")
+				}
+				return &ijwt.Claims{Subject: uuid.New()}, nil
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: "Hello, authorized user!\n",
+		},
+		{
+			name:          "Personal access token not accepted",
+			authorization: "Bearer pat_valid",
+			mockDecodeTokenFunc: func(token string) (*ijwt.Claims, error) {
+				return nil, errors.New("invalid token")
+			},
+			expectedStatusCode:   http.StatusUnauthorized,
+			expectedResponseBody: "Invalid token\n",
+		},
+		{
+			name:                 "Authorization header with another scheme",
+			authorization:        "Basic dXNlcjpwYXNz",
+			mockDecodeTokenFunc:  nil,
+			expectedStatusCode:   http.StatusUnauthorized,
+			expectedResponseBody: "Authorization token required\n",
+		},
+		{
+			name:      "Unrevoked access token",
+			setCookie: true,
+			revoker:   revoker,
+			mockDecodeTokenFunc: func(token string) (*ijwt.Claims, error) {
+				return &ijwt.Claims{Subject: uuid.New(), ID: "current", IssuedAt: issuedAt}, nil
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: "Hello, authorized user!\n",
+		},
+		{
+			name:      "Revoked access token",
+			setCookie: true,
+			revoker:   revoker,
+			mockDecodeTokenFunc: func(token string) (*ijwt.Claims, error) {
+				return &ijwt.Claims{Subject: uuid.New(), ID: "revoked", IssuedAt: issuedAt}, nil
+			},
+			expectedStatusCode:   http.StatusUnauthorized,
+			expectedResponseBody: "Token revoked\n",
+		},
+		{
+			name:      "Access token issued before all sessions were revoked",
+			setCookie: true,
+			revoker:   revoker,
+			mockDecodeTokenFunc: func(token string) (*ijwt.Claims, error) {
+				return &ijwt.Claims{Subject: userId, ID: "current", IssuedAt: issuedAt}, nil
+			},
+			expectedStatusCode:   http.StatusUnauthorized,
+			expectedResponseBody: "Token revoked\n",
+		},
+		{
+			name:      "Access token issued after all sessions were revoked",
+			setCookie: true,
+			revoker:   revoker,
+			mockDecodeTokenFunc: func(token string) (*ijwt.Claims, error) {
+				return &ijwt.Claims{Subject: userId, ID: "current", IssuedAt: issuedAt.Add(time.Minute)}, nil
 			},
 			expectedStatusCode:   http.StatusOK,
 			expectedResponseBody: "Hello, authorized user!\n",
@@ -63,12 +235,11 @@ func TestAuthorizationMiddleware(t *testing.T) {
 				DecodeTokenFunc: tt.mockDecodeTokenFunc,
 			}
 
-			mw := Authorization(mockJwtService, true)
+			mw := Authorization(mockJwtService, tt.revoker, tt.accessTokens, true)
 
 			// Create a handler to be wrapped by the middleware
 			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				claims := r.Context().Value(ContextUserClaims)
-				if claims != nil {
+				if _, ok := UserClaims(r.Context()); ok {
 					if _, err := w.Write([]byte("Hello, authorized user!\n")); err != nil {
 						t.Error("error in writing ")
 					}
@@ -89,6 +260,9 @@ func TestAuthorizationMiddleware(t *testing.T) {
 			if tt.setCookie {
 				req.AddCookie(&http.Cookie{Name: "accessToken", Value: "dummyToken"})
 			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
 
 			// Create a ResponseRecorder to capture the response
 			rr := httptest.NewRecorder()
@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+
+	errapi "github.com/beka-birhanu/finance-go/api/error"
+	"github.com/beka-birhanu/finance-go/application/idempotency"
+	ierr "github.com/beka-birhanu/finance-go/domain/common/error"
+	"github.com/google/uuid"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header carrying the idempotency key.
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// IdempotentReplayedHeader is set on responses replayed for a repeated request.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// replayedHeaders are the response headers stored and replayed with the response body.
+var replayedHeaders = []string{"Content-Type", "Location"}
+
+// storedResponse is the stored form of a response to an idempotent request.
+type storedResponse struct {
+	StatusCode int               `json:"statusCode"`
+	Header     map[string]string `json:"header"`
+	Body       []byte            `json:"body"`
+}
+
+// Idempotency is a middleware that makes requests carrying an Idempotency-Key header
+// idempotent. The response to the first request of a user with a key is stored; repeating
+// the request, with the same method, path and body, replays it without calling the next
+// handler, while reusing the key for a different request returns 422 Unprocessable Entity.
+// Server errors are not stored, so that the request can be retried. Requests without the
+// header, or without user claims in their context, are passed through unchanged.
+func Idempotency(service *idempotency.Service) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			userId, ok := claimedUserID(r)
+			if key == "" || !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				respondError(w, errapi.NewBadRequest("invalid request body"))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			stored, err := service.Begin(userId, key, r.Method+" "+r.URL.Path, body)
+			if err != nil {
+				respondError(w, errapi.Map(err.(ierr.IErr)))
+				return
+			}
+			if stored != nil {
+				replay(w, stored)
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w}
+			defer func() {
+				// The handler panicked: free the key so that the request can be retried.
+				if recovered := recover(); recovered != nil {
+					release(service, userId, key)
+					panic(recovered)
+				}
+			}()
+
+			next.ServeHTTP(recorder, r)
+			if recorder.statusCode >= http.StatusInternalServerError {
+				release(service, userId, key)
+				return
+			}
+
+			// Should storing fail, the key stays claimed until it expires rather than risking
+			// that a retry processes the request twice.
+			response, err := json.Marshal(recorder.stored())
+			if err != nil {
+				log.Printf("error encoding idempotent response: %v", err)
+				return
+			}
+			if err := service.Complete(userId, key, response); err != nil {
+				log.Printf("error storing idempotent response: %v", err)
+			}
+		})
+	}
+}
+
+// release frees the key of the user so that its request can be retried.
+func release(service *idempotency.Service, userId uuid.UUID, key string) {
+	if err := service.Release(userId, key); err != nil {
+		log.Printf("error releasing idempotency key: %v", err)
+	}
+}
+
+// claimedUserID returns the ID of the user in the claims of the request context.
+func claimedUserID(r *http.Request) (uuid.UUID, bool) {
+	claims, ok := UserClaims(r.Context())
+	if !ok {
+		return uuid.Nil, false
+	}
+	return claims.Subject, true
+}
+
+// replay writes a stored response, marking it as replayed.
+func replay(w http.ResponseWriter, stored []byte) {
+	var response storedResponse
+	if err := json.Unmarshal(stored, &response); err != nil {
+		log.Printf("error decoding idempotent response: %v", err)
+		respondError(w, errapi.NewServerError("something went wrong"))
+		return
+	}
+
+	for name, value := range response.Header {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(response.StatusCode)
+	if _, err := w.Write(response.Body); err != nil {
+		log.Printf("error replaying idempotent response: %v", err)
+	}
+}
+
+// respondError writes an error response in the format of the REST handlers, masking server errors.
+func respondError(w http.ResponseWriter, err errapi.Error) {
+	if err.StatusCode() >= http.StatusInternalServerError {
+		err = errapi.NewServerError("something went wrong")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(err.StatusCode())
+	if encodeErr := json.NewEncoder(w).Encode(map[string]string{"error": err.Error()}); encodeErr != nil {
+		log.Printf("error encoding error response: %v", encodeErr)
+	}
+}
+
+// responseRecorder passes a response through to the client while recording it.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+// WriteHeader records and writes the status code.
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if r.statusCode == 0 {
+		r.statusCode = statusCode
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write records and writes a part of the body.
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.statusCode == 0 {
+		r.statusCode = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// stored returns the recorded response in its stored form.
+func (r *responseRecorder) stored() storedResponse {
+	header := make(map[string]string)
+	for _, name := range replayedHeaders {
+		if value := r.Header().Get(name); value != "" {
+			header[name] = value
+		}
+	}
+	statusCode := r.statusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	return storedResponse{StatusCode: statusCode, Header: header, Body: r.body.Bytes()}
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ijwt "github.com/beka-birhanu/finance-go/application/common/interface/jwt"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	"github.com/beka-birhanu/finance-go/application/idempotency"
+	"github.com/google/uuid"
+)
+
+type MockIdempotencyRepository struct {
+	records map[string]*irepository.IdempotencyRecord
+}
+
+func (m *MockIdempotencyRepository) Reserve(record *irepository.IdempotencyRecord) (*irepository.IdempotencyRecord, error) {
+	if existing, ok := m.records[record.Key]; ok {
+		return existing, nil
+	}
+	m.records[record.Key] = record
+	return nil, nil
+}
+
+func (m *MockIdempotencyRepository) Complete(userId uuid.UUID, key string, response []byte) error {
+	m.records[key].Response = response
+	return nil
+}
+
+func (m *MockIdempotencyRepository) Release(userId uuid.UUID, key string) error {
+	delete(m.records, key)
+	return nil
+}
+
+type MockTimeService struct{}
+
+func (m *MockTimeService) NowUTC() time.Time {
+	return time.Now().UTC()
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	tests := []struct {
+		name               string
+		requests           []string
+		statusCode         int
+		expectedCalls      int
+		expectedStatusCode int
+		expectedReplayed   bool
+	}{
+		{
+			name:               "Repeated request is replayed",
+			requests:           []string{`{"amount":1}`, `{"amount":1}`},
+			statusCode:         http.StatusCreated,
+			expectedCalls:      1,
+			expectedStatusCode: http.StatusCreated,
+			expectedReplayed:   true,
+		},
+		{
+			name:               "Key reused for a different request",
+			requests:           []string{`{"amount":1}`, `{"amount":2}`},
+			statusCode:         http.StatusCreated,
+			expectedCalls:      1,
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:               "Server error is not stored",
+			requests:           []string{`{"amount":1}`, `{"amount":1}`},
+			statusCode:         http.StatusInternalServerError,
+			expectedCalls:      2,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := idempotency.NewService(idempotency.Config{
+				Repository:  &MockIdempotencyRepository{records: make(map[string]*irepository.IdempotencyRecord)},
+				TimeService: &MockTimeService{},
+			})
+
+			calls := 0
+			handler := Idempotency(service)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(`{"id":"1"}`))
+			}))
+
+			claims := &ijwt.Claims{Subject: uuid.New()}
+			var rr *httptest.ResponseRecorder
+			for _, body := range tt.requests {
+				req := httptest.NewRequest(http.MethodPost, "/users/expenses", strings.NewReader(body))
+				req.Header.Set(IdempotencyKeyHeader, "key-1")
+				req = req.WithContext(WithUserClaims(req.Context(), claims))
+				rr = httptest.NewRecorder()
+				handler.ServeHTTP(rr, req)
+			}
+
+			if calls != tt.expectedCalls {
+				t.Errorf("expected %d calls to the handler, got %d", tt.expectedCalls, calls)
+			}
+			if rr.Code != tt.expectedStatusCode {
+				t.Errorf("expected status code %d, got %d", tt.expectedStatusCode, rr.Code)
+			}
+			if replayed := rr.Header().Get(IdempotentReplayedHeader) == "true"; replayed != tt.expectedReplayed {
+				t.Errorf("expected replayed %v, got %v", tt.expectedReplayed, replayed)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import "net/http"
+
+// RequireRole is a middleware that only lets requests through whose user has the given role.
+// It must run after Authorization, which attaches the claims of the user to the request
+// context. Requests without claims get an HTTP 401 Unauthorized error, and requests whose user
+// lacks the role get an HTTP 403 Forbidden error. Personal access tokens are limited to
+// scopes and never act with the roles of their user, so they are always forbidden.
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := UserClaims(r.Context())
+			if !ok {
+				http.Error(w, "Authorization token required", http.StatusUnauthorized)
+				return
+			}
+			if claims.Restricted() || !claims.HasRole(role) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ijwt "github.com/beka-birhanu/finance-go/application/common/interface/jwt"
+	"github.com/google/uuid"
+)
+
+func TestRequireRoleMiddleware(t *testing.T) {
+	tests := []struct {
+		name               string
+		claims             *ijwt.Claims
+		expectedStatusCode int
+	}{
+		{
+			name:               "Without claims",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Without the role",
+			claims:             &ijwt.Claims{Subject: uuid.New()},
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "With another role",
+			claims:             &ijwt.Claims{Subject: uuid.New(), Roles: []string{"support"}},
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "With the role",
+			claims:             &ijwt.Claims{Subject: uuid.New(), Roles: []string{"admin"}},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Personal access token of a user with the role",
+			claims:             &ijwt.Claims{Subject: uuid.New(), Roles: []string{"admin"}, Personal: true, Scopes: []string{"expenses:read"}},
+			expectedStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := RequireRole("admin")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.claims != nil {
+				req = req.WithContext(WithUserClaims(req.Context(), tt.claims))
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatusCode {
+				t.Errorf("expected status %d, got %d", tt.expectedStatusCode, rr.Code)
+			}
+		})
+	}
+}
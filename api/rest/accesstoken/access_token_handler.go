@@ -0,0 +1,125 @@
+// Package accesstoken provides HTTP handlers for managing the personal access tokens of the
+// signed in user: creating, listing and revoking them. Personal access tokens themselves
+// cannot manage tokens.
+package accesstoken
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	errapi "github.com/beka-birhanu/finance-go/api/error"
+	"github.com/beka-birhanu/finance-go/api/rest/accesstoken/dto"
+	baseapi "github.com/beka-birhanu/finance-go/api/rest/base_handler"
+	accesstokencmd "github.com/beka-birhanu/finance-go/application/authentication/accesstoken"
+	icmd "github.com/beka-birhanu/finance-go/application/common/cqrs/command"
+	iquery "github.com/beka-birhanu/finance-go/application/common/cqrs/query"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	ierr "github.com/beka-birhanu/finance-go/domain/common/error"
+	"github.com/gorilla/mux"
+)
+
+// Handler manages HTTP requests for the personal access tokens of the signed in user.
+type Handler struct {
+	baseapi.BaseHandler
+	createHandler icmd.IHandler[*accesstokencmd.CreateCommand, *accesstokencmd.Created]
+	listHandler   iquery.IHandler[*accesstokencmd.ListQuery, []*irepository.AccessToken]
+	revokeHandler icmd.IHandler[*accesstokencmd.RevokeCommand, struct{}]
+}
+
+// Config holds the dependencies needed to create a Handler.
+type Config struct {
+	CreateHandler icmd.IHandler[*accesstokencmd.CreateCommand, *accesstokencmd.Created]
+	ListHandler   iquery.IHandler[*accesstokencmd.ListQuery, []*irepository.AccessToken]
+	RevokeHandler icmd.IHandler[*accesstokencmd.RevokeCommand, struct{}]
+}
+
+// NewHandler creates a new Handler with the given configuration.
+func NewHandler(config Config) *Handler {
+	return &Handler{
+		createHandler: config.CreateHandler,
+		listHandler:   config.ListHandler,
+		revokeHandler: config.RevokeHandler,
+	}
+}
+
+// RegisterPublic registers public routes for the Handler.
+// Currently, no public routes are defined.
+func (h *Handler) RegisterPublic(router *mux.Router) {}
+
+// RegisterProtected registers the routes managing the personal access tokens of the signed in
+// user.
+func (h *Handler) RegisterProtected(router *mux.Router) {
+	router.HandleFunc("/users/tokens", h.handleCreate).Methods(http.MethodPost)
+	router.HandleFunc("/users/tokens", h.handleList).Methods(http.MethodGet)
+	router.HandleFunc("/users/tokens/{tokenId}", h.handleRevoke).Methods(http.MethodDelete)
+}
+
+// handleCreate creates a personal access token for the user and responds with it, including
+// the token itself, which is not shown again.
+func (h *Handler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.UserClaims(r)
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	var request dto.CreateAccessTokenRequest
+	if err := h.ValidatedBody(r, &request); err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	cmd := &accesstokencmd.CreateCommand{UserID: claims.Subject, Name: request.Name, Scopes: request.Scopes}
+	if request.ExpiresInDays != nil {
+		cmd.TTL = time.Duration(*request.ExpiresInDays) * 24 * time.Hour
+	}
+
+	created, err := h.createHandler.Handle(cmd)
+	if err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+
+	// The token is only ever shown in this response, so it must not be stored by caches.
+	w.Header().Set("Cache-Control", "no-store")
+	location := fmt.Sprintf("%s/api/v1/users/tokens/%s", h.BaseURL(r), created.ID)
+	h.RespondWithLocation(w, http.StatusCreated, dto.FromCreated(created), location)
+}
+
+// handleList responds with the unrevoked personal access tokens of the user, newest first.
+func (h *Handler) handleList(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.UserClaims(r)
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	tokens, err := h.listHandler.Handle(&accesstokencmd.ListQuery{UserID: claims.Subject})
+	if err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+	h.Respond(w, http.StatusOK, dto.FromAccessTokens(tokens))
+}
+
+// handleRevoke revokes a personal access token of the user.
+func (h *Handler) handleRevoke(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.UserClaims(r)
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	tokenId, err := h.UUIDParam(r, "tokenId")
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	if _, err := h.revokeHandler.Handle(&accesstokencmd.RevokeCommand{UserID: claims.Subject, ID: tokenId}); err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
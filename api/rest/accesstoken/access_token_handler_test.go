@@ -0,0 +1,208 @@
+package accesstoken
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beka-birhanu/finance-go/api/middleware"
+	accesstokencmd "github.com/beka-birhanu/finance-go/application/authentication/accesstoken"
+	auth "github.com/beka-birhanu/finance-go/application/authentication/common"
+	ijwt "github.com/beka-birhanu/finance-go/application/common/interface/jwt"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// mockHandler mocks the personal access token command and query handlers.
+type mockHandler[C any, R any] struct {
+	handleFunc func(cmd C) (R, error)
+}
+
+func (m *mockHandler[C, R]) Handle(cmd C) (R, error) {
+	return m.handleFunc(cmd)
+}
+
+func TestHandler(t *testing.T) {
+	userId := uuid.New()
+	tokenId := uuid.New()
+	createdAt := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	stored := &irepository.AccessToken{
+		ID:        tokenId,
+		UserID:    userId,
+		Name:      "backup script",
+		Scopes:    []string{auth.ScopeExpensesRead},
+		CreatedAt: createdAt,
+		ExpiresAt: createdAt.Add(7 * 24 * time.Hour),
+	}
+
+	var createdTTL time.Duration
+	h := NewHandler(Config{
+		CreateHandler: &mockHandler[*accesstokencmd.CreateCommand, *accesstokencmd.Created]{
+			handleFunc: func(cmd *accesstokencmd.CreateCommand) (*accesstokencmd.Created, error) {
+				if cmd.Scopes[0] != auth.ScopeExpensesRead {
+					return nil, errdmn.NewValidation("unknown scope")
+				}
+				createdTTL = cmd.TTL
+				return &accesstokencmd.Created{AccessToken: stored, Token: "fgo_pat_secret"}, nil
+			},
+		},
+		ListHandler: &mockHandler[*accesstokencmd.ListQuery, []*irepository.AccessToken]{
+			handleFunc: func(query *accesstokencmd.ListQuery) ([]*irepository.AccessToken, error) {
+				return []*irepository.AccessToken{stored}, nil
+			},
+		},
+		RevokeHandler: &mockHandler[*accesstokencmd.RevokeCommand, struct{}]{
+			handleFunc: func(cmd *accesstokencmd.RevokeCommand) (struct{}, error) {
+				if cmd.UserID != userId || cmd.ID != tokenId {
+					return struct{}{}, errdmn.NewNotFound("access token not found")
+				}
+				return struct{}{}, nil
+			},
+		},
+	})
+	router := mux.NewRouter()
+	h.RegisterProtected(router)
+
+	session := &ijwt.Claims{Subject: userId}
+	accessToken := &ijwt.Claims{Subject: userId, Personal: true, Scopes: []string{auth.ScopeExpensesRead, auth.ScopeExpensesWrite}}
+
+	tests := []struct {
+		name             string
+		method           string
+		url              string
+		claims           *ijwt.Claims
+		body             string
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			name:           "Create",
+			method:         http.MethodPost,
+			url:            "/users/tokens",
+			claims:         session,
+			body:           `{"name":"backup script","scopes":["expenses:read"],"expiresInDays":7}`,
+			expectedStatus: http.StatusCreated,
+			expectedResponse: `{"id":"` + tokenId.String() + `","name":"backup script","scopes":["expenses:read"],` +
+				`"createdAt":"2024-09-01T12:00:00Z","expiresAt":"2024-09-08T12:00:00Z","lastUsedAt":null,"token":"fgo_pat_secret"}`,
+		},
+		{
+			name:           "Create Without Scopes",
+			method:         http.MethodPost,
+			url:            "/users/tokens",
+			claims:         session,
+			body:           `{"name":"backup script","scopes":[]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Create With Unknown Scope",
+			method:         http.MethodPost,
+			url:            "/users/tokens",
+			claims:         session,
+			body:           `{"name":"backup script","scopes":["users:admin"]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Create With a Personal Access Token",
+			method:         http.MethodPost,
+			url:            "/users/tokens",
+			claims:         accessToken,
+			body:           `{"name":"backup script","scopes":["expenses:read"]}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "List",
+			method:         http.MethodGet,
+			url:            "/users/tokens",
+			claims:         session,
+			expectedStatus: http.StatusOK,
+			expectedResponse: `[{"id":"` + tokenId.String() + `","name":"backup script","scopes":["expenses:read"],` +
+				`"createdAt":"2024-09-01T12:00:00Z","expiresAt":"2024-09-08T12:00:00Z","lastUsedAt":null}]`,
+		},
+		{
+			name:           "List Without User Claims",
+			method:         http.MethodGet,
+			url:            "/users/tokens",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Revoke",
+			method:         http.MethodDelete,
+			url:            "/users/tokens/" + tokenId.String(),
+			claims:         session,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "Revoke Unknown Token",
+			method:         http.MethodDelete,
+			url:            "/users/tokens/" + uuid.New().String(),
+			claims:         session,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Revoke With a Personal Access Token",
+			method:         http.MethodDelete,
+			url:            "/users/tokens/" + tokenId.String(),
+			claims:         accessToken,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.url, bytes.NewBufferString(tt.body))
+			req = req.WithContext(middleware.WithUserClaims(req.Context(), tt.claims))
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", status, tt.expectedStatus, rr.Body.String())
+			}
+			if tt.expectedResponse == "" {
+				return
+			}
+
+			var expected, actual interface{}
+			json.Unmarshal([]byte(tt.expectedResponse), &expected)
+			if err := json.NewDecoder(rr.Body).Decode(&actual); err != nil {
+				t.Fatalf("failed to decode response body: %v", err)
+			}
+			expectedJSON, _ := json.Marshal(expected)
+			actualJSON, _ := json.Marshal(actual)
+			if !bytes.Equal(expectedJSON, actualJSON) {
+				t.Errorf("expected response %s, got %s", expectedJSON, actualJSON)
+			}
+		})
+	}
+
+	if createdTTL != 7*24*time.Hour {
+		t.Errorf("expected the token to be created for 7 days, got %v", createdTTL)
+	}
+}
+
+func TestHandler_CreateIsNotCached(t *testing.T) {
+	h := NewHandler(Config{
+		CreateHandler: &mockHandler[*accesstokencmd.CreateCommand, *accesstokencmd.Created]{
+			handleFunc: func(cmd *accesstokencmd.CreateCommand) (*accesstokencmd.Created, error) {
+				return &accesstokencmd.Created{AccessToken: &irepository.AccessToken{ID: uuid.New()}, Token: "fgo_pat_secret"}, nil
+			},
+		},
+	})
+	router := mux.NewRouter()
+	h.RegisterProtected(router)
+
+	req, _ := http.NewRequest(http.MethodPost, "/users/tokens", strings.NewReader(`{"name":"script","scopes":["expenses:read"]}`))
+	req = req.WithContext(middleware.WithUserClaims(req.Context(), &ijwt.Claims{Subject: uuid.New()}))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if cacheControl := rr.Header().Get("Cache-Control"); cacheControl != "no-store" {
+		t.Errorf("expected Cache-Control no-store, got %q", cacheControl)
+	}
+}
@@ -0,0 +1,64 @@
+package dto
+
+import (
+	"time"
+
+	accesstokencmd "github.com/beka-birhanu/finance-go/application/authentication/accesstoken"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	"github.com/google/uuid"
+)
+
+// CreateAccessTokenRequest carries the name, scopes and lifetime of a new personal access
+// token. The lifetime defaults to 30 days.
+type CreateAccessTokenRequest struct {
+	Name          string   `json:"name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1"`
+	ExpiresInDays *int     `json:"expiresInDays,omitempty" validate:"omitempty,min=1,max=365"`
+}
+
+// AccessTokenResponse carries a personal access token, without the token itself.
+type AccessTokenResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+}
+
+// FromAccessToken maps a stored personal access token to a new AccessTokenResponse.
+func FromAccessToken(token *irepository.AccessToken) *AccessTokenResponse {
+	return &AccessTokenResponse{
+		ID:         token.ID,
+		Name:       token.Name,
+		Scopes:     token.Scopes,
+		CreatedAt:  token.CreatedAt,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+	}
+}
+
+// FromAccessTokens maps stored personal access tokens to new AccessTokenResponses.
+func FromAccessTokens(tokens []*irepository.AccessToken) []*AccessTokenResponse {
+	responses := make([]*AccessTokenResponse, len(tokens))
+	for i, token := range tokens {
+		responses[i] = FromAccessToken(token)
+	}
+	return responses
+}
+
+// CreatedAccessTokenResponse carries a personal access token that was just created, along
+// with the token itself, which is shown this one time only.
+type CreatedAccessTokenResponse struct {
+	*AccessTokenResponse
+	Token string `json:"token"`
+}
+
+// FromCreated maps a personal access token that was just created to a new
+// CreatedAccessTokenResponse.
+func FromCreated(created *accesstokencmd.Created) *CreatedAccessTokenResponse {
+	return &CreatedAccessTokenResponse{
+		AccessTokenResponse: FromAccessToken(created.AccessToken),
+		Token:               created.Token,
+	}
+}
@@ -0,0 +1,131 @@
+// Package admin provides HTTP handlers for administrators: listing users, disabling and
+// enabling their accounts, and viewing the usage statistics of the service. Its routes are
+// registered on the admin routes of the router, which only administrators reach.
+package admin
+
+import (
+	"net/http"
+
+	errapi "github.com/beka-birhanu/finance-go/api/error"
+	"github.com/beka-birhanu/finance-go/api/rest/admin/dto"
+	baseapi "github.com/beka-birhanu/finance-go/api/rest/base_handler"
+	admincmd "github.com/beka-birhanu/finance-go/application/admin"
+	icmd "github.com/beka-birhanu/finance-go/application/common/cqrs/command"
+	iquery "github.com/beka-birhanu/finance-go/application/common/cqrs/query"
+	ierr "github.com/beka-birhanu/finance-go/domain/common/error"
+	"github.com/gorilla/mux"
+)
+
+// Handler manages HTTP requests of administrators.
+type Handler struct {
+	baseapi.BaseHandler
+	listUsersHandler iquery.IHandler[*admincmd.ListUsersQuery, *admincmd.UserPage]
+	disableHandler   icmd.IHandler[*admincmd.DisableCommand, struct{}]
+	enableHandler    icmd.IHandler[*admincmd.EnableCommand, struct{}]
+	statsHandler     iquery.IHandler[*admincmd.StatsQuery, *admincmd.Stats]
+}
+
+// Config holds the dependencies needed to create a Handler.
+type Config struct {
+	ListUsersHandler iquery.IHandler[*admincmd.ListUsersQuery, *admincmd.UserPage]
+	DisableHandler   icmd.IHandler[*admincmd.DisableCommand, struct{}]
+	EnableHandler    icmd.IHandler[*admincmd.EnableCommand, struct{}]
+	StatsHandler     iquery.IHandler[*admincmd.StatsQuery, *admincmd.Stats]
+}
+
+// NewHandler creates a new Handler with the given configuration.
+func NewHandler(config Config) *Handler {
+	return &Handler{
+		listUsersHandler: config.ListUsersHandler,
+		disableHandler:   config.DisableHandler,
+		enableHandler:    config.EnableHandler,
+		statsHandler:     config.StatsHandler,
+	}
+}
+
+// RegisterAdmin registers the routes of administrators.
+func (h *Handler) RegisterAdmin(router *mux.Router) {
+	router.HandleFunc("/users", h.handleListUsers).Methods(http.MethodGet)
+	router.HandleFunc("/users/{userId}/disable", h.handleDisable).Methods(http.MethodPost)
+	router.HandleFunc("/users/{userId}/enable", h.handleEnable).Methods(http.MethodPost)
+	router.HandleFunc("/stats", h.handleStats).Methods(http.MethodGet)
+}
+
+// handleListUsers responds with a page of the users whose username contains the search
+// query parameter, newest first.
+func (h *Handler) handleListUsers(w http.ResponseWriter, r *http.Request) {
+	page, err := h.IntQueryParam(r, "page")
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+	pageSize, err := h.IntQueryParam(r, "pageSize")
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	users, err := h.listUsersHandler.Handle(&admincmd.ListUsersQuery{
+		Search:   h.StringQueryParam(r, "search"),
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+	h.Respond(w, http.StatusOK, dto.FromUserPage(users))
+}
+
+// handleDisable disables a user, signing them out everywhere.
+func (h *Handler) handleDisable(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.UserClaims(r)
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	userId, err := h.UUIDParam(r, "userId")
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	if _, err := h.disableHandler.Handle(&admincmd.DisableCommand{AdminID: claims.Subject, UserID: userId}); err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleEnable enables a disabled user.
+func (h *Handler) handleEnable(w http.ResponseWriter, r *http.Request) {
+	userId, err := h.UUIDParam(r, "userId")
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	if _, err := h.enableHandler.Handle(&admincmd.EnableCommand{UserID: userId}); err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleStats responds with the usage statistics of the service over the number of days of
+// the days query parameter.
+func (h *Handler) handleStats(w http.ResponseWriter, r *http.Request) {
+	days, err := h.IntQueryParam(r, "days")
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	stats, err := h.statsHandler.Handle(&admincmd.StatsQuery{Days: days})
+	if err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+	h.Respond(w, http.StatusOK, dto.FromStats(stats))
+}
@@ -0,0 +1,183 @@
+package admin
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/beka-birhanu/finance-go/api/middleware"
+	admincmd "github.com/beka-birhanu/finance-go/application/admin"
+	ijwt "github.com/beka-birhanu/finance-go/application/common/interface/jwt"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	usermodel "github.com/beka-birhanu/finance-go/domain/model/user"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// mockHandler mocks the admin command and query handlers.
+type mockHandler[C any, R any] struct {
+	handleFunc func(cmd C) (R, error)
+}
+
+func (m *mockHandler[C, R]) Handle(cmd C) (R, error) {
+	return m.handleFunc(cmd)
+}
+
+func TestHandler(t *testing.T) {
+	adminId := uuid.New()
+	createdAt := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	user, _ := usermodel.NewWithExistingHash(usermodel.ConfigForExistingHash{
+		ID:           uuid.New(),
+		Username:     "validUser",
+		CreationTime: createdAt,
+		UpdatedAt:    createdAt,
+	})
+
+	var listed *admincmd.ListUsersQuery
+	h := NewHandler(Config{
+		ListUsersHandler: &mockHandler[*admincmd.ListUsersQuery, *admincmd.UserPage]{
+			handleFunc: func(query *admincmd.ListUsersQuery) (*admincmd.UserPage, error) {
+				if query.Page < 0 {
+					return nil, errdmn.NewValidation("page must be at least 1")
+				}
+				listed = query
+				return &admincmd.UserPage{Users: []*usermodel.User{user}, Page: 2, PageSize: 10, TotalCount: 11}, nil
+			},
+		},
+		DisableHandler: &mockHandler[*admincmd.DisableCommand, struct{}]{
+			handleFunc: func(cmd *admincmd.DisableCommand) (struct{}, error) {
+				if cmd.AdminID == cmd.UserID {
+					return struct{}{}, errdmn.NewValidation("administrators cannot disable themselves")
+				}
+				if cmd.UserID != user.ID() {
+					return struct{}{}, errdmn.NewNotFound("user not found.")
+				}
+				return struct{}{}, nil
+			},
+		},
+		EnableHandler: &mockHandler[*admincmd.EnableCommand, struct{}]{
+			handleFunc: func(cmd *admincmd.EnableCommand) (struct{}, error) {
+				if cmd.UserID != user.ID() {
+					return struct{}{}, errdmn.NewNotFound("user not found.")
+				}
+				return struct{}{}, nil
+			},
+		},
+		StatsHandler: &mockHandler[*admincmd.StatsQuery, *admincmd.Stats]{
+			handleFunc: func(query *admincmd.StatsQuery) (*admincmd.Stats, error) {
+				return &admincmd.Stats{
+					UsageStats: &irepository.UsageStats{Users: 11, DisabledUsers: 1, NewUsers: 3, ActiveUsers: 5, Expenses: 120, NewExpenses: 40},
+					Since:      createdAt.AddDate(0, 0, -query.Days),
+				}, nil
+			},
+		},
+	})
+	router := mux.NewRouter()
+	h.RegisterAdmin(router)
+
+	admin := &ijwt.Claims{Subject: adminId, Roles: []string{usermodel.RoleAdmin}}
+
+	tests := []struct {
+		name             string
+		method           string
+		url              string
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			name:           "List Users",
+			method:         http.MethodGet,
+			url:            "/users?search=valid&page=2&pageSize=10",
+			expectedStatus: http.StatusOK,
+			expectedResponse: `{"users":[{"id":"` + user.ID().String() + `","username":"validUser","roles":[],` +
+				`"createdAt":"2024-09-01T12:00:00Z","updatedAt":"2024-09-01T12:00:00Z","disabledAt":null}],` +
+				`"page":2,"pageSize":10,"totalCount":11}`,
+		},
+		{
+			name:           "List Users With Invalid Page",
+			method:         http.MethodGet,
+			url:            "/users?page=first",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "List Users With Page Out of Range",
+			method:         http.MethodGet,
+			url:            "/users?page=-1",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Disable",
+			method:         http.MethodPost,
+			url:            "/users/" + user.ID().String() + "/disable",
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "Disable Themselves",
+			method:         http.MethodPost,
+			url:            "/users/" + adminId.String() + "/disable",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Disable Unknown User",
+			method:         http.MethodPost,
+			url:            "/users/" + uuid.New().String() + "/disable",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Enable",
+			method:         http.MethodPost,
+			url:            "/users/" + user.ID().String() + "/enable",
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "Enable Invalid User ID",
+			method:         http.MethodPost,
+			url:            "/users/123/enable",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Stats",
+			method:         http.MethodGet,
+			url:            "/stats?days=7",
+			expectedStatus: http.StatusOK,
+			expectedResponse: `{"since":"2024-08-25T12:00:00Z","users":11,"disabledUsers":1,"newUsers":3,` +
+				`"activeUsers":5,"expenses":120,"newExpenses":40}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.url, nil)
+			req = req.WithContext(middleware.WithUserClaims(req.Context(), admin))
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", status, tt.expectedStatus, rr.Body.String())
+			}
+			if tt.expectedResponse == "" {
+				return
+			}
+
+			var expected, actual interface{}
+			json.Unmarshal([]byte(tt.expectedResponse), &expected)
+			if err := json.NewDecoder(rr.Body).Decode(&actual); err != nil {
+				t.Fatalf("failed to decode response body: %v", err)
+			}
+			expectedJSON, _ := json.Marshal(expected)
+			actualJSON, _ := json.Marshal(actual)
+			if !bytes.Equal(expectedJSON, actualJSON) {
+				t.Errorf("expected response %s, got %s", expectedJSON, actualJSON)
+			}
+		})
+	}
+
+	if listed == nil || listed.Search != "valid" || listed.Page != 2 || listed.PageSize != 10 {
+		t.Errorf("expected the search and paging of the request to be passed on, got %+v", listed)
+	}
+}
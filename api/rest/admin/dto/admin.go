@@ -0,0 +1,78 @@
+package dto
+
+import (
+	"time"
+
+	admincmd "github.com/beka-birhanu/finance-go/application/admin"
+	usermodel "github.com/beka-birhanu/finance-go/domain/model/user"
+	"github.com/google/uuid"
+)
+
+// UserResponse carries a user as administrators see them.
+type UserResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Username   string     `json:"username"`
+	Roles      []string   `json:"roles"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+	DisabledAt *time.Time `json:"disabledAt"`
+}
+
+// FromUser maps a user to a new UserResponse.
+func FromUser(user *usermodel.User) *UserResponse {
+	return &UserResponse{
+		ID:         user.ID(),
+		Username:   user.Username(),
+		Roles:      user.Roles(),
+		CreatedAt:  user.CreatedAt(),
+		UpdatedAt:  user.UpdatedAt(),
+		DisabledAt: user.DisabledAt(),
+	}
+}
+
+// UserPageResponse carries one page of users together with the information needed to keep
+// paging.
+type UserPageResponse struct {
+	Users      []*UserResponse `json:"users"`
+	Page       int             `json:"page"`
+	PageSize   int             `json:"pageSize"`
+	TotalCount int             `json:"totalCount"`
+}
+
+// FromUserPage maps a page of users to a new UserPageResponse.
+func FromUserPage(page *admincmd.UserPage) *UserPageResponse {
+	users := make([]*UserResponse, len(page.Users))
+	for i, user := range page.Users {
+		users[i] = FromUser(user)
+	}
+	return &UserPageResponse{
+		Users:      users,
+		Page:       page.Page,
+		PageSize:   page.PageSize,
+		TotalCount: page.TotalCount,
+	}
+}
+
+// StatsResponse carries the usage statistics of the service over a period up to now.
+type StatsResponse struct {
+	Since         time.Time `json:"since"`
+	Users         int       `json:"users"`
+	DisabledUsers int       `json:"disabledUsers"`
+	NewUsers      int       `json:"newUsers"`
+	ActiveUsers   int       `json:"activeUsers"`
+	Expenses      int       `json:"expenses"`
+	NewExpenses   int       `json:"newExpenses"`
+}
+
+// FromStats maps usage statistics to a new StatsResponse.
+func FromStats(stats *admincmd.Stats) *StatsResponse {
+	return &StatsResponse{
+		Since:         stats.Since,
+		Users:         stats.Users,
+		DisabledUsers: stats.DisabledUsers,
+		NewUsers:      stats.NewUsers,
+		ActiveUsers:   stats.ActiveUsers,
+		Expenses:      stats.Expenses,
+		NewExpenses:   stats.NewExpenses,
+	}
+}
@@ -8,8 +8,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	errapi "github.com/beka-birhanu/finance-go/api/error"
+	"github.com/beka-birhanu/finance-go/api/middleware"
+	ijwt "github.com/beka-birhanu/finance-go/application/common/interface/jwt"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 )
@@ -82,6 +85,7 @@ func TestBaseHandler(t *testing.T) {
 
 		testErr := errapi.NewBadRequest("test error")
 		runTest(testErr)
+		runTest(errapi.NewTooManyRequests("TooManyRequests: too many failed sign in attempts, try again in 60 seconds", time.Minute))
 	})
 
 	// Test ParseJSON method
@@ -152,6 +156,27 @@ func TestBaseHandler(t *testing.T) {
 
 		testErr := errapi.NewBadRequest("test error")
 		runTest(testErr)
+		runTest(errapi.NewTooManyRequests("TooManyRequests: too many failed sign in attempts, try again in 60 seconds", time.Minute))
+	})
+
+	// Test the Retry-After header of RespondError
+	t.Run("RespondErrorRetryAfter", func(t *testing.T) {
+		cases := []struct {
+			err      errapi.Error
+			expected string
+		}{
+			{err: errapi.NewBadRequest("test error"), expected: ""},
+			{err: errapi.NewTooManyRequests("try again in 60 seconds", time.Minute), expected: "60"},
+			{err: errapi.NewTooManyRequests("try again in 2 seconds", 1500*time.Millisecond), expected: "2"},
+		}
+
+		for _, c := range cases {
+			w := httptest.NewRecorder()
+			handler.RespondError(w, c.err)
+			if retryAfter := w.Result().Header.Get("Retry-After"); retryAfter != c.expected {
+				t.Errorf("expected Retry-After %q, got %q", c.expected, retryAfter)
+			}
+		}
 	})
 
 	// Test RespondWithCookies method
@@ -201,6 +226,11 @@ func TestBaseHandler(t *testing.T) {
 				t.Errorf("expected content type 'application/json', got %q", contentType)
 			}
 
+			cacheControl := w.Result().Header.Get("Cache-Control")
+			if cacheControl != "private, no-cache" {
+				t.Errorf("expected cache control 'private, no-cache', got %q", cacheControl)
+			}
+
 			var response map[string]string
 			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
 				t.Fatalf("failed to decode response body: %v", err)
@@ -213,6 +243,38 @@ func TestBaseHandler(t *testing.T) {
 		runTest(http.StatusOK, map[string]string{"key": "value"})
 	})
 
+	// Test NotModified method
+	t.Run("NotModified", func(t *testing.T) {
+		lastModified := time.Date(2024, 6, 8, 8, 30, 15, 0, time.UTC)
+		runTestCase := func(header, value string, expected bool) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if header != "" {
+				r.Header.Set(header, value)
+			}
+			w := httptest.NewRecorder()
+
+			notModified := handler.NotModified(w, r, `"3"`, lastModified.Add(500*time.Millisecond))
+			if notModified != expected {
+				t.Fatalf("expected not modified %v, got %v for %s: %s", expected, notModified, header, value)
+			}
+			if w.Header().Get("ETag") != `"3"` || w.Header().Get("Last-Modified") != lastModified.Format(http.TimeFormat) {
+				t.Errorf("unexpected validators %q and %q", w.Header().Get("ETag"), w.Header().Get("Last-Modified"))
+			}
+			if expected && w.Code != http.StatusNotModified {
+				t.Errorf("expected status code %d, got %d", http.StatusNotModified, w.Code)
+			}
+		}
+
+		runTestCase("", "", false)
+		runTestCase("If-None-Match", `"3"`, true)
+		runTestCase("If-None-Match", `"2", W/"3"`, true)
+		runTestCase("If-None-Match", "*", true)
+		runTestCase("If-None-Match", `"2"`, false)
+		runTestCase("If-Modified-Since", lastModified.Format(http.TimeFormat), true)
+		runTestCase("If-Modified-Since", lastModified.Add(-time.Second).Format(http.TimeFormat), false)
+		runTestCase("If-Modified-Since", "yesterday", false)
+	})
+
 	// Test BaseURL method
 	t.Run("BaseURL", func(t *testing.T) {
 		r := &http.Request{
@@ -253,4 +315,97 @@ func TestBaseHandler(t *testing.T) {
 		runTestCase("id", invalidUUID, true)
 		runTestCase("missing", validUUID, true)
 	})
+
+	// Test FloatQueryParam method
+	t.Run("FloatQueryParam", func(t *testing.T) {
+		runTestCase := func(rawQuery string, expected *float64, shouldErr bool) {
+			r := httptest.NewRequest(http.MethodGet, "/?"+rawQuery, nil)
+
+			val, err := handler.FloatQueryParam(r, "amount")
+			if (err != nil) != shouldErr {
+				t.Fatalf("expected error: %v, got: %v for query %q", shouldErr, err, rawQuery)
+			}
+			if (val == nil) != (expected == nil) || (val != nil && *val != *expected) {
+				t.Errorf("expected value %v, got %v for query %q", expected, val, rawQuery)
+			}
+		}
+
+		amount := 12.5
+		runTestCase("amount=12.5", &amount, false)
+		runTestCase("", nil, false)
+		runTestCase("amount=twelve", nil, true)
+	})
+
+	// Test TimeQueryParam method
+	t.Run("TimeQueryParam", func(t *testing.T) {
+		runTestCase := func(rawQuery string, expected *time.Time, shouldErr bool) {
+			r := httptest.NewRequest(http.MethodGet, "/?"+rawQuery, nil)
+
+			val, err := handler.TimeQueryParam(r, "from")
+			if (err != nil) != shouldErr {
+				t.Fatalf("expected error: %v, got: %v for query %q", shouldErr, err, rawQuery)
+			}
+			if (val == nil) != (expected == nil) || (val != nil && !val.Equal(*expected)) {
+				t.Errorf("expected value %v, got %v for query %q", expected, val, rawQuery)
+			}
+		}
+
+		date := time.Date(2024, 6, 8, 0, 0, 0, 0, time.UTC)
+		timestamp := time.Date(2024, 6, 8, 8, 30, 0, 0, time.UTC)
+		runTestCase("from=2024-06-08", &date, false)
+		runTestCase("from=2024-06-08T08:30:00Z", &timestamp, false)
+		runTestCase("", nil, false)
+		runTestCase("from=yesterday", nil, true)
+	})
+	// Test MatchPathUserIdctxUserId method
+	t.Run("MatchPathUserIdctxUserId", func(t *testing.T) {
+		userId := uuid.New()
+		runTestCase := func(claims *ijwt.Claims, pathId uuid.UUID, scope string, expectedStatus int) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r = r.WithContext(middleware.WithUserClaims(r.Context(), claims))
+
+			err := handler.MatchPathUserIdctxUserId(r, pathId, scope)
+			if expectedStatus == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if apiErr, ok := err.(errapi.Error); !ok || apiErr.StatusCode() != expectedStatus {
+				t.Fatalf("expected status %d, got %v", expectedStatus, err)
+			}
+		}
+
+		readOnly := &ijwt.Claims{Subject: userId, Personal: true, Scopes: []string{"expenses:read"}}
+		runTestCase(&ijwt.Claims{Subject: userId}, userId, "expenses:write", 0)
+		runTestCase(&ijwt.Claims{Subject: userId, Personal: true}, userId, "expenses:read", errapi.Forbidden)
+		runTestCase(readOnly, userId, "expenses:read", 0)
+		runTestCase(readOnly, userId, "expenses:write", errapi.Forbidden)
+		runTestCase(readOnly, uuid.New(), "expenses:read", errapi.Forbidden)
+		runTestCase(nil, userId, "expenses:read", errapi.Authentication)
+	})
+
+	// Test UserClaims method
+	t.Run("UserClaims", func(t *testing.T) {
+		runTestCase := func(claims *ijwt.Claims, expectedStatus int) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r = r.WithContext(middleware.WithUserClaims(r.Context(), claims))
+
+			_, err := handler.UserClaims(r)
+			if expectedStatus == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if apiErr, ok := err.(errapi.Error); !ok || apiErr.StatusCode() != expectedStatus {
+				t.Fatalf("expected status %d, got %v", expectedStatus, err)
+			}
+		}
+
+		runTestCase(&ijwt.Claims{Subject: uuid.New()}, 0)
+		runTestCase(&ijwt.Claims{Subject: uuid.New(), Personal: true, Scopes: []string{"expenses:read"}}, errapi.Forbidden)
+		runTestCase(&ijwt.Claims{Subject: uuid.New(), Personal: true}, errapi.Forbidden)
+		runTestCase(nil, errapi.Authentication)
+	})
 }
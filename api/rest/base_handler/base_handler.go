@@ -5,11 +5,16 @@ package baseapi
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	errapi "github.com/beka-birhanu/finance-go/api/error"
+	"github.com/beka-birhanu/finance-go/api/middleware"
 	"github.com/beka-birhanu/finance-go/api/utils"
+	ijwt "github.com/beka-birhanu/finance-go/application/common/interface/jwt"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -44,7 +49,8 @@ func (h *BaseHandler) ValidatedBody(r *http.Request, s interface{}) error {
 func (h *BaseHandler) Problem(w http.ResponseWriter, err errapi.Error) {
 	var shadowedErr errapi.Error
 	switch err.StatusCode() {
-	case errapi.BadRequest, errapi.Conflict, errapi.NotFound, errapi.Forbidden:
+	case errapi.BadRequest, errapi.Conflict, errapi.NotFound, errapi.Forbidden, errapi.Unprocessable, errapi.PreconditionFailed,
+		errapi.TooManyRequests:
 		shadowedErr = err
 	case errapi.Authentication:
 		shadowedErr = errapi.NewAuthentication("invalid credentials")
@@ -68,8 +74,12 @@ func (h *BaseHandler) ParseJSON(r *http.Request, v interface{}) error {
 }
 
 // RespondError writes an error response with the appropriate status code and error message
-// to the HTTP response writer.
+// to the HTTP response writer. When the error tells how long to wait before retrying, the
+// wait is set in the Retry-After header, in whole seconds rounded up.
 func (h *BaseHandler) RespondError(w http.ResponseWriter, err errapi.Error) {
+	if retryAfter := err.RetryAfter(); retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(retryAfter.Seconds())), 10))
+	}
 	h.Respond(w, err.StatusCode(), map[string]string{"error": err.Error()})
 }
 
@@ -90,9 +100,12 @@ func (h *BaseHandler) RespondWithLocation(w http.ResponseWriter, status int, v i
 }
 
 // Respond writes a JSON response to the HTTP response writer with the given status code.
-// It encodes the provided interface as JSON in the response body.
+// It encodes the provided interface as JSON in the response body. Responses hold data of the
+// requesting user, so they are marked private: only the client may cache them, and it must
+// revalidate them (see NotModified) before reuse.
 func (h *BaseHandler) Respond(w http.ResponseWriter, status int, v interface{}) {
 	w.Header().Set("Content-Type", "application/json")
+	setCacheControl(w)
 	w.WriteHeader(status)
 
 	if v != nil {
@@ -102,6 +115,59 @@ func (h *BaseHandler) Respond(w http.ResponseWriter, status int, v interface{})
 	}
 }
 
+// NotModified sets the validators of a resource, its strong entity tag and, unless zero, its
+// last modification time, on the response. It then evaluates the If-None-Match and, in its
+// absence, the If-Modified-Since header of the request against them. When the cached copy
+// of the client is still current, it writes 304 Not Modified and returns true, and the
+// caller must not write anything else.
+func (h *BaseHandler) NotModified(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+	lastModified = lastModified.UTC().Truncate(time.Second)
+	w.Header().Set("ETag", etag)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+	}
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		if !etagListMatches(ifNoneMatch, etag) {
+			return false
+		}
+	} else {
+		since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+		if err != nil || lastModified.IsZero() || lastModified.After(since) {
+			return false
+		}
+	}
+
+	setCacheControl(w)
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagListMatches reports whether an If-None-Match header value matches the entity tag. It
+// uses the weak comparison: tags match when they are equal ignoring their weakness.
+func etagListMatches(header string, etag string) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// setCacheControl marks the response as private to the client unless it was already given a
+// caching policy. no-cache makes the client revalidate its copy instead of guessing how long
+// it stays fresh from the last modification time.
+func setCacheControl(w http.ResponseWriter) {
+	if w.Header().Get("Cache-Control") == "" {
+		w.Header().Set("Cache-Control", "private, no-cache")
+	}
+}
+
 // BaseURL returns the base URL of the request, including the scheme and host.
 func (h *BaseHandler) BaseURL(r *http.Request) string {
 	scheme := "http"
@@ -127,10 +193,27 @@ func (h *BaseHandler) UUIDParam(r *http.Request, paramName string) (uuid.UUID, e
 	return id, nil
 }
 
-// MatchPathUserIdctxUserId checks if the user ID from the request context matches the provided user ID.
-// It returns an error if the IDs do not match or if there is an issue retrieving the user ID from the context.
-func (h *BaseHandler) MatchPathUserIdctxUserId(r *http.Request, pathId uuid.UUID) error {
-	return utils.ConfirmUserID(r.Context(), pathId)
+// MatchPathUserIdctxUserId checks if the user ID from the request context matches the provided user ID,
+// and that the token of the request is allowed the given scope.
+// It returns an error if the IDs do not match, the scope is not allowed, or if there is an issue retrieving the user ID from the context.
+func (h *BaseHandler) MatchPathUserIdctxUserId(r *http.Request, pathId uuid.UUID, scope string) error {
+	return utils.ConfirmUserID(r.Context(), pathId, scope)
+}
+
+// UserClaims returns the claims of the authenticated user of the request, for routes managing
+// the account of the user.
+// It returns an authentication error if the request carries no claims, and a forbidden error
+// if its token is limited to scopes, as personal access tokens are; those only reach routes
+// that check their scope with MatchPathUserIdctxUserId.
+func (h *BaseHandler) UserClaims(r *http.Request) (*ijwt.Claims, error) {
+	claims, ok := middleware.UserClaims(r.Context())
+	if !ok {
+		return nil, errapi.NewAuthentication("User claims not found!")
+	}
+	if claims.Restricted() {
+		return nil, errapi.NewForbidden("Personal access tokens cannot manage the account.")
+	}
+	return claims, nil
 }
 
 // StringQueryParam retrieves a string query parameter from the request URL.
@@ -152,3 +235,35 @@ func (h *BaseHandler) IntQueryParam(r *http.Request, paramName string) (int, err
 	}
 	return val, nil
 }
+
+// FloatQueryParam retrieves an optional float query parameter from the request URL.
+// It returns nil if the parameter is absent and an error if it is not a valid number.
+func (h *BaseHandler) FloatQueryParam(r *http.Request, paramName string) (*float64, error) {
+	param := r.URL.Query().Get(paramName)
+	if param == "" {
+		return nil, nil
+	}
+
+	val, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return nil, errapi.NewBadRequest(fmt.Sprintf("invalid query parameter %s: %v", paramName, err))
+	}
+	return &val, nil
+}
+
+// TimeQueryParam retrieves an optional time query parameter from the request URL.
+// It accepts RFC 3339 timestamps and plain dates (YYYY-MM-DD), and returns nil if
+// the parameter is absent.
+func (h *BaseHandler) TimeQueryParam(r *http.Request, paramName string) (*time.Time, error) {
+	param := r.URL.Query().Get(paramName)
+	if param == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.RFC3339Nano, time.DateOnly} {
+		if val, err := time.Parse(layout, param); err == nil {
+			return &val, nil
+		}
+	}
+	return nil, errapi.NewBadRequest(fmt.Sprintf("invalid query parameter %s: expected RFC 3339 time or YYYY-MM-DD date", paramName))
+}
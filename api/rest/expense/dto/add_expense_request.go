@@ -8,4 +8,7 @@ type AddExpenseRequest struct {
 	Description string    `json:"description" validate:"required"`
 	Amount      float32   `json:"amount" validate:"required"`
 	Date        time.Time `json:"date" validate:"required"`
+	Category    string    `json:"category,omitempty" validate:"omitempty,max=64"`
+	Account     string    `json:"account,omitempty" validate:"omitempty,max=64"`
+	OnDuplicate string    `json:"onDuplicate,omitempty" validate:"omitempty,oneof=warn reject"`
 }
@@ -0,0 +1,38 @@
+package dto
+
+import (
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	expensecmd "github.com/beka-birhanu/finance-go/application/expense/command"
+	"github.com/google/uuid"
+)
+
+// AddExpenseResponse is the response to a successful creation: the created expense and the
+// stored expenses it likely duplicates, if any.
+type AddExpenseResponse struct {
+	*GetExpenseResponse
+	PossibleDuplicates []uuid.UUID `json:"possibleDuplicates,omitempty"`
+}
+
+// FromAddResult builds an AddExpenseResponse from the outcome of a creation.
+func FromAddResult(result *expensecmd.AddResult) *AddExpenseResponse {
+	return &AddExpenseResponse{
+		GetExpenseResponse: FromExpenseModel(result.Expense),
+		PossibleDuplicates: result.PossibleDuplicates,
+	}
+}
+
+// DuplicateErrorResponse is the response to a creation rejected because the expense likely
+// duplicates stored ones.
+type DuplicateErrorResponse struct {
+	Error      string      `json:"error"`
+	Duplicates []uuid.UUID `json:"duplicates"`
+}
+
+// FromDuplicateError builds a DuplicateErrorResponse from a duplicate error.
+func FromDuplicateError(err *apperror.DuplicateError) *DuplicateErrorResponse {
+	response := &DuplicateErrorResponse{Error: "expense rejected: it likely duplicates existing expenses"}
+	for _, duplicate := range err.Duplicates {
+		response.Duplicates = append(response.Duplicates, duplicate.IDs...)
+	}
+	return response
+}
@@ -0,0 +1,7 @@
+package dto
+
+// BatchAddRequest is the body of a request creating several expenses at once. Its items are
+// not validated with it, so that the errors of invalid items can be reported by their index.
+type BatchAddRequest struct {
+	Expenses []AddExpenseRequest `json:"expenses" validate:"required,min=1"`
+}
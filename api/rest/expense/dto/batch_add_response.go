@@ -0,0 +1,45 @@
+package dto
+
+import (
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+)
+
+// BatchAddResponse is the response to a successful batch creation, holding the created
+// expenses in the order of the request.
+type BatchAddResponse struct {
+	Expenses []*GetExpenseResponse `json:"expenses"`
+}
+
+// FromExpenseModels builds a BatchAddResponse from the created expenses.
+func FromExpenseModels(expenses []*expensemodel.Expense) *BatchAddResponse {
+	response := &BatchAddResponse{Expenses: make([]*GetExpenseResponse, 0, len(expenses))}
+	for _, expense := range expenses {
+		response.Expenses = append(response.Expenses, FromExpenseModel(expense))
+	}
+	return response
+}
+
+// BatchErrorResponse is the response to a rejected batch, listing every invalid item.
+type BatchErrorResponse struct {
+	Error string              `json:"error"`
+	Items []BatchItemResponse `json:"items"`
+}
+
+// BatchItemResponse is the error of a single batch item, identified by its index.
+type BatchItemResponse struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// FromBatchError builds a BatchErrorResponse from a batch error.
+func FromBatchError(err *apperror.BatchError) *BatchErrorResponse {
+	response := &BatchErrorResponse{
+		Error: "batch rejected: no expenses were created",
+		Items: make([]BatchItemResponse, 0, len(err.Items)),
+	}
+	for _, item := range err.Items {
+		response.Items = append(response.Items, BatchItemResponse{Index: item.Index, Error: item.Err.Error()})
+	}
+	return response
+}
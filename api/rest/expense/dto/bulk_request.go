@@ -0,0 +1,54 @@
+package dto
+
+import (
+	"strings"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	"github.com/google/uuid"
+)
+
+// FilterRequest narrows down the expenses selected by a bulk request. It accepts the same
+// filters as expense listings.
+type FilterRequest struct {
+	From        *time.Time `json:"from,omitempty"`
+	To          *time.Time `json:"to,omitempty"`
+	MinAmount   *float64   `json:"minAmount,omitempty"`
+	MaxAmount   *float64   `json:"maxAmount,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Category    string     `json:"category,omitempty"`
+	Account     string     `json:"account,omitempty"`
+}
+
+// BulkDeleteRequest selects the expenses to delete by IDs and/or a filter.
+type BulkDeleteRequest struct {
+	IDs    []uuid.UUID    `json:"ids,omitempty"`
+	Filter *FilterRequest `json:"filter,omitempty"`
+	DryRun bool           `json:"dryRun"`
+}
+
+// BulkUpdateRequest selects the expenses to update by IDs and/or a filter, and sets the
+// given fields on each of them.
+type BulkUpdateRequest struct {
+	IDs    []uuid.UUID    `json:"ids,omitempty"`
+	Filter *FilterRequest `json:"filter,omitempty"`
+	DryRun bool           `json:"dryRun"`
+	Set    PatchRequest   `json:"set"`
+}
+
+// ToSelection maps the selection of a bulk request to a repository selection.
+func ToSelection(ids []uuid.UUID, filter *FilterRequest) irepository.ExpenseSelection {
+	selection := irepository.ExpenseSelection{IDs: ids}
+	if filter != nil {
+		selection.Filter = irepository.ExpenseFilter{
+			DateFrom:    filter.From,
+			DateTo:      filter.To,
+			MinAmount:   filter.MinAmount,
+			MaxAmount:   filter.MaxAmount,
+			Description: strings.TrimSpace(filter.Description),
+			Category:    strings.TrimSpace(filter.Category),
+			Account:     strings.TrimSpace(filter.Account),
+		}
+	}
+	return selection
+}
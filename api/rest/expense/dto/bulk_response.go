@@ -0,0 +1,22 @@
+package dto
+
+import (
+	expensecmd "github.com/beka-birhanu/finance-go/application/expense/command"
+	"github.com/google/uuid"
+)
+
+// BulkResponse reports the expenses matched by a bulk request.
+type BulkResponse struct {
+	Matched int         `json:"matched"`
+	IDs     []uuid.UUID `json:"ids"`
+	DryRun  bool        `json:"dryRun"`
+}
+
+// FromBulkResult builds a BulkResponse from the result of a bulk command.
+func FromBulkResult(result *expensecmd.BulkResult) *BulkResponse {
+	return &BulkResponse{
+		Matched: result.Matched,
+		IDs:     result.IDs,
+		DryRun:  result.DryRun,
+	}
+}
@@ -0,0 +1,33 @@
+package dto
+
+import (
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+	"github.com/google/uuid"
+)
+
+// DuplicatesResponse is the report of the groups of likely duplicates among the expenses
+// of a user.
+type DuplicatesResponse struct {
+	Groups []DuplicateGroupResponse `json:"groups"`
+}
+
+// DuplicateGroupResponse is a group of expenses that likely record the same transaction,
+// newest first.
+type DuplicateGroupResponse struct {
+	Expenses []*GetExpenseResponse `json:"expenses"`
+}
+
+// FromDuplicateGroups builds a DuplicatesResponse from groups of likely duplicates.
+func FromDuplicateGroups(groups [][]*expensemodel.Expense) *DuplicatesResponse {
+	response := &DuplicatesResponse{Groups: make([]DuplicateGroupResponse, 0, len(groups))}
+	for _, group := range groups {
+		response.Groups = append(response.Groups, DuplicateGroupResponse{Expenses: FromExpenseModels(group).Expenses})
+	}
+	return response
+}
+
+// MergeRequest names the expense to keep and its duplicates to merge into it.
+type MergeRequest struct {
+	KeepID       uuid.UUID   `json:"keepId" validate:"required"`
+	DuplicateIDs []uuid.UUID `json:"duplicateIds" validate:"required,min=1"`
+}
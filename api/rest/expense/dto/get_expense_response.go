@@ -10,17 +10,25 @@ import (
 type GetExpenseResponse struct {
 	Id          uuid.UUID `json:"id"`
 	Amount      float32   `json:"amount"`
+	Kind        string    `json:"kind"`
 	Description string    `json:"description"`
 	Date        time.Time `json:"date"`
+	Category    string    `json:"category,omitempty"`
+	Account     string    `json:"account,omitempty"`
 	CreatedAt   time.Time `json:"createdAt"`
+	Version     int       `json:"version"`
 }
 
 func FromExpenseModel(expense *expensemodel.Expense) *GetExpenseResponse {
 	return &GetExpenseResponse{
 		Id:          expense.ID(),
 		Amount:      expense.Amount(),
+		Kind:        string(expense.Kind()),
 		Description: expense.Description(),
 		Date:        expense.Date(),
+		Category:    expense.Category(),
+		Account:     expense.Account(),
 		CreatedAt:   expense.CreatedAt(),
+		Version:     expense.Version(),
 	}
 }
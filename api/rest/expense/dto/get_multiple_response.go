@@ -1,6 +1,46 @@
 package dto
 
+import expensqry "github.com/beka-birhanu/finance-go/application/expense/query"
+
 type GetMultipleResponse struct {
-	Expenses []*GetExpenseResponse `json:"expenses"`
-	Cursor   string                `json:"cursor"`
+	Expenses   []*GetExpenseResponse `json:"expenses"`
+	Cursor     *string               `json:"cursor"`
+	PageInfo   PageInfo              `json:"pageInfo"`
+	TotalCount int                   `json:"totalCount"`
+}
+
+type PageInfo struct {
+	StartCursor     *string `json:"startCursor"`
+	EndCursor       *string `json:"endCursor"`
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+}
+
+// FromPage maps a page of expenses to a GetMultipleResponse, using buildCursor to encode
+// the position of an expense. Cursor is kept for older clients and is only set when
+// there is a next page.
+func FromPage(page *expensqry.Page, buildCursor func(int) string) *GetMultipleResponse {
+	response := &GetMultipleResponse{
+		Expenses:   make([]*GetExpenseResponse, 0, len(page.Expenses)),
+		TotalCount: page.TotalCount,
+		PageInfo: PageInfo{
+			HasNextPage:     page.HasNextPage,
+			HasPreviousPage: page.HasPreviousPage,
+		},
+	}
+
+	for _, expense := range page.Expenses {
+		response.Expenses = append(response.Expenses, FromExpenseModel(expense))
+	}
+
+	if len(page.Expenses) > 0 {
+		startCursor, endCursor := buildCursor(0), buildCursor(len(page.Expenses)-1)
+		response.PageInfo.StartCursor = &startCursor
+		response.PageInfo.EndCursor = &endCursor
+		if page.HasNextPage {
+			response.Cursor = &endCursor
+		}
+	}
+
+	return response
 }
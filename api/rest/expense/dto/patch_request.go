@@ -8,4 +8,6 @@ type PatchRequest struct {
 	Description *string    `json:"description,omitempty" validate:"omitempty"`
 	Amount      *float32   `json:"amount,omitempty" validate:"omitempty"`
 	Date        *time.Time `json:"date,omitempty" validate:"omitempty"`
+	Category    *string    `json:"category,omitempty" validate:"omitempty,max=64"`
+	Account     *string    `json:"account,omitempty" validate:"omitempty,max=64"`
 }
@@ -0,0 +1,74 @@
+package expense
+
+import (
+	"net/http"
+
+	errapi "github.com/beka-birhanu/finance-go/api/error"
+	"github.com/beka-birhanu/finance-go/api/rest/expense/dto"
+	auth "github.com/beka-birhanu/finance-go/application/authentication/common"
+	expensecmd "github.com/beka-birhanu/finance-go/application/expense/command"
+	expensqry "github.com/beka-birhanu/finance-go/application/expense/query"
+	ierr "github.com/beka-birhanu/finance-go/domain/common/error"
+)
+
+// handleDuplicates handles the request to find the groups of likely duplicates among the
+// stored expenses of a user. Any group can then be merged with handleMerge.
+func (h *ExpensesHandler) handleDuplicates(w http.ResponseWriter, r *http.Request) {
+	userId, err := h.UUIDParam(r, "userId")
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	// Extract userId for context and match with the userId form URL.
+	err = h.MatchPathUserIdctxUserId(r, userId, auth.ScopeExpensesRead)
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	groups, err := h.duplicatesHandler.Handle(&expensqry.DuplicatesQuery{UserID: userId})
+	if err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+
+	h.Respond(w, http.StatusOK, dto.FromDuplicateGroups(groups))
+}
+
+// handleMerge handles the request to merge duplicates of an expense into it. The kept
+// expense takes over the category, account and external ID it lacks, the duplicates are
+// deleted, and the kept expense is returned.
+func (h *ExpensesHandler) handleMerge(w http.ResponseWriter, r *http.Request) {
+	var mergeRequest dto.MergeRequest
+
+	if err := h.ValidatedBody(r, &mergeRequest); err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	userId, err := h.UUIDParam(r, "userId")
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	// Extract userId for context and match with the userId form URL.
+	err = h.MatchPathUserIdctxUserId(r, userId, auth.ScopeExpensesWrite)
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	expense, err := h.mergeHandler.Handle(&expensecmd.MergeCommand{
+		UserId:       userId,
+		KeepId:       mergeRequest.KeepID,
+		DuplicateIds: mergeRequest.DuplicateIDs,
+	})
+	if err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+
+	h.Respond(w, http.StatusOK, dto.FromExpenseModel(expense))
+}
@@ -0,0 +1,55 @@
+package expense
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	errapi "github.com/beka-birhanu/finance-go/api/error"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+)
+
+// versionETag returns the entity tag of an expense, which is its quoted version.
+func versionETag(expense *expensemodel.Expense) string {
+	return strconv.Quote(strconv.Itoa(expense.Version()))
+}
+
+// listETag returns the entity tag of a listing of expenses. It changes whenever an expense
+// matching the filter is created, updated or deleted, and differs between the paging, sort
+// and filter parameters of the request.
+func listETag(r *http.Request, freshness *irepository.ExpenseFreshness) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s?%s\x00%d\x00%d", r.URL.Path, r.URL.RawQuery, freshness.Count, freshness.LastUpdatedAt.UnixNano())
+	return strconv.Quote(hex.EncodeToString(hash.Sum(nil))[:32])
+}
+
+// expectedVersion returns the expense version required by the If-Match header of the
+// request, or nil when the header is absent or "*", which any existing expense matches.
+// Weak entity tags never match for If-Match, so they fail the precondition.
+func expectedVersion(r *http.Request) (*int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+	if strings.Contains(header, ",") {
+		return nil, errapi.NewBadRequest("If-Match must hold a single entity tag")
+	}
+	if strings.HasPrefix(header, "W/") {
+		return nil, errapi.NewPreconditionFailed("weak entity tags cannot be used with If-Match")
+	}
+
+	unquoted, err := strconv.Unquote(header)
+	if err != nil {
+		return nil, errapi.NewBadRequest(fmt.Sprintf("invalid If-Match entity tag %s", header))
+	}
+	version, err := strconv.Atoi(unquoted)
+	if err != nil {
+		// Not a tag this API hands out, so it cannot match the current version.
+		return nil, errapi.NewPreconditionFailed("the expense was changed since the expected version")
+	}
+	return &version, nil
+}
@@ -5,17 +5,25 @@
 package expense
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/beka-birhanu/finance-go/api/cursor"
 	errapi "github.com/beka-birhanu/finance-go/api/error"
 	baseapi "github.com/beka-birhanu/finance-go/api/rest/base_handler"
 	"github.com/beka-birhanu/finance-go/api/rest/expense/dto"
 	"github.com/beka-birhanu/finance-go/api/utils"
+	auth "github.com/beka-birhanu/finance-go/application/authentication/common"
 	icmd "github.com/beka-birhanu/finance-go/application/common/cqrs/command"
 	iquery "github.com/beka-birhanu/finance-go/application/common/cqrs/query"
+	iexporter "github.com/beka-birhanu/finance-go/application/common/interface/exporter"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
 	expensecmd "github.com/beka-birhanu/finance-go/application/expense/command"
+	expensedup "github.com/beka-birhanu/finance-go/application/expense/duplicate"
 	expensqry "github.com/beka-birhanu/finance-go/application/expense/query"
 	ierr "github.com/beka-birhanu/finance-go/domain/common/error"
 	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
@@ -26,28 +34,60 @@ import (
 // adding a new expense, retrieving expenses by user ID or expense ID, and updating existing expenses.
 type ExpensesHandler struct {
 	baseapi.BaseHandler
-	addHandler         icmd.IHandler[*expensecmd.AddCommand, *expensemodel.Expense]
+	addHandler         icmd.IHandler[*expensecmd.AddCommand, *expensecmd.AddResult]
+	batchAddHandler    icmd.IHandler[*expensecmd.BatchAddCommand, []*expensemodel.Expense]
 	getHandler         iquery.IHandler[*expensqry.GetQuery, *expensemodel.Expense]
-	getMultipleHandler iquery.IHandler[*expensqry.GetMultipleQuery, []*expensemodel.Expense]
+	getMultipleHandler iquery.IHandler[*expensqry.GetMultipleQuery, *expensqry.Page]
+	freshnessHandler   iquery.IHandler[*expensqry.FreshnessQuery, *irepository.ExpenseFreshness]
+	exportHandler      iquery.IHandler[*expensqry.ExportQuery, int]
+	journals           map[string]iexporter.IJournal
 	patchHandler       icmd.IHandler[*expensecmd.PatchCommand, *expensemodel.Expense]
+	bulkPatchHandler   icmd.IHandler[*expensecmd.BulkPatchCommand, *expensecmd.BulkResult]
+	bulkDeleteHandler  icmd.IHandler[*expensecmd.BulkDeleteCommand, *expensecmd.BulkResult]
+	duplicatesHandler  iquery.IHandler[*expensqry.DuplicatesQuery, [][]*expensemodel.Expense]
+	mergeHandler       icmd.IHandler[*expensecmd.MergeCommand, *expensemodel.Expense]
+	cursorCodec        *cursor.Codec
+	idempotent         func(http.Handler) http.Handler
 }
 
 // Config contains the configuration for setting up the ExpensesHandler,
 // including handlers for the commands and queries needed to manage expenses.
 type Config struct {
-	AddHandler         icmd.IHandler[*expensecmd.AddCommand, *expensemodel.Expense]
+	AddHandler         icmd.IHandler[*expensecmd.AddCommand, *expensecmd.AddResult]
+	BatchAddHandler    icmd.IHandler[*expensecmd.BatchAddCommand, []*expensemodel.Expense]
 	GetHandler         iquery.IHandler[*expensqry.GetQuery, *expensemodel.Expense]
-	GetMultipleHandler iquery.IHandler[*expensqry.GetMultipleQuery, []*expensemodel.Expense]
+	GetMultipleHandler iquery.IHandler[*expensqry.GetMultipleQuery, *expensqry.Page]
+	FreshnessHandler   iquery.IHandler[*expensqry.FreshnessQuery, *irepository.ExpenseFreshness]
+	ExportHandler      iquery.IHandler[*expensqry.ExportQuery, int]
+	Journals           map[string]iexporter.IJournal // Journal export formats by name
 	PatchHandler       icmd.IHandler[*expensecmd.PatchCommand, *expensemodel.Expense]
+	BulkPatchHandler   icmd.IHandler[*expensecmd.BulkPatchCommand, *expensecmd.BulkResult]
+	BulkDeleteHandler  icmd.IHandler[*expensecmd.BulkDeleteCommand, *expensecmd.BulkResult]
+	DuplicatesHandler  iquery.IHandler[*expensqry.DuplicatesQuery, [][]*expensemodel.Expense]
+	MergeHandler       icmd.IHandler[*expensecmd.MergeCommand, *expensemodel.Expense]
+	CursorCodec        *cursor.Codec
+
+	// IdempotencyMiddleware makes the expense-creating requests idempotent.
+	IdempotencyMiddleware func(http.Handler) http.Handler
 }
 
 // NewHandler initializes and returns a new ExpensesHandler with the provided configuration.
 func NewHandler(config Config) *ExpensesHandler {
 	return &ExpensesHandler{
 		addHandler:         config.AddHandler,
+		batchAddHandler:    config.BatchAddHandler,
 		getHandler:         config.GetHandler,
 		patchHandler:       config.PatchHandler,
+		bulkPatchHandler:   config.BulkPatchHandler,
+		bulkDeleteHandler:  config.BulkDeleteHandler,
 		getMultipleHandler: config.GetMultipleHandler,
+		freshnessHandler:   config.FreshnessHandler,
+		exportHandler:      config.ExportHandler,
+		journals:           config.Journals,
+		duplicatesHandler:  config.DuplicatesHandler,
+		mergeHandler:       config.MergeHandler,
+		cursorCodec:        config.CursorCodec,
+		idempotent:         config.IdempotencyMiddleware,
 	}
 }
 
@@ -56,11 +96,41 @@ func NewHandler(config Config) *ExpensesHandler {
 func (h *ExpensesHandler) RegisterPublic(router *mux.Router) {}
 
 // RegisterProtected registers protected routes for the ExpensesHandler,
-// including routes for adding, retrieving, and updating expenses.
+// including routes for adding, retrieving, exporting and updating expenses.
 func (h *ExpensesHandler) RegisterProtected(router *mux.Router) {
-	router.HandleFunc(
+	router.Handle(
 		"/users/{userId}/expenses",
-		h.handleAdd,
+		h.idempotent(http.HandlerFunc(h.handleAdd)),
+	).Methods(http.MethodPost)
+
+	router.Handle(
+		"/users/{userId}/expenses:batch",
+		h.idempotent(http.HandlerFunc(h.handleBatchAdd)),
+	).Methods(http.MethodPost)
+
+	router.HandleFunc(
+		"/users/{userId}/expenses:bulkUpdate",
+		h.handleBulkUpdate,
+	).Methods(http.MethodPost)
+
+	router.HandleFunc(
+		"/users/{userId}/expenses:bulkDelete",
+		h.handleBulkDelete,
+	).Methods(http.MethodPost)
+
+	router.HandleFunc(
+		"/users/{userId}/expenses:export",
+		h.handleExport,
+	).Methods(http.MethodGet)
+
+	router.HandleFunc(
+		"/users/{userId}/expenses:duplicates",
+		h.handleDuplicates,
+	).Methods(http.MethodGet)
+
+	router.HandleFunc(
+		"/users/{userId}/expenses:merge",
+		h.handleMerge,
 	).Methods(http.MethodPost)
 
 	router.HandleFunc(
@@ -81,7 +151,9 @@ func (h *ExpensesHandler) RegisterProtected(router *mux.Router) {
 
 // handleAdd handles the request to add a new expense for a user.
 // It validates the request body, constructs the appropriate command,
-// and returns the created expense along with its resource location.
+// and returns the created expense along with its resource location. An expense that likely
+// duplicates stored ones is created with a warning listing them, or rejected with a
+// conflict listing them when onDuplicate is reject.
 func (h *ExpensesHandler) handleAdd(w http.ResponseWriter, r *http.Request) {
 	var addExpenseRequest dto.AddExpenseRequest
 
@@ -98,7 +170,7 @@ func (h *ExpensesHandler) handleAdd(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Extract userId for context and match with the userId form URL.
-	err = h.MatchPathUserIdctxUserId(r, userId)
+	err = h.MatchPathUserIdctxUserId(r, userId, auth.ScopeExpensesWrite)
 	if err != nil {
 		h.Problem(w, err.(errapi.Error))
 		return
@@ -109,10 +181,18 @@ func (h *ExpensesHandler) handleAdd(w http.ResponseWriter, r *http.Request) {
 		Description: addExpenseRequest.Description,
 		Amount:      addExpenseRequest.Amount,
 		Date:        addExpenseRequest.Date,
+		Category:    addExpenseRequest.Category,
+		Account:     addExpenseRequest.Account,
+		OnDuplicate: expensedup.Policy(addExpenseRequest.OnDuplicate),
 	}
 
-	expense, err := h.addHandler.Handle(addExpenseCommand)
+	result, err := h.addHandler.Handle(addExpenseCommand)
 	if err != nil {
+		var duplicateErr *apperror.DuplicateError
+		if errors.As(err, &duplicateErr) {
+			h.Respond(w, http.StatusConflict, dto.FromDuplicateError(duplicateErr))
+			return
+		}
 		apiErr := errapi.Map(err.(ierr.IErr))
 		h.Problem(w, apiErr)
 		return
@@ -121,13 +201,164 @@ func (h *ExpensesHandler) handleAdd(w http.ResponseWriter, r *http.Request) {
 	baseURL := h.BaseURL(r)
 
 	// Construct the resource location URL
-	resourceLocation := fmt.Sprintf("%s%s/%s", baseURL, r.URL.Path, expense.ID().String())
-	response := dto.FromExpenseModel(expense)
+	resourceLocation := fmt.Sprintf("%s%s/%s", baseURL, r.URL.Path, result.Expense.ID().String())
+	response := dto.FromAddResult(result)
 	h.RespondWithLocation(w, http.StatusCreated, response, resourceLocation)
 }
 
+// handleBatchAdd handles the request to add several expenses for a user at once.
+// Either all expenses are created or none; when any item is invalid, the response lists
+// the error of every invalid item by its index in the request.
+func (h *ExpensesHandler) handleBatchAdd(w http.ResponseWriter, r *http.Request) {
+	var batchAddRequest dto.BatchAddRequest
+
+	if err := h.ValidatedBody(r, &batchAddRequest); err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	userId, err := h.UUIDParam(r, "userId")
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	// Extract userId for context and match with the userId form URL.
+	err = h.MatchPathUserIdctxUserId(r, userId, auth.ScopeExpensesWrite)
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	if batchErr := validateBatchItems(batchAddRequest.Expenses); batchErr != nil {
+		h.Respond(w, http.StatusBadRequest, dto.FromBatchError(batchErr))
+		return
+	}
+
+	items := make([]expensecmd.BatchAddItem, 0, len(batchAddRequest.Expenses))
+	for _, item := range batchAddRequest.Expenses {
+		items = append(items, expensecmd.BatchAddItem{
+			Description: item.Description,
+			Amount:      item.Amount,
+			Date:        item.Date,
+			Category:    item.Category,
+			Account:     item.Account,
+		})
+	}
+
+	expenses, err := h.batchAddHandler.Handle(&expensecmd.BatchAddCommand{UserId: userId, Items: items})
+	if err != nil {
+		var batchErr *apperror.BatchError
+		if errors.As(err, &batchErr) {
+			h.Respond(w, http.StatusBadRequest, dto.FromBatchError(batchErr))
+			return
+		}
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+
+	h.Respond(w, http.StatusCreated, dto.FromExpenseModels(expenses))
+}
+
+// validateBatchItems validates every item of a batch and returns a batch error listing the
+// invalid ones by their index, or nil if all of them are valid.
+func validateBatchItems(items []dto.AddExpenseRequest) *apperror.BatchError {
+	batchErr := &apperror.BatchError{}
+	for i, item := range items {
+		if err := baseapi.Validate.Struct(item); err != nil {
+			batchErr.Items = append(batchErr.Items, apperror.ItemError{
+				Index: i,
+				Err:   errapi.NewBadRequest(fmt.Sprintf("invalid payload: %v", err)),
+			})
+		}
+	}
+	if len(batchErr.Items) == 0 {
+		return nil
+	}
+	return batchErr
+}
+
+// handleBulkUpdate handles the request to update every expense of a user selected by IDs
+// and/or a filter. With dryRun set, it only reports the expenses that would change.
+func (h *ExpensesHandler) handleBulkUpdate(w http.ResponseWriter, r *http.Request) {
+	var bulkUpdateRequest dto.BulkUpdateRequest
+
+	if err := h.ValidatedBody(r, &bulkUpdateRequest); err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	userId, err := h.UUIDParam(r, "userId")
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	// Extract userId for context and match with the userId form URL.
+	err = h.MatchPathUserIdctxUserId(r, userId, auth.ScopeExpensesWrite)
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	result, err := h.bulkPatchHandler.Handle(&expensecmd.BulkPatchCommand{
+		UserId:      userId,
+		Selection:   dto.ToSelection(bulkUpdateRequest.IDs, bulkUpdateRequest.Filter),
+		DryRun:      bulkUpdateRequest.DryRun,
+		Description: bulkUpdateRequest.Set.Description,
+		Amount:      bulkUpdateRequest.Set.Amount,
+		Date:        bulkUpdateRequest.Set.Date,
+		Category:    bulkUpdateRequest.Set.Category,
+		Account:     bulkUpdateRequest.Set.Account,
+	})
+	if err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+
+	h.Respond(w, http.StatusOK, dto.FromBulkResult(result))
+}
+
+// handleBulkDelete handles the request to delete every expense of a user selected by IDs
+// and/or a filter. With dryRun set, it only reports the expenses that would be deleted.
+func (h *ExpensesHandler) handleBulkDelete(w http.ResponseWriter, r *http.Request) {
+	var bulkDeleteRequest dto.BulkDeleteRequest
+
+	if err := h.ValidatedBody(r, &bulkDeleteRequest); err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	userId, err := h.UUIDParam(r, "userId")
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	// Extract userId for context and match with the userId form URL.
+	err = h.MatchPathUserIdctxUserId(r, userId, auth.ScopeExpensesWrite)
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	result, err := h.bulkDeleteHandler.Handle(&expensecmd.BulkDeleteCommand{
+		UserId:    userId,
+		Selection: dto.ToSelection(bulkDeleteRequest.IDs, bulkDeleteRequest.Filter),
+		DryRun:    bulkDeleteRequest.DryRun,
+	})
+	if err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+
+	h.Respond(w, http.StatusOK, dto.FromBulkResult(result))
+}
+
 // handleById handles the request to retrieve a specific expense by its ID.
-// It validates the provided user ID and expense ID and returns the corresponding expense data.
+// It validates the provided user ID and expense ID and returns the corresponding expense data,
+// with its version as the ETag for conditional updates and its update time as Last-Modified.
+// A request whose cached copy is still current is answered with 304 Not Modified.
 func (h *ExpensesHandler) handleById(w http.ResponseWriter, r *http.Request) {
 	userId, err := h.UUIDParam(r, "userId")
 	if err != nil {
@@ -142,7 +373,7 @@ func (h *ExpensesHandler) handleById(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Extract userId for context and match with the userId form URL.
-	err = h.MatchPathUserIdctxUserId(r, userId)
+	err = h.MatchPathUserIdctxUserId(r, userId, auth.ScopeExpensesRead)
 	if err != nil {
 		h.Problem(w, err.(errapi.Error))
 		return
@@ -153,12 +384,17 @@ func (h *ExpensesHandler) handleById(w http.ResponseWriter, r *http.Request) {
 		h.Problem(w, errapi.Map(err.(ierr.IErr)))
 		return
 	}
+	if h.NotModified(w, r, versionETag(expense), expense.UpdatedAt()) {
+		return
+	}
 	response := dto.FromExpenseModel(expense)
 	h.Respond(w, http.StatusOK, response)
 }
 
 // handlePatch handles the request to update an existing expense.
-// It validates the request, constructs a PatchCommand, and updates the expense data.
+// It validates the request, constructs a PatchCommand, and updates the expense data. With an
+// If-Match header, the update only applies while the expense still has the ETag it names,
+// and fails with 412 Precondition Failed otherwise.
 func (h *ExpensesHandler) handlePatch(w http.ResponseWriter, r *http.Request) {
 	var patchRequest dto.PatchRequest
 	userId, err := h.UUIDParam(r, "userId")
@@ -174,7 +410,7 @@ func (h *ExpensesHandler) handlePatch(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Extract userId for context and match with the userId form URL.
-	err = h.MatchPathUserIdctxUserId(r, userId)
+	err = h.MatchPathUserIdctxUserId(r, userId, auth.ScopeExpensesWrite)
 	if err != nil {
 		h.Problem(w, err.(errapi.Error))
 		return
@@ -186,12 +422,21 @@ func (h *ExpensesHandler) handlePatch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, err := expectedVersion(r)
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
 	expense, err := h.patchHandler.Handle(&expensecmd.PatchCommand{
-		Description: patchRequest.Description,
-		Amount:      patchRequest.Amount,
-		Date:        patchRequest.Date,
-		Id:          expenseId,
-		UserId:      userId,
+		Description:     patchRequest.Description,
+		Amount:          patchRequest.Amount,
+		Date:            patchRequest.Date,
+		Category:        patchRequest.Category,
+		Account:         patchRequest.Account,
+		ExpectedVersion: version,
+		Id:              expenseId,
+		UserId:          userId,
 	})
 
 	if err != nil {
@@ -199,11 +444,15 @@ func (h *ExpensesHandler) handlePatch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	response := dto.FromExpenseModel(expense)
+	w.Header().Set("ETag", versionETag(expense))
 	h.Respond(w, http.StatusOK, response)
 }
 
 // handleByUserId handles the request to retrieve multiple expenses for a user.
-// It extracts and validates the query parameters and returns a list of expenses along with pagination data.
+// It extracts and validates the query parameters and returns a page of expenses along with
+// Relay-style paging data and the total count. The page is validated by the number and the
+// latest update time of the expenses matching the filter, and is answered with 304 Not
+// Modified while the cached copy of the client is still current.
 func (h *ExpensesHandler) handleByUserId(w http.ResponseWriter, r *http.Request) {
 	userId, err := h.UUIDParam(r, "userId")
 	if err != nil {
@@ -211,77 +460,133 @@ func (h *ExpensesHandler) handleByUserId(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = h.MatchPathUserIdctxUserId(r, userId)
+	err = h.MatchPathUserIdctxUserId(r, userId, auth.ScopeExpensesRead)
 	if err != nil {
 		h.Problem(w, errapi.NewBadRequest(err.Error()))
 		return
 	}
 
-	cursor, limit, sortField, sortOrder, err := h.extractAndValidateParams(r)
+	pagingArgs, sort, err := h.extractAndValidateParams(r)
 	if err != nil {
 		h.Problem(w, errapi.NewBadRequest(err.Error()))
 		return
 	}
 
-	queryParams, err := utils.ConstructQueryParams(userId, cursor, limit, sortField, sortOrder)
+	token, limit, backward, err := pagingArgs.Resolve()
 	if err != nil {
-		h.Problem(w, errapi.NewBadRequest(err.Error()))
+		h.Problem(w, err.(errapi.Error))
 		return
 	}
 
-	expenses, err := h.getMultipleHandler.Handle(queryParams)
+	filter, err := h.extractFilter(r)
 	if err != nil {
-		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		h.Problem(w, err.(errapi.Error))
 		return
 	}
 
-	expensesResponse := make([]*dto.GetExpenseResponse, 0)
-	for _, expense := range expenses {
-		if expense != nil {
-			expensesResponse = append(expensesResponse, dto.FromExpenseModel(expense))
-		}
+	queryParams, err := utils.ConstructQueryParams(h.cursorCodec, userId, token, limit, backward, sort, filter)
+	if err != nil {
+		h.Problem(w, errapi.NewBadRequest(err.Error()))
+		return
 	}
 
-	nextCursor := ""
-	if len(expenses) > 0 {
-		nextCursor = utils.BuildCursor(expenses[len(expenses)-1], sortField)
+	// Answer from the cache of the client when none of the listed expenses changed, before
+	// building the page. The latest update time does not move when an expense is deleted,
+	// so only the entity tag, which also covers the count, is offered as a validator.
+	freshness, err := h.freshnessHandler.Handle(&expensqry.FreshnessQuery{UserID: userId, Filter: filter})
+	if err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+	if h.NotModified(w, r, listETag(r, freshness), time.Time{}) {
+		return
 	}
 
-	response := dto.GetMultipleResponse{
-		Expenses: expensesResponse,
-		Cursor:   nextCursor,
+	page, err := h.getMultipleHandler.Handle(queryParams)
+	if err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
 	}
 
+	response := dto.FromPage(page, func(i int) string {
+		return utils.BuildCursor(h.cursorCodec, page.Expenses[i], sort, filter)
+	})
 	h.Respond(w, http.StatusOK, response)
 }
 
 // extractAndValidateParams extracts and validates the query parameters from the request,
-// including cursor, limit, sort field, and sort order. It returns these parameters or an error.
-func (h *ExpensesHandler) extractAndValidateParams(r *http.Request) (string, int, string, string, error) {
-	cursor := h.StringQueryParam(r, "cursor")
+// including the paging arguments and the sort keys. sortBy takes comma separated
+// "field.order" keys, most significant first, e.g. "amount.desc,date.asc".
+// The legacy limit and cursor parameters are accepted as aliases of first and after.
+func (h *ExpensesHandler) extractAndValidateParams(r *http.Request) (utils.PagingArgs, []irepository.SortKey, error) {
+	var pagingArgs utils.PagingArgs
+	var err error
+	if pagingArgs.First, err = h.optionalIntQueryParam(r, "first", "limit"); err != nil {
+		return pagingArgs, nil, err
+	}
+	if pagingArgs.Last, err = h.optionalIntQueryParam(r, "last"); err != nil {
+		return pagingArgs, nil, err
+	}
 
-	limit, err := h.IntQueryParam(r, "limit")
+	if after := h.StringQueryParam(r, "after"); after != "" {
+		pagingArgs.After = &after
+	} else if cursor := h.StringQueryParam(r, "cursor"); cursor != "" {
+		pagingArgs.After = &cursor
+	}
+	if before := h.StringQueryParam(r, "before"); before != "" {
+		pagingArgs.Before = &before
+	}
+
+	sort, err := utils.ParseSort(h.StringQueryParam(r, "sortBy"))
 	if err != nil {
-		return "", 0, "", "", err
+		return pagingArgs, nil, err
 	}
+	return pagingArgs, sort, nil
+}
 
-	sortBy := h.StringQueryParam(r, "sortBy")
-	sortField := "date"
-	sortOrder := "desc"
-	if sortBy != "" {
-		parts := strings.Split(sortBy, ".")
-		if len(parts) != 2 {
-			return "", 0, "", "", errapi.NewBadRequest("invalid sortBy format")
-		}
-		sortField = parts[0]
-		sortOrder = parts[1]
-		if sortField != "date" && sortField != "amount" {
-			return "", 0, "", "", errapi.NewBadRequest(fmt.Sprintf("invalid sortBy field: %s", sortField))
+// optionalIntQueryParam returns the first of the named integer query parameters that is
+// present, or nil if none of them is.
+func (h *ExpensesHandler) optionalIntQueryParam(r *http.Request, names ...string) (*int, error) {
+	for _, name := range names {
+		if h.StringQueryParam(r, name) == "" {
+			continue
 		}
-		if sortOrder != "asc" && sortOrder != "desc" {
-			return "", 0, "", "", errapi.NewBadRequest(fmt.Sprintf("invalid sortBy order: %s", sortOrder))
+		val, err := h.IntQueryParam(r, name)
+		if err != nil {
+			return nil, err
 		}
+		return &val, nil
 	}
+	return nil, nil
+}
+
+// extractFilter extracts the optional listing filters from the query parameters:
+// from, to, minAmount, maxAmount, description, category and account.
+// A plain date given for "to" covers the whole day.
+func (h *ExpensesHandler) extractFilter(r *http.Request) (irepository.ExpenseFilter, error) {
+	var filter irepository.ExpenseFilter
+	var err error
+
+	if filter.DateFrom, err = h.TimeQueryParam(r, "from"); err != nil {
+		return filter, err
+	}
+	if filter.DateTo, err = h.TimeQueryParam(r, "to"); err != nil {
+		return filter, err
+	}
+	if filter.DateTo != nil && len(h.StringQueryParam(r, "to")) == len(time.DateOnly) {
+		endOfDay := filter.DateTo.Add(24*time.Hour - time.Nanosecond)
+		filter.DateTo = &endOfDay
+	}
+	if filter.MinAmount, err = h.FloatQueryParam(r, "minAmount"); err != nil {
+		return filter, err
+	}
+	if filter.MaxAmount, err = h.FloatQueryParam(r, "maxAmount"); err != nil {
+		return filter, err
+	}
+
+	filter.Description = strings.TrimSpace(h.StringQueryParam(r, "description"))
+	filter.Category = strings.TrimSpace(h.StringQueryParam(r, "category"))
+	filter.Account = strings.TrimSpace(h.StringQueryParam(r, "account"))
 
-	return cursor, limit, sortField, sortOrder, nil
+	return filter, nil
 }
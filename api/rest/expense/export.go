@@ -0,0 +1,302 @@
+package expense
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	errapi "github.com/beka-birhanu/finance-go/api/error"
+	"github.com/beka-birhanu/finance-go/api/rest/expense/dto"
+	auth "github.com/beka-birhanu/finance-go/application/authentication/common"
+	iexporter "github.com/beka-birhanu/finance-go/application/common/interface/exporter"
+	expensqry "github.com/beka-birhanu/finance-go/application/expense/query"
+	ierr "github.com/beka-birhanu/finance-go/domain/common/error"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+)
+
+// exportFlushInterval is the number of exported expenses after which the output is flushed
+// to the client.
+const exportFlushInterval = 100
+
+// csvExportHeader is the header row of CSV exports.
+var csvExportHeader = []string{"id", "date", "description", "amount", "kind", "category", "account", "createdAt", "updatedAt"}
+
+// exportEncoder writes exported expenses in one format.
+type exportEncoder interface {
+	// Begin writes whatever precedes the first expense.
+	Begin() error
+
+	// Encode writes one expense.
+	Encode(expense *expensemodel.Expense) error
+
+	// End writes whatever follows the last expense and flushes the output to the client.
+	End() error
+}
+
+// exportFormat describes an export format.
+type exportFormat struct {
+	contentType string
+	newEncoder  func(w http.ResponseWriter, currency string) (exportEncoder, error)
+}
+
+// exportFormats are the tabular export formats by name, which are also their file extensions.
+var exportFormats = map[string]exportFormat{
+	"csv": {
+		contentType: "text/csv; charset=utf-8",
+		newEncoder: func(w http.ResponseWriter, currency string) (exportEncoder, error) {
+			return &csvEncoder{w: w, writer: csv.NewWriter(w)}, nil
+		},
+	},
+	"ndjson": {
+		contentType: "application/x-ndjson",
+		newEncoder: func(w http.ResponseWriter, currency string) (exportEncoder, error) {
+			return &ndjsonEncoder{w: w, encoder: json.NewEncoder(w)}, nil
+		},
+	},
+}
+
+// exportFormat returns the named export format: a tabular one or a journal format.
+func (h *ExpensesHandler) exportFormat(name string) (exportFormat, bool) {
+	if format, ok := exportFormats[name]; ok {
+		return format, true
+	}
+
+	journal, ok := h.journals[name]
+	if !ok {
+		return exportFormat{}, false
+	}
+	return exportFormat{
+		contentType: "text/plain; charset=utf-8",
+		newEncoder: func(w http.ResponseWriter, currency string) (exportEncoder, error) {
+			writer, err := journal.NewWriter(w, currency)
+			if err != nil {
+				return nil, err
+			}
+			return &journalEncoder{w: w, writer: writer}, nil
+		},
+	}, true
+}
+
+// exportFormatNames returns the names of the supported export formats, sorted.
+func (h *ExpensesHandler) exportFormatNames() []string {
+	names := make([]string, 0, len(exportFormats)+len(h.journals))
+	for name := range exportFormats {
+		names = append(names, name)
+	}
+	for name := range h.journals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// handleExport handles the request to export every expense of a user that matches the
+// listing filters, as CSV, newline-delimited JSON or an accounting journal depending on
+// the format query parameter; journals take the currency of the amounts from the
+// currency query parameter. Expenses are written as they are read from the database, so
+// the response is streamed rather than built in memory. Errors that occur once the first
+// expense was written can no longer be reported and cut the response short.
+func (h *ExpensesHandler) handleExport(w http.ResponseWriter, r *http.Request) {
+	userId, err := h.UUIDParam(r, "userId")
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	err = h.MatchPathUserIdctxUserId(r, userId, auth.ScopeExpensesRead)
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	formatName := h.StringQueryParam(r, "format")
+	if formatName == "" {
+		formatName = "csv"
+	}
+	format, ok := h.exportFormat(formatName)
+	if !ok {
+		h.Problem(w, errapi.NewBadRequest(fmt.Sprintf("invalid format %q: must be one of %s", formatName, strings.Join(h.exportFormatNames(), ", "))))
+		return
+	}
+
+	filter, err := h.extractFilter(r)
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	encoder, err := format.newEncoder(w, h.StringQueryParam(r, "currency"))
+	if err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+
+	started := false
+	begin := func() error {
+		started = true
+		filename := fmt.Sprintf("expenses-%s.%s", time.Now().UTC().Format("20060102"), formatName)
+		w.Header().Set("Content-Type", format.contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		w.WriteHeader(http.StatusOK)
+		return encoder.Begin()
+	}
+
+	exported, err := h.exportHandler.Handle(&expensqry.ExportQuery{
+		Context: r.Context(),
+		UserID:  userId,
+		Filter:  filter,
+		Each: func(expense *expensemodel.Expense) error {
+			if !started {
+				if err := begin(); err != nil {
+					return err
+				}
+			}
+			return encoder.Encode(expense)
+		},
+	})
+	if err != nil {
+		if !started {
+			if domainErr, ok := err.(ierr.IErr); ok {
+				h.Problem(w, errapi.Map(domainErr))
+			} else {
+				h.Problem(w, errapi.NewServerError("export failed"))
+			}
+			return
+		}
+		log.Printf("export of expenses for user %s aborted after %d rows: %v", userId, exported, err)
+		return
+	}
+
+	if !started {
+		if err := begin(); err != nil {
+			log.Printf("export of expenses for user %s failed: %v", userId, err)
+			return
+		}
+	}
+	if err := encoder.End(); err != nil {
+		log.Printf("export of expenses for user %s failed: %v", userId, err)
+	}
+}
+
+// csvEncoder writes exported expenses as CSV rows under a header row.
+type csvEncoder struct {
+	w       http.ResponseWriter
+	writer  *csv.Writer
+	written int
+}
+
+func (e *csvEncoder) Begin() error {
+	return e.writer.Write(csvExportHeader)
+}
+
+func (e *csvEncoder) Encode(expense *expensemodel.Expense) error {
+	err := e.writer.Write([]string{
+		expense.ID().String(),
+		expense.Date().Format(time.RFC3339),
+		expense.Description(),
+		strconv.FormatFloat(float64(expense.Amount()), 'f', 2, 32),
+		string(expense.Kind()),
+		expense.Category(),
+		expense.Account(),
+		expense.CreatedAt().Format(time.RFC3339),
+		expense.UpdatedAt().Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+
+	e.written++
+	if e.written%exportFlushInterval == 0 {
+		return e.flush()
+	}
+	return nil
+}
+
+func (e *csvEncoder) End() error {
+	return e.flush()
+}
+
+// flush sends the buffered rows to the client.
+func (e *csvEncoder) flush() error {
+	e.writer.Flush()
+	if err := e.writer.Error(); err != nil {
+		return err
+	}
+	flush(e.w)
+	return nil
+}
+
+// ndjsonEncoder writes exported expenses as one JSON object per line.
+type ndjsonEncoder struct {
+	w       http.ResponseWriter
+	encoder *json.Encoder
+	written int
+}
+
+func (e *ndjsonEncoder) Begin() error {
+	return nil
+}
+
+func (e *ndjsonEncoder) Encode(expense *expensemodel.Expense) error {
+	if err := e.encoder.Encode(dto.FromExpenseModel(expense)); err != nil {
+		return err
+	}
+
+	e.written++
+	if e.written%exportFlushInterval == 0 {
+		flush(e.w)
+	}
+	return nil
+}
+
+func (e *ndjsonEncoder) End() error {
+	flush(e.w)
+	return nil
+}
+
+// journalEncoder writes exported expenses as the transactions of an accounting journal.
+type journalEncoder struct {
+	w       http.ResponseWriter
+	writer  iexporter.IWriter
+	written int
+}
+
+func (e *journalEncoder) Begin() error {
+	return nil
+}
+
+func (e *journalEncoder) Encode(expense *expensemodel.Expense) error {
+	if err := e.writer.Write(expense); err != nil {
+		return err
+	}
+
+	e.written++
+	if e.written%exportFlushInterval == 0 {
+		if err := e.writer.Flush(); err != nil {
+			return err
+		}
+		flush(e.w)
+	}
+	return nil
+}
+
+func (e *journalEncoder) End() error {
+	if err := e.writer.Close(); err != nil {
+		return err
+	}
+	flush(e.w)
+	return nil
+}
+
+// flush sends the output written so far to the client, if the writer supports it.
+func flush(w http.ResponseWriter) {
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
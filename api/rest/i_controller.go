@@ -14,3 +14,9 @@ type IController interface {
 	// RegisterProtected sets up routes that require authentication.
 	RegisterProtected(router *mux.Router)
 }
+
+// IAdminController outlines route registration for controllers serving administrators only.
+type IAdminController interface {
+	// RegisterAdmin sets up routes that require authentication by an administrator.
+	RegisterAdmin(router *mux.Router)
+}
@@ -0,0 +1,24 @@
+package dto
+
+import iimporter "github.com/beka-birhanu/finance-go/application/common/interface/importer"
+
+// ColumnMappingRequest maps expense fields to the columns of an uploaded file, by header
+// name or, for files without a header, by 1-based column number.
+type ColumnMappingRequest struct {
+	Date        string `json:"date"`
+	Description string `json:"description"`
+	Amount      string `json:"amount"`
+	Category    string `json:"category"`
+	Account     string `json:"account"`
+}
+
+// ToColumnMapping converts the request to the importer column mapping.
+func (m ColumnMappingRequest) ToColumnMapping() iimporter.ColumnMapping {
+	return iimporter.ColumnMapping{
+		Date:        m.Date,
+		Description: m.Description,
+		Amount:      m.Amount,
+		Category:    m.Category,
+		Account:     m.Account,
+	}
+}
@@ -0,0 +1,89 @@
+package dto
+
+import (
+	"time"
+
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	importjobmodel "github.com/beka-birhanu/finance-go/domain/model/importjob"
+	"github.com/google/uuid"
+)
+
+// ImportRowResponse is the outcome of a single row of an imported file.
+type ImportRowResponse struct {
+	Line               int         `json:"line"`
+	Error              string      `json:"error,omitempty"`
+	Duplicate          bool        `json:"duplicate,omitempty"`
+	PossibleDuplicates []uuid.UUID `json:"possibleDuplicates,omitempty"`
+	ExpenseId          *uuid.UUID  `json:"expenseId,omitempty"`
+}
+
+// ImportJobResponse reports an import job and the outcome of every row of its file.
+type ImportJobResponse struct {
+	Id            uuid.UUID           `json:"id"`
+	Format        string              `json:"format"`
+	Status        string              `json:"status"`
+	TotalRows     int                 `json:"totalRows"`
+	ValidRows     int                 `json:"validRows"`
+	InvalidRows   int                 `json:"invalidRows"`
+	DuplicateRows int                 `json:"duplicateRows"`
+	Rows          []ImportRowResponse `json:"rows"`
+	CreatedAt     time.Time           `json:"createdAt"`
+	UpdatedAt     time.Time           `json:"updatedAt"`
+}
+
+// FromImportJobModel builds an ImportJobResponse from an import job.
+func FromImportJobModel(job *importjobmodel.ImportJob) *ImportJobResponse {
+	rows := make([]ImportRowResponse, 0, len(job.Rows()))
+	for _, row := range job.Rows() {
+		response := ImportRowResponse{
+			Line:               row.Line,
+			Error:              row.Error,
+			Duplicate:          row.Duplicate,
+			PossibleDuplicates: row.PossibleDuplicates,
+		}
+		if row.ExpenseID != uuid.Nil {
+			expenseId := row.ExpenseID
+			response.ExpenseId = &expenseId
+		}
+		rows = append(rows, response)
+	}
+
+	return &ImportJobResponse{
+		Id:            job.ID(),
+		Format:        job.Format(),
+		Status:        string(job.Status()),
+		TotalRows:     len(job.Rows()),
+		ValidRows:     job.ValidRows(),
+		InvalidRows:   job.InvalidRows(),
+		DuplicateRows: job.DuplicateRows(),
+		Rows:          rows,
+		CreatedAt:     job.CreatedAt(),
+		UpdatedAt:     job.UpdatedAt(),
+	}
+}
+
+// DuplicateErrorResponse is the response to an import rejected because rows likely
+// duplicate stored expenses.
+type DuplicateErrorResponse struct {
+	Error string                 `json:"error"`
+	Rows  []DuplicateRowResponse `json:"rows"`
+}
+
+// DuplicateRowResponse lists the stored expenses a row likely duplicates.
+type DuplicateRowResponse struct {
+	Line       int         `json:"line"`
+	Duplicates []uuid.UUID `json:"duplicates"`
+}
+
+// FromDuplicateError builds a DuplicateErrorResponse from a duplicate error, whose items
+// are identified by their line.
+func FromDuplicateError(err *apperror.DuplicateError) *DuplicateErrorResponse {
+	response := &DuplicateErrorResponse{
+		Error: "import rejected: rows likely duplicate existing expenses",
+		Rows:  make([]DuplicateRowResponse, 0, len(err.Duplicates)),
+	}
+	for _, duplicate := range err.Duplicates {
+		response.Rows = append(response.Rows, DuplicateRowResponse{Line: duplicate.Index, Duplicates: duplicate.IDs})
+	}
+	return response
+}
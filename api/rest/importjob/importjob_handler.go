@@ -0,0 +1,268 @@
+// Package importjob provides HTTP handlers for importing files of expenses,
+// reviewing the outcome of an import and undoing it.
+package importjob
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	errapi "github.com/beka-birhanu/finance-go/api/error"
+	baseapi "github.com/beka-birhanu/finance-go/api/rest/base_handler"
+	"github.com/beka-birhanu/finance-go/api/rest/importjob/dto"
+	auth "github.com/beka-birhanu/finance-go/application/authentication/common"
+	icmd "github.com/beka-birhanu/finance-go/application/common/cqrs/command"
+	iquery "github.com/beka-birhanu/finance-go/application/common/cqrs/query"
+	iimporter "github.com/beka-birhanu/finance-go/application/common/interface/importer"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	expensedup "github.com/beka-birhanu/finance-go/application/expense/duplicate"
+	importcmd "github.com/beka-birhanu/finance-go/application/importjob/command"
+	importqry "github.com/beka-birhanu/finance-go/application/importjob/query"
+	ierr "github.com/beka-birhanu/finance-go/domain/common/error"
+	importjobmodel "github.com/beka-birhanu/finance-go/domain/model/importjob"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// defaultFormat is the format of an upload that names no format and has no file extension.
+	defaultFormat = "csv"
+
+	// defaultMaxUploadSize is the maximum size of an upload, in bytes, when none is configured.
+	defaultMaxUploadSize = 10 << 20
+)
+
+// ImportsHandler handles HTTP requests for importing files of expenses.
+type ImportsHandler struct {
+	baseapi.BaseHandler
+	importHandler icmd.IHandler[*importcmd.ImportCommand, *importjobmodel.ImportJob]
+	undoHandler   icmd.IHandler[*importcmd.UndoCommand, *importjobmodel.ImportJob]
+	getHandler    iquery.IHandler[*importqry.GetQuery, *importjobmodel.ImportJob]
+	maxUploadSize int64
+}
+
+// Config contains the configuration for setting up the ImportsHandler.
+type Config struct {
+	ImportHandler icmd.IHandler[*importcmd.ImportCommand, *importjobmodel.ImportJob]
+	UndoHandler   icmd.IHandler[*importcmd.UndoCommand, *importjobmodel.ImportJob]
+	GetHandler    iquery.IHandler[*importqry.GetQuery, *importjobmodel.ImportJob]
+	MaxUploadSize int64 // Maximum size of an uploaded file in bytes; defaults to 10 MiB
+}
+
+// NewHandler initializes and returns a new ImportsHandler with the provided configuration.
+func NewHandler(config Config) *ImportsHandler {
+	maxUploadSize := config.MaxUploadSize
+	if maxUploadSize <= 0 {
+		maxUploadSize = defaultMaxUploadSize
+	}
+
+	return &ImportsHandler{
+		importHandler: config.ImportHandler,
+		undoHandler:   config.UndoHandler,
+		getHandler:    config.GetHandler,
+		maxUploadSize: maxUploadSize,
+	}
+}
+
+// RegisterPublic registers public routes for the ImportsHandler.
+// Currently, no public routes are defined.
+func (h *ImportsHandler) RegisterPublic(router *mux.Router) {}
+
+// RegisterProtected registers protected routes for the ImportsHandler,
+// including routes for importing files, retrieving imports and undoing them.
+func (h *ImportsHandler) RegisterProtected(router *mux.Router) {
+	router.HandleFunc(
+		"/users/{userId}/imports",
+		h.handleImport,
+	).Methods(http.MethodPost)
+
+	router.HandleFunc(
+		"/users/{userId}/imports/{importId}",
+		h.handleById,
+	).Methods(http.MethodGet)
+
+	router.HandleFunc(
+		"/users/{userId}/imports/{importId}:undo",
+		h.handleUndo,
+	).Methods(http.MethodPost)
+}
+
+// handleImport handles the upload of a file of expenses as a multipart form. The file is
+// parsed in the given format, or the one its extension names, with the given column
+// mapping and formats, and every row is validated; unless dryRun is set, the valid rows
+// are inserted as expenses. Rows that likely duplicate stored expenses are reported on the
+// rows, or make the import fail with a conflict listing them when onDuplicate is reject.
+func (h *ImportsHandler) handleImport(w http.ResponseWriter, r *http.Request) {
+	userId, err := h.UUIDParam(r, "userId")
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	// Extract userId for context and match with the userId form URL.
+	err = h.MatchPathUserIdctxUserId(r, userId, auth.ScopeExpensesWrite)
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxUploadSize)
+	if err := r.ParseMultipartForm(h.maxUploadSize); err != nil {
+		h.Problem(w, errapi.NewBadRequest(fmt.Sprintf("invalid upload: the request must be a multipart form of at most %d bytes", h.maxUploadSize)))
+		return
+	}
+
+	file, fileHeader, err := r.FormFile("file")
+	if err != nil {
+		h.Problem(w, errapi.NewBadRequest("invalid upload: missing file"))
+		return
+	}
+	defer file.Close()
+
+	options, err := h.extractOptions(r)
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	dryRun, err := formBool(r, "dryRun", false)
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	onDuplicate, err := expensedup.ParsePolicy(r.FormValue("onDuplicate"))
+	if err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+
+	format := formatOf(r.FormValue("format"), fileHeader.Filename)
+
+	job, err := h.importHandler.Handle(&importcmd.ImportCommand{
+		UserId:      userId,
+		Format:      format,
+		File:        file,
+		Options:     options,
+		DryRun:      dryRun,
+		OnDuplicate: onDuplicate,
+	})
+	if err != nil {
+		var duplicateErr *apperror.DuplicateError
+		if errors.As(err, &duplicateErr) {
+			h.Respond(w, http.StatusConflict, dto.FromDuplicateError(duplicateErr))
+			return
+		}
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+
+	resourceLocation := fmt.Sprintf("%s%s/%s", h.BaseURL(r), r.URL.Path, job.ID().String())
+	h.RespondWithLocation(w, http.StatusCreated, dto.FromImportJobModel(job), resourceLocation)
+}
+
+// handleById handles the request to retrieve an import and the outcome of its rows.
+func (h *ImportsHandler) handleById(w http.ResponseWriter, r *http.Request) {
+	userId, importId, err := h.importParams(r, auth.ScopeExpensesRead)
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	job, err := h.getHandler.Handle(&importqry.GetQuery{UserId: userId, ImportId: importId})
+	if err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+
+	h.Respond(w, http.StatusOK, dto.FromImportJobModel(job))
+}
+
+// handleUndo handles the request to undo a committed import, deleting every expense it
+// created.
+func (h *ImportsHandler) handleUndo(w http.ResponseWriter, r *http.Request) {
+	userId, importId, err := h.importParams(r, auth.ScopeExpensesWrite)
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	job, err := h.undoHandler.Handle(&importcmd.UndoCommand{UserId: userId, ImportId: importId})
+	if err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+
+	h.Respond(w, http.StatusOK, dto.FromImportJobModel(job))
+}
+
+// importParams extracts the user and import IDs from the path and checks that the user
+// is the authenticated one, and that their token is allowed the given scope.
+func (h *ImportsHandler) importParams(r *http.Request, scope string) (userId, importId uuid.UUID, err error) {
+	userId, err = h.UUIDParam(r, "userId")
+	if err != nil {
+		return userId, importId, err
+	}
+
+	if err = h.MatchPathUserIdctxUserId(r, userId, scope); err != nil {
+		return userId, importId, err
+	}
+
+	importId, err = h.UUIDParam(r, "importId")
+	return userId, importId, err
+}
+
+// extractOptions reads the parsing options of an upload from its form fields.
+func (h *ImportsHandler) extractOptions(r *http.Request) (iimporter.Options, error) {
+	options := iimporter.Options{
+		DateFormat:       r.FormValue("dateFormat"),
+		DecimalSeparator: r.FormValue("decimalSeparator"),
+		Delimiter:        r.FormValue("delimiter"),
+	}
+
+	if rawMapping := r.FormValue("mapping"); rawMapping != "" {
+		var mapping dto.ColumnMappingRequest
+		if err := json.Unmarshal([]byte(rawMapping), &mapping); err != nil {
+			return iimporter.Options{}, errapi.NewBadRequest("invalid mapping: must be a JSON object of column names")
+		}
+		options.Mapping = mapping.ToColumnMapping()
+	}
+
+	hasHeader, err := formBool(r, "hasHeader", true)
+	if err != nil {
+		return iimporter.Options{}, err
+	}
+	options.HasHeader = hasHeader
+
+	return options, nil
+}
+
+// formatOf returns the format of an upload: the given one, else the extension of the file
+// name, else the default format.
+func formatOf(format, filename string) string {
+	if format != "" {
+		return strings.ToLower(format)
+	}
+	if extension := strings.TrimPrefix(filepath.Ext(filename), "."); extension != "" {
+		return strings.ToLower(extension)
+	}
+	return defaultFormat
+}
+
+// formBool reads a boolean form field, returning fallback when it is absent.
+func formBool(r *http.Request, name string, fallback bool) (bool, error) {
+	raw := r.FormValue(name)
+	if raw == "" {
+		return fallback, nil
+	}
+
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, errapi.NewBadRequest(fmt.Sprintf("invalid %s: must be a boolean", name))
+	}
+	return value, nil
+}
@@ -0,0 +1,26 @@
+package dto
+
+import (
+	twofactorcmd "github.com/beka-birhanu/finance-go/application/authentication/twofactor"
+)
+
+// CodeRequest carries a code of the second factor of the user, or one of their recovery codes.
+type CodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// EnrollmentResponse carries a newly enrolled second factor.
+type EnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauthUri"`
+}
+
+// FromEnrollment maps an enrolled second factor to a new EnrollmentResponse.
+func FromEnrollment(enrollment *twofactorcmd.Enrollment) *EnrollmentResponse {
+	return &EnrollmentResponse{Secret: enrollment.Secret, OtpauthURI: enrollment.URI}
+}
+
+// RecoveryCodesResponse carries the recovery codes of the user, which are only ever shown once.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
@@ -0,0 +1,135 @@
+// Package twofactor provides HTTP handlers for managing the second factor of the signed in
+// user: enrolling and confirming it, disabling it and regenerating its recovery codes.
+package twofactor
+
+import (
+	"net/http"
+
+	errapi "github.com/beka-birhanu/finance-go/api/error"
+	baseapi "github.com/beka-birhanu/finance-go/api/rest/base_handler"
+	"github.com/beka-birhanu/finance-go/api/rest/twofactor/dto"
+	twofactorcmd "github.com/beka-birhanu/finance-go/application/authentication/twofactor"
+	icmd "github.com/beka-birhanu/finance-go/application/common/cqrs/command"
+	ierr "github.com/beka-birhanu/finance-go/domain/common/error"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// Handler manages HTTP requests for the second factor of the signed in user.
+type Handler struct {
+	baseapi.BaseHandler
+	enrollHandler     icmd.IHandler[*twofactorcmd.EnrollCommand, *twofactorcmd.Enrollment]
+	confirmHandler    icmd.IHandler[*twofactorcmd.ConfirmCommand, []string]
+	disableHandler    icmd.IHandler[*twofactorcmd.DisableCommand, struct{}]
+	regenerateHandler icmd.IHandler[*twofactorcmd.RegenerateCommand, []string]
+}
+
+// Config holds the dependencies needed to create a Handler.
+type Config struct {
+	EnrollHandler     icmd.IHandler[*twofactorcmd.EnrollCommand, *twofactorcmd.Enrollment]
+	ConfirmHandler    icmd.IHandler[*twofactorcmd.ConfirmCommand, []string]
+	DisableHandler    icmd.IHandler[*twofactorcmd.DisableCommand, struct{}]
+	RegenerateHandler icmd.IHandler[*twofactorcmd.RegenerateCommand, []string]
+}
+
+// NewHandler creates a new Handler with the given configuration.
+func NewHandler(config Config) *Handler {
+	return &Handler{
+		enrollHandler:     config.EnrollHandler,
+		confirmHandler:    config.ConfirmHandler,
+		disableHandler:    config.DisableHandler,
+		regenerateHandler: config.RegenerateHandler,
+	}
+}
+
+// RegisterPublic registers public routes for the Handler.
+// Currently, no public routes are defined.
+func (h *Handler) RegisterPublic(router *mux.Router) {}
+
+// RegisterProtected registers the routes managing the second factor of the signed in user.
+func (h *Handler) RegisterProtected(router *mux.Router) {
+	router.HandleFunc("/users/2fa/enroll", h.handleEnroll).Methods(http.MethodPost)
+	router.HandleFunc("/users/2fa/confirm", h.handleConfirm).Methods(http.MethodPost)
+	router.HandleFunc("/users/2fa/disable", h.handleDisable).Methods(http.MethodPost)
+	router.HandleFunc("/users/2fa/recovery-codes", h.handleRegenerate).Methods(http.MethodPost)
+}
+
+// handleEnroll enrolls a new second factor for the user and responds with its secret and
+// otpauth URI. It is not required at sign in until it is confirmed.
+func (h *Handler) handleEnroll(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.UserClaims(r)
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	enrollment, err := h.enrollHandler.Handle(&twofactorcmd.EnrollCommand{UserID: claims.Subject})
+	if err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+	h.Respond(w, http.StatusOK, dto.FromEnrollment(enrollment))
+}
+
+// handleConfirm confirms the enrolled second factor with a code of it, enabling it, and
+// responds with the first recovery codes.
+func (h *Handler) handleConfirm(w http.ResponseWriter, r *http.Request) {
+	userId, codeRequest, err := h.codeRequest(r)
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	codes, err := h.confirmHandler.Handle(&twofactorcmd.ConfirmCommand{UserID: userId, Code: codeRequest.Code})
+	if err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+	h.Respond(w, http.StatusOK, &dto.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// handleDisable removes the second factor of the user, given a code of it or a recovery code.
+func (h *Handler) handleDisable(w http.ResponseWriter, r *http.Request) {
+	userId, codeRequest, err := h.codeRequest(r)
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	if _, err := h.disableHandler.Handle(&twofactorcmd.DisableCommand{UserID: userId, Code: codeRequest.Code}); err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRegenerate replaces the recovery codes of the user, given a code of the second factor
+// or a recovery code, and responds with the new ones.
+func (h *Handler) handleRegenerate(w http.ResponseWriter, r *http.Request) {
+	userId, codeRequest, err := h.codeRequest(r)
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	codes, err := h.regenerateHandler.Handle(&twofactorcmd.RegenerateCommand{UserID: userId, Code: codeRequest.Code})
+	if err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+	h.Respond(w, http.StatusOK, &dto.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// codeRequest returns the ID of the signed in user and the code in the body of the request.
+func (h *Handler) codeRequest(r *http.Request) (uuid.UUID, *dto.CodeRequest, error) {
+	claims, err := h.UserClaims(r)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	var codeRequest dto.CodeRequest
+	if err := h.ValidatedBody(r, &codeRequest); err != nil {
+		return uuid.Nil, nil, err
+	}
+	return claims.Subject, &codeRequest, nil
+}
@@ -0,0 +1,156 @@
+package twofactor
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/beka-birhanu/finance-go/api/middleware"
+	twofactorcmd "github.com/beka-birhanu/finance-go/application/authentication/twofactor"
+	icmd "github.com/beka-birhanu/finance-go/application/common/cqrs/command"
+	ijwt "github.com/beka-birhanu/finance-go/application/common/interface/jwt"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// mockCommandHandler mocks the two-factor command handlers.
+type mockCommandHandler[C any, R any] struct {
+	handleFunc func(cmd C) (R, error)
+}
+
+func (m *mockCommandHandler[C, R]) Handle(cmd C) (R, error) {
+	return m.handleFunc(cmd)
+}
+
+var (
+	_ icmd.IHandler[*twofactorcmd.EnrollCommand, *twofactorcmd.Enrollment] = &mockCommandHandler[*twofactorcmd.EnrollCommand, *twofactorcmd.Enrollment]{}
+	_ icmd.IHandler[*twofactorcmd.ConfirmCommand, []string]                = &mockCommandHandler[*twofactorcmd.ConfirmCommand, []string]{}
+	_ icmd.IHandler[*twofactorcmd.DisableCommand, struct{}]                = &mockCommandHandler[*twofactorcmd.DisableCommand, struct{}]{}
+	_ icmd.IHandler[*twofactorcmd.RegenerateCommand, []string]             = &mockCommandHandler[*twofactorcmd.RegenerateCommand, []string]{}
+)
+
+func TestHandler(t *testing.T) {
+	userId := uuid.New()
+	recoveryCodes := []string{"aaaaaaaa-bbbbbbbb"}
+	checkCode := func(id uuid.UUID, code string) error {
+		if id != userId || code != "123456" {
+			return errdmn.NewValidation("invalid two-factor code")
+		}
+		return nil
+	}
+
+	h := NewHandler(Config{
+		EnrollHandler: &mockCommandHandler[*twofactorcmd.EnrollCommand, *twofactorcmd.Enrollment]{
+			handleFunc: func(cmd *twofactorcmd.EnrollCommand) (*twofactorcmd.Enrollment, error) {
+				return &twofactorcmd.Enrollment{Secret: "SECRET", URI: "otpauth://totp/app:user?secret=SECRET"}, nil
+			},
+		},
+		ConfirmHandler: &mockCommandHandler[*twofactorcmd.ConfirmCommand, []string]{
+			handleFunc: func(cmd *twofactorcmd.ConfirmCommand) ([]string, error) {
+				return recoveryCodes, checkCode(cmd.UserID, cmd.Code)
+			},
+		},
+		DisableHandler: &mockCommandHandler[*twofactorcmd.DisableCommand, struct{}]{
+			handleFunc: func(cmd *twofactorcmd.DisableCommand) (struct{}, error) {
+				return struct{}{}, checkCode(cmd.UserID, cmd.Code)
+			},
+		},
+		RegenerateHandler: &mockCommandHandler[*twofactorcmd.RegenerateCommand, []string]{
+			handleFunc: func(cmd *twofactorcmd.RegenerateCommand) ([]string, error) {
+				return recoveryCodes, checkCode(cmd.UserID, cmd.Code)
+			},
+		},
+	})
+	router := mux.NewRouter()
+	h.RegisterProtected(router)
+
+	tests := []struct {
+		name             string
+		url              string
+		claims           *ijwt.Claims
+		body             string
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			name:             "Enroll",
+			url:              "/users/2fa/enroll",
+			claims:           &ijwt.Claims{Subject: userId},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"secret":"SECRET","otpauthUri":"otpauth://totp/app:user?secret=SECRET"}`,
+		},
+		{
+			name:           "Enroll Without User Claims",
+			url:            "/users/2fa/enroll",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:             "Confirm",
+			url:              "/users/2fa/confirm",
+			claims:           &ijwt.Claims{Subject: userId},
+			body:             `{"code":"123456"}`,
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"recoveryCodes":["aaaaaaaa-bbbbbbbb"]}`,
+		},
+		{
+			name:           "Confirm With Invalid Code",
+			url:            "/users/2fa/confirm",
+			claims:         &ijwt.Claims{Subject: userId},
+			body:           `{"code":"000000"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Confirm Without Code",
+			url:            "/users/2fa/confirm",
+			claims:         &ijwt.Claims{Subject: userId},
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Disable",
+			url:            "/users/2fa/disable",
+			claims:         &ijwt.Claims{Subject: userId},
+			body:           `{"code":"123456"}`,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:             "Regenerate Recovery Codes",
+			url:              "/users/2fa/recovery-codes",
+			claims:           &ijwt.Claims{Subject: userId},
+			body:             `{"code":"123456"}`,
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"recoveryCodes":["aaaaaaaa-bbbbbbbb"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, tt.url, bytes.NewBufferString(tt.body))
+			req = req.WithContext(middleware.WithUserClaims(req.Context(), tt.claims))
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+			if tt.expectedResponse == "" {
+				return
+			}
+
+			var expected, actual interface{}
+			json.Unmarshal([]byte(tt.expectedResponse), &expected)
+			if err := json.NewDecoder(rr.Body).Decode(&actual); err != nil {
+				t.Fatalf("failed to decode response body: %v", err)
+			}
+			expectedJSON, _ := json.Marshal(expected)
+			actualJSON, _ := json.Marshal(actual)
+			if !bytes.Equal(expectedJSON, actualJSON) {
+				t.Errorf("expected response %s, got %s", expectedJSON, actualJSON)
+			}
+		})
+	}
+}
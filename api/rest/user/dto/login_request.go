@@ -1,6 +1,16 @@
 package dto
 
+const (
+	// TokenDeliveryCookie delivers the tokens of a session in HttpOnly cookies, for browsers.
+	TokenDeliveryCookie = "cookie"
+
+	// TokenDeliveryBody delivers the tokens of a session in the response body, for clients
+	// that send the access token in an "Authorization: Bearer" header.
+	TokenDeliveryBody = "body"
+)
+
 type LoginUserRequest struct {
-	Username string `json:"username" validate:"required"`
-	Password string `json:"password" validate:"required"`
+	Username      string `json:"username" validate:"required"`
+	Password      string `json:"password" validate:"required"`
+	TokenDelivery string `json:"tokenDelivery" validate:"omitempty,oneof=cookie body"`
 }
@@ -0,0 +1,18 @@
+package dto
+
+// ChangePasswordRequest carries the current and the new password of an authenticated user.
+type ChangePasswordRequest struct {
+	OldPassword string `json:"oldPassword" validate:"required"`
+	NewPassword string `json:"newPassword" validate:"required,min=8"`
+}
+
+// PasswordResetRequest carries the username of a user who forgot their password.
+type PasswordResetRequest struct {
+	Username string `json:"username" validate:"required"`
+}
+
+// ResetPasswordRequest carries a password reset token and the new password it sets.
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"newPassword" validate:"required,min=8"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+// RefreshRequest carries the refresh token of clients that do not use cookies.
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
+// LogoutRequest optionally carries the refresh token of the session being ended, for clients
+// that do not use cookies.
+type LogoutRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
@@ -0,0 +1,25 @@
+package dto
+
+import (
+	"github.com/beka-birhanu/finance-go/application/authentication/common"
+)
+
+type TokenResponse struct {
+	ID           string `json:"id"`
+	Username     string `json:"username"`
+	TokenType    string `json:"tokenType"`
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+}
+
+// TokensFromAuthResult extracts the info for a response delivering the tokens in the body
+// from the given auth.Result and map them to new TokenResponse
+func TokensFromAuthResult(authResult *auth.Result) *TokenResponse {
+	return &TokenResponse{
+		ID:           authResult.ID.String(),
+		Username:     authResult.Username,
+		TokenType:    "Bearer",
+		AccessToken:  authResult.Token,
+		RefreshToken: authResult.RefreshToken,
+	}
+}
@@ -0,0 +1,31 @@
+package dto
+
+import (
+	"github.com/beka-birhanu/finance-go/application/authentication/common"
+)
+
+// TwoFactorLoginRequest completes a sign in with a code of the second factor of the user.
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challengeToken" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+	TokenDelivery  string `json:"tokenDelivery" validate:"omitempty,oneof=cookie body"`
+}
+
+// TwoFactorChallengeResponse answers the first step of a sign in of a user with a second factor.
+type TwoFactorChallengeResponse struct {
+	ID                string `json:"id"`
+	Username          string `json:"username"`
+	TwoFactorRequired bool   `json:"twoFactorRequired"`
+	ChallengeToken    string `json:"challengeToken"`
+}
+
+// ChallengeFromAuthResult extracts the info for the response to the first step of a sign in
+// from the given auth.Result and map them to new TwoFactorChallengeResponse
+func ChallengeFromAuthResult(authResult *auth.Result) *TwoFactorChallengeResponse {
+	return &TwoFactorChallengeResponse{
+		ID:                authResult.ID.String(),
+		Username:          authResult.Username,
+		TwoFactorRequired: true,
+		ChallengeToken:    authResult.ChallengeToken,
+	}
+}
@@ -5,13 +5,19 @@ package user
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	errapi "github.com/beka-birhanu/finance-go/api/error"
 	baseapi "github.com/beka-birhanu/finance-go/api/rest/base_handler"
 	"github.com/beka-birhanu/finance-go/api/rest/user/dto"
 	registercmd "github.com/beka-birhanu/finance-go/application/authentication/command"
 	auth "github.com/beka-birhanu/finance-go/application/authentication/common"
+	oidccmd "github.com/beka-birhanu/finance-go/application/authentication/oidc"
+	passwordcmd "github.com/beka-birhanu/finance-go/application/authentication/password"
 	loginqry "github.com/beka-birhanu/finance-go/application/authentication/query"
+	refreshcmd "github.com/beka-birhanu/finance-go/application/authentication/refresh"
+	sessioncmd "github.com/beka-birhanu/finance-go/application/authentication/session"
+	twofactorcmd "github.com/beka-birhanu/finance-go/application/authentication/twofactor"
 	icmd "github.com/beka-birhanu/finance-go/application/common/cqrs/command"
 	iquery "github.com/beka-birhanu/finance-go/application/common/cqrs/query"
 	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
@@ -19,12 +25,37 @@ import (
 	"github.com/gorilla/mux"
 )
 
+const (
+	// refreshTokenCookie is the name of the cookie holding the refresh token.
+	refreshTokenCookie = "refreshToken"
+
+	// refreshTokenPath limits the refresh token cookie to the user routes, so that it is not
+	// sent along with every request.
+	refreshTokenPath = "/api/v1/users"
+
+	// oidcStateCookie is the name of the cookie holding the state of a sign in through an
+	// OpenID Connect provider, which proves that the browser completing it started it.
+	oidcStateCookie = "oidcState"
+
+	// oidcStatePath limits the state cookie to the routes of sign ins through providers.
+	oidcStatePath = "/api/v1/users/oidc"
+)
+
 // Handler manages HTTP requests related to user actions such as registration and login.
 type Handler struct {
 	baseapi.BaseHandler
 	repository      irepository.IUserRepository
 	registerHandler icmd.IHandler[*registercmd.Command, *auth.Result]
 	loginHandler    iquery.IHandler[*loginqry.Query, *auth.Result]
+	twoFactorLogin  icmd.IHandler[*twofactorcmd.VerifyCommand, *auth.Result]
+	refreshHandler  icmd.IHandler[*refreshcmd.Command, *auth.Result]
+	logoutHandler   icmd.IHandler[*sessioncmd.Command, struct{}]
+	passwordHandler icmd.IHandler[*passwordcmd.ChangeCommand, struct{}]
+	requestReset    icmd.IHandler[*passwordcmd.RequestResetCommand, struct{}]
+	resetHandler    icmd.IHandler[*passwordcmd.ResetCommand, struct{}]
+	oidcStart       icmd.IHandler[*oidccmd.StartCommand, *oidccmd.Authorization]
+	oidcCallback    icmd.IHandler[*oidccmd.CallbackCommand, *auth.Result]
+	refreshTTL      time.Duration
 }
 
 // Config holds the dependencies needed to create a Handler.
@@ -32,6 +63,15 @@ type Config struct {
 	UserRepository  irepository.IUserRepository
 	RegisterHandler icmd.IHandler[*registercmd.Command, *auth.Result]
 	LoginHandler    iquery.IHandler[*loginqry.Query, *auth.Result]
+	TwoFactorLogin  icmd.IHandler[*twofactorcmd.VerifyCommand, *auth.Result]
+	RefreshHandler  icmd.IHandler[*refreshcmd.Command, *auth.Result]
+	LogoutHandler   icmd.IHandler[*sessioncmd.Command, struct{}]
+	PasswordHandler icmd.IHandler[*passwordcmd.ChangeCommand, struct{}]
+	RequestReset    icmd.IHandler[*passwordcmd.RequestResetCommand, struct{}]
+	ResetHandler    icmd.IHandler[*passwordcmd.ResetCommand, struct{}]
+	OIDCStart       icmd.IHandler[*oidccmd.StartCommand, *oidccmd.Authorization]
+	OIDCCallback    icmd.IHandler[*oidccmd.CallbackCommand, *auth.Result]
+	RefreshTokenTTL time.Duration // Lifetime of the refresh token cookie
 }
 
 // NewHandler creates a new Handler with the given configuration.
@@ -40,24 +80,45 @@ func NewHandler(config Config) *Handler {
 		repository:      config.UserRepository,
 		registerHandler: config.RegisterHandler,
 		loginHandler:    config.LoginHandler,
+		twoFactorLogin:  config.TwoFactorLogin,
+		refreshHandler:  config.RefreshHandler,
+		logoutHandler:   config.LogoutHandler,
+		passwordHandler: config.PasswordHandler,
+		requestReset:    config.RequestReset,
+		resetHandler:    config.ResetHandler,
+		oidcStart:       config.OIDCStart,
+		oidcCallback:    config.OIDCCallback,
+		refreshTTL:      config.RefreshTokenTTL,
 	}
 }
 
-// RegisterPublicRoutes registers public routes for user registration and login.
-// These routes are accessible without authentication.
+// RegisterPublicRoutes registers public routes for user registration, login, login through
+// OpenID Connect providers, token refresh and password reset. These routes are accessible
+// without authentication.
 func (h *Handler) RegisterPublic(router *mux.Router) {
 	router.HandleFunc("/users/register", h.handleRegistration).Methods(http.MethodPost)
 	router.HandleFunc("/users/login", h.handleLogin).Methods(http.MethodPost)
+	router.HandleFunc("/users/login/2fa", h.handleTwoFactorLogin).Methods(http.MethodPost)
+	router.HandleFunc("/users/oidc/{provider}/login", h.handleOIDCLogin).Methods(http.MethodGet)
+	router.HandleFunc("/users/oidc/{provider}/callback", h.handleOIDCCallback).Methods(http.MethodGet)
+	router.HandleFunc("/users/refresh", h.handleRefresh).Methods(http.MethodPost)
+	router.HandleFunc("/users/password/reset-request", h.handleRequestPasswordReset).Methods(http.MethodPost)
+	router.HandleFunc("/users/password/reset", h.handleResetPassword).Methods(http.MethodPost)
 }
 
-// RegisterProtectedRoutes registers routes that require authentication.
-// This method is a placeholder and currently does not register any routes.
-func (h *Handler) RegisterProtected(router *mux.Router) {}
+// RegisterProtectedRoutes registers routes that require authentication: logging out of the
+// current session or of all sessions, and changing the password.
+func (h *Handler) RegisterProtected(router *mux.Router) {
+	router.HandleFunc("/users/logout", h.handleLogout(false)).Methods(http.MethodPost)
+	router.HandleFunc("/users/logout:all", h.handleLogout(true)).Methods(http.MethodPost)
+	router.HandleFunc("/users/password", h.handleChangePassword).Methods(http.MethodPost)
+}
 
 // handleRegistration processes user registration requests.
 // It validates the registration request, creates a registration command,
 // and uses the registerHandler to handle the registration logic. On success,
-// it sends a response with the authentication result and sets a cookie with the access token.
+// it sends a response with the authentication result and sets cookies with the access and
+// refresh tokens.
 func (h *Handler) handleRegistration(w http.ResponseWriter, r *http.Request) {
 	var registerRequest dto.RegisterRequest
 	if err := h.ValidatedBody(r, &registerRequest); err != nil {
@@ -89,13 +150,15 @@ func (h *Handler) handleRegistration(w http.ResponseWriter, r *http.Request) {
 		SameSite: http.SameSiteStrictMode,
 	}
 
-	h.RespondWithCookies(w, http.StatusOK, registerResponse, []*http.Cookie{&cookie})
+	h.RespondWithCookies(w, http.StatusOK, registerResponse, h.withRefreshCookie(authResult, &cookie))
 }
 
 // handleLogin processes user login requests.
 // It validates the login request, creates a login query, and uses the loginHandler
 // to handle the login logic. On success, it sends a response with the authentication result
-// and sets a cookie with the access token.
+// and sets cookies with the access and refresh tokens, or, when the request asks for the
+// tokens to be delivered in the body, sends them in the response instead of cookies. Users
+// with a second factor get a challenge token instead, which they complete the sign in with.
 func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
 	var loginRequest dto.LoginUserRequest
 	if err := h.ValidatedBody(r, &loginRequest); err != nil {
@@ -109,6 +172,104 @@ func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
 		h.Problem(w, errapi.Map(err.(ierr.IErr)))
 		return
 	}
+	if authResult.ChallengeToken != "" {
+		h.Respond(w, http.StatusOK, dto.ChallengeFromAuthResult(authResult))
+		return
+	}
+
+	h.respondWithSession(w, authResult, loginRequest.TokenDelivery)
+}
+
+// handleTwoFactorLogin processes the second step of the login of users with a second factor.
+// It exchanges the challenge token of the first step and a code of the second factor, or a
+// recovery code, for the tokens of a session, delivered as for handleLogin. Responds with
+// 401 Unauthorized if the challenge or the code is not accepted.
+func (h *Handler) handleTwoFactorLogin(w http.ResponseWriter, r *http.Request) {
+	var loginRequest dto.TwoFactorLoginRequest
+	if err := h.ValidatedBody(r, &loginRequest); err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	authResult, err := h.twoFactorLogin.Handle(&twofactorcmd.VerifyCommand{
+		ChallengeToken: loginRequest.ChallengeToken,
+		Code:           loginRequest.Code,
+	})
+	if err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+
+	h.respondWithSession(w, authResult, loginRequest.TokenDelivery)
+}
+
+// handleOIDCLogin starts a sign in through the OpenID Connect provider of the path, by
+// redirecting the browser to the provider. The state of the sign in is kept in a cookie, so
+// that only the browser that started it can complete it. Responds with 404 Not Found if there
+// is no such provider.
+func (h *Handler) handleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	authorization, err := h.oidcStart.Handle(&oidccmd.StartCommand{Provider: mux.Vars(r)["provider"]})
+	if err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+
+	// Lax rather than Strict, so that the cookie is sent along when the provider, another
+	// site, sends the browser back.
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    authorization.State,
+		Path:     oidcStatePath,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authorization.URL, http.StatusFound)
+}
+
+// handleOIDCCallback completes a sign in through the OpenID Connect provider of the path,
+// which sends the browser back with an authorization code, and sets cookies with the access
+// and refresh tokens as for handleLogin. Users with a second factor get a challenge token
+// instead. Responds with 401 Unauthorized if the sign in failed at the provider, or is
+// unknown, expired, or not started by the browser.
+func (h *Handler) handleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: oidcStatePath, MaxAge: -1, HttpOnly: true, Secure: true})
+
+	query := r.URL.Query()
+	if providerError := query.Get("error"); providerError != "" {
+		h.Problem(w, errapi.NewAuthentication("sign in failed at the identity provider: "+providerError))
+		return
+	}
+
+	callbackCommand := &oidccmd.CallbackCommand{
+		Provider: mux.Vars(r)["provider"],
+		Code:     query.Get("code"),
+		State:    query.Get("state"),
+	}
+	if stateCookie, err := r.Cookie(oidcStateCookie); err == nil {
+		callbackCommand.StateCookie = stateCookie.Value
+	}
+
+	authResult, err := h.oidcCallback.Handle(callbackCommand)
+	if err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+	if authResult.ChallengeToken != "" {
+		h.Respond(w, http.StatusOK, dto.ChallengeFromAuthResult(authResult))
+		return
+	}
+
+	h.respondWithSession(w, authResult, dto.TokenDeliveryCookie)
+}
+
+// respondWithSession sends the tokens of a new session, in cookies or, when tokenDelivery
+// asks for it, in the response body.
+func (h *Handler) respondWithSession(w http.ResponseWriter, authResult *auth.Result, tokenDelivery string) {
+	if tokenDelivery == dto.TokenDeliveryBody {
+		h.Respond(w, http.StatusOK, dto.TokensFromAuthResult(authResult))
+		return
+	}
 
 	loginResponse := dto.FromAuthResult(authResult)
 	cookie := http.Cookie{
@@ -121,5 +282,213 @@ func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
 		SameSite: http.SameSiteLaxMode,
 	}
 
-	h.RespondWithCookies(w, http.StatusOK, loginResponse, []*http.Cookie{&cookie})
+	h.RespondWithCookies(w, http.StatusOK, loginResponse, h.withRefreshCookie(authResult, &cookie))
+}
+
+// handleRefresh processes token refresh requests.
+// It exchanges the refresh token for a new access token and a new refresh token, which
+// replaces the exchanged one. The refresh token is read from its cookie, or from the request
+// body, in which case the new tokens are sent in the response body rather than cookies.
+// Responds with 401 Unauthorized if the token is missing or not accepted.
+func (h *Handler) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	refreshToken := ""
+	if refreshCookie, err := r.Cookie(refreshTokenCookie); err == nil {
+		refreshToken = refreshCookie.Value
+	}
+
+	// Clients without cookies send the refresh token in the body and get the new tokens back in the body.
+	inBody := refreshToken == "" && r.ContentLength != 0
+	if inBody {
+		var refreshRequest dto.RefreshRequest
+		if err := h.ValidatedBody(r, &refreshRequest); err != nil {
+			h.Problem(w, err.(errapi.Error))
+			return
+		}
+		refreshToken = refreshRequest.RefreshToken
+	}
+	if refreshToken == "" {
+		h.Problem(w, errapi.NewAuthentication("missing refresh token"))
+		return
+	}
+
+	authResult, err := h.refreshHandler.Handle(&refreshcmd.Command{Token: refreshToken})
+	if err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+	if inBody {
+		h.Respond(w, http.StatusOK, dto.TokensFromAuthResult(authResult))
+		return
+	}
+
+	refreshResponse := dto.FromAuthResult(authResult)
+	cookie := http.Cookie{
+		Name:     "accessToken",
+		Value:    authResult.Token,
+		Path:     "/",
+		MaxAge:   24 * 60,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	}
+
+	h.RespondWithCookies(w, http.StatusOK, refreshResponse, h.withRefreshCookie(authResult, &cookie))
+}
+
+// withRefreshCookie returns the access token cookie along with a cookie holding the refresh
+// token of the authentication result, if one was issued.
+func (h *Handler) withRefreshCookie(authResult *auth.Result, accessCookie *http.Cookie) []*http.Cookie {
+	cookies := []*http.Cookie{accessCookie}
+	if authResult.RefreshToken == "" {
+		return cookies
+	}
+
+	return append(cookies, &http.Cookie{
+		Name:     refreshTokenCookie,
+		Value:    authResult.RefreshToken,
+		Path:     refreshTokenPath,
+		MaxAge:   int(h.refreshTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// handleLogout returns the handler for logout requests, which ends the current session, or
+// every session of the user when allSessions is set. The access and refresh tokens are
+// revoked, so they are rejected even by clients that keep them, and their cookies are cleared.
+func (h *Handler) handleLogout(allSessions bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logoutCommand, err := h.logoutCommandFromRequest(r)
+		if err != nil {
+			h.Problem(w, err.(errapi.Error))
+			return
+		}
+		logoutCommand.AllSessions = allSessions
+		if logoutCommand.RefreshToken == "" {
+			if logoutCommand.RefreshToken, err = h.logoutRefreshToken(r); err != nil {
+				h.Problem(w, err.(errapi.Error))
+				return
+			}
+		}
+
+		if _, err := h.logoutHandler.Handle(logoutCommand); err != nil {
+			h.Problem(w, errapi.Map(err.(ierr.IErr)))
+			return
+		}
+
+		h.clearTokenCookies(w)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// clearTokenCookies clears the access and refresh token cookies of an ended session.
+func (h *Handler) clearTokenCookies(w http.ResponseWriter) {
+	for _, cookie := range []*http.Cookie{
+		{Name: "accessToken", Path: "/", MaxAge: -1, HttpOnly: true, Secure: true},
+		{Name: refreshTokenCookie, Path: refreshTokenPath, MaxAge: -1, HttpOnly: true, Secure: true},
+	} {
+		http.SetCookie(w, cookie)
+	}
+}
+
+// logoutCommandFromRequest builds the logout command for the session of the request, from
+// the claims of its access token and its refresh token cookie.
+func (h *Handler) logoutCommandFromRequest(r *http.Request) (*sessioncmd.Command, error) {
+	claims, err := h.UserClaims(r)
+	if err != nil {
+		return nil, err
+	}
+
+	logoutCommand := &sessioncmd.Command{
+		UserID:    claims.Subject,
+		TokenID:   claims.ID,
+		ExpiresAt: claims.ExpiresAt,
+	}
+	if refreshCookie, err := r.Cookie(refreshTokenCookie); err == nil {
+		logoutCommand.RefreshToken = refreshCookie.Value
+	}
+	return logoutCommand, nil
+}
+
+// logoutRefreshToken returns the refresh token in the body of a logout request, which is
+// optional, from clients that do not use cookies.
+func (h *Handler) logoutRefreshToken(r *http.Request) (string, error) {
+	if r.ContentLength == 0 {
+		return "", nil
+	}
+
+	var logoutRequest dto.LogoutRequest
+	if err := h.ParseJSON(r, &logoutRequest); err != nil {
+		return "", err
+	}
+	return logoutRequest.RefreshToken, nil
+}
+
+// handleChangePassword processes requests of an authenticated user to change their password.
+// The current password must be given. Every session of the user, including the current one,
+// is ended, so the token cookies are cleared and the user has to sign in again.
+func (h *Handler) handleChangePassword(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.UserClaims(r)
+	if err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	var changeRequest dto.ChangePasswordRequest
+	if err := h.ValidatedBody(r, &changeRequest); err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	_, err = h.passwordHandler.Handle(&passwordcmd.ChangeCommand{
+		UserID:      claims.Subject,
+		OldPassword: changeRequest.OldPassword,
+		NewPassword: changeRequest.NewPassword,
+	})
+	if err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+
+	h.clearTokenCookies(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRequestPasswordReset processes requests to deliver a password reset token to a user
+// who forgot their password. It responds with 202 Accepted whether or not the username
+// exists, so that it cannot be used to find out which usernames exist.
+func (h *Handler) handleRequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var resetRequest dto.PasswordResetRequest
+	if err := h.ValidatedBody(r, &resetRequest); err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	if _, err := h.requestReset.Handle(&passwordcmd.RequestResetCommand{Username: resetRequest.Username}); err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleResetPassword processes requests setting a new password with a password reset token.
+// Every session of the user is ended. Responds with 401 Unauthorized if the token is unknown,
+// expired or already used.
+func (h *Handler) handleResetPassword(w http.ResponseWriter, r *http.Request) {
+	var resetRequest dto.ResetPasswordRequest
+	if err := h.ValidatedBody(r, &resetRequest); err != nil {
+		h.Problem(w, err.(errapi.Error))
+		return
+	}
+
+	_, err := h.resetHandler.Handle(&passwordcmd.ResetCommand{
+		Token:       resetRequest.Token,
+		NewPassword: resetRequest.NewPassword,
+	})
+	if err != nil {
+		h.Problem(w, errapi.Map(err.(ierr.IErr)))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
 }
@@ -7,13 +7,21 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/beka-birhanu/finance-go/api/middleware"
 	"github.com/beka-birhanu/finance-go/api/rest/user/dto"
 	registercmd "github.com/beka-birhanu/finance-go/application/authentication/command"
 	auth "github.com/beka-birhanu/finance-go/application/authentication/common"
+	oidccmd "github.com/beka-birhanu/finance-go/application/authentication/oidc"
+	passwordcmd "github.com/beka-birhanu/finance-go/application/authentication/password"
 	loginqry "github.com/beka-birhanu/finance-go/application/authentication/query"
+	refreshcmd "github.com/beka-birhanu/finance-go/application/authentication/refresh"
+	sessioncmd "github.com/beka-birhanu/finance-go/application/authentication/session"
+	twofactorcmd "github.com/beka-birhanu/finance-go/application/authentication/twofactor"
 	handlerInterface "github.com/beka-birhanu/finance-go/application/common/cqrs/command"
 	queryHandlerInterface "github.com/beka-birhanu/finance-go/application/common/cqrs/query"
+	ijwt "github.com/beka-birhanu/finance-go/application/common/interface/jwt"
 	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
 	appError "github.com/beka-birhanu/finance-go/application/error"
 	erruser "github.com/beka-birhanu/finance-go/domain/error/user"
@@ -63,6 +71,76 @@ func (m *mockUserLoginQueryHandler) Handle(q *loginqry.Query) (*auth.Result, err
 
 var _ queryHandlerInterface.IHandler[*loginqry.Query, *auth.Result] = &mockUserLoginQueryHandler{}
 
+// Mock implementations for the two-factor login command handler interface
+type mockTwoFactorLoginCommandHandler struct {
+	handleFunc func(cmd *twofactorcmd.VerifyCommand) (*auth.Result, error)
+}
+
+func (m *mockTwoFactorLoginCommandHandler) Handle(cmd *twofactorcmd.VerifyCommand) (*auth.Result, error) {
+	return m.handleFunc(cmd)
+}
+
+var _ handlerInterface.IHandler[*twofactorcmd.VerifyCommand, *auth.Result] = &mockTwoFactorLoginCommandHandler{}
+
+// Mock implementations for the refresh command handler interface
+type mockRefreshCommandHandler struct {
+	handleFunc func(cmd *refreshcmd.Command) (*auth.Result, error)
+}
+
+func (m *mockRefreshCommandHandler) Handle(cmd *refreshcmd.Command) (*auth.Result, error) {
+	return m.handleFunc(cmd)
+}
+
+var _ handlerInterface.IHandler[*refreshcmd.Command, *auth.Result] = &mockRefreshCommandHandler{}
+
+// Mock implementations for the logout command handler interface
+type mockLogoutCommandHandler struct {
+	handleFunc func(cmd *sessioncmd.Command) (struct{}, error)
+}
+
+func (m *mockLogoutCommandHandler) Handle(cmd *sessioncmd.Command) (struct{}, error) {
+	return m.handleFunc(cmd)
+}
+
+var _ handlerInterface.IHandler[*sessioncmd.Command, struct{}] = &mockLogoutCommandHandler{}
+
+// Mock implementations for the password command handler interfaces
+type mockPasswordCommandHandler[C any] struct {
+	handleFunc func(cmd C) (struct{}, error)
+}
+
+func (m *mockPasswordCommandHandler[C]) Handle(cmd C) (struct{}, error) {
+	return m.handleFunc(cmd)
+}
+
+var (
+	_ handlerInterface.IHandler[*passwordcmd.ChangeCommand, struct{}]       = &mockPasswordCommandHandler[*passwordcmd.ChangeCommand]{}
+	_ handlerInterface.IHandler[*passwordcmd.RequestResetCommand, struct{}] = &mockPasswordCommandHandler[*passwordcmd.RequestResetCommand]{}
+	_ handlerInterface.IHandler[*passwordcmd.ResetCommand, struct{}]        = &mockPasswordCommandHandler[*passwordcmd.ResetCommand]{}
+)
+
+// Mock implementation for the OpenID Connect start command handler
+type mockOIDCStartCommandHandler struct {
+	handleFunc func(cmd *oidccmd.StartCommand) (*oidccmd.Authorization, error)
+}
+
+func (m *mockOIDCStartCommandHandler) Handle(cmd *oidccmd.StartCommand) (*oidccmd.Authorization, error) {
+	return m.handleFunc(cmd)
+}
+
+var _ handlerInterface.IHandler[*oidccmd.StartCommand, *oidccmd.Authorization] = &mockOIDCStartCommandHandler{}
+
+// Mock implementation for the OpenID Connect callback command handler
+type mockOIDCCallbackCommandHandler struct {
+	handleFunc func(cmd *oidccmd.CallbackCommand) (*auth.Result, error)
+}
+
+func (m *mockOIDCCallbackCommandHandler) Handle(cmd *oidccmd.CallbackCommand) (*auth.Result, error) {
+	return m.handleFunc(cmd)
+}
+
+var _ handlerInterface.IHandler[*oidccmd.CallbackCommand, *auth.Result] = &mockOIDCCallbackCommandHandler{}
+
 func TestHandler_UserRegistrationAndLogin(t *testing.T) {
 	mockRepo := &MockUserRepository{}
 	mockRegisterCommandHandler := &mockUserRegisterCommandHandler{
@@ -217,3 +295,561 @@ func TestHandler_UserRegistrationAndLogin(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_Refresh(t *testing.T) {
+	h := NewHandler(Config{
+		UserRepository: &MockUserRepository{},
+		RefreshHandler: &mockRefreshCommandHandler{
+			handleFunc: func(cmd *refreshcmd.Command) (*auth.Result, error) {
+				if cmd.Token != "validrefreshtoken" {
+					return nil, appError.InvalidCredential("invalid refresh token")
+				}
+				result := auth.NewResult(uuid.New(), "existinguser", "newtoken")
+				result.RefreshToken = "newrefreshtoken"
+				return result, nil
+			},
+		},
+		RefreshTokenTTL: time.Hour,
+	})
+
+	router := mux.NewRouter()
+	h.RegisterPublic(router)
+
+	tests := []struct {
+		name                 string
+		refreshToken         string
+		body                 string
+		expectedStatus       int
+		expectedCookies      map[string]string
+		expectedRefreshToken string
+	}{
+		{
+			name:            "Valid Refresh Token",
+			refreshToken:    "validrefreshtoken",
+			expectedStatus:  http.StatusOK,
+			expectedCookies: map[string]string{"accessToken": "newtoken", refreshTokenCookie: "newrefreshtoken"},
+		},
+		{
+			name:           "Invalid Refresh Token",
+			refreshToken:   "reusedrefreshtoken",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:                 "Refresh Token In Body",
+			body:                 `{"refreshToken":"validrefreshtoken"}`,
+			expectedStatus:       http.StatusOK,
+			expectedRefreshToken: "newrefreshtoken",
+		},
+		{
+			name:           "Missing Refresh Token",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/users/refresh", bytes.NewBufferString(tt.body))
+			if tt.refreshToken != "" {
+				req.AddCookie(&http.Cookie{Name: refreshTokenCookie, Value: tt.refreshToken})
+			}
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+
+			cookies := make(map[string]string)
+			for _, cookie := range rr.Result().Cookies() {
+				cookies[cookie.Name] = cookie.Value
+				if cookie.Name == refreshTokenCookie && (!cookie.HttpOnly || cookie.Path != refreshTokenPath || cookie.MaxAge != 3600) {
+					t.Errorf("unexpected refresh token cookie: %+v", cookie)
+				}
+			}
+			for name, value := range tt.expectedCookies {
+				if cookies[name] != value {
+					t.Errorf("expected cookie %s %q, got %q", name, value, cookies[name])
+				}
+			}
+
+			if tt.expectedRefreshToken != "" {
+				var response dto.TokenResponse
+				if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+					t.Fatalf("failed to decode response body: %v", err)
+				}
+				if response.RefreshToken != tt.expectedRefreshToken || response.AccessToken != "newtoken" || len(cookies) != 0 {
+					t.Errorf("expected tokens in the body only, got %+v and cookies %v", response, cookies)
+				}
+			}
+		})
+	}
+}
+
+func TestHandler_LoginTokenDelivery(t *testing.T) {
+	h := NewHandler(Config{
+		UserRepository: &MockUserRepository{},
+		LoginHandler: &mockUserLoginQueryHandler{
+			handleFunc: func(query *loginqry.Query) (*auth.Result, error) {
+				return auth.NewResult(uuid.New(), query.Username, "testtoken"), nil
+			},
+		},
+	})
+	router := mux.NewRouter()
+	h.RegisterPublic(router)
+
+	tests := []struct {
+		name                string
+		tokenDelivery       string
+		expectedStatus      int
+		expectedBodyToken   string
+		expectedCookieToken string
+	}{
+		{
+			name:                "Default Delivery",
+			expectedStatus:      http.StatusOK,
+			expectedCookieToken: "testtoken",
+		},
+		{
+			name:                "Cookie Delivery",
+			tokenDelivery:       dto.TokenDeliveryCookie,
+			expectedStatus:      http.StatusOK,
+			expectedCookieToken: "testtoken",
+		},
+		{
+			name:              "Body Delivery",
+			tokenDelivery:     dto.TokenDeliveryBody,
+			expectedStatus:    http.StatusOK,
+			expectedBodyToken: "testtoken",
+		},
+		{
+			name:           "Unknown Delivery",
+			tokenDelivery:  "header",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(dto.LoginUserRequest{Username: "existinguser", Password: "correctpassword", TokenDelivery: tt.tokenDelivery})
+			req, _ := http.NewRequest(http.MethodPost, "/users/login", bytes.NewBuffer(body))
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+
+			var response dto.TokenResponse
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response body: %v", err)
+			}
+			if response.AccessToken != tt.expectedBodyToken {
+				t.Errorf("expected access token %q in the body, got %q", tt.expectedBodyToken, response.AccessToken)
+			}
+
+			cookieToken := ""
+			for _, cookie := range rr.Result().Cookies() {
+				if cookie.Name == "accessToken" {
+					cookieToken = cookie.Value
+				}
+			}
+			if cookieToken != tt.expectedCookieToken {
+				t.Errorf("expected access token %q in a cookie, got %q", tt.expectedCookieToken, cookieToken)
+			}
+		})
+	}
+}
+
+func TestHandler_Logout(t *testing.T) {
+	userId := uuid.New()
+
+	tests := []struct {
+		name                string
+		url                 string
+		claims              *ijwt.Claims
+		expectedStatus      int
+		expectedAllSessions bool
+	}{
+		{
+			name:           "Logout Current Session",
+			url:            "/users/logout",
+			claims:         &ijwt.Claims{Subject: userId, ID: "current", ExpiresAt: time.Now().Add(time.Hour)},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:                "Logout All Sessions",
+			url:                 "/users/logout:all",
+			claims:              &ijwt.Claims{Subject: userId, ID: "current", ExpiresAt: time.Now().Add(time.Hour)},
+			expectedStatus:      http.StatusNoContent,
+			expectedAllSessions: true,
+		},
+		{
+			name:           "Missing User Claims",
+			url:            "/users/logout",
+			claims:         nil,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var handled *sessioncmd.Command
+			h := NewHandler(Config{
+				UserRepository: &MockUserRepository{},
+				LogoutHandler: &mockLogoutCommandHandler{
+					handleFunc: func(cmd *sessioncmd.Command) (struct{}, error) {
+						handled = cmd
+						return struct{}{}, nil
+					},
+				},
+			})
+			router := mux.NewRouter()
+			h.RegisterProtected(router)
+
+			req, _ := http.NewRequest(http.MethodPost, tt.url, nil)
+			req.AddCookie(&http.Cookie{Name: refreshTokenCookie, Value: "refreshtoken"})
+			req = req.WithContext(middleware.WithUserClaims(req.Context(), tt.claims))
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusNoContent {
+				return
+			}
+
+			if handled.UserID != userId || handled.TokenID != "current" || handled.RefreshToken != "refreshtoken" || handled.AllSessions != tt.expectedAllSessions {
+				t.Errorf("unexpected logout command: %+v", handled)
+			}
+			for _, cookie := range rr.Result().Cookies() {
+				if cookie.MaxAge >= 0 {
+					t.Errorf("expected cookie %s to be cleared, got %+v", cookie.Name, cookie)
+				}
+			}
+		})
+	}
+}
+
+func TestHandler_Password(t *testing.T) {
+	userId := uuid.New()
+	validToken := "resettoken"
+	claims := &ijwt.Claims{Subject: userId}
+
+	tests := []struct {
+		name           string
+		url            string
+		protected      bool
+		claims         *ijwt.Claims
+		body           string
+		expectedStatus int
+		expectCleared  bool
+	}{
+		{
+			name:           "Change Password",
+			url:            "/users/password",
+			protected:      true,
+			claims:         claims,
+			body:           `{"oldPassword":"old-password","newPassword":"new-password"}`,
+			expectedStatus: http.StatusNoContent,
+			expectCleared:  true,
+		},
+		{
+			name:           "Change Password With Incorrect Old Password",
+			url:            "/users/password",
+			protected:      true,
+			claims:         claims,
+			body:           `{"oldPassword":"wrong-password","newPassword":"new-password"}`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Change Password Without User Claims",
+			url:            "/users/password",
+			protected:      true,
+			body:           `{"oldPassword":"old-password","newPassword":"new-password"}`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Request Password Reset",
+			url:            "/users/password/reset-request",
+			body:           `{"username":"validUser"}`,
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "Request Password Reset Without Username",
+			url:            "/users/password/reset-request",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Reset Password",
+			url:            "/users/password/reset",
+			body:           `{"token":"resettoken","newPassword":"new-password"}`,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "Reset Password With Invalid Token",
+			url:            "/users/password/reset",
+			body:           `{"token":"invalid","newPassword":"new-password"}`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(Config{
+				UserRepository: &MockUserRepository{},
+				PasswordHandler: &mockPasswordCommandHandler[*passwordcmd.ChangeCommand]{
+					handleFunc: func(cmd *passwordcmd.ChangeCommand) (struct{}, error) {
+						if cmd.UserID != userId || cmd.OldPassword != "old-password" {
+							return struct{}{}, appError.InvalidCredential("incorrect password")
+						}
+						return struct{}{}, nil
+					},
+				},
+				RequestReset: &mockPasswordCommandHandler[*passwordcmd.RequestResetCommand]{
+					handleFunc: func(cmd *passwordcmd.RequestResetCommand) (struct{}, error) {
+						return struct{}{}, nil
+					},
+				},
+				ResetHandler: &mockPasswordCommandHandler[*passwordcmd.ResetCommand]{
+					handleFunc: func(cmd *passwordcmd.ResetCommand) (struct{}, error) {
+						if cmd.Token != validToken {
+							return struct{}{}, appError.InvalidCredential("invalid password reset token")
+						}
+						return struct{}{}, nil
+					},
+				},
+			})
+			router := mux.NewRouter()
+			if tt.protected {
+				h.RegisterProtected(router)
+			} else {
+				h.RegisterPublic(router)
+			}
+
+			req, _ := http.NewRequest(http.MethodPost, tt.url, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(middleware.WithUserClaims(req.Context(), tt.claims))
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+			cookies := rr.Result().Cookies()
+			if tt.expectCleared != (len(cookies) > 0) {
+				t.Errorf("expected cookies cleared %v, got %+v", tt.expectCleared, cookies)
+			}
+			for _, cookie := range cookies {
+				if cookie.MaxAge >= 0 {
+					t.Errorf("expected cookie %s to be cleared, got %+v", cookie.Name, cookie)
+				}
+			}
+		})
+	}
+}
+
+func TestHandler_TwoFactorLogin(t *testing.T) {
+	userId := uuid.New()
+	h := NewHandler(Config{
+		UserRepository: &MockUserRepository{},
+		LoginHandler: &mockUserLoginQueryHandler{
+			handleFunc: func(query *loginqry.Query) (*auth.Result, error) {
+				return &auth.Result{ID: userId, Username: query.Username, ChallengeToken: "challengetoken"}, nil
+			},
+		},
+		TwoFactorLogin: &mockTwoFactorLoginCommandHandler{
+			handleFunc: func(cmd *twofactorcmd.VerifyCommand) (*auth.Result, error) {
+				if cmd.ChallengeToken != "challengetoken" || cmd.Code != "123456" {
+					return nil, appError.InvalidCredential("invalid two-factor code")
+				}
+				return auth.NewResult(userId, "existinguser", "testtoken"), nil
+			},
+		},
+	})
+	router := mux.NewRouter()
+	h.RegisterPublic(router)
+
+	t.Run("Login Returns A Challenge", func(t *testing.T) {
+		body, _ := json.Marshal(dto.LoginUserRequest{Username: "existinguser", Password: "correctpassword"})
+		req, _ := http.NewRequest(http.MethodPost, "/users/login", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		var response dto.TwoFactorChallengeResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response body: %v", err)
+		}
+		if !response.TwoFactorRequired || response.ChallengeToken != "challengetoken" {
+			t.Errorf("unexpected challenge response %+v", response)
+		}
+		if cookies := rr.Result().Cookies(); len(cookies) != 0 {
+			t.Errorf("expected no session cookies before the second step, got %v", cookies)
+		}
+	})
+
+	tests := []struct {
+		name                string
+		code                string
+		tokenDelivery       string
+		expectedStatus      int
+		expectedBodyToken   string
+		expectedCookieToken string
+	}{
+		{
+			name:                "Valid Code",
+			code:                "123456",
+			expectedStatus:      http.StatusOK,
+			expectedCookieToken: "testtoken",
+		},
+		{
+			name:              "Valid Code With Body Delivery",
+			code:              "123456",
+			tokenDelivery:     dto.TokenDeliveryBody,
+			expectedStatus:    http.StatusOK,
+			expectedBodyToken: "testtoken",
+		},
+		{
+			name:           "Invalid Code",
+			code:           "000000",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(dto.TwoFactorLoginRequest{ChallengeToken: "challengetoken", Code: tt.code, TokenDelivery: tt.tokenDelivery})
+			req, _ := http.NewRequest(http.MethodPost, "/users/login/2fa", bytes.NewBuffer(body))
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+
+			var response dto.TokenResponse
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response body: %v", err)
+			}
+			if response.AccessToken != tt.expectedBodyToken {
+				t.Errorf("expected access token %q in the body, got %q", tt.expectedBodyToken, response.AccessToken)
+			}
+
+			cookieToken := ""
+			for _, cookie := range rr.Result().Cookies() {
+				if cookie.Name == "accessToken" {
+					cookieToken = cookie.Value
+				}
+			}
+			if cookieToken != tt.expectedCookieToken {
+				t.Errorf("expected access token %q in a cookie, got %q", tt.expectedCookieToken, cookieToken)
+			}
+		})
+	}
+}
+
+func TestHandler_OIDCLogin(t *testing.T) {
+	userId := uuid.New()
+	h := NewHandler(Config{
+		UserRepository: &MockUserRepository{},
+		OIDCStart: &mockOIDCStartCommandHandler{
+			handleFunc: func(cmd *oidccmd.StartCommand) (*oidccmd.Authorization, error) {
+				if cmd.Provider != "company" {
+					return nil, erruser.NotFound
+				}
+				return &oidccmd.Authorization{URL: "https://idp.example.com/authorize?state=somestate", State: "somestate"}, nil
+			},
+		},
+		OIDCCallback: &mockOIDCCallbackCommandHandler{
+			handleFunc: func(cmd *oidccmd.CallbackCommand) (*auth.Result, error) {
+				if cmd.Provider != "company" || cmd.Code != "code" || cmd.State != cmd.StateCookie {
+					return nil, appError.InvalidCredential("unknown or expired sign in")
+				}
+				return auth.NewResult(userId, "janedoe", "testtoken"), nil
+			},
+		},
+	})
+	router := mux.NewRouter()
+	h.RegisterPublic(router)
+
+	tests := []struct {
+		name             string
+		url              string
+		stateCookie      string
+		expectedStatus   int
+		expectedLocation string
+		expectedCookies  map[string]string
+	}{
+		{
+			name:             "Login Redirects To Provider",
+			url:              "/users/oidc/company/login",
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "https://idp.example.com/authorize?state=somestate",
+			expectedCookies:  map[string]string{oidcStateCookie: "somestate"},
+		},
+		{
+			name:           "Login With Unknown Provider",
+			url:            "/users/oidc/unknown/login",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:            "Callback",
+			url:             "/users/oidc/company/callback?code=code&state=somestate",
+			stateCookie:     "somestate",
+			expectedStatus:  http.StatusOK,
+			expectedCookies: map[string]string{oidcStateCookie: "", "accessToken": "testtoken"},
+		},
+		{
+			name:            "Callback Without State Cookie",
+			url:             "/users/oidc/company/callback?code=code&state=somestate",
+			expectedStatus:  http.StatusUnauthorized,
+			expectedCookies: map[string]string{oidcStateCookie: ""},
+		},
+		{
+			name:            "Callback With Provider Error",
+			url:             "/users/oidc/company/callback?error=access_denied&state=somestate",
+			stateCookie:     "somestate",
+			expectedStatus:  http.StatusUnauthorized,
+			expectedCookies: map[string]string{oidcStateCookie: ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.stateCookie != "" {
+				req.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: tt.stateCookie})
+			}
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+			if location := rr.Header().Get("Location"); location != tt.expectedLocation {
+				t.Errorf("expected location %q, got %q", tt.expectedLocation, location)
+			}
+
+			cookies := map[string]string{}
+			for _, cookie := range rr.Result().Cookies() {
+				cookies[cookie.Name] = cookie.Value
+			}
+			for name, value := range tt.expectedCookies {
+				if got, ok := cookies[name]; !ok || got != value {
+					t.Errorf("expected cookie %s to be %q, got %q", name, value, got)
+				}
+			}
+		})
+	}
+}
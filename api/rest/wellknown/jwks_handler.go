@@ -0,0 +1,36 @@
+// Package wellknown provides HTTP handlers for the well-known URIs (RFC 8615) of the API,
+// such as the JSON Web Key Set other services verify our tokens with.
+package wellknown
+
+import (
+	"net/http"
+
+	baseapi "github.com/beka-birhanu/finance-go/api/rest/base_handler"
+	ijwt "github.com/beka-birhanu/finance-go/application/common/interface/jwt"
+)
+
+// JWKSPath is the path the JSON Web Key Set is served at.
+const JWKSPath = "/.well-known/jwks.json"
+
+// JWKSHandler serves the public keys tokens are verified with as a JSON Web Key Set (RFC 7517).
+type JWKSHandler struct {
+	baseapi.BaseHandler
+	keys ijwt.IKeyPublisher
+}
+
+// jwksResponse is a JSON Web Key Set.
+type jwksResponse struct {
+	Keys []ijwt.PublicKey `json:"keys"`
+}
+
+// NewJWKSHandler creates a new JWKSHandler publishing the keys of the given publisher.
+func NewJWKSHandler(keys ijwt.IKeyPublisher) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// ServeHTTP responds with the JSON Web Key Set. It may be cached for a few minutes, so keys
+// should be published a little before tokens are signed with them.
+func (h *JWKSHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	h.Respond(w, http.StatusOK, jwksResponse{Keys: h.keys.PublicKeys()})
+}
@@ -0,0 +1,48 @@
+package wellknown
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ijwt "github.com/beka-birhanu/finance-go/application/common/interface/jwt"
+)
+
+type MockKeyPublisher struct {
+	keys []ijwt.PublicKey
+}
+
+func (m *MockKeyPublisher) PublicKeys() []ijwt.PublicKey {
+	return m.keys
+}
+
+func TestJWKSHandler(t *testing.T) {
+	keys := []ijwt.PublicKey{
+		{KeyType: "OKP", KeyID: "current", Algorithm: "EdDSA", Use: "sig", Curve: "Ed25519", X: "x"},
+		{KeyType: "RSA", KeyID: "retired", Algorithm: "RS256", Use: "sig", Modulus: "n", Exponent: "AQAB"},
+	}
+
+	rr := httptest.NewRecorder()
+	NewJWKSHandler(&MockKeyPublisher{keys: keys}).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, JWKSPath, nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	if cacheControl := rr.Header().Get("Cache-Control"); cacheControl != "public, max-age=300" {
+		t.Errorf("expected public caching, got %q", cacheControl)
+	}
+
+	var response struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response body: %v", err)
+	}
+	if len(response.Keys) != 2 || response.Keys[0]["kid"] != "current" || response.Keys[0]["crv"] != "Ed25519" || response.Keys[1]["n"] != "n" {
+		t.Errorf("unexpected key set: %+v", response.Keys)
+	}
+	if _, ok := response.Keys[0]["n"]; ok {
+		t.Errorf("expected RSA members to be omitted from OKP keys, got %+v", response.Keys[0])
+	}
+}
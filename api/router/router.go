@@ -4,6 +4,7 @@
 // It configures and initializes routes with varying access requirements:
 // - Public routes: Accessible without authentication.
 // - Protected routes: Require authentication.
+// - Admin routes: Require authentication by an administrator.
 package router
 
 import (
@@ -12,6 +13,7 @@ import (
 
 	"github.com/99designs/gqlgen/graphql/playground"
 	api "github.com/beka-birhanu/finance-go/api/rest"
+	"github.com/beka-birhanu/finance-go/api/rest/wellknown"
 	"github.com/gorilla/mux"
 )
 
@@ -21,8 +23,11 @@ type Router struct {
 	addr                     string
 	baseURL                  string
 	restfullControllers      []api.IController
+	adminControllers         []api.IAdminController
 	graphQlController        http.Handler
+	jwksHandler              http.Handler
 	authorizationMiddleware  func(http.Handler) http.Handler
+	adminMiddleware          func(http.Handler) http.Handler
 	populateClaimsMiddleware func(http.Handler) http.Handler
 	rateLimitMiddleware      func(http.Handler) http.Handler
 }
@@ -32,8 +37,11 @@ type Config struct {
 	Addr                     string            // Address to listen on
 	BaseURL                  string            // Base URL for API routes
 	RestfullControllers      []api.IController // List of controllers
+	AdminControllers         []api.IAdminController
 	GraphQlController        http.Handler
+	JWKSHandler              http.Handler // Serves the public keys tokens are verified with; optional
 	AuthorizationMiddleware  func(http.Handler) http.Handler
+	AdminMiddleware          func(http.Handler) http.Handler // Lets only administrators through; runs after AuthorizationMiddleware
 	PopulateClaimsMiddleware func(http.Handler) http.Handler
 	RateLimitMiddleware      func(http.Handler) http.Handler
 }
@@ -45,8 +53,11 @@ func NewRouter(config Config) *Router {
 		addr:                     config.Addr,
 		baseURL:                  config.BaseURL,
 		restfullControllers:      config.RestfullControllers,
+		adminControllers:         config.AdminControllers,
 		graphQlController:        config.GraphQlController,
+		jwksHandler:              config.JWKSHandler,
 		authorizationMiddleware:  config.AuthorizationMiddleware,
+		adminMiddleware:          config.AdminMiddleware,
 		populateClaimsMiddleware: config.PopulateClaimsMiddleware,
 		rateLimitMiddleware:      config.RateLimitMiddleware,
 	}
@@ -57,10 +68,15 @@ func NewRouter(config Config) *Router {
 // Routes are grouped and managed under the base URL, with the following access levels:
 // - Public routes: No authentication required.
 // - Protected routes: Authentication required.
+// - Admin routes: Authentication by an administrator required, under /api/v1/admin.
 func (r *Router) Run() error {
 	router := mux.NewRouter()
 	router.Use((r.rateLimitMiddleware))
 
+	if r.jwksHandler != nil {
+		router.Handle(wellknown.JWKSPath, r.jwksHandler).Methods(http.MethodGet)
+	}
+
 	// Setting up routes under baseURL
 	api := router.PathPrefix("/api").Subrouter()
 
@@ -82,6 +98,15 @@ func (r *Router) Run() error {
 			}
 		}
 
+		// Admin routes (authentication by an administrator required)
+		adminRoutes := api.PathPrefix("/v1/admin").Subrouter()
+		adminRoutes.Use(r.authorizationMiddleware, r.adminMiddleware)
+		{
+			for _, c := range r.adminControllers {
+				c.RegisterAdmin(adminRoutes)
+			}
+		}
+
 		graphApi := api.PathPrefix(("/graph")).Subrouter()
 		graphApi.Use(r.populateClaimsMiddleware)
 
@@ -2,102 +2,224 @@ package utils
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
-	"log"
-	"strconv"
 	"strings"
 	"time"
 
+	"github.com/beka-birhanu/finance-go/api/cursor"
 	errapi "github.com/beka-birhanu/finance-go/api/error"
 	"github.com/beka-birhanu/finance-go/api/middleware"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
 	expensqry "github.com/beka-birhanu/finance-go/application/expense/query"
 	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
-	"github.com/dgrijalva/jwt-go"
 	"github.com/google/uuid"
 )
 
-func ConfirmUserID(ctx context.Context, userId uuid.UUID) error {
-	claims, ok := ctx.Value(middleware.ContextUserClaims).(jwt.MapClaims)
+// UserID returns the ID of the authenticated user from the claims in the context.
+func UserID(ctx context.Context) (uuid.UUID, error) {
+	claims, ok := middleware.UserClaims(ctx)
 	if !ok {
-		return errapi.NewServerError("Error on retrieving user id from context")
+		return uuid.Nil, errapi.NewAuthentication("User claims not found!")
 	}
+	return claims.Subject, nil
+}
 
-	userIDStr, ok := claims["user_id"].(string)
-	if userIDStr == "" {
+// ConfirmUserID checks that the authenticated user in the context is the user with the given
+// ID, and that their token is allowed the given scope.
+func ConfirmUserID(ctx context.Context, userId uuid.UUID, scope string) error {
+	claims, ok := middleware.UserClaims(ctx)
+	if !ok {
 		return errapi.NewAuthentication("User claims not found!")
-
-	} else if !ok || userId.String() != userIDStr {
+	}
+	if claims.Subject != userId {
 		return errapi.NewForbidden("The response does not belong to the user requesting.")
 	}
+	if !claims.HasScope(scope) {
+		return errapi.NewForbidden(fmt.Sprintf("The token is not allowed the %s scope.", scope))
+	}
 
 	return nil
 }
 
-// constructQueryParams constructs the query parameters for retrieving multiple expenses,
-// based on the user ID, cursor, limit, sort field, and sort order.
-func ConstructQueryParams(userId uuid.UUID, cursor string, limit int, sortField string, sortOrder string) (*expensqry.GetMultipleQuery, error) {
-	var lastSeenID uuid.UUID
-	var lastSeenDate time.Time
-	var lastSeenAmt float64
-	var ascending bool
+// PagingArgs holds the Relay-style paging arguments of a listing request: First/After
+// page forwards and Last/Before page backwards.
+type PagingArgs struct {
+	First  *int
+	After  *string
+	Last   *int
+	Before *string
+}
 
-	if cursor != "" {
-		cursorByte, err := base64.StdEncoding.DecodeString(cursor)
-		if err != nil {
-			return &expensqry.GetMultipleQuery{}, errapi.NewBadRequest("invalid cursor format1")
-		}
+// Resolve validates the paging arguments and returns the cursor to continue from, the
+// page size and whether paging runs backwards. Forward and backward arguments cannot be mixed.
+func (a PagingArgs) Resolve() (string, int, bool, error) {
+	if (a.First != nil || a.After != nil) && (a.Last != nil || a.Before != nil) {
+		return "", 0, false, errapi.NewBadRequest("first/after cannot be combined with last/before")
+	}
+	if (a.First != nil && *a.First < 0) || (a.Last != nil && *a.Last < 0) {
+		return "", 0, false, errapi.NewBadRequest("first and last cannot be negative")
+	}
+
+	if a.Last != nil || a.Before != nil {
+		return valueOrZero(a.Before), valueOrZero(a.Last), true, nil
+	}
+	return valueOrZero(a.After), valueOrZero(a.First), false, nil
+}
+
+// valueOrZero dereferences an optional argument, treating nil as the zero value.
+func valueOrZero[T any](v *T) T {
+	var zero T
+	if v == nil {
+		return zero
+	}
+	return *v
+}
 
-		cursor = string(cursorByte)
-		cursorParts := strings.Split(cursor, ",")
-		if len(cursorParts) != 2 {
-			return &expensqry.GetMultipleQuery{}, errapi.NewBadRequest("invalid cursor format1")
+// sortFields lists the fields listings can be sorted by.
+var sortFields = map[string]bool{
+	irepository.SortByDate:        true,
+	irepository.SortByAmount:      true,
+	irepository.SortByDescription: true,
+	irepository.SortByCreatedAt:   true,
+	irepository.SortByUpdatedAt:   true,
+}
+
+// DefaultSort is the order of a listing that does not ask for one.
+var DefaultSort = []irepository.SortKey{{Field: irepository.SortByDate}}
+
+// ParseSort parses a sort specification of comma separated "field.order" keys, most
+// significant first, e.g. "amount.desc,date.asc". An empty specification yields DefaultSort.
+func ParseSort(spec string) ([]irepository.SortKey, error) {
+	if spec == "" {
+		return DefaultSort, nil
+	}
+
+	var sort []irepository.SortKey
+	for _, key := range strings.Split(spec, ",") {
+		parts := strings.Split(key, ".")
+		if len(parts) != 2 {
+			return nil, errapi.NewBadRequest("invalid sortBy format")
 		}
-		lastSeenID, err = uuid.Parse(cursorParts[0])
+		sortKey, err := NewSortKey(parts[0], parts[1])
 		if err != nil {
-			return &expensqry.GetMultipleQuery{}, errapi.NewBadRequest("invalid cursor format2")
+			return nil, err
 		}
+		sort = append(sort, sortKey)
+	}
+	return sort, nil
+}
+
+// NewSortKey validates a sort field and order and returns the sort key they describe.
+func NewSortKey(field, order string) (irepository.SortKey, error) {
+	if !sortFields[field] {
+		return irepository.SortKey{}, errapi.NewBadRequest(fmt.Sprintf("invalid sortBy field: %s", field))
+	}
+	if order != "asc" && order != "desc" {
+		return irepository.SortKey{}, errapi.NewBadRequest(fmt.Sprintf("invalid sortBy order: %s", order))
+	}
+	return irepository.SortKey{Field: field, Ascending: order == "asc"}, nil
+}
 
-		if sortField == "date" {
-			lastSeenDate, err = time.Parse(time.RFC3339Nano, cursorParts[1])
-			if err != nil {
-				return &expensqry.GetMultipleQuery{}, fmt.Errorf("invalid cursor format for createdAt: %v", err)
-			}
-		} else if sortField == "amount" {
-			lastSeenAmt, err = strconv.ParseFloat(cursorParts[1], 32)
-			if err != nil {
-				return &expensqry.GetMultipleQuery{}, fmt.Errorf("invalid cursor format for amount")
-			}
+// FormatSort returns the sort specification of the sort keys, the inverse of ParseSort.
+func FormatSort(sort []irepository.SortKey) string {
+	keys := make([]string, 0, len(sort))
+	for _, key := range sort {
+		order := "desc"
+		if key.Ascending {
+			order = "asc"
 		}
+		keys = append(keys, key.Field+"."+order)
+	}
+	return strings.Join(keys, ",")
+}
+
+// ConstructQueryParams constructs the query parameters for retrieving multiple expenses,
+// based on the user ID, cursor, limit, paging direction, sort keys, and filter.
+// The cursor is verified against the sort keys and filter of the request.
+func ConstructQueryParams(codec *cursor.Codec, userId uuid.UUID, token string, limit int, backward bool, sort []irepository.SortKey, filter irepository.ExpenseFilter) (*expensqry.GetMultipleQuery, error) {
+	query := &expensqry.GetMultipleQuery{
+		UserID:   userId,
+		Limit:    limit,
+		Sort:     sort,
+		Backward: backward,
+		Filter:   filter,
+	}
+	if token == "" {
+		return query, nil
+	}
+
+	cur, err := codec.Decode(token, FormatSort(sort), cursor.HashFilter(filter))
+	if err != nil {
+		return &expensqry.GetMultipleQuery{}, err
+	}
+	if len(cur.Values) != len(sort) {
+		return &expensqry.GetMultipleQuery{}, errapi.NewBadRequest("invalid cursor: does not match the sort keys")
 	}
 
-	if sortOrder == "asc" {
-		ascending = true
+	values := make([]interface{}, 0, len(sort))
+	for i, key := range sort {
+		value, err := parseCursorValue(key.Field, cur.Values[i])
+		if err != nil {
+			return &expensqry.GetMultipleQuery{}, err
+		}
+		values = append(values, value)
 	}
 
-	log.Println(userId, limit, sortField, &lastSeenID, &lastSeenDate, lastSeenAmt, ascending)
-	return &expensqry.GetMultipleQuery{
-		UserID:       userId,
-		Limit:        limit,
-		By:           sortField,
-		LastSeenID:   &lastSeenID,
-		LastSeenDate: &lastSeenDate,
-		LastSeenAmt:  lastSeenAmt,
-		Ascending:    ascending,
-	}, nil
+	query.LastSeenID = &cur.LastSeenID
+	query.LastSeenValues = values
+	return query, nil
 }
 
-// BuildCursor constructs a cursor string for pagination, based on the last expense and the sort field.
-func BuildCursor(lastExpense *expensemodel.Expense, field string) string {
-	nextCursor := ""
-	if lastExpense != nil {
-		if field == "amount" {
-			nextCursor = base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s,%f", lastExpense.ID(), lastExpense.Amount())))
-		} else {
-			date := lastExpense.Date().Format(time.RFC3339Nano)
-			nextCursor = base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s,%v", lastExpense.ID(), date)))
+// parseCursorValue parses a cursor value into the type of the sort field it belongs to.
+func parseCursorValue(field, value string) (interface{}, error) {
+	switch field {
+	case irepository.SortByDate, irepository.SortByCreatedAt, irepository.SortByUpdatedAt:
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return nil, errapi.NewBadRequest(fmt.Sprintf("invalid cursor: malformed %s", field))
+		}
+		return t, nil
+	case irepository.SortByAmount:
+		cents, ok := expensemodel.ParseCents(value)
+		if !ok {
+			return nil, errapi.NewBadRequest("invalid cursor: malformed amount")
 		}
+		// The amount is compared as an exact decimal, never as a float.
+		return expensemodel.FormatCents(cents), nil
+	default:
+		return value, nil
 	}
+}
 
-	return nextCursor
+// BuildCursor constructs a signed cursor pointing at the given expense within the listing
+// described by the sort keys and filter.
+func BuildCursor(codec *cursor.Codec, expense *expensemodel.Expense, sort []irepository.SortKey, filter irepository.ExpenseFilter) string {
+	values := make([]string, 0, len(sort))
+	for _, key := range sort {
+		values = append(values, cursorValue(expense, key.Field))
+	}
+
+	return codec.Encode(cursor.Cursor{
+		Version:    cursor.Version,
+		Sort:       FormatSort(sort),
+		FilterHash: cursor.HashFilter(filter),
+		LastSeenID: expense.ID(),
+		Values:     values,
+	})
+}
+
+// cursorValue returns the value of the sort field of the expense as stored in a cursor.
+func cursorValue(expense *expensemodel.Expense, field string) string {
+	switch field {
+	case irepository.SortByAmount:
+		return expensemodel.FormatCents(expense.AmountCents())
+	case irepository.SortByDescription:
+		return expense.Description()
+	case irepository.SortByCreatedAt:
+		return expense.CreatedAt().UTC().Format(time.RFC3339Nano)
+	case irepository.SortByUpdatedAt:
+		return expense.UpdatedAt().UTC().Format(time.RFC3339Nano)
+	default:
+		return expense.Date().UTC().Format(time.RFC3339Nano)
+	}
 }
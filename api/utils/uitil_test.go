@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/beka-birhanu/finance-go/api/cursor"
+	errapi "github.com/beka-birhanu/finance-go/api/error"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+	"github.com/google/uuid"
+)
+
+func TestCursorAmount(t *testing.T) {
+	codec := cursor.NewCodec(cursor.Config{Secret: "secret"})
+	sort := []irepository.SortKey{{Field: irepository.SortByAmount}}
+	userId := uuid.New()
+
+	// Above 2^24 cents a float32 can no longer hold every cent.
+	cases := []struct {
+		cents int64
+		want  string
+	}{
+		{cents: 27970, want: "279.70"},
+		{cents: 1<<24 + 1, want: "167772.17"},
+		{cents: 123456789, want: "1234567.89"},
+		{cents: 9999999999, want: "99999999.99"},
+	}
+	for _, c := range cases {
+		expense, err := expensemodel.NewWithID(uuid.New(), expensemodel.Config{
+			Description:  "Laptop",
+			AmountCents:  c.cents,
+			UserId:       userId,
+			Date:         time.Date(2024, 6, 8, 8, 0, 0, 0, time.UTC),
+			CreationTime: time.Date(2024, 6, 8, 8, 0, 0, 0, time.UTC),
+		})
+		if err != nil {
+			t.Fatalf("%d: expected no error, got %v", c.cents, err)
+		}
+
+		if got := cursorValue(expense, irepository.SortByAmount); got != c.want {
+			t.Errorf("%d: expected cursor value %q, got %q", c.cents, c.want, got)
+		}
+
+		token := BuildCursor(codec, expense, sort, irepository.ExpenseFilter{})
+		query, err := ConstructQueryParams(codec, userId, token, 10, false, sort, irepository.ExpenseFilter{})
+		if err != nil {
+			t.Fatalf("%d: expected no error, got %v", c.cents, err)
+		}
+		if len(query.LastSeenValues) != 1 || query.LastSeenValues[0] != c.want {
+			t.Errorf("%d: expected last seen amount %q, got %v", c.cents, c.want, query.LastSeenValues)
+		}
+	}
+}
+
+func TestParseCursorValue(t *testing.T) {
+	valid := []struct {
+		value string
+		want  string
+	}{
+		{value: "1234567.89", want: "1234567.89"},
+		{value: "12.5", want: "12.50"},
+		{value: "12", want: "12.00"},
+	}
+	for _, c := range valid {
+		got, err := parseCursorValue(irepository.SortByAmount, c.value)
+		if err != nil || got != c.want {
+			t.Errorf("%q: expected %q, got %v (error %v)", c.value, c.want, got, err)
+		}
+	}
+
+	for _, value := range []string{"", "abc", "1.234", "1e3", ".5", "1.-5", "--1"} {
+		_, err := parseCursorValue(irepository.SortByAmount, value)
+		apiErr, ok := err.(errapi.Error)
+		if !ok || apiErr.StatusCode() != errapi.BadRequest {
+			t.Errorf("%q: expected bad request error, got %v", value, err)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package admincmd
+
+import "github.com/google/uuid"
+
+// ListUsersQuery represents a query for a page of users.
+type ListUsersQuery struct {
+	Search   string // Case-insensitive substring of the username; empty matches every user
+	Page     int    // Number of the page, starting at 1; defaults to the first page
+	PageSize int    // Number of users per page; defaults to 20
+}
+
+// DisableCommand represents a command to disable a user, so that they cannot sign in.
+type DisableCommand struct {
+	AdminID uuid.UUID // ID of the administrator disabling the user
+	UserID  uuid.UUID // ID of the user to disable
+}
+
+// EnableCommand represents a command to enable a disabled user.
+type EnableCommand struct {
+	UserID uuid.UUID // ID of the user to enable
+}
+
+// StatsQuery represents a query for the usage statistics of the service.
+type StatsQuery struct {
+	Days int // Length of the period of the statistics in days, up to today; defaults to 30
+}
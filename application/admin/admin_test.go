@@ -0,0 +1,341 @@
+package admincmd
+
+import (
+	"testing"
+	"time"
+
+	auth "github.com/beka-birhanu/finance-go/application/authentication/common"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	ierr "github.com/beka-birhanu/finance-go/domain/common/error"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	usermodel "github.com/beka-birhanu/finance-go/domain/model/user"
+	"github.com/google/uuid"
+)
+
+// MockUserRepository keeps users in memory.
+type MockUserRepository struct {
+	users map[uuid.UUID]*usermodel.User
+	saves int
+}
+
+func (m *MockUserRepository) Save(user *usermodel.User) error {
+	m.users[user.ID()] = user
+	m.saves++
+	return nil
+}
+
+func (m *MockUserRepository) ById(id uuid.UUID) (*usermodel.User, error) {
+	if user, ok := m.users[id]; ok {
+		return user, nil
+	}
+	return nil, errdmn.NewNotFound("user not found.")
+}
+
+func (m *MockUserRepository) ByUsername(username string) (*usermodel.User, error) {
+	for _, user := range m.users {
+		if user.Username() == username {
+			return user, nil
+		}
+	}
+	return nil, errdmn.NewNotFound("user not found.")
+}
+
+var _ irepository.IUserRepository = &MockUserRepository{}
+
+// MockAdminRepository records the parameters it is called with.
+type MockAdminRepository struct {
+	users  []*usermodel.User
+	params irepository.UserListParams
+	since  time.Time
+}
+
+func (m *MockAdminRepository) List(params irepository.UserListParams) ([]*usermodel.User, error) {
+	m.params = params
+	return m.users, nil
+}
+
+func (m *MockAdminRepository) Count(search string) (int, error) {
+	return len(m.users), nil
+}
+
+func (m *MockAdminRepository) Stats(since time.Time) (*irepository.UsageStats, error) {
+	m.since = since
+	return &irepository.UsageStats{Users: len(m.users)}, nil
+}
+
+var _ irepository.IUserAdminRepository = &MockAdminRepository{}
+
+// MockAccessTokenRepository records the users whose tokens were all revoked.
+type MockAccessTokenRepository struct {
+	revoked []uuid.UUID
+}
+
+func (m *MockAccessTokenRepository) Save(token *irepository.AccessToken) error {
+	return nil
+}
+
+func (m *MockAccessTokenRepository) ByHash(tokenHash string) (*irepository.AccessToken, error) {
+	return nil, nil
+}
+
+func (m *MockAccessTokenRepository) ByUser(userId uuid.UUID) ([]*irepository.AccessToken, error) {
+	return nil, nil
+}
+
+func (m *MockAccessTokenRepository) Revoke(userId uuid.UUID, id uuid.UUID, at time.Time) (bool, error) {
+	return false, nil
+}
+
+func (m *MockAccessTokenRepository) RevokeAll(userId uuid.UUID, at time.Time) error {
+	m.revoked = append(m.revoked, userId)
+	return nil
+}
+
+func (m *MockAccessTokenRepository) Touch(id uuid.UUID, at time.Time, notBefore time.Time) error {
+	return nil
+}
+
+var _ irepository.IAccessTokenRepository = &MockAccessTokenRepository{}
+
+// MockSessionRevoker records the users whose sessions were all revoked.
+type MockSessionRevoker struct {
+	revoked []uuid.UUID
+}
+
+func (m *MockSessionRevoker) RevokeAll(userId uuid.UUID) error {
+	m.revoked = append(m.revoked, userId)
+	return nil
+}
+
+var _ auth.ISessionRevoker = &MockSessionRevoker{}
+
+type MockTimeService struct {
+	now time.Time
+}
+
+func (m *MockTimeService) NowUTC() time.Time {
+	return m.now
+}
+
+type fixture struct {
+	config       Config
+	users        *MockUserRepository
+	admins       *MockAdminRepository
+	accessTokens *MockAccessTokenRepository
+	sessions     *MockSessionRevoker
+	timeSvc      *MockTimeService
+	admin        *usermodel.User
+	user         *usermodel.User
+}
+
+func newFixture() *fixture {
+	f := &fixture{
+		users:        &MockUserRepository{users: map[uuid.UUID]*usermodel.User{}},
+		accessTokens: &MockAccessTokenRepository{},
+		sessions:     &MockSessionRevoker{},
+		timeSvc:      &MockTimeService{now: time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)},
+	}
+	f.admin, _ = usermodel.NewWithExistingHash(usermodel.ConfigForExistingHash{
+		ID:       uuid.New(),
+		Username: "admin",
+		Roles:    []string{usermodel.RoleAdmin},
+	})
+	f.user, _ = usermodel.NewWithExistingHash(usermodel.ConfigForExistingHash{
+		ID:       uuid.New(),
+		Username: "validUser",
+	})
+	f.users.users[f.admin.ID()] = f.admin
+	f.users.users[f.user.ID()] = f.user
+	f.admins = &MockAdminRepository{users: []*usermodel.User{f.user, f.admin}}
+
+	f.config = Config{
+		UserRepository:  f.users,
+		AdminRepository: f.admins,
+		AccessTokens:    f.accessTokens,
+		Sessions:        f.sessions,
+		TimeService:     f.timeSvc,
+	}
+	return f
+}
+
+func assertErrorType(t *testing.T, err error, expectedType string) {
+	t.Helper()
+	typed, ok := err.(ierr.IErr)
+	if !ok || typed.Type() != expectedType {
+		t.Fatalf("expected a %s error, got %v", expectedType, err)
+	}
+}
+
+func TestListUsersHandler_Handle(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          *ListUsersQuery
+		expectedParams irepository.UserListParams
+		expectedError  string
+	}{
+		{
+			name:           "defaults",
+			query:          &ListUsersQuery{},
+			expectedParams: irepository.UserListParams{Limit: defaultPageSize},
+		},
+		{
+			name:           "page with search",
+			query:          &ListUsersQuery{Search: "val", Page: 3, PageSize: 10},
+			expectedParams: irepository.UserListParams{Search: "val", Limit: 10, Offset: 20},
+		},
+		{
+			name:          "negative page",
+			query:         &ListUsersQuery{Page: -1},
+			expectedError: errdmn.Validation,
+		},
+		{
+			name:          "page size too large",
+			query:         &ListUsersQuery{PageSize: maxPageSize + 1},
+			expectedError: errdmn.Validation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture()
+			page, err := NewListUsersHandler(f.config).Handle(tt.query)
+			if tt.expectedError != "" {
+				assertErrorType(t, err, tt.expectedError)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if f.admins.params != tt.expectedParams {
+				t.Errorf("expected params %+v, got %+v", tt.expectedParams, f.admins.params)
+			}
+			if page.TotalCount != 2 || len(page.Users) != 2 {
+				t.Errorf("expected 2 users, got %d of %d", len(page.Users), page.TotalCount)
+			}
+		})
+	}
+}
+
+func TestDisableHandler_Handle(t *testing.T) {
+	f := newFixture()
+	handler := NewDisableHandler(f.config)
+
+	if _, err := handler.Handle(&DisableCommand{AdminID: f.admin.ID(), UserID: f.user.ID()}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !f.user.Disabled() || !f.user.DisabledAt().Equal(f.timeSvc.now) {
+		t.Errorf("expected the user to be disabled at %v, got %v", f.timeSvc.now, f.user.DisabledAt())
+	}
+	if f.users.saves != 1 {
+		t.Errorf("expected the user to be saved once, got %d", f.users.saves)
+	}
+	if len(f.sessions.revoked) != 1 || f.sessions.revoked[0] != f.user.ID() {
+		t.Errorf("expected the sessions of the user to be revoked, got %v", f.sessions.revoked)
+	}
+	if len(f.accessTokens.revoked) != 1 || f.accessTokens.revoked[0] != f.user.ID() {
+		t.Errorf("expected the access tokens of the user to be revoked, got %v", f.accessTokens.revoked)
+	}
+
+	// Disabling again keeps the time the user was first disabled.
+	disabledAt := *f.user.DisabledAt()
+	f.timeSvc.now = f.timeSvc.now.Add(time.Hour)
+	if _, err := handler.Handle(&DisableCommand{AdminID: f.admin.ID(), UserID: f.user.ID()}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !f.user.DisabledAt().Equal(disabledAt) {
+		t.Errorf("expected the user to stay disabled at %v, got %v", disabledAt, f.user.DisabledAt())
+	}
+}
+
+func TestDisableHandler_HandleRejected(t *testing.T) {
+	tests := []struct {
+		name          string
+		cmd           func(f *fixture) *DisableCommand
+		expectedError string
+	}{
+		{
+			name: "administrator disabling themselves",
+			cmd: func(f *fixture) *DisableCommand {
+				return &DisableCommand{AdminID: f.admin.ID(), UserID: f.admin.ID()}
+			},
+			expectedError: errdmn.Validation,
+		},
+		{
+			name: "unknown user",
+			cmd: func(f *fixture) *DisableCommand {
+				return &DisableCommand{AdminID: f.admin.ID(), UserID: uuid.New()}
+			},
+			expectedError: errdmn.NotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture()
+			_, err := NewDisableHandler(f.config).Handle(tt.cmd(f))
+			assertErrorType(t, err, tt.expectedError)
+			if f.users.saves != 0 || len(f.sessions.revoked) != 0 {
+				t.Errorf("expected nothing to be saved or revoked")
+			}
+		})
+	}
+}
+
+func TestEnableHandler_Handle(t *testing.T) {
+	f := newFixture()
+	f.user.Disable(f.timeSvc.now)
+
+	if _, err := NewEnableHandler(f.config).Handle(&EnableCommand{UserID: f.user.ID()}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f.user.Disabled() {
+		t.Errorf("expected the user to be enabled")
+	}
+
+	_, err := NewEnableHandler(f.config).Handle(&EnableCommand{UserID: uuid.New()})
+	assertErrorType(t, err, errdmn.NotFound)
+}
+
+func TestStatsHandler_Handle(t *testing.T) {
+	tests := []struct {
+		name          string
+		days          int
+		expectedSince time.Time
+		expectedError string
+	}{
+		{
+			name:          "default period",
+			expectedSince: time.Date(2024, 8, 2, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "last week",
+			days:          7,
+			expectedSince: time.Date(2024, 8, 25, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "period too long",
+			days:          maxStatsDays + 1,
+			expectedError: errdmn.Validation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture()
+			stats, err := NewStatsHandler(f.config).Handle(&StatsQuery{Days: tt.days})
+			if tt.expectedError != "" {
+				assertErrorType(t, err, tt.expectedError)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !stats.Since.Equal(tt.expectedSince) || !f.admins.since.Equal(tt.expectedSince) {
+				t.Errorf("expected the period to start at %v, got %v", tt.expectedSince, stats.Since)
+			}
+			if stats.Users != 2 {
+				t.Errorf("expected 2 users, got %d", stats.Users)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package admincmd
+
+import (
+	"fmt"
+	"time"
+
+	iquery "github.com/beka-birhanu/finance-go/application/common/cqrs/query"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	itimeservice "github.com/beka-birhanu/finance-go/application/common/interface/time_service"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+)
+
+const (
+	defaultStatsDays = 30  // Default length of the period of the statistics in days
+	maxStatsDays     = 365 // Maximum length of the period of the statistics in days
+)
+
+// Stats are the usage statistics of the service over a period up to now.
+type Stats struct {
+	*irepository.UsageStats
+	Since time.Time // Start of the period
+}
+
+// StatsHandler processes queries for the usage statistics of the service.
+type StatsHandler struct {
+	admins  irepository.IUserAdminRepository
+	timeSvc itimeservice.IService
+}
+
+var _ iquery.IHandler[*StatsQuery, *Stats] = &StatsHandler{}
+
+// NewStatsHandler creates a new StatsHandler with the provided configuration.
+func NewStatsHandler(config Config) *StatsHandler {
+	return &StatsHandler{
+		admins:  config.AdminRepository,
+		timeSvc: config.TimeService,
+	}
+}
+
+// Handle returns the usage statistics of the service over the last days of the query.
+//
+// Returns:
+//   - *Stats: The statistics along with the start of their period.
+//   - error: A validation error if the number of days is out of range, or an unexpected
+//     error if the statistics cannot be computed.
+func (h *StatsHandler) Handle(query *StatsQuery) (*Stats, error) {
+	days := query.Days
+	if days == 0 {
+		days = defaultStatsDays
+	}
+	if days < 1 || days > maxStatsDays {
+		return nil, errdmn.NewValidation(fmt.Sprintf("days must be between 1 and %d", maxStatsDays))
+	}
+
+	since := h.timeSvc.NowUTC().AddDate(0, 0, -days)
+	usage, err := h.admins.Stats(since)
+	if err != nil {
+		return nil, err
+	}
+	return &Stats{UsageStats: usage, Since: since}, nil
+}
@@ -0,0 +1,169 @@
+// Package admincmd provides functionality for administrators: listing users, disabling and
+// enabling their accounts, and viewing the usage statistics of the service.
+package admincmd
+
+import (
+	"fmt"
+
+	auth "github.com/beka-birhanu/finance-go/application/authentication/common"
+	icmd "github.com/beka-birhanu/finance-go/application/common/cqrs/command"
+	iquery "github.com/beka-birhanu/finance-go/application/common/cqrs/query"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	itimeservice "github.com/beka-birhanu/finance-go/application/common/interface/time_service"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	usermodel "github.com/beka-birhanu/finance-go/domain/model/user"
+)
+
+const (
+	defaultPageSize = 20  // Default number of users per page
+	maxPageSize     = 100 // Maximum number of users per page
+)
+
+// UserPage holds one page of users together with the information needed to keep paging.
+type UserPage struct {
+	Users      []*usermodel.User // Users of the page, newest first
+	Page       int               // Number of the page, starting at 1
+	PageSize   int               // Number of users per page
+	TotalCount int               // Number of users matching the search, across all pages
+}
+
+// Config holds the dependencies needed to create the handlers of this package.
+type Config struct {
+	UserRepository  irepository.IUserRepository        // Repository for users
+	AdminRepository irepository.IUserAdminRepository   // Repository for listing users and their statistics
+	AccessTokens    irepository.IAccessTokenRepository // Revokes the personal access tokens of disabled users
+	Sessions        auth.ISessionRevoker               // Ends the sessions of disabled users
+	TimeService     itimeservice.IService              // Service for time-related operations
+}
+
+// ListUsersHandler processes queries for pages of users.
+type ListUsersHandler struct {
+	admins irepository.IUserAdminRepository
+}
+
+var _ iquery.IHandler[*ListUsersQuery, *UserPage] = &ListUsersHandler{}
+
+// NewListUsersHandler creates a new ListUsersHandler with the provided configuration.
+func NewListUsersHandler(config Config) *ListUsersHandler {
+	return &ListUsersHandler{admins: config.AdminRepository}
+}
+
+// Handle retrieves the page of users matching the search.
+//
+// Returns:
+//   - *UserPage: The users of the page along with the total count.
+//   - error: A validation error if the page or page size are out of range, or an unexpected
+//     error if the users cannot be retrieved.
+func (h *ListUsersHandler) Handle(query *ListUsersQuery) (*UserPage, error) {
+	page, pageSize := query.Page, query.PageSize
+	if page == 0 {
+		page = 1
+	}
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	if page < 1 {
+		return nil, errdmn.NewValidation("page must be at least 1")
+	}
+	if pageSize < 1 || pageSize > maxPageSize {
+		return nil, errdmn.NewValidation(fmt.Sprintf("page size must be between 1 and %d", maxPageSize))
+	}
+
+	users, err := h.admins.List(irepository.UserListParams{
+		Search: query.Search,
+		Limit:  pageSize,
+		Offset: (page - 1) * pageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := h.admins.Count(query.Search)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserPage{Users: users, Page: page, PageSize: pageSize, TotalCount: total}, nil
+}
+
+// DisableHandler processes commands to disable users.
+type DisableHandler struct {
+	userRepo     irepository.IUserRepository
+	accessTokens irepository.IAccessTokenRepository
+	sessions     auth.ISessionRevoker
+	timeSvc      itimeservice.IService
+}
+
+var _ icmd.IHandler[*DisableCommand, struct{}] = &DisableHandler{}
+
+// NewDisableHandler creates a new DisableHandler with the provided configuration.
+func NewDisableHandler(config Config) *DisableHandler {
+	return &DisableHandler{
+		userRepo:     config.UserRepository,
+		accessTokens: config.AccessTokens,
+		sessions:     config.Sessions,
+		timeSvc:      config.TimeService,
+	}
+}
+
+// Handle disables the user, so that they cannot sign in, and signs them out: every session of
+// the user is ended and every personal access token of the user is revoked. Administrators
+// cannot disable themselves, so that there is always one left to enable them again.
+//
+// Returns:
+//   - error: A validation error if the administrator disables themselves, a not found error
+//     if there is no such user, or an unexpected error if the user cannot be saved or signed
+//     out.
+func (h *DisableHandler) Handle(cmd *DisableCommand) (struct{}, error) {
+	if cmd.AdminID == cmd.UserID {
+		return struct{}{}, errdmn.NewValidation("administrators cannot disable themselves")
+	}
+
+	user, err := h.userRepo.ById(cmd.UserID)
+	if err != nil {
+		return struct{}{}, err
+	}
+
+	now := h.timeSvc.NowUTC()
+	user.Disable(now)
+	if err := h.userRepo.Save(user); err != nil {
+		return struct{}{}, err
+	}
+
+	if err := h.sessions.RevokeAll(user.ID()); err != nil {
+		return struct{}{}, err
+	}
+	return struct{}{}, h.accessTokens.RevokeAll(user.ID(), now)
+}
+
+// EnableHandler processes commands to enable disabled users.
+type EnableHandler struct {
+	userRepo irepository.IUserRepository
+	timeSvc  itimeservice.IService
+}
+
+var _ icmd.IHandler[*EnableCommand, struct{}] = &EnableHandler{}
+
+// NewEnableHandler creates a new EnableHandler with the provided configuration.
+func NewEnableHandler(config Config) *EnableHandler {
+	return &EnableHandler{
+		userRepo: config.UserRepository,
+		timeSvc:  config.TimeService,
+	}
+}
+
+// Handle enables the user, so that they can sign in again. Their ended sessions and revoked
+// personal access tokens stay ended and revoked.
+//
+// Returns:
+//   - error: A not found error if there is no such user, or an unexpected error if the user
+//     cannot be saved.
+func (h *EnableHandler) Handle(cmd *EnableCommand) (struct{}, error) {
+	user, err := h.userRepo.ById(cmd.UserID)
+	if err != nil {
+		return struct{}{}, err
+	}
+
+	user.Enable(h.timeSvc.NowUTC())
+	return struct{}{}, h.userRepo.Save(user)
+}
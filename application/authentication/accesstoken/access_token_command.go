@@ -0,0 +1,26 @@
+package accesstokencmd
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateCommand represents a command to create a personal access token.
+type CreateCommand struct {
+	UserID uuid.UUID     // ID of the user the token acts on behalf of
+	Name   string        // Name of the token, telling the user what it is used for
+	Scopes []string      // Scopes the token is limited to
+	TTL    time.Duration // How long the token is accepted; defaults to 30 days
+}
+
+// ListQuery represents a query for the personal access tokens of a user.
+type ListQuery struct {
+	UserID uuid.UUID // ID of the user whose tokens are listed
+}
+
+// RevokeCommand represents a command to revoke a personal access token.
+type RevokeCommand struct {
+	UserID uuid.UUID // ID of the user the token belongs to
+	ID     uuid.UUID // ID of the token
+}
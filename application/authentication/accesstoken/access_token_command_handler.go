@@ -0,0 +1,183 @@
+package accesstokencmd
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	auth "github.com/beka-birhanu/finance-go/application/authentication/common"
+	icmd "github.com/beka-birhanu/finance-go/application/common/cqrs/command"
+	iquery "github.com/beka-birhanu/finance-go/application/common/cqrs/query"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	itimeservice "github.com/beka-birhanu/finance-go/application/common/interface/time_service"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultTTL is how long tokens are accepted when the command does not say.
+	DefaultTTL = 30 * 24 * time.Hour
+
+	// MaxTTL is the longest a token can be accepted for.
+	MaxTTL = 365 * 24 * time.Hour
+
+	// maxNameLength is the maximum length of the name of a token.
+	maxNameLength = 100
+
+	// maxTokens is the maximum number of unrevoked tokens of a user.
+	maxTokens = 50
+)
+
+// Created is a personal access token that was just created, along with the token itself,
+// which is shown this one time only.
+type Created struct {
+	*irepository.AccessToken
+	Token string
+}
+
+// CreateHandler processes commands to create personal access tokens.
+type CreateHandler struct {
+	repository irepository.IAccessTokenRepository
+	timeSvc    itimeservice.IService
+}
+
+var _ icmd.IHandler[*CreateCommand, *Created] = &CreateHandler{}
+
+// Config holds the dependencies needed to create the handlers of this package.
+type Config struct {
+	Repository  irepository.IAccessTokenRepository // Repository for personal access tokens
+	TimeService itimeservice.IService              // Service for time-related operations
+}
+
+// NewCreateHandler creates a new CreateHandler with the provided configuration.
+func NewCreateHandler(config Config) *CreateHandler {
+	return &CreateHandler{
+		repository: config.Repository,
+		timeSvc:    config.TimeService,
+	}
+}
+
+// Handle creates a personal access token limited to the scopes of the command.
+//
+// Returns:
+//   - *Created: The stored token along with the token itself.
+//   - error: A validation error if the name, scopes or lifetime are invalid or the user has
+//     too many tokens, or an unexpected error if the token cannot be stored.
+func (h *CreateHandler) Handle(cmd *CreateCommand) (*Created, error) {
+	ttl, err := validate(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := h.repository.ByUser(cmd.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxTokens {
+		return nil, errdmn.NewValidation(fmt.Sprintf("a user can have at most %d access tokens", maxTokens))
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := h.timeSvc.NowUTC()
+	accessToken := &irepository.AccessToken{
+		ID:        uuid.New(),
+		UserID:    cmd.UserID,
+		Name:      cmd.Name,
+		TokenHash: hashToken(token),
+		Scopes:    normalizeScopes(cmd.Scopes),
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	if err := h.repository.Save(accessToken); err != nil {
+		return nil, err
+	}
+	return &Created{AccessToken: accessToken, Token: token}, nil
+}
+
+// validate checks the name, scopes and lifetime of the command, and returns the lifetime of
+// the token.
+func validate(cmd *CreateCommand) (time.Duration, error) {
+	if cmd.Name == "" || len(cmd.Name) > maxNameLength {
+		return 0, errdmn.NewValidation(fmt.Sprintf("the name of a token must be 1 to %d characters long", maxNameLength))
+	}
+
+	if len(cmd.Scopes) == 0 {
+		return 0, errdmn.NewValidation("a token must be limited to at least one scope")
+	}
+	for _, scope := range cmd.Scopes {
+		if !slices.Contains(auth.Scopes, scope) {
+			return 0, errdmn.NewValidation(fmt.Sprintf("unknown scope %q", scope))
+		}
+	}
+
+	ttl := cmd.TTL
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	if ttl < 0 || ttl > MaxTTL {
+		return 0, errdmn.NewValidation("a token can be accepted for at most 365 days")
+	}
+	return ttl, nil
+}
+
+// normalizeScopes returns the scopes sorted and without duplicates.
+func normalizeScopes(scopes []string) []string {
+	normalized := slices.Clone(scopes)
+	slices.Sort(normalized)
+	return slices.Compact(normalized)
+}
+
+// ListHandler processes queries for the personal access tokens of a user.
+type ListHandler struct {
+	repository irepository.IAccessTokenRepository
+}
+
+var _ iquery.IHandler[*ListQuery, []*irepository.AccessToken] = &ListHandler{}
+
+// NewListHandler creates a new ListHandler with the provided repository.
+func NewListHandler(repository irepository.IAccessTokenRepository) *ListHandler {
+	return &ListHandler{repository: repository}
+}
+
+// Handle returns the unrevoked personal access tokens of the user, newest first, expired
+// ones included.
+func (h *ListHandler) Handle(query *ListQuery) ([]*irepository.AccessToken, error) {
+	return h.repository.ByUser(query.UserID)
+}
+
+// RevokeHandler processes commands to revoke personal access tokens.
+type RevokeHandler struct {
+	repository irepository.IAccessTokenRepository
+	timeSvc    itimeservice.IService
+}
+
+var _ icmd.IHandler[*RevokeCommand, struct{}] = &RevokeHandler{}
+
+// NewRevokeHandler creates a new RevokeHandler with the provided configuration.
+func NewRevokeHandler(config Config) *RevokeHandler {
+	return &RevokeHandler{
+		repository: config.Repository,
+		timeSvc:    config.TimeService,
+	}
+}
+
+// Handle revokes the personal access token of the user, after which it is no longer
+// accepted.
+//
+// Returns:
+//   - error: A not found error if the user has no such unrevoked token, or an unexpected
+//     error if it cannot be revoked.
+func (h *RevokeHandler) Handle(cmd *RevokeCommand) (struct{}, error) {
+	revoked, err := h.repository.Revoke(cmd.UserID, cmd.ID, h.timeSvc.NowUTC())
+	if err != nil {
+		return struct{}{}, err
+	}
+	if !revoked {
+		return struct{}{}, errdmn.NewNotFound("access token not found")
+	}
+	return struct{}{}, nil
+}
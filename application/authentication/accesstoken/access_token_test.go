@@ -0,0 +1,232 @@
+package accesstokencmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	auth "github.com/beka-birhanu/finance-go/application/authentication/common"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	ierr "github.com/beka-birhanu/finance-go/domain/common/error"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	"github.com/google/uuid"
+)
+
+// MockAccessTokenRepository keeps tokens in memory, as the database does.
+type MockAccessTokenRepository struct {
+	tokens []*irepository.AccessToken
+}
+
+func (m *MockAccessTokenRepository) Save(token *irepository.AccessToken) error {
+	m.tokens = append(m.tokens, token)
+	return nil
+}
+
+func (m *MockAccessTokenRepository) ByHash(tokenHash string) (*irepository.AccessToken, error) {
+	for _, token := range m.tokens {
+		if token.TokenHash == tokenHash {
+			return token, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockAccessTokenRepository) ByUser(userId uuid.UUID) ([]*irepository.AccessToken, error) {
+	var tokens []*irepository.AccessToken
+	for i := len(m.tokens) - 1; i >= 0; i-- {
+		if m.tokens[i].UserID == userId && m.tokens[i].RevokedAt == nil {
+			tokens = append(tokens, m.tokens[i])
+		}
+	}
+	return tokens, nil
+}
+
+func (m *MockAccessTokenRepository) Revoke(userId uuid.UUID, id uuid.UUID, at time.Time) (bool, error) {
+	for _, token := range m.tokens {
+		if token.ID == id && token.UserID == userId && token.RevokedAt == nil {
+			token.RevokedAt = &at
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *MockAccessTokenRepository) RevokeAll(userId uuid.UUID, at time.Time) error {
+	for _, token := range m.tokens {
+		if token.UserID == userId && token.RevokedAt == nil {
+			token.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+func (m *MockAccessTokenRepository) Touch(id uuid.UUID, at time.Time, notBefore time.Time) error {
+	for _, token := range m.tokens {
+		if token.ID == id && (token.LastUsedAt == nil || !token.LastUsedAt.After(notBefore)) {
+			token.LastUsedAt = &at
+		}
+	}
+	return nil
+}
+
+var _ irepository.IAccessTokenRepository = &MockAccessTokenRepository{}
+
+type MockTimeService struct {
+	now time.Time
+}
+
+func (m *MockTimeService) NowUTC() time.Time {
+	return m.now
+}
+
+func newConfig() (Config, *MockAccessTokenRepository, *MockTimeService) {
+	repository := &MockAccessTokenRepository{}
+	timeService := &MockTimeService{now: time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)}
+	return Config{Repository: repository, TimeService: timeService}, repository, timeService
+}
+
+func assertErrorType(t *testing.T, err error, expectedType string) {
+	t.Helper()
+	typed, ok := err.(ierr.IErr)
+	if !ok || typed.Type() != expectedType {
+		t.Fatalf("expected a %s error, got %v", expectedType, err)
+	}
+}
+
+func TestCreateHandler_Handle(t *testing.T) {
+	userId := uuid.New()
+
+	tests := []struct {
+		name          string
+		cmd           *CreateCommand
+		expectedError bool
+	}{
+		{
+			name: "valid token",
+			cmd:  &CreateCommand{UserID: userId, Name: "backup script", Scopes: []string{auth.ScopeExpensesRead}},
+		},
+		{
+			name: "valid token with a lifetime",
+			cmd:  &CreateCommand{UserID: userId, Name: "importer", Scopes: []string{auth.ScopeExpensesWrite, auth.ScopeExpensesRead}, TTL: 24 * time.Hour},
+		},
+		{
+			name:          "missing name",
+			cmd:           &CreateCommand{UserID: userId, Scopes: []string{auth.ScopeExpensesRead}},
+			expectedError: true,
+		},
+		{
+			name:          "missing scopes",
+			cmd:           &CreateCommand{UserID: userId, Name: "script"},
+			expectedError: true,
+		},
+		{
+			name:          "unknown scope",
+			cmd:           &CreateCommand{UserID: userId, Name: "script", Scopes: []string{"users:admin"}},
+			expectedError: true,
+		},
+		{
+			name:          "lifetime too long",
+			cmd:           &CreateCommand{UserID: userId, Name: "script", Scopes: []string{auth.ScopeExpensesRead}, TTL: MaxTTL + time.Hour},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, repository, timeService := newConfig()
+			created, err := NewCreateHandler(config).Handle(tt.cmd)
+			if tt.expectedError {
+				assertErrorType(t, err, errdmn.Validation)
+				if len(repository.tokens) != 0 {
+					t.Errorf("expected no token to be stored, got %d", len(repository.tokens))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !strings.HasPrefix(created.Token, TokenPrefix) {
+				t.Errorf("expected the token to start with %q, got %q", TokenPrefix, created.Token)
+			}
+			stored := repository.tokens[0]
+			if stored.TokenHash == created.Token || stored.TokenHash != hashToken(created.Token) {
+				t.Errorf("expected the hash of the token to be stored, got %q", stored.TokenHash)
+			}
+			expectedTTL := tt.cmd.TTL
+			if expectedTTL == 0 {
+				expectedTTL = DefaultTTL
+			}
+			if !stored.ExpiresAt.Equal(timeService.now.Add(expectedTTL)) {
+				t.Errorf("expected the token to expire at %v, got %v", timeService.now.Add(expectedTTL), stored.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestAuthenticator_Authenticate(t *testing.T) {
+	config, repository, timeService := newConfig()
+	userId := uuid.New()
+	created, err := NewCreateHandler(config).Handle(&CreateCommand{
+		UserID: userId,
+		Name:   "script",
+		Scopes: []string{auth.ScopeExpensesRead},
+		TTL:    time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	authenticator := NewAuthenticator(config)
+
+	// Tokens without the prefix are left to the JWT service.
+	if _, ok, err := authenticator.Authenticate("eyJhbGciOiJIUzI1NiJ9.e30.sig"); ok || err != nil {
+		t.Fatalf("expected a JWT to be left alone, got %v, %v", ok, err)
+	}
+
+	claims, ok, err := authenticator.Authenticate(created.Token)
+	if !ok || err != nil {
+		t.Fatalf("expected the token to be accepted, got %v, %v", ok, err)
+	}
+	if claims.Subject != userId || !claims.Restricted() || !claims.HasScope(auth.ScopeExpensesRead) || claims.HasScope(auth.ScopeExpensesWrite) {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+	if used := repository.tokens[0].LastUsedAt; used == nil || !used.Equal(timeService.now) {
+		t.Errorf("expected the use to be recorded, got %v", used)
+	}
+
+	if _, ok, err := authenticator.Authenticate(TokenPrefix + "unknown"); !ok {
+		t.Errorf("expected an unknown token with the prefix to be claimed")
+	} else {
+		assertErrorType(t, err, apperror.Authentication)
+	}
+
+	timeService.now = timeService.now.Add(time.Hour)
+	_, _, err = authenticator.Authenticate(created.Token)
+	assertErrorType(t, err, apperror.Authentication)
+}
+
+func TestRevokeHandler_Handle(t *testing.T) {
+	config, _, _ := newConfig()
+	userId := uuid.New()
+	created, err := NewCreateHandler(config).Handle(&CreateCommand{UserID: userId, Name: "script", Scopes: []string{auth.ScopeExpensesRead}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Another user cannot revoke the token.
+	_, err = NewRevokeHandler(config).Handle(&RevokeCommand{UserID: uuid.New(), ID: created.ID})
+	assertErrorType(t, err, errdmn.NotFound)
+
+	if _, err := NewRevokeHandler(config).Handle(&RevokeCommand{UserID: userId, ID: created.ID}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tokens, err := NewListHandler(config.Repository).Handle(&ListQuery{UserID: userId})
+	if err != nil || len(tokens) != 0 {
+		t.Errorf("expected no tokens to be listed after revoking, got %v, %v", tokens, err)
+	}
+
+	_, _, err = NewAuthenticator(config).Authenticate(created.Token)
+	assertErrorType(t, err, apperror.Authentication)
+}
@@ -23,17 +23,20 @@ type Handler struct {
 	jwtSvc   ijwt.IService
 	hashSvc  hash.IService
 	timeSvc  itimeservice.IService
+	refresh  auth.IRefreshTokenIssuer
 }
 
 // Ensure Handler implements the ICommandHandler interface for Command type and Result type.
 var _ icmd.IHandler[*Command, *auth.Result] = &Handler{}
 
 // Config holds the dependencies needed to create a new Handler.
+// RefreshTokens is optional; no refresh token is issued when it is nil.
 type Config struct {
-	UserRepo irepository.IUserRepository
-	JwtSvc   ijwt.IService
-	HashSvc  hash.IService
-	TimeSvc  itimeservice.IService
+	UserRepo      irepository.IUserRepository
+	JwtSvc        ijwt.IService
+	HashSvc       hash.IService
+	TimeSvc       itimeservice.IService
+	RefreshTokens auth.IRefreshTokenIssuer
 }
 
 // NewHandler creates a new Handler with the provided configuration.
@@ -44,6 +47,7 @@ func NewHandler(cfg Config) *Handler {
 		jwtSvc:   cfg.JwtSvc,
 		hashSvc:  cfg.HashSvc,
 		timeSvc:  cfg.TimeSvc,
+		refresh:  cfg.RefreshTokens,
 	}
 }
 
@@ -68,7 +72,13 @@ func (h *Handler) Handle(cmd *Command) (*auth.Result, error) {
 		return nil, fmt.Errorf("JWT generation failed: %w", err)
 	}
 
-	return auth.NewResult(user.ID(), user.Username(), token), nil
+	result := auth.NewResult(user.ID(), user.Username(), token)
+	if h.refresh != nil {
+		if result.RefreshToken, err = h.refresh.Issue(user.ID()); err != nil {
+			return nil, fmt.Errorf("refresh token generation failed: %w", err)
+		}
+	}
+	return result, nil
 }
 
 // createUser initializes a new user instance using the provided command,
@@ -5,11 +5,11 @@ import (
 	"testing"
 	"time"
 
+	ijwt "github.com/beka-birhanu/finance-go/application/common/interface/jwt"
 	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
 	erruser "github.com/beka-birhanu/finance-go/domain/error/user"
 	usermodel "github.com/beka-birhanu/finance-go/domain/model/user"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/google/uuid"
 )
 
@@ -42,7 +42,7 @@ func (m *MockJwtService) Generate(user *usermodel.User) (string, error) {
 	return m.GenerateTokenFunc(user)
 }
 
-func (m *MockJwtService) Decode(token string) (jwt.MapClaims, error) {
+func (m *MockJwtService) Decode(token string) (*ijwt.Claims, error) {
 	return nil, nil
 }
 
@@ -0,0 +1,11 @@
+package auth
+
+import ijwt "github.com/beka-birhanu/finance-go/application/common/interface/jwt"
+
+// IAccessTokenAuthenticator authenticates requests made with personal access tokens.
+type IAccessTokenAuthenticator interface {
+	// Authenticate returns the claims of the personal access token, and whether the token is
+	// a personal access token at all; tokens that are not are left to the JWT service.
+	// Returns an error if the token is a personal access token that is not accepted.
+	Authenticate(token string) (*ijwt.Claims, bool, error)
+}
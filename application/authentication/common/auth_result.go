@@ -12,6 +12,14 @@ type Result struct {
 
 	// Token is the authentication token issued to the authenticated user.
 	Token string
+
+	// RefreshToken is the refresh token issued to the authenticated user, used to obtain a new
+	// Token once it expires. Empty when no refresh token was issued.
+	RefreshToken string
+
+	// ChallengeToken is set instead of Token and RefreshToken when the user has to complete the
+	// sign in with their second factor; it identifies the pending sign in.
+	ChallengeToken string
 }
 
 // NewResult creates and return a new Result instance with the provided
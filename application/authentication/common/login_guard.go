@@ -0,0 +1,11 @@
+package auth
+
+// ILoginGuard limits the attempts to sign in under a username.
+type ILoginGuard interface {
+	// Attempt counts an attempt to sign in under the username, and returns an error if
+	// attempts under it are blocked after too many failures.
+	Attempt(username string) error
+
+	// Succeed forgets the failed attempts under the username after a successful sign in.
+	Succeed(username string) error
+}
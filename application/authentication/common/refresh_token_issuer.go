@@ -0,0 +1,9 @@
+package auth
+
+import "github.com/google/uuid"
+
+// IRefreshTokenIssuer issues the refresh token of a new session of a user.
+type IRefreshTokenIssuer interface {
+	// Issue issues a refresh token to the user with the given ID.
+	Issue(userId uuid.UUID) (string, error)
+}
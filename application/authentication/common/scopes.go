@@ -0,0 +1,11 @@
+package auth
+
+// Scopes personal access tokens can be limited to. Access tokens issued at sign in are not
+// limited to any, and are allowed everything.
+const (
+	ScopeExpensesRead  = "expenses:read"  // Reading expenses and imports
+	ScopeExpensesWrite = "expenses:write" // Creating, changing, deleting and importing expenses
+)
+
+// Scopes lists every scope tokens can be limited to.
+var Scopes = []string{ScopeExpensesRead, ScopeExpensesWrite}
@@ -0,0 +1,9 @@
+package auth
+
+import "github.com/google/uuid"
+
+// ISessionRevoker ends every session of a user.
+type ISessionRevoker interface {
+	// RevokeAll revokes every access and refresh token issued to the user with the given ID.
+	RevokeAll(userId uuid.UUID) error
+}
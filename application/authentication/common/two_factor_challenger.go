@@ -0,0 +1,10 @@
+package auth
+
+import "github.com/google/uuid"
+
+// ITwoFactorChallenger starts the second step of a sign in for users with a second factor.
+type ITwoFactorChallenger interface {
+	// Challenge returns the token of a new challenge for the user with the given ID, and
+	// whether the user has a second factor at all; no challenge is issued when they do not.
+	Challenge(userId uuid.UUID) (string, bool, error)
+}
@@ -0,0 +1,119 @@
+// Package lockout provides protection against guessing passwords: attempts to sign in under a
+// username are counted, each failed attempt makes the next one wait longer, and after too many
+// failures attempts under the username are blocked for a while. Blocks lift on their own.
+//
+// Attempts are counted per username whether or not a user with the username exists, so that
+// being blocked does not tell which usernames exist.
+package lockout
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	auth "github.com/beka-birhanu/finance-go/application/authentication/common"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	itimeservice "github.com/beka-birhanu/finance-go/application/common/interface/time_service"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+)
+
+const (
+	// DefaultMaxFailures is the number of failed attempts after which a username is locked
+	// when none is configured.
+	DefaultMaxFailures = 5
+
+	// DefaultDuration is how long a username is locked when no duration is configured.
+	DefaultDuration = 15 * time.Minute
+
+	// DefaultDelay is the wait after the first failed attempt when none is configured.
+	DefaultDelay = time.Second
+)
+
+// Guard limits the attempts to sign in under a username.
+type Guard struct {
+	repository  irepository.ILoginAttemptRepository // Repository for counted attempts
+	timeSvc     itimeservice.IService               // Service for time-related operations
+	maxFailures int                                 // Failed attempts after which a username is locked
+	duration    time.Duration                       // How long a username is locked
+	delay       time.Duration                       // Wait after the first failed attempt
+}
+
+var _ auth.ILoginGuard = &Guard{}
+
+// Config holds dependencies required for creating a Guard.
+type Config struct {
+	Repository  irepository.ILoginAttemptRepository // Repository for counted attempts
+	TimeService itimeservice.IService               // Service for time-related operations
+	MaxFailures int                                 // Failed attempts after which a username is locked; defaults to 5
+	Duration    time.Duration                       // How long a username is locked, and failures are remembered; defaults to 15 minutes
+	Delay       time.Duration                       // Wait after the first failed attempt, doubled by each further one; defaults to 1 second
+}
+
+// NewGuard creates a new Guard with the specified configuration.
+func NewGuard(config Config) *Guard {
+	guard := &Guard{
+		repository:  config.Repository,
+		timeSvc:     config.TimeService,
+		maxFailures: config.MaxFailures,
+		duration:    config.Duration,
+		delay:       config.Delay,
+	}
+	if guard.maxFailures <= 0 {
+		guard.maxFailures = DefaultMaxFailures
+	}
+	if guard.duration <= 0 {
+		guard.duration = DefaultDuration
+	}
+	if guard.delay <= 0 {
+		guard.delay = DefaultDelay
+	}
+	return guard
+}
+
+// Attempt counts an attempt to sign in under the username. The attempt counts as failed until
+// Succeed is called, and attempts under the username wait accordingly before being accepted
+// again; blocking them before the password is even checked keeps concurrent guesses from
+// slipping through.
+//
+// Returns:
+//   - error: A too many requests error telling how long to wait if attempts under the
+//     username are blocked, or an unexpected error if the attempt could not be counted.
+func (g *Guard) Attempt(username string) error {
+	now := g.timeSvc.NowUTC()
+	key := hashUsername(username)
+
+	attempts, accepted, err := g.repository.Attempt(key, now, now.Add(-g.duration))
+	if err != nil {
+		return err
+	}
+	if !accepted {
+		return apperror.TooManyAttempts(attempts.BlockedUntil.Sub(now))
+	}
+	return g.repository.Block(key, now.Add(g.wait(attempts.Failures)))
+}
+
+// Succeed forgets the failed attempts under the username.
+func (g *Guard) Succeed(username string) error {
+	return g.repository.Reset(hashUsername(username))
+}
+
+// wait returns how long attempts wait after the given number of failures: the delay doubles
+// with each failure until the username is locked.
+func (g *Guard) wait(failures int) time.Duration {
+	if failures >= g.maxFailures {
+		return g.duration
+	}
+
+	wait := g.delay
+	for i := 1; i < failures && wait < g.duration; i++ {
+		wait *= 2
+	}
+	return min(wait, g.duration)
+}
+
+// hashUsername returns the key attempts under the username are counted by; the usernames that
+// were tried, which are sometimes passwords typed into the wrong field, are never stored.
+func hashUsername(username string) string {
+	sum := sha256.Sum256([]byte(username))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,168 @@
+package lockout
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+)
+
+// MockLoginAttemptRepository keeps attempts in memory, as the database does.
+type MockLoginAttemptRepository struct {
+	attempts map[string]*irepository.LoginAttempts
+}
+
+func (m *MockLoginAttemptRepository) Attempt(key string, at time.Time, resetBefore time.Time) (*irepository.LoginAttempts, bool, error) {
+	attempts, ok := m.attempts[key]
+	if !ok {
+		attempts = &irepository.LoginAttempts{Key: key, LastAttemptAt: at, BlockedUntil: at}
+		m.attempts[key] = attempts
+	}
+	if attempts.BlockedUntil.After(at) {
+		copied := *attempts
+		return &copied, false, nil
+	}
+	if !attempts.LastAttemptAt.After(resetBefore) {
+		attempts.Failures = 0
+	}
+	attempts.Failures++
+	attempts.LastAttemptAt = at
+
+	copied := *attempts
+	return &copied, true, nil
+}
+
+func (m *MockLoginAttemptRepository) Block(key string, until time.Time) error {
+	if attempts, ok := m.attempts[key]; ok && until.After(attempts.BlockedUntil) {
+		attempts.BlockedUntil = until
+	}
+	return nil
+}
+
+func (m *MockLoginAttemptRepository) Reset(key string) error {
+	delete(m.attempts, key)
+	return nil
+}
+
+var _ irepository.ILoginAttemptRepository = &MockLoginAttemptRepository{}
+
+type MockTimeService struct {
+	now time.Time
+}
+
+func (m *MockTimeService) NowUTC() time.Time {
+	return m.now
+}
+
+func newGuard() (*Guard, *MockTimeService) {
+	timeService := &MockTimeService{now: time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)}
+	guard := NewGuard(Config{
+		Repository:  &MockLoginAttemptRepository{attempts: map[string]*irepository.LoginAttempts{}},
+		TimeService: timeService,
+		MaxFailures: 3,
+		Duration:    10 * time.Minute,
+		Delay:       time.Second,
+	})
+	return guard, timeService
+}
+
+func assertBlocked(t *testing.T, err error, expectedSeconds string) {
+	t.Helper()
+	appErr, ok := err.(apperror.Error)
+	if !ok || appErr.Type() != apperror.TooManyRequests {
+		t.Fatalf("expected a too many requests error, got %v", err)
+	}
+	if !strings.Contains(appErr.Message, "try again in "+expectedSeconds+" seconds") {
+		t.Errorf("expected to wait %s seconds, got %q", expectedSeconds, appErr.Message)
+	}
+}
+
+func TestGuard_ProgressiveDelays(t *testing.T) {
+	guard, timeService := newGuard()
+
+	// The first failure makes the next attempt wait a second, the second two seconds.
+	if err := guard.Attempt("alice"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertBlocked(t, guard.Attempt("alice"), "1")
+
+	timeService.now = timeService.now.Add(time.Second)
+	if err := guard.Attempt("alice"); err != nil {
+		t.Fatalf("unexpected error after waiting: %v", err)
+	}
+	timeService.now = timeService.now.Add(time.Second)
+	assertBlocked(t, guard.Attempt("alice"), "1")
+
+	// Other usernames are not affected.
+	if err := guard.Attempt("bob"); err != nil {
+		t.Fatalf("unexpected error for another username: %v", err)
+	}
+}
+
+func TestGuard_LockoutAndUnlock(t *testing.T) {
+	guard, timeService := newGuard()
+
+	for i := 0; i < 3; i++ {
+		if err := guard.Attempt("alice"); err != nil {
+			t.Fatalf("attempt %d: unexpected error: %v", i+1, err)
+		}
+		timeService.now = timeService.now.Add(time.Minute)
+	}
+
+	// The third failure locks the username for ten minutes from that attempt.
+	assertBlocked(t, guard.Attempt("alice"), "540")
+
+	timeService.now = timeService.now.Add(9 * time.Minute)
+	if err := guard.Attempt("alice"); err != nil {
+		t.Fatalf("unexpected error after the lock expired: %v", err)
+	}
+	// The count started over, so the next attempt waits as after a first failure.
+	assertBlocked(t, guard.Attempt("alice"), "1")
+}
+
+func TestGuard_SucceedForgetsFailures(t *testing.T) {
+	guard, timeService := newGuard()
+
+	for i := 0; i < 2; i++ {
+		if err := guard.Attempt("alice"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		timeService.now = timeService.now.Add(time.Minute)
+	}
+	if err := guard.Succeed("alice"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := guard.Attempt("alice"); err != nil {
+		t.Fatalf("unexpected error after a successful sign in: %v", err)
+	}
+	if err := guard.Succeed("alice"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := guard.Attempt("alice"); err != nil {
+		t.Fatalf("a successful sign in must not make the next attempt wait: %v", err)
+	}
+}
+
+func TestGuard_Wait(t *testing.T) {
+	guard := NewGuard(Config{MaxFailures: 10, Duration: 5 * time.Second, Delay: time.Second})
+
+	tests := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{failures: 1, expected: time.Second},
+		{failures: 2, expected: 2 * time.Second},
+		{failures: 3, expected: 4 * time.Second},
+		{failures: 4, expected: 5 * time.Second},
+		{failures: 10, expected: 5 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if wait := guard.wait(tt.failures); wait != tt.expected {
+			t.Errorf("wait after %d failures: expected %v, got %v", tt.failures, tt.expected, wait)
+		}
+	}
+}
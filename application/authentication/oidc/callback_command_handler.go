@@ -0,0 +1,240 @@
+package oidccmd
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	auth "github.com/beka-birhanu/finance-go/application/authentication/common"
+	icmd "github.com/beka-birhanu/finance-go/application/common/cqrs/command"
+	ijwt "github.com/beka-birhanu/finance-go/application/common/interface/jwt"
+	ioidc "github.com/beka-birhanu/finance-go/application/common/interface/oidc"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	itimeservice "github.com/beka-birhanu/finance-go/application/common/interface/time_service"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	erruser "github.com/beka-birhanu/finance-go/domain/error/user"
+	usermodel "github.com/beka-birhanu/finance-go/domain/model/user"
+)
+
+const (
+	// maxUsernameBase is the maximum length of the username derived from an identity, which
+	// leaves room for the suffix that makes it unique within the 20 characters of a username.
+	maxUsernameBase = 15
+
+	// minUsernameBase is the minimum length of a username derived from an identity, below
+	// which a generic one is used.
+	minUsernameBase = 3
+
+	// usernameAttempts is the number of usernames tried for a new user before giving up.
+	usernameAttempts = 5
+)
+
+// CallbackHandler processes commands to complete sign ins through OpenID Connect providers.
+type CallbackHandler struct {
+	providers  map[string]ioidc.IProvider
+	states     irepository.IOIDCStateRepository
+	identities irepository.IExternalIdentityRepository
+	userRepo   irepository.IUserRepository
+	jwtSvc     ijwt.IService
+	refresh    auth.IRefreshTokenIssuer
+	twoFactor  auth.ITwoFactorChallenger
+	timeSvc    itimeservice.IService
+}
+
+var _ icmd.IHandler[*CallbackCommand, *auth.Result] = &CallbackHandler{}
+
+// CallbackConfig holds the dependencies needed to create a new CallbackHandler. RefreshTokens
+// and TwoFactor are optional; no refresh token is issued and no second factor is asked for
+// when the respective one is nil.
+type CallbackConfig struct {
+	Providers      map[string]ioidc.IProvider
+	States         irepository.IOIDCStateRepository
+	Identities     irepository.IExternalIdentityRepository
+	UserRepository irepository.IUserRepository
+	JwtService     ijwt.IService
+	RefreshTokens  auth.IRefreshTokenIssuer
+	TwoFactor      auth.ITwoFactorChallenger
+	TimeService    itimeservice.IService
+}
+
+// NewCallbackHandler creates a new CallbackHandler with the provided configuration.
+func NewCallbackHandler(config CallbackConfig) *CallbackHandler {
+	return &CallbackHandler{
+		providers:  config.Providers,
+		states:     config.States,
+		identities: config.Identities,
+		userRepo:   config.UserRepository,
+		jwtSvc:     config.JwtService,
+		refresh:    config.RefreshTokens,
+		twoFactor:  config.TwoFactor,
+		timeSvc:    config.TimeService,
+	}
+}
+
+// Handle completes a sign in through a provider. The state must match the one kept by the
+// browser and belong to a pending sign in with the provider, which is completed at most once.
+// The user is the one linked to the identity in the ID token, or a new user without a
+// password, linked to it, on the first sign in with the identity. As for signing in with a
+// password, users with a second factor get a challenge token instead of a session.
+//
+// Returns:
+//   - *auth.Result: The authentication result of the user.
+//   - error: An authentication error if the sign in is unknown, expired, started by another
+//     browser or rejected by the provider, a forbidden error if the user is disabled, or an
+//     unexpected error if the provider cannot be reached or the user cannot be retrieved or
+//     created.
+func (h *CallbackHandler) Handle(cmd *CallbackCommand) (*auth.Result, error) {
+	if cmd.State == "" || subtle.ConstantTimeCompare([]byte(cmd.State), []byte(cmd.StateCookie)) != 1 {
+		return nil, apperror.InvalidCredential("the sign in was not started by this browser")
+	}
+
+	pending, err := h.states.Consume(hashState(cmd.State), h.timeSvc.NowUTC())
+	if err != nil {
+		return nil, err
+	}
+	if pending == nil || pending.Provider != cmd.Provider {
+		return nil, apperror.InvalidCredential("unknown or expired sign in")
+	}
+
+	provider, ok := h.providers[pending.Provider]
+	if !ok {
+		return nil, errdmn.NewNotFound(fmt.Sprintf("unknown identity provider %q", pending.Provider))
+	}
+
+	identity, err := provider.Exchange(cmd.Code, pending.CodeVerifier, pending.Nonce)
+	if err != nil {
+		if errors.Is(err, ioidc.ErrRejected) {
+			return nil, apperror.InvalidCredential(err.Error())
+		}
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("failed to complete sign in with %s, %v", pending.Provider, err))
+	}
+
+	user, err := h.linkedUser(identity)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled() {
+		return nil, apperror.AccountDisabled()
+	}
+
+	if h.twoFactor != nil {
+		challengeToken, enabled, err := h.twoFactor.Challenge(user.ID())
+		if err != nil {
+			return nil, err
+		}
+		if enabled {
+			return &auth.Result{ID: user.ID(), Username: user.Username(), ChallengeToken: challengeToken}, nil
+		}
+	}
+
+	token, err := h.jwtSvc.Generate(user)
+	if err != nil {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("failed to generate JWT for user, %v", err))
+	}
+
+	result := auth.NewResult(user.ID(), user.Username(), token)
+	if h.refresh != nil {
+		if result.RefreshToken, err = h.refresh.Issue(user.ID()); err != nil {
+			return nil, errdmn.NewUnexpected(fmt.Sprintf("failed to issue refresh token for user, %v", err))
+		}
+	}
+	return result, nil
+}
+
+// linkedUser returns the user linked to the identity, creating and linking a new user if
+// there is none.
+func (h *CallbackHandler) linkedUser(identity *ioidc.Identity) (*usermodel.User, error) {
+	linked, err := h.identities.ByIdentity(identity.Issuer, identity.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if linked != nil {
+		return h.userRepo.ById(linked.UserID)
+	}
+
+	user, err := h.createUser(identity)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := h.identities.Link(&irepository.ExternalIdentity{
+		Issuer:    identity.Issuer,
+		Subject:   identity.Subject,
+		UserID:    user.ID(),
+		CreatedAt: h.timeSvc.NowUTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return user, nil
+	}
+
+	// A concurrent first sign in with the identity linked it first; sign in as its user.
+	linked, err = h.identities.ByIdentity(identity.Issuer, identity.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if linked == nil {
+		return nil, errdmn.NewUnexpected("identity is neither linked nor linkable")
+	}
+	return h.userRepo.ById(linked.UserID)
+}
+
+// createUser creates and saves a user without a password for the identity, under a username
+// derived from it, made unique with a random suffix if it is taken.
+func (h *CallbackHandler) createUser(identity *ioidc.Identity) (*usermodel.User, error) {
+	base := usernameBase(identity)
+	username := base
+
+	var err error
+	for range usernameAttempts {
+		var user *usermodel.User
+		user, err = usermodel.NewWithoutPassword(usermodel.ConfigWithoutPassword{
+			Username:     username,
+			CreationTime: h.timeSvc.NowUTC(),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if err = h.userRepo.Save(user); err == nil {
+			return user, nil
+		}
+		if !errors.Is(err, erruser.UsernameConflict) {
+			return nil, err
+		}
+
+		suffix, err := rand.Int(rand.Reader, big.NewInt(10000))
+		if err != nil {
+			return nil, errdmn.NewUnexpected(fmt.Sprintf("failed to generate username, %v", err))
+		}
+		username = fmt.Sprintf("%s_%04d", base, suffix.Int64())
+	}
+	return nil, err
+}
+
+// usernameBase derives a username from the preferred username or the email address of the
+// identity, keeping only the characters allowed in usernames.
+func usernameBase(identity *ioidc.Identity) string {
+	email, _, _ := strings.Cut(identity.Email, "@")
+	for _, name := range []string{identity.PreferredUsername, email} {
+		base := strings.Map(func(r rune) rune {
+			if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, name)
+		if len(base) > maxUsernameBase {
+			base = base[:maxUsernameBase]
+		}
+		if len(base) >= minUsernameBase {
+			return base
+		}
+	}
+	return "user"
+}
@@ -0,0 +1,15 @@
+package oidccmd
+
+// StartCommand represents a command to start a sign in through an OpenID Connect provider.
+type StartCommand struct {
+	Provider string // Name of the configured provider to sign in with
+}
+
+// CallbackCommand represents a command to complete a sign in through an OpenID Connect
+// provider, with what the provider sent the user back with.
+type CallbackCommand struct {
+	Provider    string // Name of the provider the user comes back from
+	Code        string // Authorization code issued by the provider
+	State       string // State parameter the provider sent back
+	StateCookie string // State the browser kept since the sign in started
+}
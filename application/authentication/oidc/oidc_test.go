@@ -0,0 +1,377 @@
+package oidccmd
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	ijwt "github.com/beka-birhanu/finance-go/application/common/interface/jwt"
+	ioidc "github.com/beka-birhanu/finance-go/application/common/interface/oidc"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	ierr "github.com/beka-birhanu/finance-go/domain/common/error"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	erruser "github.com/beka-birhanu/finance-go/domain/error/user"
+	usermodel "github.com/beka-birhanu/finance-go/domain/model/user"
+	"github.com/google/uuid"
+)
+
+// MockProvider accepts the code "valid" for the identity it holds, if the code verifier
+// matches the code challenge of the authorization URL.
+type MockProvider struct {
+	identity      *ioidc.Identity
+	codeChallenge string
+	nonce         string
+}
+
+func (m *MockProvider) AuthCodeURL(state, nonce, codeChallenge string) (string, error) {
+	m.codeChallenge, m.nonce = codeChallenge, nonce
+	return "https://idp.example.com/authorize?state=" + url.QueryEscape(state), nil
+}
+
+func (m *MockProvider) Exchange(code, codeVerifier, nonce string) (*ioidc.Identity, error) {
+	if code != "valid" || codeChallenge(codeVerifier) != m.codeChallenge || nonce != m.nonce {
+		return nil, fmt.Errorf("invalid_grant: %w", ioidc.ErrRejected)
+	}
+	return m.identity, nil
+}
+
+var _ ioidc.IProvider = &MockProvider{}
+
+// MockStateRepository keeps pending sign ins in memory, as the database does.
+type MockStateRepository struct {
+	states map[string]*irepository.OIDCState
+}
+
+func (m *MockStateRepository) Save(state *irepository.OIDCState) error {
+	m.states[state.StateHash] = state
+	return nil
+}
+
+func (m *MockStateRepository) Consume(stateHash string, at time.Time) (*irepository.OIDCState, error) {
+	state, ok := m.states[stateHash]
+	delete(m.states, stateHash)
+	if !ok || !state.ExpiresAt.After(at) {
+		return nil, nil
+	}
+	return state, nil
+}
+
+var _ irepository.IOIDCStateRepository = &MockStateRepository{}
+
+// MockIdentityRepository keeps linked identities in memory, as the database does.
+type MockIdentityRepository struct {
+	identities map[string]*irepository.ExternalIdentity
+}
+
+func (m *MockIdentityRepository) Link(identity *irepository.ExternalIdentity) (bool, error) {
+	key := identity.Issuer + " " + identity.Subject
+	if _, ok := m.identities[key]; ok {
+		return false, nil
+	}
+	m.identities[key] = identity
+	return true, nil
+}
+
+func (m *MockIdentityRepository) ByIdentity(issuer string, subject string) (*irepository.ExternalIdentity, error) {
+	return m.identities[issuer+" "+subject], nil
+}
+
+var _ irepository.IExternalIdentityRepository = &MockIdentityRepository{}
+
+// MockUserRepository keeps users in memory and rejects taken usernames, as the database does.
+type MockUserRepository struct {
+	users map[uuid.UUID]*usermodel.User
+}
+
+func (m *MockUserRepository) Save(user *usermodel.User) error {
+	for _, existing := range m.users {
+		if existing.Username() == user.Username() && existing.ID() != user.ID() {
+			return erruser.UsernameConflict
+		}
+	}
+	m.users[user.ID()] = user
+	return nil
+}
+
+func (m *MockUserRepository) ById(id uuid.UUID) (*usermodel.User, error) {
+	if user, ok := m.users[id]; ok {
+		return user, nil
+	}
+	return nil, erruser.NotFound
+}
+
+func (m *MockUserRepository) ByUsername(username string) (*usermodel.User, error) {
+	for _, user := range m.users {
+		if user.Username() == username {
+			return user, nil
+		}
+	}
+	return nil, erruser.NotFound
+}
+
+var _ irepository.IUserRepository = &MockUserRepository{}
+
+type MockJwtService struct{}
+
+func (m *MockJwtService) Generate(user *usermodel.User) (string, error) {
+	return "token-" + user.Username(), nil
+}
+
+func (m *MockJwtService) Decode(token string) (*ijwt.Claims, error) {
+	return nil, errdmn.NewUnexpected("not implemented")
+}
+
+var _ ijwt.IService = &MockJwtService{}
+
+type MockTimeService struct {
+	now time.Time
+}
+
+func (m *MockTimeService) NowUTC() time.Time {
+	return m.now
+}
+
+type fixture struct {
+	provider   *MockProvider
+	users      *MockUserRepository
+	timeSvc    *MockTimeService
+	start      *StartHandler
+	callback   *CallbackHandler
+	identities *MockIdentityRepository
+}
+
+func newFixture() *fixture {
+	f := &fixture{
+		provider: &MockProvider{identity: &ioidc.Identity{
+			Issuer:            "https://idp.example.com",
+			Subject:           "248289761001",
+			Email:             "jane.doe@example.com",
+			PreferredUsername: "jane.doe",
+		}},
+		users:      &MockUserRepository{users: map[uuid.UUID]*usermodel.User{}},
+		timeSvc:    &MockTimeService{now: time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)},
+		identities: &MockIdentityRepository{identities: map[string]*irepository.ExternalIdentity{}},
+	}
+	providers := map[string]ioidc.IProvider{"company": f.provider}
+	states := &MockStateRepository{states: map[string]*irepository.OIDCState{}}
+
+	f.start = NewStartHandler(StartConfig{Providers: providers, States: states, TimeService: f.timeSvc})
+	f.callback = NewCallbackHandler(CallbackConfig{
+		Providers:      providers,
+		States:         states,
+		Identities:     f.identities,
+		UserRepository: f.users,
+		JwtService:     &MockJwtService{},
+		TimeService:    f.timeSvc,
+	})
+	return f
+}
+
+// signIn starts a sign in and completes it with the given code.
+func (f *fixture) signIn(t *testing.T, code string) (string, error) {
+	t.Helper()
+	authorization, err := f.start.Handle(&StartCommand{Provider: "company"})
+	if err != nil {
+		t.Fatalf("unexpected error starting sign in: %v", err)
+	}
+
+	result, err := f.callback.Handle(&CallbackCommand{
+		Provider:    "company",
+		Code:        code,
+		State:       authorization.State,
+		StateCookie: authorization.State,
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.Token, nil
+}
+
+func assertErrorType(t *testing.T, err error, expectedType string) {
+	t.Helper()
+	typed, ok := err.(ierr.IErr)
+	if !ok || typed.Type() != expectedType {
+		t.Fatalf("expected a %s error, got %v", expectedType, err)
+	}
+}
+
+func TestStartHandler_Handle(t *testing.T) {
+	f := newFixture()
+
+	authorization, err := f.start.Handle(&StartCommand{Provider: "company"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(authorization.State) != 43 || !strings.Contains(authorization.URL, url.QueryEscape(authorization.State)) {
+		t.Errorf("expected the URL to carry a random state, got %+v", authorization)
+	}
+	if len(f.provider.codeChallenge) != 43 || len(f.provider.nonce) != 43 {
+		t.Errorf("expected a S256 code challenge and a random nonce, got %q and %q", f.provider.codeChallenge, f.provider.nonce)
+	}
+
+	_, err = f.start.Handle(&StartCommand{Provider: "unknown"})
+	assertErrorType(t, err, errdmn.NotFound)
+}
+
+func TestCallbackHandler_Handle(t *testing.T) {
+	f := newFixture()
+
+	token, err := f.signIn(t, "valid")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token != "token-janedoe" || len(f.users.users) != 1 {
+		t.Fatalf("expected a new user janedoe, got token %q and %d users", token, len(f.users.users))
+	}
+	for _, user := range f.users.users {
+		if user.HasPassword() {
+			t.Errorf("expected the new user to have no password")
+		}
+	}
+
+	token, err = f.signIn(t, "valid")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token != "token-janedoe" || len(f.users.users) != 1 {
+		t.Errorf("expected to sign in as the linked user, got token %q and %d users", token, len(f.users.users))
+	}
+
+	// Another subject with the same preferred username gets a username of its own.
+	f.provider.identity = &ioidc.Identity{Issuer: "https://idp.example.com", Subject: "1234", PreferredUsername: "jane.doe"}
+	token, err = f.signIn(t, "valid")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(token, "token-janedoe_") || len(f.users.users) != 2 {
+		t.Errorf("expected a second user with a suffixed username, got token %q and %d users", token, len(f.users.users))
+	}
+}
+
+func TestCallbackHandler_HandleRejected(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  func(state string) *CallbackCommand
+	}{
+		{
+			name: "code rejected by the provider",
+			cmd: func(state string) *CallbackCommand {
+				return &CallbackCommand{Provider: "company", Code: "invalid", State: state, StateCookie: state}
+			},
+		},
+		{
+			name: "state not kept by the browser",
+			cmd: func(state string) *CallbackCommand {
+				return &CallbackCommand{Provider: "company", Code: "valid", State: state}
+			},
+		},
+		{
+			name: "unknown state",
+			cmd: func(state string) *CallbackCommand {
+				return &CallbackCommand{Provider: "company", Code: "valid", State: "forged", StateCookie: "forged"}
+			},
+		},
+		{
+			name: "state of another provider",
+			cmd: func(state string) *CallbackCommand {
+				return &CallbackCommand{Provider: "other", Code: "valid", State: state, StateCookie: state}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture()
+			authorization, err := f.start.Handle(&StartCommand{Provider: "company"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			_, err = f.callback.Handle(tt.cmd(authorization.State))
+			assertErrorType(t, err, apperror.Authentication)
+			if len(f.users.users) != 0 {
+				t.Errorf("expected no user to be created")
+			}
+		})
+	}
+}
+
+func TestCallbackHandler_HandleOnce(t *testing.T) {
+	f := newFixture()
+	authorization, err := f.start.Handle(&StartCommand{Provider: "company"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cmd := &CallbackCommand{Provider: "company", Code: "valid", State: authorization.State, StateCookie: authorization.State}
+
+	if _, err := f.callback.Handle(cmd); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = f.callback.Handle(cmd)
+	assertErrorType(t, err, apperror.Authentication)
+}
+
+func TestCallbackHandler_HandleExpired(t *testing.T) {
+	f := newFixture()
+	authorization, err := f.start.Handle(&StartCommand{Provider: "company"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f.timeSvc.now = f.timeSvc.now.Add(DefaultStateTTL)
+	_, err = f.callback.Handle(&CallbackCommand{Provider: "company", Code: "valid", State: authorization.State, StateCookie: authorization.State})
+	assertErrorType(t, err, apperror.Authentication)
+}
+
+func TestCallbackHandler_HandleDisabled(t *testing.T) {
+	f := newFixture()
+	if _, err := f.signIn(t, "valid"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, user := range f.users.users {
+		user.Disable(f.timeSvc.now)
+	}
+
+	_, err := f.signIn(t, "valid")
+	assertErrorType(t, err, apperror.Forbidden)
+}
+
+func TestUsernameBase(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity *ioidc.Identity
+		expected string
+	}{
+		{
+			name:     "preferred username",
+			identity: &ioidc.Identity{PreferredUsername: "jane.doe", Email: "jd@example.com"},
+			expected: "janedoe",
+		},
+		{
+			name:     "email address",
+			identity: &ioidc.Identity{PreferredUsername: "j", Email: "jane_doe@example.com"},
+			expected: "jane_doe",
+		},
+		{
+			name:     "long name",
+			identity: &ioidc.Identity{PreferredUsername: "jane.alexandra.doe.smith"},
+			expected: "janealexandrado",
+		},
+		{
+			name:     "nothing usable",
+			identity: &ioidc.Identity{PreferredUsername: "ü"},
+			expected: "user",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if base := usernameBase(tt.identity); base != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, base)
+			}
+		})
+	}
+}
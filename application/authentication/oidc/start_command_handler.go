@@ -0,0 +1,134 @@
+// Package oidccmd provides sign in through external OpenID Connect providers with the
+// authorization code flow and PKCE. Starting a sign in sends the user to the provider with a
+// random state, nonce and code challenge; completing it redeems the authorization code the
+// provider sent the user back with, and signs the user in as the user linked to their
+// identity at the provider, who is created on their first sign in and has no local password.
+package oidccmd
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	icmd "github.com/beka-birhanu/finance-go/application/common/cqrs/command"
+	ioidc "github.com/beka-birhanu/finance-go/application/common/interface/oidc"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	itimeservice "github.com/beka-birhanu/finance-go/application/common/interface/time_service"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+)
+
+const (
+	// DefaultStateTTL is how long a started sign in can be completed when the configuration
+	// does not say.
+	DefaultStateTTL = 10 * time.Minute
+
+	// randomBytes is the number of random bytes of states, nonces and code verifiers.
+	randomBytes = 32
+)
+
+// Authorization is a started sign in: the URL of the provider to send the user to, and the
+// state the user's browser keeps to prove it is the one completing the sign in.
+type Authorization struct {
+	URL   string
+	State string
+}
+
+// StartHandler processes commands to start sign ins through OpenID Connect providers.
+type StartHandler struct {
+	providers map[string]ioidc.IProvider
+	states    irepository.IOIDCStateRepository
+	timeSvc   itimeservice.IService
+	stateTTL  time.Duration
+}
+
+var _ icmd.IHandler[*StartCommand, *Authorization] = &StartHandler{}
+
+// StartConfig holds the dependencies needed to create a new StartHandler.
+type StartConfig struct {
+	Providers   map[string]ioidc.IProvider       // Configured providers by name
+	States      irepository.IOIDCStateRepository // Repository for pending sign ins
+	TimeService itimeservice.IService            // Service for time-related operations
+	StateTTL    time.Duration                    // How long a sign in can be completed; DefaultStateTTL if zero
+}
+
+// NewStartHandler creates a new StartHandler with the provided configuration.
+func NewStartHandler(config StartConfig) *StartHandler {
+	stateTTL := config.StateTTL
+	if stateTTL == 0 {
+		stateTTL = DefaultStateTTL
+	}
+
+	return &StartHandler{
+		providers: config.Providers,
+		states:    config.States,
+		timeSvc:   config.TimeService,
+		stateTTL:  stateTTL,
+	}
+}
+
+// Handle starts a sign in through the provider of the command.
+//
+// Returns:
+//   - *Authorization: The URL to send the user to and the state their browser keeps.
+//   - error: A not found error if there is no such provider, or an unexpected error if the
+//     provider cannot be reached or the sign in cannot be stored.
+func (h *StartHandler) Handle(cmd *StartCommand) (*Authorization, error) {
+	provider, ok := h.providers[cmd.Provider]
+	if !ok {
+		return nil, errdmn.NewNotFound(fmt.Sprintf("unknown identity provider %q", cmd.Provider))
+	}
+
+	values, err := randomValues(3)
+	if err != nil {
+		return nil, err
+	}
+	state, nonce, codeVerifier := values[0], values[1], values[2]
+
+	authURL, err := provider.AuthCodeURL(state, nonce, codeChallenge(codeVerifier))
+	if err != nil {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("failed to start sign in with %s, %v", cmd.Provider, err))
+	}
+
+	now := h.timeSvc.NowUTC()
+	err = h.states.Save(&irepository.OIDCState{
+		StateHash:    hashState(state),
+		Provider:     cmd.Provider,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(h.stateTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Authorization{URL: authURL, State: state}, nil
+}
+
+// randomValues returns n random URL-safe values, each of randomBytes random bytes. Encoded,
+// they are 43 characters long, as long as a PKCE code verifier has to be at least.
+func randomValues(n int) ([]string, error) {
+	values := make([]string, n)
+	for i := range values {
+		b := make([]byte, randomBytes)
+		if _, err := rand.Read(b); err != nil {
+			return nil, errdmn.NewUnexpected(fmt.Sprintf("failed to generate random value, %v", err))
+		}
+		values[i] = base64.RawURLEncoding.EncodeToString(b)
+	}
+	return values, nil
+}
+
+// codeChallenge returns the S256 PKCE code challenge of the code verifier.
+func codeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// hashState returns the hash under which a pending sign in is stored.
+func hashState(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
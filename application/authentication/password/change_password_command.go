@@ -0,0 +1,10 @@
+package passwordcmd
+
+import "github.com/google/uuid"
+
+// ChangeCommand represents a command of an authenticated user to change their password.
+type ChangeCommand struct {
+	UserID      uuid.UUID // Identifier of the user changing their password
+	OldPassword string    // The current password, which must be given to change it
+	NewPassword string    // The password replacing it
+}
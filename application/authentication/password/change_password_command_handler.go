@@ -0,0 +1,102 @@
+// Package passwordcmd provides functionality for changing passwords: by an authenticated user
+// who knows their current password, or through a single-use reset token delivered to a user
+// who forgot it. Either way, every session of the user is ended and every personal access token
+// of the user is revoked, so that whoever knew the old password or held a token of the user is
+// signed out.
+package passwordcmd
+
+import (
+	"fmt"
+
+	auth "github.com/beka-birhanu/finance-go/application/authentication/common"
+	icmd "github.com/beka-birhanu/finance-go/application/common/cqrs/command"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	itimeservice "github.com/beka-birhanu/finance-go/application/common/interface/time_service"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	"github.com/beka-birhanu/finance-go/domain/common/hash"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	usermodel "github.com/beka-birhanu/finance-go/domain/model/user"
+)
+
+// ChangeHandler processes change password commands.
+type ChangeHandler struct {
+	passwordSetter
+}
+
+// Ensure ChangeHandler implements the icmd.IHandler interface for ChangeCommand type.
+var _ icmd.IHandler[*ChangeCommand, struct{}] = &ChangeHandler{}
+
+// ChangeConfig holds the dependencies needed to create a new ChangeHandler.
+type ChangeConfig struct {
+	UserRepository irepository.IUserRepository
+	HashService    hash.IService
+	TimeService    itimeservice.IService
+	Sessions       auth.ISessionRevoker               // Ends the sessions of the user once the password changed
+	AccessTokens   irepository.IAccessTokenRepository // Revokes the personal access tokens of the user likewise
+}
+
+// NewChangeHandler creates a new ChangeHandler with the provided configuration.
+func NewChangeHandler(config ChangeConfig) *ChangeHandler {
+	return &ChangeHandler{
+		passwordSetter: passwordSetter{
+			userRepo:     config.UserRepository,
+			hashSvc:      config.HashService,
+			timeSvc:      config.TimeService,
+			sessions:     config.Sessions,
+			accessTokens: config.AccessTokens,
+		},
+	}
+}
+
+// Handle processes a change password command. The old password must match the current one
+// and the new password must be strong enough. Every session of the user, including the one
+// changing the password, is ended afterwards, and every personal access token is revoked.
+// Returns:
+// - error: An authentication error if the old password is incorrect or the user has none, a
+// validation error if the new password is too weak, or an unexpected error if the user cannot
+// be retrieved or saved.
+func (h *ChangeHandler) Handle(cmd *ChangeCommand) (struct{}, error) {
+	user, err := h.userRepo.ById(cmd.UserID)
+	if err != nil {
+		return struct{}{}, err
+	}
+
+	if !user.HasPassword() {
+		return struct{}{}, apperror.InvalidCredential("the user has no password; reset it to set one")
+	}
+
+	isPasswordCorrect, err := h.hashSvc.Match(user.PasswordHash(), cmd.OldPassword)
+	if err != nil {
+		return struct{}{}, errdmn.NewUnexpected(fmt.Sprintf("failed to validate user password, %v", err))
+	}
+	if !isPasswordCorrect {
+		return struct{}{}, apperror.InvalidCredential("incorrect password")
+	}
+
+	return struct{}{}, h.setPassword(user, cmd.NewPassword)
+}
+
+// passwordSetter sets new passwords of users.
+type passwordSetter struct {
+	userRepo     irepository.IUserRepository
+	hashSvc      hash.IService
+	timeSvc      itimeservice.IService
+	sessions     auth.ISessionRevoker
+	accessTokens irepository.IAccessTokenRepository
+}
+
+// setPassword replaces the password of the user, saves the user, ends all of their sessions
+// and revokes all of their personal access tokens.
+func (s *passwordSetter) setPassword(user *usermodel.User, plainPassword string) error {
+	now := s.timeSvc.NowUTC()
+	if err := user.ChangePassword(plainPassword, s.hashSvc, now); err != nil {
+		return err
+	}
+	if err := s.userRepo.Save(user); err != nil {
+		return err
+	}
+	if err := s.sessions.RevokeAll(user.ID()); err != nil {
+		return err
+	}
+	return s.accessTokens.RevokeAll(user.ID(), now)
+}
@@ -0,0 +1,440 @@
+package passwordcmd
+
+import (
+	"testing"
+	"time"
+
+	auth "github.com/beka-birhanu/finance-go/application/authentication/common"
+	imailer "github.com/beka-birhanu/finance-go/application/common/interface/mailer"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	ierr "github.com/beka-birhanu/finance-go/domain/common/error"
+	"github.com/beka-birhanu/finance-go/domain/common/hash"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	erruser "github.com/beka-birhanu/finance-go/domain/error/user"
+	usermodel "github.com/beka-birhanu/finance-go/domain/model/user"
+	"github.com/google/uuid"
+)
+
+const (
+	oldPassword     = "#%@@strong@@password#%"
+	newPassword     = "another$$Strong!!passphrase"
+	weakPassword    = "password"
+	wrongPassword   = "not-the-password"
+	testUsername    = "validUser"
+	unknownUsername = "unknownUser"
+)
+
+type MockHashService struct{}
+
+func (m *MockHashService) Hash(word string) (string, error) {
+	return word, nil
+}
+
+func (m *MockHashService) Match(hashedWord, plainWord string) (bool, error) {
+	return hashedWord == plainWord, nil
+}
+
+var _ hash.IService = &MockHashService{}
+
+type MockUserRepository struct {
+	user  *usermodel.User
+	saved bool
+}
+
+func (m *MockUserRepository) Save(user *usermodel.User) error {
+	m.saved = true
+	return nil
+}
+
+func (m *MockUserRepository) ById(id uuid.UUID) (*usermodel.User, error) {
+	if m.user.ID() != id {
+		return nil, erruser.NotFound
+	}
+	return m.user, nil
+}
+
+func (m *MockUserRepository) ByUsername(username string) (*usermodel.User, error) {
+	if m.user.Username() != username {
+		return nil, erruser.NotFound
+	}
+	return m.user, nil
+}
+
+var _ irepository.IUserRepository = &MockUserRepository{}
+
+type MockPasswordResetTokenRepository struct {
+	tokens map[string]*irepository.PasswordResetToken
+}
+
+func (m *MockPasswordResetTokenRepository) Save(token *irepository.PasswordResetToken) error {
+	m.tokens[token.TokenHash] = token
+	return nil
+}
+
+func (m *MockPasswordResetTokenRepository) Consume(tokenHash string, at time.Time) (*irepository.PasswordResetToken, error) {
+	token, ok := m.tokens[tokenHash]
+	if !ok || token.UsedAt != nil || !at.Before(token.ExpiresAt) {
+		return nil, nil
+	}
+	token.UsedAt = &at
+	return token, nil
+}
+
+func (m *MockPasswordResetTokenRepository) DiscardUser(userId uuid.UUID, at time.Time) error {
+	for _, token := range m.tokens {
+		if token.UserID == userId && token.UsedAt == nil {
+			token.UsedAt = &at
+		}
+	}
+	return nil
+}
+
+var _ irepository.IPasswordResetTokenRepository = &MockPasswordResetTokenRepository{}
+
+type MockMailer struct {
+	sent    []imailer.PasswordReset
+	release chan struct{} // When set, sending blocks until it is closed
+}
+
+func (m *MockMailer) SendPasswordReset(message imailer.PasswordReset) error {
+	if m.release != nil {
+		<-m.release
+	}
+	m.sent = append(m.sent, message)
+	return nil
+}
+
+var _ imailer.IMailer = &MockMailer{}
+
+type MockSessionRevoker struct {
+	revoked []uuid.UUID
+}
+
+func (m *MockSessionRevoker) RevokeAll(userId uuid.UUID) error {
+	m.revoked = append(m.revoked, userId)
+	return nil
+}
+
+var _ auth.ISessionRevoker = &MockSessionRevoker{}
+
+// MockAccessTokenRepository records the users whose tokens were all revoked.
+type MockAccessTokenRepository struct {
+	revoked []uuid.UUID
+}
+
+func (m *MockAccessTokenRepository) Save(token *irepository.AccessToken) error {
+	return nil
+}
+
+func (m *MockAccessTokenRepository) ByHash(tokenHash string) (*irepository.AccessToken, error) {
+	return nil, nil
+}
+
+func (m *MockAccessTokenRepository) ByUser(userId uuid.UUID) ([]*irepository.AccessToken, error) {
+	return nil, nil
+}
+
+func (m *MockAccessTokenRepository) Revoke(userId uuid.UUID, id uuid.UUID, at time.Time) (bool, error) {
+	return false, nil
+}
+
+func (m *MockAccessTokenRepository) RevokeAll(userId uuid.UUID, at time.Time) error {
+	m.revoked = append(m.revoked, userId)
+	return nil
+}
+
+func (m *MockAccessTokenRepository) Touch(id uuid.UUID, at time.Time, notBefore time.Time) error {
+	return nil
+}
+
+var _ irepository.IAccessTokenRepository = &MockAccessTokenRepository{}
+
+type MockTimeService struct {
+	now time.Time
+}
+
+func (m *MockTimeService) NowUTC() time.Time {
+	return m.now
+}
+
+func newTestUser(t *testing.T) *usermodel.User {
+	user, err := usermodel.New(usermodel.Config{
+		Username:       testUsername,
+		PlainPassword:  oldPassword,
+		CreationTime:   time.Now().UTC(),
+		PasswordHasher: &MockHashService{},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return user
+}
+
+func TestChangeHandler_Handle(t *testing.T) {
+	tests := []struct {
+		name          string
+		oldPassword   string
+		newPassword   string
+		expectedError string
+	}{
+		{
+			name:        "correct old password",
+			oldPassword: oldPassword,
+			newPassword: newPassword,
+		},
+		{
+			name:          "incorrect old password",
+			oldPassword:   wrongPassword,
+			newPassword:   newPassword,
+			expectedError: apperror.Authentication,
+		},
+		{
+			name:          "weak new password",
+			oldPassword:   oldPassword,
+			newPassword:   weakPassword,
+			expectedError: errdmn.Validation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := newTestUser(t)
+			userRepo := &MockUserRepository{user: user}
+			sessions := &MockSessionRevoker{}
+			accessTokens := &MockAccessTokenRepository{}
+			handler := NewChangeHandler(ChangeConfig{
+				UserRepository: userRepo,
+				HashService:    &MockHashService{},
+				TimeService:    &MockTimeService{now: time.Now().UTC()},
+				Sessions:       sessions,
+				AccessTokens:   accessTokens,
+			})
+
+			_, err := handler.Handle(&ChangeCommand{
+				UserID:      user.ID(),
+				OldPassword: tt.oldPassword,
+				NewPassword: tt.newPassword,
+			})
+			if tt.expectedError != "" {
+				typedErr, ok := err.(ierr.IErr)
+				if !ok || typedErr.Type() != tt.expectedError {
+					t.Fatalf("expected %s error, got %v", tt.expectedError, err)
+				}
+				if userRepo.saved || len(sessions.revoked) != 0 || len(accessTokens.revoked) != 0 || user.PasswordHash() != oldPassword {
+					t.Error("expected the password to be left unchanged")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !userRepo.saved || user.PasswordHash() != tt.newPassword {
+				t.Error("expected the new password to be saved")
+			}
+			if len(sessions.revoked) != 1 || sessions.revoked[0] != user.ID() {
+				t.Errorf("expected the sessions of the user to be revoked, got %v", sessions.revoked)
+			}
+			if len(accessTokens.revoked) != 1 || accessTokens.revoked[0] != user.ID() {
+				t.Errorf("expected the access tokens of the user to be revoked, got %v", accessTokens.revoked)
+			}
+		})
+	}
+}
+
+func TestRequestResetHandler_Handle(t *testing.T) {
+	tests := []struct {
+		name         string
+		username     string
+		expectedSent int
+	}{
+		{
+			name:         "known username",
+			username:     testUsername,
+			expectedSent: 1,
+		},
+		{
+			name:     "unknown username is not reported",
+			username: unknownUsername,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now().UTC()
+			user := newTestUser(t)
+			resetRepo := &MockPasswordResetTokenRepository{tokens: make(map[string]*irepository.PasswordResetToken)}
+			mailer := &MockMailer{}
+			handler := NewRequestResetHandler(RequestResetConfig{
+				UserRepository:  &MockUserRepository{user: user},
+				ResetRepository: resetRepo,
+				Mailer:          mailer,
+				TimeService:     &MockTimeService{now: now},
+			})
+
+			if _, err := handler.Handle(&RequestResetCommand{Username: tt.username}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			handler.Wait()
+			if len(mailer.sent) != tt.expectedSent {
+				t.Fatalf("expected %d messages, got %d", tt.expectedSent, len(mailer.sent))
+			}
+			if tt.expectedSent == 0 {
+				return
+			}
+
+			message := mailer.sent[0]
+			stored, ok := resetRepo.tokens[hashResetToken(message.Token)]
+			if !ok {
+				t.Fatal("expected the token to be stored by its hash")
+			}
+			if stored.TokenHash == message.Token {
+				t.Error("expected the token itself not to be stored")
+			}
+			if message.UserID != user.ID() || stored.UserID != user.ID() {
+				t.Error("expected the token to be issued to the user")
+			}
+			if !message.ExpiresAt.Equal(now.Add(DefaultResetTTL)) || !stored.ExpiresAt.Equal(message.ExpiresAt) {
+				t.Errorf("expected the token to expire after %v, got %v", DefaultResetTTL, message.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestRequestResetHandler_MaxPending(t *testing.T) {
+	mailer := &MockMailer{release: make(chan struct{})}
+	handler := NewRequestResetHandler(RequestResetConfig{
+		UserRepository:  &MockUserRepository{user: newTestUser(t)},
+		ResetRepository: &MockPasswordResetTokenRepository{tokens: make(map[string]*irepository.PasswordResetToken)},
+		Mailer:          mailer,
+		TimeService:     &MockTimeService{now: time.Now().UTC()},
+		MaxPending:      1,
+	})
+
+	// The first token is still being issued, so the second request is dropped.
+	for i := 0; i < 2; i++ {
+		if _, err := handler.Handle(&RequestResetCommand{Username: testUsername}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	close(mailer.release)
+	handler.Wait()
+	if len(mailer.sent) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(mailer.sent))
+	}
+
+	// Once it was issued, requests are accepted again.
+	if _, err := handler.Handle(&RequestResetCommand{Username: testUsername}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	handler.Wait()
+	if len(mailer.sent) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(mailer.sent))
+	}
+}
+
+func TestResetHandler_Handle(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		elapsed       time.Duration
+		newPassword   string
+		expectedError string
+	}{
+		{
+			name:        "valid token",
+			newPassword: newPassword,
+		},
+		{
+			name:          "unknown token",
+			token:         "unknown",
+			newPassword:   newPassword,
+			expectedError: apperror.Authentication,
+		},
+		{
+			name:          "expired token",
+			elapsed:       DefaultResetTTL,
+			newPassword:   newPassword,
+			expectedError: apperror.Authentication,
+		},
+		{
+			name:          "weak new password",
+			newPassword:   weakPassword,
+			expectedError: errdmn.Validation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeSvc := &MockTimeService{now: time.Now().UTC()}
+			user := newTestUser(t)
+			userRepo := &MockUserRepository{user: user}
+			resetRepo := &MockPasswordResetTokenRepository{tokens: make(map[string]*irepository.PasswordResetToken)}
+			mailer := &MockMailer{}
+			requestHandler := NewRequestResetHandler(RequestResetConfig{
+				UserRepository:  userRepo,
+				ResetRepository: resetRepo,
+				Mailer:          mailer,
+				TimeService:     timeSvc,
+			})
+			// A second token, which a successful reset discards.
+			for i := 0; i < 2; i++ {
+				if _, err := requestHandler.Handle(&RequestResetCommand{Username: testUsername}); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				requestHandler.Wait()
+			}
+
+			sessions := &MockSessionRevoker{}
+			accessTokens := &MockAccessTokenRepository{}
+			handler := NewResetHandler(ResetConfig{
+				UserRepository:  userRepo,
+				ResetRepository: resetRepo,
+				HashService:     &MockHashService{},
+				TimeService:     timeSvc,
+				Sessions:        sessions,
+				AccessTokens:    accessTokens,
+			})
+
+			token := tt.token
+			if token == "" {
+				token = mailer.sent[0].Token
+			}
+			timeSvc.now = timeSvc.now.Add(tt.elapsed)
+			_, err := handler.Handle(&ResetCommand{Token: token, NewPassword: tt.newPassword})
+			if tt.expectedError != "" {
+				typedErr, ok := err.(ierr.IErr)
+				if !ok || typedErr.Type() != tt.expectedError {
+					t.Fatalf("expected %s error, got %v", tt.expectedError, err)
+				}
+				if userRepo.saved || len(sessions.revoked) != 0 || len(accessTokens.revoked) != 0 {
+					t.Error("expected the password to be left unchanged")
+				}
+				if stored, ok := resetRepo.tokens[hashResetToken(token)]; ok && stored.UsedAt != nil && tt.expectedError == errdmn.Validation {
+					t.Error("expected a weak password not to use up the token")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !userRepo.saved || user.PasswordHash() != tt.newPassword {
+				t.Error("expected the new password to be saved")
+			}
+			if len(sessions.revoked) != 1 {
+				t.Errorf("expected the sessions of the user to be revoked, got %v", sessions.revoked)
+			}
+			if len(accessTokens.revoked) != 1 || accessTokens.revoked[0] != user.ID() {
+				t.Errorf("expected the access tokens of the user to be revoked, got %v", accessTokens.revoked)
+			}
+			for _, message := range mailer.sent {
+				_, err := handler.Handle(&ResetCommand{Token: message.Token, NewPassword: newPassword})
+				if typedErr, ok := err.(ierr.IErr); !ok || typedErr.Type() != apperror.Authentication {
+					t.Errorf("expected used and discarded tokens to be rejected, got %v", err)
+				}
+			}
+		})
+	}
+}
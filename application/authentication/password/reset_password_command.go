@@ -0,0 +1,13 @@
+package passwordcmd
+
+// RequestResetCommand represents a command to deliver a password reset token to a user who
+// forgot their password.
+type RequestResetCommand struct {
+	Username string // Username of the user resetting their password
+}
+
+// ResetCommand represents a command to set a new password with a password reset token.
+type ResetCommand struct {
+	Token       string // The reset token delivered to the user
+	NewPassword string // The password replacing the forgotten one
+}
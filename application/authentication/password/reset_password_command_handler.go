@@ -0,0 +1,230 @@
+package passwordcmd
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	auth "github.com/beka-birhanu/finance-go/application/authentication/common"
+	icmd "github.com/beka-birhanu/finance-go/application/common/cqrs/command"
+	imailer "github.com/beka-birhanu/finance-go/application/common/interface/mailer"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	itimeservice "github.com/beka-birhanu/finance-go/application/common/interface/time_service"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	"github.com/beka-birhanu/finance-go/domain/common/hash"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	usermodel "github.com/beka-birhanu/finance-go/domain/model/user"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultResetTTL is how long password reset tokens are accepted when no lifetime is configured.
+	DefaultResetTTL = time.Hour
+
+	// DefaultMaxPendingResets is how many reset tokens are issued at once when no limit is
+	// configured.
+	DefaultMaxPendingResets = 64
+
+	// resetTokenBytes is the number of random bytes of a password reset token.
+	resetTokenBytes = 32
+)
+
+// RequestResetHandler processes request reset commands by issuing a password reset token and
+// delivering it to the user.
+type RequestResetHandler struct {
+	userRepo  irepository.IUserRepository
+	resetRepo irepository.IPasswordResetTokenRepository
+	mailer    imailer.IMailer
+	timeSvc   itimeservice.IService
+	ttl       time.Duration
+	slots     chan struct{}  // One entry per token being issued, bounding them
+	pending   sync.WaitGroup // Tokens being issued, waited for on shutdown
+}
+
+// Ensure RequestResetHandler implements the icmd.IHandler interface for RequestResetCommand type.
+var _ icmd.IHandler[*RequestResetCommand, struct{}] = &RequestResetHandler{}
+
+// RequestResetConfig holds the dependencies needed to create a new RequestResetHandler.
+type RequestResetConfig struct {
+	UserRepository  irepository.IUserRepository
+	ResetRepository irepository.IPasswordResetTokenRepository
+	Mailer          imailer.IMailer
+	TimeService     itimeservice.IService
+	TTL             time.Duration // How long reset tokens are accepted; defaults to an hour
+	MaxPending      int           // Most tokens issued at once; defaults to DefaultMaxPendingResets
+}
+
+// NewRequestResetHandler creates a new RequestResetHandler with the provided configuration.
+func NewRequestResetHandler(config RequestResetConfig) *RequestResetHandler {
+	ttl := config.TTL
+	if ttl <= 0 {
+		ttl = DefaultResetTTL
+	}
+	maxPending := config.MaxPending
+	if maxPending <= 0 {
+		maxPending = DefaultMaxPendingResets
+	}
+
+	return &RequestResetHandler{
+		userRepo:  config.UserRepository,
+		resetRepo: config.ResetRepository,
+		mailer:    config.Mailer,
+		timeSvc:   config.TimeService,
+		ttl:       ttl,
+		slots:     make(chan struct{}, maxPending),
+	}
+}
+
+// Handle processes a request reset command. A new token is stored, by its hash only, and
+// delivered to the user through the mailer. An unknown username is not reported, so that
+// the command cannot be used to find out which usernames exist. For the same reason, the
+// token is issued in the background: the time it takes to store and deliver it, and any
+// failure to, would otherwise tell known usernames from unknown ones. Failures are logged,
+// and so are requests made while MaxPending tokens are still being issued, which are dropped
+// rather than delayed for the same reason.
+func (h *RequestResetHandler) Handle(cmd *RequestResetCommand) (struct{}, error) {
+	user, err := h.userRepo.ByUsername(cmd.Username)
+	if err != nil {
+		var domainErr *errdmn.Error
+		if errors.As(err, &domainErr) && domainErr.Type() == errdmn.NotFound {
+			return struct{}{}, nil
+		}
+		return struct{}{}, err
+	}
+
+	select {
+	case h.slots <- struct{}{}:
+	default:
+		log.Printf("dropping password reset request of user %s: too many tokens being issued", user.ID())
+		return struct{}{}, nil
+	}
+
+	h.pending.Add(1)
+	go func() {
+		defer func() {
+			<-h.slots
+			h.pending.Done()
+		}()
+		if err := h.issue(user); err != nil {
+			log.Printf("error issuing password reset token to user %s: %v", user.ID(), err)
+		}
+	}()
+	return struct{}{}, nil
+}
+
+// Wait blocks until every token being issued was delivered or failed. It is called on
+// shutdown, so that requests already answered are not lost.
+func (h *RequestResetHandler) Wait() {
+	h.pending.Wait()
+}
+
+// issue stores a new password reset token of the user and delivers it to them.
+func (h *RequestResetHandler) issue(user *usermodel.User) error {
+	raw := make([]byte, resetTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error generating password reset token: %v", err))
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	now := h.timeSvc.NowUTC()
+	stored := &irepository.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID(),
+		TokenHash: hashResetToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(h.ttl),
+	}
+	if err := h.resetRepo.Save(stored); err != nil {
+		return err
+	}
+
+	err := h.mailer.SendPasswordReset(imailer.PasswordReset{
+		UserID:    user.ID(),
+		Username:  user.Username(),
+		Token:     token,
+		ExpiresAt: stored.ExpiresAt,
+	})
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error sending password reset token: %v", err))
+	}
+	return nil
+}
+
+// ResetHandler processes reset commands by setting the new password of the user a password
+// reset token was issued to.
+type ResetHandler struct {
+	passwordSetter
+	resetRepo irepository.IPasswordResetTokenRepository
+}
+
+// Ensure ResetHandler implements the icmd.IHandler interface for ResetCommand type.
+var _ icmd.IHandler[*ResetCommand, struct{}] = &ResetHandler{}
+
+// ResetConfig holds the dependencies needed to create a new ResetHandler.
+type ResetConfig struct {
+	UserRepository  irepository.IUserRepository
+	ResetRepository irepository.IPasswordResetTokenRepository
+	HashService     hash.IService
+	TimeService     itimeservice.IService
+	Sessions        auth.ISessionRevoker               // Ends the sessions of the user once the password changed
+	AccessTokens    irepository.IAccessTokenRepository // Revokes the personal access tokens of the user likewise
+}
+
+// NewResetHandler creates a new ResetHandler with the provided configuration.
+func NewResetHandler(config ResetConfig) *ResetHandler {
+	return &ResetHandler{
+		passwordSetter: passwordSetter{
+			userRepo:     config.UserRepository,
+			hashSvc:      config.HashService,
+			timeSvc:      config.TimeService,
+			sessions:     config.Sessions,
+			accessTokens: config.AccessTokens,
+		},
+		resetRepo: config.ResetRepository,
+	}
+}
+
+// Handle processes a reset command. The strength of the new password is checked first, so
+// that a weak password does not use up the token; the token is then consumed, so it is good
+// for a single reset. Once the password is set, the other reset tokens of the user are
+// discarded, every session of the user is ended and every personal access token is revoked.
+// Returns:
+// - error: An authentication error if the token is unknown, expired or used, a validation
+// error if the new password is too weak, or an unexpected error if the user cannot be
+// retrieved or saved.
+func (h *ResetHandler) Handle(cmd *ResetCommand) (struct{}, error) {
+	if err := usermodel.ValidatePassword(cmd.NewPassword); err != nil {
+		return struct{}{}, err
+	}
+
+	now := h.timeSvc.NowUTC()
+	stored, err := h.resetRepo.Consume(hashResetToken(cmd.Token), now)
+	if err != nil {
+		return struct{}{}, err
+	}
+	if stored == nil {
+		return struct{}{}, apperror.InvalidCredential("invalid password reset token")
+	}
+
+	user, err := h.userRepo.ById(stored.UserID)
+	if err != nil {
+		return struct{}{}, err
+	}
+	if err := h.setPassword(user, cmd.NewPassword); err != nil {
+		return struct{}{}, err
+	}
+	return struct{}{}, h.resetRepo.DiscardUser(user.ID(), now)
+}
+
+// hashResetToken returns the hash password reset tokens are stored and looked up by. The
+// tokens are random, so a fast unsalted hash suffices.
+func hashResetToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
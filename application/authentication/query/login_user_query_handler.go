@@ -3,8 +3,11 @@
 package loginqry
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"sync"
 
 	auth "github.com/beka-birhanu/finance-go/application/authentication/common"
 	iquery "github.com/beka-birhanu/finance-go/application/common/cqrs/query"
@@ -17,48 +20,84 @@ import (
 
 // Handler processes login queries by validating user credentials and generating authentication results.
 type Handler struct {
-	userRepo irepository.IUserRepository
-	jwtSvc   ijwt.IService
-	hashSvc  hash.IService
+	userRepo  irepository.IUserRepository
+	jwtSvc    ijwt.IService
+	hashSvc   hash.IService
+	refresh   auth.IRefreshTokenIssuer
+	twoFactor auth.ITwoFactorChallenger
+	guard     auth.ILoginGuard
+
+	decoyOnce sync.Once
+	decoy     string // Hash passwords are checked against when the username does not exist
 }
 
 // Ensure Handler implements the iquery.IHandler interface for Query type and auth.Result type.
 var _ iquery.IHandler[*Query, *auth.Result] = &Handler{}
 
 // Config holds the dependencies needed to create a new Handler.
-// It includes the user repository, JWT service, and hash service.
+// It includes the user repository, JWT service, hash service, and optionally the issuer of
+// refresh tokens, the challenger of second factors and the guard limiting attempts; no refresh
+// token is issued, no second factor is asked for, and attempts are not limited when the
+// respective one is nil.
 type Config struct {
 	UserRepository irepository.IUserRepository
 	JwtService     ijwt.IService
 	HashService    hash.IService
+	RefreshTokens  auth.IRefreshTokenIssuer
+	TwoFactor      auth.ITwoFactorChallenger
+	Guard          auth.ILoginGuard
 }
 
 // NewHandler creates a new Handler with the provided configuration.
 // It initializes the Handler with the necessary services for processing login queries.
 func NewHandler(config Config) *Handler {
 	return &Handler{
-		userRepo: config.UserRepository,
-		jwtSvc:   config.JwtService,
-		hashSvc:  config.HashService,
+		userRepo:  config.UserRepository,
+		jwtSvc:    config.JwtService,
+		hashSvc:   config.HashService,
+		refresh:   config.RefreshTokens,
+		twoFactor: config.TwoFactor,
+		guard:     config.Guard,
 	}
 }
 
-// Handle processes a login query and returns an authentication result if successful.
+// Handle processes a login query and returns an authentication result if successful. For users
+// with a second factor, the result carries a challenge token instead of the tokens of a session,
+// which the user exchanges for them by giving a code of their second factor.
 // Returns:
-// - *auth.Result: A pointer to the authentication result containing user ID, username, and token.
+// - *auth.Result: A pointer to the authentication result containing user ID, username, token and
+// refresh token, or challenge token.
 // - error: An error if the login fails. Possible errors include:
-//   - InvalidCredential: If the username is not found or the password is incorrect.
+//   - TooManyRequests: If attempts under the username are blocked after failed attempts.
+//   - InvalidCredential: If the username is not found, the user has no password or the
+//     password is incorrect.
+//   - Forbidden: If the password is correct but the account is disabled.
 //   - Unexpected: For unexpected errors during user retrieval or password validation.
 func (h *Handler) Handle(query *Query) (*auth.Result, error) {
+	if h.guard != nil {
+		if err := h.guard.Attempt(query.Username); err != nil {
+			return nil, err
+		}
+	}
+
 	user, err := h.userRepo.ByUsername(query.Username)
 	if err != nil {
 		var domainErr *errdmn.Error
 		if errors.As(err, &domainErr) {
+			// Checking the password anyway makes the response take as long as for a user
+			// that exists, so that its timing does not tell whether the username does.
+			_, _ = h.hashSvc.Match(h.decoyHash(), query.Password)
 			return nil, appError.InvalidCredential(domainErr.Message)
 		}
 		return nil, errdmn.NewUnexpected(fmt.Sprintf("failed to retrieve user, %v", err))
 	}
 
+	if !user.HasPassword() {
+		// Users signing in through an identity provider may have no password to check.
+		_, _ = h.hashSvc.Match(h.decoyHash(), query.Password)
+		return nil, appError.InvalidCredential("incorrect password")
+	}
+
 	isPasswordCorrect, err := h.hashSvc.Match(user.PasswordHash(), query.Password)
 	if err != nil {
 		return nil, fmt.Errorf("failed to validate user password, %w", err)
@@ -68,10 +107,49 @@ func (h *Handler) Handle(query *Query) (*auth.Result, error) {
 		return nil, appError.InvalidCredential("incorrect password")
 	}
 
+	if h.guard != nil {
+		if err := h.guard.Succeed(query.Username); err != nil {
+			return nil, err
+		}
+	}
+
+	if user.Disabled() {
+		return nil, appError.AccountDisabled()
+	}
+
+	if h.twoFactor != nil {
+		challengeToken, enabled, err := h.twoFactor.Challenge(user.ID())
+		if err != nil {
+			return nil, err
+		}
+		if enabled {
+			return &auth.Result{ID: user.ID(), Username: user.Username(), ChallengeToken: challengeToken}, nil
+		}
+	}
+
 	token, err := h.jwtSvc.Generate(user)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate JWT for user, %w", err)
 	}
 
-	return auth.NewResult(user.ID(), user.Username(), token), nil
+	result := auth.NewResult(user.ID(), user.Username(), token)
+	if h.refresh != nil {
+		if result.RefreshToken, err = h.refresh.Issue(user.ID()); err != nil {
+			return nil, fmt.Errorf("failed to issue refresh token for user, %w", err)
+		}
+	}
+	return result, nil
+}
+
+// decoyHash returns the hash of a random password, created on first use, to check passwords
+// against when there is no user to check them against.
+func (h *Handler) decoyHash() string {
+	h.decoyOnce.Do(func() {
+		password := make([]byte, 32)
+		if _, err := rand.Read(password); err != nil {
+			return
+		}
+		h.decoy, _ = h.hashSvc.Hash(base64.RawURLEncoding.EncodeToString(password))
+	})
+	return h.decoy
 }
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	auth "github.com/beka-birhanu/finance-go/application/authentication/common"
 	ijwt "github.com/beka-birhanu/finance-go/application/common/interface/jwt"
 	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
 	appError "github.com/beka-birhanu/finance-go/application/error"
@@ -11,7 +12,6 @@ import (
 	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
 	erruser "github.com/beka-birhanu/finance-go/domain/error/user"
 	usermodel "github.com/beka-birhanu/finance-go/domain/model/user"
-	"github.com/dgrijalva/jwt-go"
 	"github.com/google/uuid"
 )
 
@@ -44,7 +44,7 @@ func (m *MockJwtService) Generate(user *usermodel.User) (string, error) {
 	return m.GenerateTokenFunc(user)
 }
 
-func (m *MockJwtService) Decode(token string) (jwt.MapClaims, error) {
+func (m *MockJwtService) Decode(token string) (*ijwt.Claims, error) {
 	return nil, nil
 }
 
@@ -65,6 +65,40 @@ func (m *MockHashService) Match(hashedWord, plainWord string) (bool, error) {
 
 var _ hash.IService = &MockHashService{}
 
+type MockTwoFactorChallenger struct {
+	enabled bool
+}
+
+func (m *MockTwoFactorChallenger) Challenge(userId uuid.UUID) (string, bool, error) {
+	if !m.enabled {
+		return "", false, nil
+	}
+	return "challengeToken", true, nil
+}
+
+var _ auth.ITwoFactorChallenger = &MockTwoFactorChallenger{}
+
+type MockLoginGuard struct {
+	blocked   bool
+	attempts  []string
+	successes []string
+}
+
+func (m *MockLoginGuard) Attempt(username string) error {
+	m.attempts = append(m.attempts, username)
+	if m.blocked {
+		return appError.TooManyAttempts(time.Minute)
+	}
+	return nil
+}
+
+func (m *MockLoginGuard) Succeed(username string) error {
+	m.successes = append(m.successes, username)
+	return nil
+}
+
+var _ auth.ILoginGuard = &MockLoginGuard{}
+
 var validUser, _ = usermodel.New(usermodel.Config{
 	Username:      "validUser",
 	PlainPassword: `#%@@strong@@password#%`,
@@ -76,12 +110,34 @@ var validUser, _ = usermodel.New(usermodel.Config{
 },
 )
 
+var externalUser, _ = usermodel.NewWithoutPassword(usermodel.ConfigWithoutPassword{
+	Username:     "externalUser",
+	CreationTime: time.Now().UTC(),
+})
+
+var disabledAt = time.Now().UTC()
+
+var disabledUser, _ = usermodel.NewWithExistingHash(usermodel.ConfigForExistingHash{
+	ID:           uuid.New(),
+	Username:     "disabledUser",
+	PasswordHash: validUser.PasswordHash(),
+	CreationTime: time.Now().UTC(),
+	UpdatedAt:    time.Now().UTC(),
+	DisabledAt:   &disabledAt,
+})
+
 func TestHandler_Handle(t *testing.T) {
 	mockUserRepository := &MockUserRepository{
 		ByUsernameFunc: func(username string) (*usermodel.User, error) {
 			if username == "validUser" {
 				return validUser, nil
 			}
+			if username == "externalUser" {
+				return externalUser, nil
+			}
+			if username == "disabledUser" {
+				return disabledUser, nil
+			}
 			return nil, errdmn.NewNotFound("user not found")
 		},
 		AddFunc: func(user *usermodel.User) error {
@@ -145,6 +201,30 @@ func TestHandler_Handle(t *testing.T) {
 			},
 			expectedError: appError.InvalidCredential("Authentication: invalid credentials"),
 		},
+		{
+			name: "user without password",
+			query: &Query{
+				Username: "externalUser",
+				Password: "password",
+			},
+			expectedError: appError.InvalidCredential("Authentication: invalid credentials"),
+		},
+		{
+			name: "disabled user",
+			query: &Query{
+				Username: "disabledUser",
+				Password: "password",
+			},
+			expectedError: appError.AccountDisabled(),
+		},
+		{
+			name: "disabled user with incorrect password",
+			query: &Query{
+				Username: "disabledUser",
+				Password: "wrongPassword",
+			},
+			expectedError: appError.InvalidCredential("Authentication: invalid credentials"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -162,3 +242,138 @@ func TestHandler_Handle(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_HandleTwoFactor(t *testing.T) {
+	tests := []struct {
+		name              string
+		enabled           bool
+		expectedToken     string
+		expectedChallenge string
+	}{
+		{
+			name:          "without a second factor",
+			expectedToken: "validToken",
+		},
+		{
+			name:              "with a second factor",
+			enabled:           true,
+			expectedChallenge: "challengeToken",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(Config{
+				UserRepository: &MockUserRepository{
+					ByUsernameFunc: func(username string) (*usermodel.User, error) {
+						return validUser, nil
+					},
+				},
+				JwtService: &MockJwtService{
+					GenerateTokenFunc: func(user *usermodel.User) (string, error) {
+						return "validToken", nil
+					},
+				},
+				HashService: &MockHashService{
+					MatchFunc: func(hashedWord, plainWord string) (bool, error) {
+						return true, nil
+					},
+				},
+				TwoFactor: &MockTwoFactorChallenger{enabled: tt.enabled},
+			})
+
+			result, err := handler.Handle(&Query{Username: "validUser", Password: "password"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Token != tt.expectedToken || result.ChallengeToken != tt.expectedChallenge {
+				t.Errorf("expected token %q and challenge %q, got %+v", tt.expectedToken, tt.expectedChallenge, result)
+			}
+		})
+	}
+}
+
+func TestHandler_HandleGuard(t *testing.T) {
+	tests := []struct {
+		name              string
+		query             *Query
+		blocked           bool
+		expectedError     string
+		expectedSuccesses int
+		expectedMatches   int
+	}{
+		{
+			name:              "successful sign in resets the failures",
+			query:             &Query{Username: "validUser", Password: "password"},
+			expectedSuccesses: 1,
+			expectedMatches:   1,
+		},
+		{
+			name:            "incorrect password",
+			query:           &Query{Username: "validUser", Password: "wrongPassword"},
+			expectedError:   appError.InvalidCredential("").Error(),
+			expectedMatches: 1,
+		},
+		{
+			name:            "unknown username checks a password all the same",
+			query:           &Query{Username: "invalidUser", Password: "password"},
+			expectedError:   appError.InvalidCredential("").Error(),
+			expectedMatches: 1,
+		},
+		{
+			name:          "blocked username is rejected before the password is checked",
+			query:         &Query{Username: "validUser", Password: "password"},
+			blocked:       true,
+			expectedError: appError.TooManyAttempts(time.Minute).Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := &MockLoginGuard{blocked: tt.blocked}
+			matches := 0
+			handler := NewHandler(Config{
+				UserRepository: &MockUserRepository{
+					ByUsernameFunc: func(username string) (*usermodel.User, error) {
+						if username == "validUser" {
+							return validUser, nil
+						}
+						return nil, errdmn.NewNotFound("user not found")
+					},
+				},
+				JwtService: &MockJwtService{
+					GenerateTokenFunc: func(user *usermodel.User) (string, error) {
+						return "validToken", nil
+					},
+				},
+				HashService: &MockHashService{
+					MatchFunc: func(hashedWord, plainWord string) (bool, error) {
+						matches++
+						return hashedWord == validUser.PasswordHash() && plainWord == "password", nil
+					},
+					HashFunc: func(word string) (string, error) {
+						return "decoyHash", nil
+					},
+				},
+				Guard: guard,
+			})
+
+			_, err := handler.Handle(tt.query)
+			if tt.expectedError == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.expectedError != "" && (err == nil || err.Error() != tt.expectedError) {
+				t.Fatalf("expected error %q, got %v", tt.expectedError, err)
+			}
+			if len(guard.attempts) != 1 || guard.attempts[0] != tt.query.Username {
+				t.Errorf("expected one attempt under %q, got %v", tt.query.Username, guard.attempts)
+			}
+			if len(guard.successes) != tt.expectedSuccesses {
+				t.Errorf("expected %d successes, got %v", tt.expectedSuccesses, guard.successes)
+			}
+			if matches != tt.expectedMatches {
+				t.Errorf("expected the password to be checked %d times, got %d", tt.expectedMatches, matches)
+			}
+		})
+	}
+}
@@ -0,0 +1,7 @@
+package refreshcmd
+
+// Command represents a command to exchange a refresh token for a new access token.
+type Command struct {
+	// Token is the refresh token presented by the client.
+	Token string
+}
@@ -0,0 +1,69 @@
+package refreshcmd
+
+import (
+	"fmt"
+
+	auth "github.com/beka-birhanu/finance-go/application/authentication/common"
+	icmd "github.com/beka-birhanu/finance-go/application/common/cqrs/command"
+	ijwt "github.com/beka-birhanu/finance-go/application/common/interface/jwt"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+)
+
+// Handler processes refresh commands by rotating the refresh token and generating a new
+// access token for its user.
+type Handler struct {
+	userRepo irepository.IUserRepository
+	jwtSvc   ijwt.IService
+	tokens   *TokenService
+}
+
+// Ensure Handler implements the icmd.IHandler interface for Command type and auth.Result type.
+var _ icmd.IHandler[*Command, *auth.Result] = &Handler{}
+
+// Config holds the dependencies needed to create a new Handler.
+type Config struct {
+	UserRepository irepository.IUserRepository
+	JwtService     ijwt.IService
+	TokenService   *TokenService
+}
+
+// NewHandler creates a new Handler with the provided configuration.
+func NewHandler(config Config) *Handler {
+	return &Handler{
+		userRepo: config.UserRepository,
+		jwtSvc:   config.JwtService,
+		tokens:   config.TokenService,
+	}
+}
+
+// Handle processes a refresh command and returns a new authentication result if successful.
+// Returns:
+// - *auth.Result: The user with a new access token and the refresh token replacing the presented one.
+// - error: An authentication error if the refresh token is not accepted, a forbidden error if
+// the user is disabled, or an unexpected error if the user cannot be retrieved or the access
+// token cannot be generated.
+func (h *Handler) Handle(cmd *Command) (*auth.Result, error) {
+	userId, refreshToken, err := h.tokens.Rotate(cmd.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := h.userRepo.ById(userId)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled() {
+		return nil, apperror.AccountDisabled()
+	}
+
+	token, err := h.jwtSvc.Generate(user)
+	if err != nil {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("failed to generate JWT for user, %v", err))
+	}
+
+	result := auth.NewResult(user.ID(), user.Username(), token)
+	result.RefreshToken = refreshToken
+	return result, nil
+}
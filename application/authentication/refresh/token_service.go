@@ -0,0 +1,157 @@
+// Package refreshcmd provides functionality for refresh tokens: long-lived tokens that are
+// exchanged for a new access token when the current one expires. Every exchange rotates the
+// refresh token, and presenting a token that was already rotated, which means it leaked,
+// revokes the whole family of tokens descending from the same login.
+package refreshcmd
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	itimeservice "github.com/beka-birhanu/finance-go/application/common/interface/time_service"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultTTL is how long refresh tokens are accepted when no lifetime is configured.
+	DefaultTTL = 30 * 24 * time.Hour
+
+	// tokenBytes is the number of random bytes of a refresh token.
+	tokenBytes = 32
+)
+
+// TokenService issues and rotates refresh tokens.
+type TokenService struct {
+	repository irepository.IRefreshTokenRepository // Repository for refresh tokens
+	timeSvc    itimeservice.IService               // Service for time-related operations
+	ttl        time.Duration                       // How long refresh tokens are accepted
+}
+
+// TokenServiceConfig holds dependencies required for creating a TokenService.
+type TokenServiceConfig struct {
+	Repository  irepository.IRefreshTokenRepository // Repository for refresh tokens
+	TimeService itimeservice.IService               // Service for time-related operations
+	TTL         time.Duration                       // How long refresh tokens are accepted; defaults to 30 days
+}
+
+// NewTokenService creates a new TokenService with the specified configuration.
+func NewTokenService(config TokenServiceConfig) *TokenService {
+	ttl := config.TTL
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	return &TokenService{
+		repository: config.Repository,
+		timeSvc:    config.TimeService,
+		ttl:        ttl,
+	}
+}
+
+// TTL returns how long the refresh tokens issued by the service are accepted.
+func (s *TokenService) TTL() time.Duration {
+	return s.ttl
+}
+
+// Issue issues the refresh token of a new session of the user, starting a new family.
+func (s *TokenService) Issue(userId uuid.UUID) (string, error) {
+	return s.issue(userId, uuid.New(), s.timeSvc.NowUTC())
+}
+
+// Rotate exchanges a refresh token for its successor in the same family.
+//
+// Returns:
+//   - uuid.UUID: The ID of the user the token was issued to.
+//   - string: The new refresh token, which replaces the exchanged one.
+//   - error: An authentication error if the token is unknown, expired, revoked or was already
+//     rotated. In the last case the whole family is revoked, so that neither the holder of
+//     the leaked token nor the legitimate client can keep using the session.
+func (s *TokenService) Rotate(token string) (uuid.UUID, string, error) {
+	stored, err := s.repository.ByHash(hashToken(token))
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	now := s.timeSvc.NowUTC()
+	if stored == nil || stored.RevokedAt != nil || !now.Before(stored.ExpiresAt) {
+		return uuid.Nil, "", apperror.InvalidCredential("invalid refresh token")
+	}
+	if stored.RotatedAt != nil {
+		return uuid.Nil, "", s.revokeReused(stored, now)
+	}
+
+	rotated, err := s.repository.Rotate(stored.ID, now)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	if !rotated {
+		// Another request rotated or revoked the token since it was read.
+		return uuid.Nil, "", s.revokeReused(stored, now)
+	}
+
+	next, err := s.issue(stored.UserID, stored.FamilyID, now)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	return stored.UserID, next, nil
+}
+
+// Revoke revokes the family of a refresh token, ending the session it belongs to. Unknown
+// tokens are ignored.
+func (s *TokenService) Revoke(token string) error {
+	stored, err := s.repository.ByHash(hashToken(token))
+	if err != nil || stored == nil {
+		return err
+	}
+	return s.repository.RevokeFamily(stored.FamilyID, s.timeSvc.NowUTC())
+}
+
+// RevokeAll revokes every refresh token of the user, ending all of their sessions.
+func (s *TokenService) RevokeAll(userId uuid.UUID) error {
+	return s.repository.RevokeUser(userId, s.timeSvc.NowUTC())
+}
+
+// revokeReused revokes the family of a reused refresh token and returns the error reporting
+// the reuse.
+func (s *TokenService) revokeReused(token *irepository.RefreshToken, now time.Time) error {
+	if err := s.repository.RevokeFamily(token.FamilyID, now); err != nil {
+		return err
+	}
+	return apperror.InvalidCredential("refresh token reused")
+}
+
+// issue stores and returns a new refresh token of the user in the family.
+func (s *TokenService) issue(userId uuid.UUID, familyId uuid.UUID, now time.Time) (string, error) {
+	raw := make([]byte, tokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", errdmn.NewUnexpected(fmt.Sprintf("error generating refresh token: %v", err))
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	err := s.repository.Save(&irepository.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    userId,
+		FamilyID:  familyId,
+		TokenHash: hashToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// hashToken returns the hash refresh tokens are stored and looked up by. The tokens are
+// random, so a fast unsalted hash suffices.
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
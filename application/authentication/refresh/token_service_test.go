@@ -0,0 +1,166 @@
+package refreshcmd
+
+import (
+	"testing"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	ierr "github.com/beka-birhanu/finance-go/domain/common/error"
+	"github.com/google/uuid"
+)
+
+// MockRefreshTokenRepository keeps refresh tokens in memory, keyed by their hash.
+type MockRefreshTokenRepository struct {
+	tokens map[string]*irepository.RefreshToken
+}
+
+func (m *MockRefreshTokenRepository) Save(token *irepository.RefreshToken) error {
+	m.tokens[token.TokenHash] = token
+	return nil
+}
+
+func (m *MockRefreshTokenRepository) ByHash(hash string) (*irepository.RefreshToken, error) {
+	return m.tokens[hash], nil
+}
+
+func (m *MockRefreshTokenRepository) Rotate(id uuid.UUID, at time.Time) (bool, error) {
+	for _, token := range m.tokens {
+		if token.ID == id && token.RotatedAt == nil && token.RevokedAt == nil {
+			token.RotatedAt = &at
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(familyId uuid.UUID, at time.Time) error {
+	for _, token := range m.tokens {
+		if token.FamilyID == familyId && token.RevokedAt == nil {
+			token.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+func (m *MockRefreshTokenRepository) RevokeUser(userId uuid.UUID, at time.Time) error {
+	for _, token := range m.tokens {
+		if token.UserID == userId && token.RevokedAt == nil {
+			token.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+var _ irepository.IRefreshTokenRepository = &MockRefreshTokenRepository{}
+
+type MockTimeService struct {
+	now time.Time
+}
+
+func (m *MockTimeService) NowUTC() time.Time {
+	return m.now
+}
+
+func TestTokenService_Rotate(t *testing.T) {
+	userId := uuid.New()
+
+	tests := []struct {
+		name string
+		// exchange rotates the issued token and returns the token to present last.
+		exchange      func(t *testing.T, service *TokenService, timeSvc *MockTimeService, issued string) string
+		expectedError string
+	}{
+		{
+			name: "issued token",
+			exchange: func(t *testing.T, service *TokenService, timeSvc *MockTimeService, issued string) string {
+				return issued
+			},
+		},
+		{
+			name: "rotated successor",
+			exchange: func(t *testing.T, service *TokenService, timeSvc *MockTimeService, issued string) string {
+				return rotate(t, service, issued)
+			},
+		},
+		{
+			name: "unknown token",
+			exchange: func(t *testing.T, service *TokenService, timeSvc *MockTimeService, issued string) string {
+				return "unknown"
+			},
+			expectedError: apperror.Authentication,
+		},
+		{
+			name: "expired token",
+			exchange: func(t *testing.T, service *TokenService, timeSvc *MockTimeService, issued string) string {
+				timeSvc.now = timeSvc.now.Add(DefaultTTL)
+				return issued
+			},
+			expectedError: apperror.Authentication,
+		},
+		{
+			name: "reused token",
+			exchange: func(t *testing.T, service *TokenService, timeSvc *MockTimeService, issued string) string {
+				rotate(t, service, issued)
+				return issued
+			},
+			expectedError: apperror.Authentication,
+		},
+		{
+			name: "successor of a reused token",
+			exchange: func(t *testing.T, service *TokenService, timeSvc *MockTimeService, issued string) string {
+				successor := rotate(t, service, issued)
+				if _, _, err := service.Rotate(issued); err == nil {
+					t.Fatal("expected reusing the token to fail")
+				}
+				return successor
+			},
+			expectedError: apperror.Authentication,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeSvc := &MockTimeService{now: time.Now().UTC()}
+			service := NewTokenService(TokenServiceConfig{
+				Repository:  &MockRefreshTokenRepository{tokens: make(map[string]*irepository.RefreshToken)},
+				TimeService: timeSvc,
+			})
+
+			issued, err := service.Issue(userId)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			token := tt.exchange(t, service, timeSvc, issued)
+			gotUserId, next, err := service.Rotate(token)
+			if tt.expectedError != "" {
+				typedErr, ok := err.(ierr.IErr)
+				if !ok || typedErr.Type() != tt.expectedError {
+					t.Fatalf("expected %s error, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if gotUserId != userId {
+				t.Errorf("expected user %v, got %v", userId, gotUserId)
+			}
+			if next == "" || next == token {
+				t.Errorf("expected a new refresh token, got %q", next)
+			}
+		})
+	}
+}
+
+// rotate rotates the token and returns its successor.
+func rotate(t *testing.T, service *TokenService, token string) string {
+	t.Helper()
+	_, next, err := service.Rotate(token)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return next
+}
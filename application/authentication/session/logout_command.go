@@ -0,0 +1,25 @@
+package sessioncmd
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Command represents a command to log a user out.
+type Command struct {
+	// UserID is the ID of the user logging out.
+	UserID uuid.UUID
+
+	// TokenID is the ID (jti) of the access token of the current session.
+	TokenID string
+
+	// ExpiresAt is when the access token of the current session expires.
+	ExpiresAt time.Time
+
+	// RefreshToken is the refresh token of the current session, if the client holds one.
+	RefreshToken string
+
+	// AllSessions ends every session of the user instead of the current one only.
+	AllSessions bool
+}
@@ -0,0 +1,28 @@
+package sessioncmd
+
+import (
+	icmd "github.com/beka-birhanu/finance-go/application/common/cqrs/command"
+)
+
+// Handler processes logout commands by revoking the tokens of the sessions being ended.
+type Handler struct {
+	revoker *Revoker
+}
+
+// Ensure Handler implements the icmd.IHandler interface for Command type.
+var _ icmd.IHandler[*Command, struct{}] = &Handler{}
+
+// NewHandler creates a new Handler revoking tokens with the given Revoker.
+func NewHandler(revoker *Revoker) *Handler {
+	return &Handler{revoker: revoker}
+}
+
+// Handle processes a logout command. Logging out of the current session revokes its access
+// token and its refresh token family; logging out of all sessions revokes every access and
+// refresh token issued to the user so far.
+func (h *Handler) Handle(cmd *Command) (struct{}, error) {
+	if cmd.AllSessions {
+		return struct{}{}, h.revoker.RevokeAll(cmd.UserID)
+	}
+	return struct{}{}, h.revoker.revokeSession(cmd.UserID, cmd.TokenID, cmd.ExpiresAt, cmd.RefreshToken)
+}
@@ -0,0 +1,210 @@
+package sessioncmd
+
+import (
+	"testing"
+	"time"
+
+	refreshcmd "github.com/beka-birhanu/finance-go/application/authentication/refresh"
+	ijwt "github.com/beka-birhanu/finance-go/application/common/interface/jwt"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	"github.com/google/uuid"
+)
+
+type MockTokenRevocationRepository struct {
+	revokedTokens map[string]bool
+	revokedBefore map[uuid.UUID]time.Time
+}
+
+func (m *MockTokenRevocationRepository) RevokeToken(tokenId string, userId uuid.UUID, expiresAt time.Time) error {
+	m.revokedTokens[tokenId] = true
+	return nil
+}
+
+func (m *MockTokenRevocationRepository) IsTokenRevoked(tokenId string) (bool, error) {
+	return m.revokedTokens[tokenId], nil
+}
+
+func (m *MockTokenRevocationRepository) RevokeAllBefore(userId uuid.UUID, at time.Time) error {
+	m.revokedBefore[userId] = at
+	return nil
+}
+
+func (m *MockTokenRevocationRepository) RevokedBefore(userId uuid.UUID) (*time.Time, error) {
+	if at, ok := m.revokedBefore[userId]; ok {
+		return &at, nil
+	}
+	return nil, nil
+}
+
+var _ irepository.ITokenRevocationRepository = &MockTokenRevocationRepository{}
+
+type MockRefreshTokenRepository struct {
+	tokens map[string]*irepository.RefreshToken
+}
+
+func (m *MockRefreshTokenRepository) Save(token *irepository.RefreshToken) error {
+	m.tokens[token.TokenHash] = token
+	return nil
+}
+
+func (m *MockRefreshTokenRepository) ByHash(hash string) (*irepository.RefreshToken, error) {
+	return m.tokens[hash], nil
+}
+
+func (m *MockRefreshTokenRepository) Rotate(id uuid.UUID, at time.Time) (bool, error) {
+	return true, nil
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(familyId uuid.UUID, at time.Time) error {
+	for _, token := range m.tokens {
+		if token.FamilyID == familyId {
+			token.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+func (m *MockRefreshTokenRepository) RevokeUser(userId uuid.UUID, at time.Time) error {
+	for _, token := range m.tokens {
+		if token.UserID == userId {
+			token.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+var _ irepository.IRefreshTokenRepository = &MockRefreshTokenRepository{}
+
+type MockTimeService struct {
+	now time.Time
+}
+
+func (m *MockTimeService) NowUTC() time.Time {
+	return m.now
+}
+
+func TestHandler_Handle(t *testing.T) {
+	tests := []struct {
+		name                   string
+		allSessions            bool
+		expectedCurrentRevoked bool
+		expectedOtherRevoked   bool
+	}{
+		{
+			name:                   "current session",
+			expectedCurrentRevoked: true,
+		},
+		{
+			name:                   "all sessions",
+			allSessions:            true,
+			expectedCurrentRevoked: true,
+			expectedOtherRevoked:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userId := uuid.New()
+			issuedAt := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+			timeSvc := &MockTimeService{now: issuedAt}
+			refreshRepo := &MockRefreshTokenRepository{tokens: make(map[string]*irepository.RefreshToken)}
+			refreshTokens := refreshcmd.NewTokenService(refreshcmd.TokenServiceConfig{
+				Repository:  refreshRepo,
+				TimeService: timeSvc,
+			})
+			revoker := NewRevoker(RevokerConfig{
+				Revocations: &MockTokenRevocationRepository{
+					revokedTokens: make(map[string]bool),
+					revokedBefore: make(map[uuid.UUID]time.Time),
+				},
+				RefreshTokens: refreshTokens,
+				TimeService:   timeSvc,
+			})
+
+			currentRefresh, _ := refreshTokens.Issue(userId)
+			if _, err := refreshTokens.Issue(userId); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			timeSvc.now = issuedAt.Add(time.Minute)
+			_, err := NewHandler(revoker).Handle(&Command{
+				UserID:       userId,
+				TokenID:      "current",
+				ExpiresAt:    issuedAt.Add(time.Hour),
+				RefreshToken: currentRefresh,
+				AllSessions:  tt.allSessions,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			current := &ijwt.Claims{Subject: userId, ID: "current", IssuedAt: issuedAt}
+			other := &ijwt.Claims{Subject: userId, ID: "other", IssuedAt: issuedAt}
+			if revoked, _ := revoker.IsRevoked(current); revoked != tt.expectedCurrentRevoked {
+				t.Errorf("expected current access token revoked %v, got %v", tt.expectedCurrentRevoked, revoked)
+			}
+			if revoked, _ := revoker.IsRevoked(other); revoked != tt.expectedOtherRevoked {
+				t.Errorf("expected other access token revoked %v, got %v", tt.expectedOtherRevoked, revoked)
+			}
+
+			revokedRefresh := 0
+			for _, token := range refreshRepo.tokens {
+				if token.RevokedAt != nil {
+					revokedRefresh++
+				}
+			}
+			expectedRevokedRefresh := 1
+			if tt.allSessions {
+				expectedRevokedRefresh = 2
+			}
+			if revokedRefresh != expectedRevokedRefresh {
+				t.Errorf("expected %d revoked refresh tokens, got %d", expectedRevokedRefresh, revokedRefresh)
+			}
+		})
+	}
+}
+
+func TestRevoker_IsRevoked(t *testing.T) {
+	revokedAt := time.Date(2024, 6, 8, 8, 0, 0, 500*int(time.Millisecond), time.UTC)
+	second := revokedAt.Truncate(time.Second)
+
+	tests := []struct {
+		name            string
+		issuedAt        time.Time
+		expectedRevoked bool
+	}{
+		{name: "issued a second before", issuedAt: second.Add(-time.Second), expectedRevoked: true},
+		{name: "signed in again right after", issuedAt: second},
+		{name: "personal access token created right after", issuedAt: revokedAt.Add(200 * time.Millisecond)},
+		{name: "issued a second after", issuedAt: second.Add(time.Second)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userId := uuid.New()
+			timeSvc := &MockTimeService{now: revokedAt}
+			revoker := NewRevoker(RevokerConfig{
+				Revocations: &MockTokenRevocationRepository{
+					revokedTokens: make(map[string]bool),
+					revokedBefore: make(map[uuid.UUID]time.Time),
+				},
+				RefreshTokens: refreshcmd.NewTokenService(refreshcmd.TokenServiceConfig{
+					Repository:  &MockRefreshTokenRepository{tokens: make(map[string]*irepository.RefreshToken)},
+					TimeService: timeSvc,
+				}),
+				TimeService: timeSvc,
+			})
+			if err := revoker.RevokeAll(userId); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			revoked, err := revoker.IsRevoked(&ijwt.Claims{Subject: userId, ID: "token", IssuedAt: tt.issuedAt})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if revoked != tt.expectedRevoked {
+				t.Errorf("expected revoked %v, got %v", tt.expectedRevoked, revoked)
+			}
+		})
+	}
+}
@@ -0,0 +1,316 @@
+// Package twofactorcmd provides functionality for the optional second factor of users: a TOTP
+// secret (RFC 6238) shared with an authenticator app, and one-time recovery codes for when the
+// app is lost. Users with a second factor sign in in two steps: the password yields a
+// short-lived challenge, which a code of the second factor exchanges for a session.
+package twofactorcmd
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	itimeservice "github.com/beka-birhanu/finance-go/application/common/interface/time_service"
+	itotp "github.com/beka-birhanu/finance-go/application/common/interface/totp"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultChallengeTTL is how long a sign in waits for its second step when no lifetime is configured.
+	DefaultChallengeTTL = 5 * time.Minute
+
+	// maxChallengeAttempts is the number of codes that can be given for one challenge, so that
+	// codes cannot be guessed; the user signs in again after that.
+	maxChallengeAttempts = 5
+
+	// recoveryCodeCount is the number of recovery codes generated at once.
+	recoveryCodeCount = 10
+
+	// recoveryCodeBytes is the number of random bytes of a recovery code, which encode to 16
+	// base32 characters.
+	recoveryCodeBytes = 10
+
+	// challengeTokenBytes is the number of random bytes of a challenge token.
+	challengeTokenBytes = 32
+)
+
+// recoveryEncoding is the encoding of recovery codes, which users may have to type.
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Enrollment is a second factor enrolled for a user, to be added to their authenticator app.
+type Enrollment struct {
+	Secret string // The TOTP secret, for apps that cannot scan the URI
+	URI    string // The otpauth URI of the secret, usually shown as a QR code
+}
+
+// Service manages the second factors of users and the second step of their sign ins.
+type Service struct {
+	factors      irepository.ITwoFactorRepository
+	challenges   irepository.ITwoFactorChallengeRepository
+	totp         itotp.IService
+	timeSvc      itimeservice.IService
+	issuer       string
+	challengeTTL time.Duration
+}
+
+// Config holds dependencies required for creating a Service.
+type Config struct {
+	Repository   irepository.ITwoFactorRepository          // Repository for second factors and recovery codes
+	Challenges   irepository.ITwoFactorChallengeRepository // Repository for pending second steps of sign ins
+	TOTP         itotp.IService                            // Service for TOTP secrets and codes
+	TimeService  itimeservice.IService                     // Service for time-related operations
+	Issuer       string                                    // Name authenticator apps list the secret under
+	ChallengeTTL time.Duration                             // How long a sign in waits for its second step; defaults to 5 minutes
+}
+
+// NewService creates a new Service with the specified configuration.
+func NewService(config Config) *Service {
+	challengeTTL := config.ChallengeTTL
+	if challengeTTL <= 0 {
+		challengeTTL = DefaultChallengeTTL
+	}
+
+	return &Service{
+		factors:      config.Repository,
+		challenges:   config.Challenges,
+		totp:         config.TOTP,
+		timeSvc:      config.TimeService,
+		issuer:       config.Issuer,
+		challengeTTL: challengeTTL,
+	}
+}
+
+// Enroll enrolls a new, unconfirmed second factor for the user, replacing an earlier
+// unconfirmed one. It is not required at sign in until it is confirmed.
+//
+// Returns:
+//   - *Enrollment: The secret and its otpauth URI for the account of the user.
+//   - error: A conflict error if the user already has a confirmed second factor.
+func (s *Service) Enroll(userId uuid.UUID, accountName string) (*Enrollment, error) {
+	factor, err := s.factors.ByUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	if factor != nil && factor.ConfirmedAt != nil {
+		return nil, errdmn.NewConflict("two-factor authentication is already enabled")
+	}
+
+	secret, err := s.totp.GenerateSecret()
+	if err != nil {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("error generating two-factor secret: %v", err))
+	}
+	err = s.factors.Save(&irepository.TwoFactor{
+		UserID:    userId,
+		Secret:    secret,
+		CreatedAt: s.timeSvc.NowUTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Enrollment{Secret: secret, URI: s.totp.URI(secret, s.issuer, accountName)}, nil
+}
+
+// Confirm confirms the enrolled second factor of the user with a code of it, proving the
+// authenticator app holds the secret, and returns the first recovery codes. From then on, the
+// second factor is required at sign in.
+//
+// Returns:
+//   - []string: The recovery codes, which are only ever shown here.
+//   - error: A not found error if nothing is enrolled, a conflict error if the second factor is
+//     already confirmed, or a validation error if the code is not valid.
+func (s *Service) Confirm(userId uuid.UUID, code string) ([]string, error) {
+	factor, err := s.factors.ByUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	if factor == nil {
+		return nil, errdmn.NewNotFound("no two-factor enrollment to confirm")
+	}
+	if factor.ConfirmedAt != nil {
+		return nil, errdmn.NewConflict("two-factor authentication is already enabled")
+	}
+
+	now := s.timeSvc.NowUTC()
+	step, ok := s.totp.Verify(factor.Secret, code, now)
+	if !ok {
+		return nil, errdmn.NewValidation("invalid two-factor code")
+	}
+	factor.ConfirmedAt = &now
+	factor.LastUsedStep = step
+	if err := s.factors.Save(factor); err != nil {
+		return nil, err
+	}
+	return s.replaceRecoveryCodes(userId)
+}
+
+// Disable removes the second factor of the user, who must give a code of it or a recovery code.
+//
+// Returns:
+//   - error: A not found error if the user has no confirmed second factor, or a validation error
+//     if the code is not valid.
+func (s *Service) Disable(userId uuid.UUID, code string) error {
+	if _, err := s.checkEnabled(userId, code); err != nil {
+		return err
+	}
+	return s.factors.Delete(userId)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user, who must give a code of
+// their second factor or a recovery code, and returns the new ones.
+//
+// Returns:
+//   - []string: The new recovery codes; the earlier ones are no longer accepted.
+//   - error: A not found error if the user has no confirmed second factor, or a validation error
+//     if the code is not valid.
+func (s *Service) RegenerateRecoveryCodes(userId uuid.UUID, code string) ([]string, error) {
+	if _, err := s.checkEnabled(userId, code); err != nil {
+		return nil, err
+	}
+	return s.replaceRecoveryCodes(userId)
+}
+
+// Challenge starts the second step of a sign in of the user, if they have a confirmed second
+// factor, and returns the token identifying it.
+func (s *Service) Challenge(userId uuid.UUID) (string, bool, error) {
+	factor, err := s.factors.ByUser(userId)
+	if err != nil || factor == nil || factor.ConfirmedAt == nil {
+		return "", false, err
+	}
+
+	token, err := randomToken(challengeTokenBytes)
+	if err != nil {
+		return "", false, err
+	}
+	err = s.challenges.SaveChallenge(&irepository.TwoFactorChallenge{
+		ID:        uuid.New(),
+		UserID:    userId,
+		TokenHash: hashSecret(token),
+		ExpiresAt: s.timeSvc.NowUTC().Add(s.challengeTTL),
+	})
+	if err != nil {
+		return "", false, err
+	}
+	return token, true, nil
+}
+
+// Verify completes the second step of a sign in with a code of the second factor of the user,
+// or one of their recovery codes. A challenge is good for a limited number of attempts and is
+// used up once it succeeds.
+//
+// Returns:
+//   - uuid.UUID: The ID of the user signing in.
+//   - error: An authentication error if the challenge is unknown, expired or used up, or the
+//     code is not valid.
+func (s *Service) Verify(challengeToken string, code string) (uuid.UUID, error) {
+	now := s.timeSvc.NowUTC()
+	challenge, err := s.challenges.AttemptChallenge(hashSecret(challengeToken), now, maxChallengeAttempts)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if challenge == nil {
+		return uuid.Nil, apperror.InvalidCredential("invalid two-factor challenge")
+	}
+
+	factor, err := s.factors.ByUser(challenge.UserID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if factor == nil || factor.ConfirmedAt == nil {
+		return uuid.Nil, apperror.InvalidCredential("two-factor authentication is not enabled")
+	}
+
+	ok, err := s.checkCode(factor, code, now)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if !ok {
+		return uuid.Nil, apperror.InvalidCredential("invalid two-factor code")
+	}
+
+	if err := s.challenges.DeleteChallenge(challenge.ID); err != nil {
+		return uuid.Nil, err
+	}
+	return challenge.UserID, nil
+}
+
+// checkEnabled returns the confirmed second factor of the user after checking the code given
+// for it.
+func (s *Service) checkEnabled(userId uuid.UUID, code string) (*irepository.TwoFactor, error) {
+	factor, err := s.factors.ByUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	if factor == nil || factor.ConfirmedAt == nil {
+		return nil, errdmn.NewNotFound("two-factor authentication is not enabled")
+	}
+
+	ok, err := s.checkCode(factor, code, s.timeSvc.NowUTC())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errdmn.NewValidation("invalid two-factor code")
+	}
+	return factor, nil
+}
+
+// checkCode reports whether the code is a valid TOTP code of the second factor that was not
+// used before, or an unused recovery code of the user, and uses it up.
+func (s *Service) checkCode(factor *irepository.TwoFactor, code string, now time.Time) (bool, error) {
+	code = strings.TrimSpace(code)
+	if step, ok := s.totp.Verify(factor.Secret, code, now); ok {
+		return s.factors.UseStep(factor.UserID, step)
+	}
+	return s.factors.UseRecoveryCode(factor.UserID, hashSecret(normalizeRecoveryCode(code)), now)
+}
+
+// replaceRecoveryCodes generates new recovery codes for the user, stores their hashes in place
+// of the earlier ones, and returns them.
+func (s *Service) replaceRecoveryCodes(userId uuid.UUID) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, errdmn.NewUnexpected(fmt.Sprintf("error generating recovery code: %v", err))
+		}
+		code := strings.ToLower(recoveryEncoding.EncodeToString(raw))
+		codes = append(codes, code[:8]+"-"+code[8:])
+		hashes = append(hashes, hashSecret(code))
+	}
+
+	if err := s.factors.ReplaceRecoveryCodes(userId, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode returns the form recovery codes are hashed in, ignoring the dash and
+// the case, which do not matter to users typing the code.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(code, "-", ""))
+}
+
+// randomToken returns a new random token of the given number of bytes.
+func randomToken(size int) (string, error) {
+	raw := make([]byte, size)
+	if _, err := rand.Read(raw); err != nil {
+		return "", errdmn.NewUnexpected(fmt.Sprintf("error generating challenge token: %v", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// hashSecret returns the hash challenge tokens and recovery codes are stored and looked up by.
+// Both are random, so a fast unsalted hash suffices.
+func hashSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,313 @@
+package twofactorcmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	itotp "github.com/beka-birhanu/finance-go/application/common/interface/totp"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	ierr "github.com/beka-birhanu/finance-go/domain/common/error"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	"github.com/google/uuid"
+)
+
+const validCode = "123456"
+
+// MockTOTPService accepts validCode as the code of the current 30 second step of any secret.
+type MockTOTPService struct{}
+
+func (m *MockTOTPService) GenerateSecret() (string, error) {
+	return "SECRET", nil
+}
+
+func (m *MockTOTPService) URI(secret string, issuer string, accountName string) string {
+	return "otpauth://totp/" + issuer + ":" + accountName + "?secret=" + secret
+}
+
+func (m *MockTOTPService) Verify(secret string, code string, at time.Time) (int64, bool) {
+	return at.Unix() / 30, code == validCode
+}
+
+var _ itotp.IService = &MockTOTPService{}
+
+type MockTwoFactorRepository struct {
+	factors       map[uuid.UUID]*irepository.TwoFactor
+	recoveryCodes map[uuid.UUID]map[string]bool
+	challenges    map[string]*irepository.TwoFactorChallenge
+}
+
+func newMockRepository() *MockTwoFactorRepository {
+	return &MockTwoFactorRepository{
+		factors:       make(map[uuid.UUID]*irepository.TwoFactor),
+		recoveryCodes: make(map[uuid.UUID]map[string]bool),
+		challenges:    make(map[string]*irepository.TwoFactorChallenge),
+	}
+}
+
+func (m *MockTwoFactorRepository) ByUser(userId uuid.UUID) (*irepository.TwoFactor, error) {
+	factor, ok := m.factors[userId]
+	if !ok {
+		return nil, nil
+	}
+	copied := *factor
+	return &copied, nil
+}
+
+func (m *MockTwoFactorRepository) Save(factor *irepository.TwoFactor) error {
+	copied := *factor
+	m.factors[factor.UserID] = &copied
+	return nil
+}
+
+func (m *MockTwoFactorRepository) Delete(userId uuid.UUID) error {
+	delete(m.factors, userId)
+	delete(m.recoveryCodes, userId)
+	return nil
+}
+
+func (m *MockTwoFactorRepository) UseStep(userId uuid.UUID, step int64) (bool, error) {
+	factor := m.factors[userId]
+	if factor.LastUsedStep >= step {
+		return false, nil
+	}
+	factor.LastUsedStep = step
+	return true, nil
+}
+
+func (m *MockTwoFactorRepository) ReplaceRecoveryCodes(userId uuid.UUID, codeHashes []string) error {
+	m.recoveryCodes[userId] = make(map[string]bool)
+	for _, codeHash := range codeHashes {
+		m.recoveryCodes[userId][codeHash] = false
+	}
+	return nil
+}
+
+func (m *MockTwoFactorRepository) UseRecoveryCode(userId uuid.UUID, codeHash string, at time.Time) (bool, error) {
+	used, ok := m.recoveryCodes[userId][codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	m.recoveryCodes[userId][codeHash] = true
+	return true, nil
+}
+
+func (m *MockTwoFactorRepository) SaveChallenge(challenge *irepository.TwoFactorChallenge) error {
+	m.challenges[challenge.TokenHash] = challenge
+	return nil
+}
+
+func (m *MockTwoFactorRepository) AttemptChallenge(tokenHash string, at time.Time, maxAttempts int) (*irepository.TwoFactorChallenge, error) {
+	challenge, ok := m.challenges[tokenHash]
+	if !ok || !at.Before(challenge.ExpiresAt) || challenge.Attempts >= maxAttempts {
+		return nil, nil
+	}
+	challenge.Attempts++
+	return challenge, nil
+}
+
+func (m *MockTwoFactorRepository) DeleteChallenge(id uuid.UUID) error {
+	for tokenHash, challenge := range m.challenges {
+		if challenge.ID == id {
+			delete(m.challenges, tokenHash)
+		}
+	}
+	return nil
+}
+
+var (
+	_ irepository.ITwoFactorRepository          = &MockTwoFactorRepository{}
+	_ irepository.ITwoFactorChallengeRepository = &MockTwoFactorRepository{}
+)
+
+type MockTimeService struct {
+	now time.Time
+}
+
+func (m *MockTimeService) NowUTC() time.Time {
+	return m.now
+}
+
+// newService returns a Service storing second factors and challenges in memory.
+func newService(timeSvc *MockTimeService) *Service {
+	repository := newMockRepository()
+	return NewService(Config{
+		Repository:  repository,
+		Challenges:  repository,
+		TOTP:        &MockTOTPService{},
+		TimeService: timeSvc,
+		Issuer:      "Finance Go",
+	})
+}
+
+// newEnabledService returns a Service and the ID and recovery codes of a user who enabled
+// their second factor.
+func newEnabledService(t *testing.T, timeSvc *MockTimeService) (*Service, uuid.UUID, []string) {
+	service := newService(timeSvc)
+
+	userId := uuid.New()
+	if _, err := service.Enroll(userId, "validUser"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	codes, err := service.Confirm(userId, validCode)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Codes of the step of the confirmation are used up.
+	timeSvc.now = timeSvc.now.Add(30 * time.Second)
+	return service, userId, codes
+}
+
+func assertErrorType(t *testing.T, err error, expected string) {
+	t.Helper()
+	typedErr, ok := err.(ierr.IErr)
+	if !ok || typedErr.Type() != expected {
+		t.Fatalf("expected %s error, got %v", expected, err)
+	}
+}
+
+func TestService_Enrollment(t *testing.T) {
+	timeSvc := &MockTimeService{now: time.Now().UTC()}
+	service := newService(timeSvc)
+	userId := uuid.New()
+
+	if _, enabled, err := service.Challenge(userId); err != nil || enabled {
+		t.Fatalf("expected no challenge without a second factor, got %v, %v", enabled, err)
+	}
+
+	enrollment, err := service.Enroll(userId, "validUser")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if enrollment.Secret != "SECRET" || !strings.Contains(enrollment.URI, "Finance Go:validUser") {
+		t.Errorf("unexpected enrollment %+v", enrollment)
+	}
+	if _, enabled, _ := service.Challenge(userId); enabled {
+		t.Error("expected an unconfirmed second factor not to be required")
+	}
+
+	_, err = service.Confirm(userId, "000000")
+	assertErrorType(t, err, errdmn.Validation)
+
+	codes, err := service.Confirm(userId, validCode)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Errorf("expected %d recovery codes, got %d", recoveryCodeCount, len(codes))
+	}
+
+	_, err = service.Enroll(userId, "validUser")
+	assertErrorType(t, err, errdmn.Conflict)
+	_, err = service.Confirm(userId, validCode)
+	assertErrorType(t, err, errdmn.Conflict)
+}
+
+func TestService_Verify(t *testing.T) {
+	tests := []struct {
+		name          string
+		code          func(recoveryCodes []string) string
+		wrongAttempts int
+		elapsed       time.Duration
+		expectValid   bool
+	}{
+		{
+			name:        "valid code",
+			code:        func([]string) string { return validCode },
+			expectValid: true,
+		},
+		{
+			name:        "recovery code",
+			code:        func(codes []string) string { return codes[0] },
+			expectValid: true,
+		},
+		{
+			name:        "recovery code typed in upper case without dash",
+			code:        func(codes []string) string { return strings.ToUpper(strings.ReplaceAll(codes[1], "-", "")) },
+			expectValid: true,
+		},
+		{
+			name: "invalid code",
+			code: func([]string) string { return "000000" },
+		},
+		{
+			name:          "valid code after the attempts are used up",
+			code:          func([]string) string { return validCode },
+			wrongAttempts: maxChallengeAttempts,
+		},
+		{
+			name:          "valid code after some wrong attempts",
+			code:          func([]string) string { return validCode },
+			wrongAttempts: maxChallengeAttempts - 1,
+			expectValid:   true,
+		},
+		{
+			name:    "expired challenge",
+			code:    func([]string) string { return validCode },
+			elapsed: DefaultChallengeTTL,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeSvc := &MockTimeService{now: time.Now().UTC()}
+			service, userId, codes := newEnabledService(t, timeSvc)
+
+			challengeToken, enabled, err := service.Challenge(userId)
+			if err != nil || !enabled {
+				t.Fatalf("expected a challenge, got %v, %v", enabled, err)
+			}
+			for i := 0; i < tt.wrongAttempts; i++ {
+				if _, err := service.Verify(challengeToken, "000000"); err == nil {
+					t.Fatal("expected a wrong code to be rejected")
+				}
+			}
+
+			timeSvc.now = timeSvc.now.Add(tt.elapsed)
+			verified, err := service.Verify(challengeToken, tt.code(codes))
+			if !tt.expectValid {
+				assertErrorType(t, err, apperror.Authentication)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if verified != userId {
+				t.Errorf("expected user %v, got %v", userId, verified)
+			}
+
+			// Neither the challenge nor the code can be used again.
+			_, err = service.Verify(challengeToken, tt.code(codes))
+			assertErrorType(t, err, apperror.Authentication)
+			nextToken, _, _ := service.Challenge(userId)
+			_, err = service.Verify(nextToken, tt.code(codes))
+			assertErrorType(t, err, apperror.Authentication)
+		})
+	}
+}
+
+func TestService_DisableAndRegenerate(t *testing.T) {
+	timeSvc := &MockTimeService{now: time.Now().UTC()}
+	service, userId, codes := newEnabledService(t, timeSvc)
+
+	err := service.Disable(userId, "000000")
+	assertErrorType(t, err, errdmn.Validation)
+
+	regenerated, err := service.RegenerateRecoveryCodes(userId, codes[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := service.Disable(userId, codes[1]); err == nil {
+		t.Error("expected a replaced recovery code to be rejected")
+	}
+
+	if err := service.Disable(userId, regenerated[0]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, enabled, _ := service.Challenge(userId); enabled {
+		t.Error("expected the second factor not to be required once disabled")
+	}
+	err = service.Disable(userId, validCode)
+	assertErrorType(t, err, errdmn.NotFound)
+}
@@ -0,0 +1,32 @@
+package twofactorcmd
+
+import "github.com/google/uuid"
+
+// EnrollCommand represents a command to enroll a second factor for a user.
+type EnrollCommand struct {
+	UserID uuid.UUID // Identifier of the user enrolling a second factor
+}
+
+// ConfirmCommand represents a command to confirm the enrolled second factor of a user.
+type ConfirmCommand struct {
+	UserID uuid.UUID // Identifier of the user confirming their second factor
+	Code   string    // A code of the enrolled second factor
+}
+
+// DisableCommand represents a command to remove the second factor of a user.
+type DisableCommand struct {
+	UserID uuid.UUID // Identifier of the user removing their second factor
+	Code   string    // A code of the second factor, or a recovery code
+}
+
+// RegenerateCommand represents a command to replace the recovery codes of a user.
+type RegenerateCommand struct {
+	UserID uuid.UUID // Identifier of the user replacing their recovery codes
+	Code   string    // A code of the second factor, or a recovery code
+}
+
+// VerifyCommand represents a command to complete the second step of a sign in.
+type VerifyCommand struct {
+	ChallengeToken string // The challenge token returned by the first step
+	Code           string // A code of the second factor, or a recovery code
+}
@@ -0,0 +1,84 @@
+package twofactorcmd
+
+import (
+	icmd "github.com/beka-birhanu/finance-go/application/common/cqrs/command"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+)
+
+// EnrollHandler processes enroll commands.
+type EnrollHandler struct {
+	userRepo irepository.IUserRepository
+	service  *Service
+}
+
+// Ensure EnrollHandler implements the icmd.IHandler interface for EnrollCommand type.
+var _ icmd.IHandler[*EnrollCommand, *Enrollment] = &EnrollHandler{}
+
+// NewEnrollHandler creates a new EnrollHandler enrolling second factors with the given Service,
+// under the username of the user read from the repository.
+func NewEnrollHandler(userRepository irepository.IUserRepository, service *Service) *EnrollHandler {
+	return &EnrollHandler{userRepo: userRepository, service: service}
+}
+
+// Handle processes an enroll command and returns the enrolled secret.
+func (h *EnrollHandler) Handle(cmd *EnrollCommand) (*Enrollment, error) {
+	user, err := h.userRepo.ById(cmd.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return h.service.Enroll(user.ID(), user.Username())
+}
+
+// ConfirmHandler processes confirm commands.
+type ConfirmHandler struct {
+	service *Service
+}
+
+// Ensure ConfirmHandler implements the icmd.IHandler interface for ConfirmCommand type.
+var _ icmd.IHandler[*ConfirmCommand, []string] = &ConfirmHandler{}
+
+// NewConfirmHandler creates a new ConfirmHandler confirming second factors with the given Service.
+func NewConfirmHandler(service *Service) *ConfirmHandler {
+	return &ConfirmHandler{service: service}
+}
+
+// Handle processes a confirm command and returns the first recovery codes of the user.
+func (h *ConfirmHandler) Handle(cmd *ConfirmCommand) ([]string, error) {
+	return h.service.Confirm(cmd.UserID, cmd.Code)
+}
+
+// DisableHandler processes disable commands.
+type DisableHandler struct {
+	service *Service
+}
+
+// Ensure DisableHandler implements the icmd.IHandler interface for DisableCommand type.
+var _ icmd.IHandler[*DisableCommand, struct{}] = &DisableHandler{}
+
+// NewDisableHandler creates a new DisableHandler removing second factors with the given Service.
+func NewDisableHandler(service *Service) *DisableHandler {
+	return &DisableHandler{service: service}
+}
+
+// Handle processes a disable command.
+func (h *DisableHandler) Handle(cmd *DisableCommand) (struct{}, error) {
+	return struct{}{}, h.service.Disable(cmd.UserID, cmd.Code)
+}
+
+// RegenerateHandler processes regenerate commands.
+type RegenerateHandler struct {
+	service *Service
+}
+
+// Ensure RegenerateHandler implements the icmd.IHandler interface for RegenerateCommand type.
+var _ icmd.IHandler[*RegenerateCommand, []string] = &RegenerateHandler{}
+
+// NewRegenerateHandler creates a new RegenerateHandler replacing recovery codes with the given Service.
+func NewRegenerateHandler(service *Service) *RegenerateHandler {
+	return &RegenerateHandler{service: service}
+}
+
+// Handle processes a regenerate command and returns the new recovery codes of the user.
+func (h *RegenerateHandler) Handle(cmd *RegenerateCommand) ([]string, error) {
+	return h.service.RegenerateRecoveryCodes(cmd.UserID, cmd.Code)
+}
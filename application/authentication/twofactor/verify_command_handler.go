@@ -0,0 +1,77 @@
+package twofactorcmd
+
+import (
+	"fmt"
+
+	auth "github.com/beka-birhanu/finance-go/application/authentication/common"
+	icmd "github.com/beka-birhanu/finance-go/application/common/cqrs/command"
+	ijwt "github.com/beka-birhanu/finance-go/application/common/interface/jwt"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+)
+
+// VerifyHandler processes verify commands by completing the sign in of the user and issuing
+// the tokens of their session.
+type VerifyHandler struct {
+	userRepo irepository.IUserRepository
+	jwtSvc   ijwt.IService
+	refresh  auth.IRefreshTokenIssuer
+	service  *Service
+}
+
+// Ensure VerifyHandler implements the icmd.IHandler interface for VerifyCommand type and auth.Result type.
+var _ icmd.IHandler[*VerifyCommand, *auth.Result] = &VerifyHandler{}
+
+// VerifyConfig holds the dependencies needed to create a new VerifyHandler. No refresh token
+// is issued when RefreshTokens is nil.
+type VerifyConfig struct {
+	UserRepository irepository.IUserRepository
+	JwtService     ijwt.IService
+	RefreshTokens  auth.IRefreshTokenIssuer
+	Service        *Service
+}
+
+// NewVerifyHandler creates a new VerifyHandler with the provided configuration.
+func NewVerifyHandler(config VerifyConfig) *VerifyHandler {
+	return &VerifyHandler{
+		userRepo: config.UserRepository,
+		jwtSvc:   config.JwtService,
+		refresh:  config.RefreshTokens,
+		service:  config.Service,
+	}
+}
+
+// Handle processes a verify command and returns an authentication result if successful.
+// Returns:
+// - *auth.Result: The user with their access token and refresh token.
+// - error: An authentication error if the challenge or the code is not accepted, a forbidden
+// error if the user is disabled, or an unexpected error if the user cannot be retrieved or the
+// tokens cannot be issued.
+func (h *VerifyHandler) Handle(cmd *VerifyCommand) (*auth.Result, error) {
+	userId, err := h.service.Verify(cmd.ChallengeToken, cmd.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := h.userRepo.ById(userId)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled() {
+		return nil, apperror.AccountDisabled()
+	}
+
+	token, err := h.jwtSvc.Generate(user)
+	if err != nil {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("failed to generate JWT for user, %v", err))
+	}
+
+	result := auth.NewResult(user.ID(), user.Username(), token)
+	if h.refresh != nil {
+		if result.RefreshToken, err = h.refresh.Issue(user.ID()); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,31 @@
+/*
+Package iexporter provides interfaces for writing exported expenses in formats that
+other tools read, such as accounting journals.
+*/
+package iexporter
+
+import (
+	"io"
+
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+)
+
+// IWriter writes exported expenses one at a time.
+type IWriter interface {
+	// Write writes one expense.
+	Write(expense *expensemodel.Expense) error
+
+	// Flush writes any buffered output to the underlying writer.
+	Flush() error
+
+	// Close writes whatever follows the last expense and flushes the output. It does not
+	// close the underlying writer.
+	Close() error
+}
+
+// IJournal creates writers of one journal format.
+type IJournal interface {
+	// NewWriter creates a writer of the journal to w, for amounts in the given currency or
+	// the format's default when empty. It returns a validation error for an invalid currency.
+	NewWriter(w io.Writer, currency string) (IWriter, error)
+}
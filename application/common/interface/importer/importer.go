@@ -0,0 +1,60 @@
+/*
+Package iimporter provides interfaces for parsing imported files into expense records.
+
+It includes the `IParser` interface, implemented once per file format, and the options
+and records shared by all formats.
+*/
+package iimporter
+
+import (
+	"io"
+	"time"
+
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+)
+
+// ColumnMapping names the columns of a tabular file that hold each expense field. With a
+// header row the names are matched against the header; without one they are 1-based
+// column numbers. Date, Description and Amount are required.
+type ColumnMapping struct {
+	Date        string
+	Description string
+	Amount      string
+	Category    string
+	Account     string
+}
+
+// Options configures how a file is parsed. Formats ignore the options that do not apply
+// to them.
+type Options struct {
+	Mapping          ColumnMapping // Columns holding each field
+	DateFormat       string        // Date pattern such as "DD.MM.YYYY"; defaults to "YYYY-MM-DD"
+	DecimalSeparator string        // "." or ","; defaults to "."
+	Delimiter        string        // Field delimiter; defaults to "," or ";" with a comma decimal separator
+	HasHeader        bool          // Whether the first row holds column names
+}
+
+// Record is an expense read from a file, not yet validated.
+type Record struct {
+	Date        time.Time
+	Description string
+	Amount      float32
+	Kind        expensemodel.Kind // Empty means expensemodel.KindExpense
+	Category    string
+	Account     string
+	ExternalID  string // Identifies the transaction across imports, e.g. a bank's FITID; empty when unknown
+}
+
+// Row is a parsed row of a file: either a record or the reason it could not be read.
+type Row struct {
+	Line   int    // Line of the row in the file
+	Record Record // Record read from the row; only set when Err is nil
+	Err    error  // Why the row could not be read
+}
+
+// IParser parses the files of one format.
+type IParser interface {
+	// Parse reads every row of the file. Rows that cannot be read are returned with an
+	// error, while an error is returned only when the file as a whole cannot be parsed.
+	Parse(r io.Reader, options Options) ([]Row, error)
+}
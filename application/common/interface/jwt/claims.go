@@ -0,0 +1,40 @@
+package ijwt
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Claims are the validated claims of an access token.
+type Claims struct {
+	Subject   uuid.UUID // ID of the user the token was issued to (sub)
+	Username  string    // Username of the user at the time the token was issued
+	Roles     []string  // Roles of the user
+	Personal  bool      // Whether the token is a personal access token, limited to Scopes
+	Scopes    []string  // Scopes a personal access token is limited to
+	ID        string    // Unique ID of the token (jti), by which it can be revoked
+	Issuer    string    // Issuer of the token (iss)
+	Audience  []string  // Recipients the token is intended for (aud)
+	IssuedAt  time.Time // When the token was issued (iat)
+	NotBefore time.Time // When the token becomes valid (nbf)
+	ExpiresAt time.Time // When the token expires (exp)
+}
+
+// HasScope reports whether the token is allowed the given scope: restricted tokens are
+// allowed their scopes only, so one without scopes is allowed none, and other tokens are
+// allowed every scope.
+func (c *Claims) HasScope(scope string) bool {
+	return !c.Restricted() || slices.Contains(c.Scopes, scope)
+}
+
+// Restricted reports whether the token is limited to its scopes, as personal access tokens are.
+func (c *Claims) Restricted() bool {
+	return c.Personal
+}
+
+// HasRole reports whether the user the token was issued to has the given role.
+func (c *Claims) HasRole(role string) bool {
+	return slices.Contains(c.Roles, role)
+}
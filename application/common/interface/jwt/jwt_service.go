@@ -7,7 +7,6 @@ package ijwt
 
 import (
 	"github.com/beka-birhanu/finance-go/domain/model/user"
-	"github.com/dgrijalva/jwt-go"
 )
 
 // IService defines methods for generating and decoding JSON Web Tokens (JWT).
@@ -15,12 +14,12 @@ import (
 // Methods:
 // - Generate(user *usermodel.User) (string, error): Generates a JWT for the
 // given user and returns the token or an error.
-// - Decode(token string) (jwt.MapClaims, error): Decodes the provided JWT
-// and returns the claims or an error.
+// - Decode(token string) (*Claims, error): Decodes and validates the provided
+// JWT and returns its claims or an error.
 type IService interface {
 	// Generate creates a JWT for the specified user.
 	Generate(user *usermodel.User) (string, error)
 
-	// Decode parses the provided JWT and returns the claims or an error.
-	Decode(token string) (jwt.MapClaims, error)
+	// Decode parses and validates the provided JWT and returns its claims or an error.
+	Decode(token string) (*Claims, error)
 }
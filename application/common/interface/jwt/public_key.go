@@ -0,0 +1,20 @@
+package ijwt
+
+// PublicKey is a public key tokens are verified with, in the JSON Web Key format (RFC 7517).
+type PublicKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	Modulus   string `json:"n,omitempty"`   // RSA modulus
+	Exponent  string `json:"e,omitempty"`   // RSA public exponent
+	Curve     string `json:"crv,omitempty"` // Curve of OKP keys
+	X         string `json:"x,omitempty"`   // Public key of OKP keys
+}
+
+// IKeyPublisher publishes the public keys tokens are verified with, so that other services
+// can verify tokens without sharing a secret.
+type IKeyPublisher interface {
+	// PublicKeys returns the public keys tokens are verified with.
+	PublicKeys() []PublicKey
+}
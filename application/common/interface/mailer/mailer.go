@@ -0,0 +1,25 @@
+/*
+Package imailer provides interfaces for delivering messages to users outside of the API,
+such as password reset tokens.
+*/
+package imailer
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PasswordReset is the message delivering a password reset token to a user.
+type PasswordReset struct {
+	UserID    uuid.UUID // ID of the user the token was issued to
+	Username  string    // Username of the user the token was issued to
+	Token     string    // The reset token, which only the recipient may learn
+	ExpiresAt time.Time // When the token stops being accepted
+}
+
+// IMailer delivers messages to users.
+type IMailer interface {
+	// SendPasswordReset delivers a password reset token to the user it was issued to.
+	SendPasswordReset(message PasswordReset) error
+}
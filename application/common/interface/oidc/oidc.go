@@ -0,0 +1,32 @@
+/*
+Package ioidc provides an interface for OpenID Connect providers, the external identity
+providers users sign in with through the authorization code flow with PKCE (RFC 7636).
+*/
+package ioidc
+
+import "errors"
+
+// ErrRejected is wrapped by the errors of a provider rejecting a sign in, or of its response
+// failing validation, as opposed to the provider being unreachable.
+var ErrRejected = errors.New("sign in rejected by the identity provider")
+
+// Identity is the identity of a user, as asserted by the validated ID token of a provider.
+type Identity struct {
+	Issuer            string // Issuer of the ID token, identifying the provider
+	Subject           string // Identifier of the user at the provider, never reassigned
+	Email             string // Email address of the user; may be empty
+	PreferredUsername string // Username the user goes by at the provider; may be empty
+}
+
+// IProvider is an OpenID Connect provider.
+type IProvider interface {
+	// AuthCodeURL returns the URL of the authorization endpoint of the provider that the user
+	// is sent to, asking for an authorization code bound to the state, the nonce and the S256
+	// code challenge.
+	AuthCodeURL(state, nonce, codeChallenge string) (string, error)
+
+	// Exchange redeems the authorization code at the token endpoint of the provider with the
+	// code verifier of its challenge, validates the ID token it returns, which must carry the
+	// nonce, and returns the identity of the user.
+	Exchange(code, codeVerifier, nonce string) (*Identity, error)
+}
@@ -0,0 +1,44 @@
+package irepository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccessToken is a stored personal access token, with which scripts and integrations act on
+// behalf of a user within the scopes of the token.
+type AccessToken struct {
+	ID         uuid.UUID  // ID of the token
+	UserID     uuid.UUID  // ID of the user the token acts on behalf of
+	Name       string     // Name the user gave the token
+	TokenHash  string     // Hash of the token; the token itself is never stored
+	Scopes     []string   // Scopes the token is limited to
+	CreatedAt  time.Time  // When the token was created
+	ExpiresAt  time.Time  // When the token stops being accepted
+	LastUsedAt *time.Time // When the token was last used; nil if it never was
+	RevokedAt  *time.Time // When the token was revoked; nil while it is valid
+}
+
+// IAccessTokenRepository defines methods for storing personal access tokens.
+type IAccessTokenRepository interface {
+	// Save inserts a new personal access token.
+	Save(token *AccessToken) error
+
+	// ByHash retrieves the personal access token with the given hash, or nil if there is none.
+	ByHash(tokenHash string) (*AccessToken, error)
+
+	// ByUser retrieves the unrevoked personal access tokens of the user, newest first.
+	ByUser(userId uuid.UUID) ([]*AccessToken, error)
+
+	// Revoke revokes the token of the user with the given ID at the given time, unless it
+	// was already revoked, and reports whether it did.
+	Revoke(userId uuid.UUID, id uuid.UUID, at time.Time) (bool, error)
+
+	// RevokeAll revokes every unrevoked token of the user at the given time.
+	RevokeAll(userId uuid.UUID, at time.Time) error
+
+	// Touch records that the token with the given ID was used at the given time, unless its
+	// last use was recorded after notBefore.
+	Touch(id uuid.UUID, at time.Time, notBefore time.Time) error
+}
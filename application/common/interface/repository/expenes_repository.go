@@ -2,41 +2,122 @@
 package irepository
 
 import (
+	"context"
 	"time"
 
 	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
 	"github.com/google/uuid"
 )
 
-// ListByTimeParams defines parameters for retrieving expenses by time.
-type ListByTimeParams struct {
-	UserID       uuid.UUID  // ID of the user
-	Limit        int        // Max number of expenses to return
-	LastSeenID   *uuid.UUID // Pagination: ID of the last seen expense
-	LastSeenDate *time.Time // Pagination: Time of the last seen expense
-	Ascending    bool       // Sort order: true for ascending
+// ExpenseFilter narrows down the expenses returned by a listing. Nil or empty
+// fields are ignored.
+type ExpenseFilter struct {
+	DateFrom    *time.Time // Inclusive lower bound on the expense date
+	DateTo      *time.Time // Inclusive upper bound on the expense date
+	MinAmount   *float64   // Inclusive lower bound on the amount
+	MaxAmount   *float64   // Inclusive upper bound on the amount
+	Description string     // Case-insensitive substring of the description
+	Category    string     // Exact category
+	Account     string     // Exact account
 }
 
-// ListByAmountParams defines parameters for retrieving expenses by amount.
-type ListByAmountParams struct {
-	UserID      uuid.UUID  // ID of the user
-	Limit       int        // Max number of expenses to return
-	LastSeenID  *uuid.UUID // Pagination: ID of the last seen expense
-	LastSeenAmt float64    // Pagination: Amount of the last seen expense
-	Ascending   bool       // Sort order: true for ascending
+// IsEmpty reports whether the filter has no conditions, matching every expense.
+func (f ExpenseFilter) IsEmpty() bool {
+	return f == ExpenseFilter{}
+}
+
+// ExpenseSelection selects the expenses targeted by a bulk operation. When both IDs and a
+// filter are given, only the listed expenses that also match the filter are selected.
+type ExpenseSelection struct {
+	IDs    []uuid.UUID   // IDs of the expenses to select
+	Filter ExpenseFilter // Filter the expenses must match
+}
+
+// IsEmpty reports whether the selection has neither IDs nor filter conditions.
+func (s ExpenseSelection) IsEmpty() bool {
+	return len(s.IDs) == 0 && s.Filter.IsEmpty()
+}
+
+// Fields expense listings can be sorted by.
+const (
+	SortByDate        = "date"
+	SortByAmount      = "amount"
+	SortByDescription = "description"
+	SortByCreatedAt   = "createdAt"
+	SortByUpdatedAt   = "updatedAt"
+)
+
+// SortKey is one key of the order of an expense listing.
+type SortKey struct {
+	Field     string // Field to sort by, one of the SortBy constants
+	Ascending bool   // Sort order: true for ascending
+}
+
+// ExpenseFreshness summarizes the expenses matching a filter by how many there are and when
+// the latest of them was updated, which changes whenever any of them changes.
+type ExpenseFreshness struct {
+	Count         int       // Number of matching expenses
+	LastUpdatedAt time.Time // Latest update time among them; zero when there are none
+}
+
+// ListParams defines parameters for retrieving a page of expenses.
+type ListParams struct {
+	UserID         uuid.UUID     // ID of the user
+	Limit          int           // Max number of expenses to return
+	Sort           []SortKey     // Sort keys, most significant first; ties are broken by ID
+	LastSeenID     *uuid.UUID    // Pagination: ID of the last seen expense
+	LastSeenValues []interface{} // Pagination: Sort key values of the last seen expense, one per sort key
+	Backward       bool          // Pagination: return the expenses before the last seen one
+	Filter         ExpenseFilter
 }
 
 // IExpenseRepository defines methods for accessing and managing expense data.
 type IExpenseRepository interface {
-	// Save inserts or updates an expense in the repository.
+	// Save inserts or updates an expense in the repository. Updates are optimistic: an
+	// expense changed since it was read is not overwritten, and errexpense.VersionConflict is
+	// returned instead. On success the version of the expense is incremented.
 	Save(expense *expensemodel.Expense) error
 
+	// SaveMany inserts the expenses in a single transaction: either all of them are saved or none.
+	SaveMany(expenses []*expensemodel.Expense) error
+
 	// ById retrieves an expense by its unique identifier and user ID.
 	ById(id uuid.UUID, userId uuid.UUID) (*expensemodel.Expense, error)
 
-	// ListByTime retrieves paginated expenses by user ID based on created time.
-	ListByTime(params ListByTimeParams) ([]*expensemodel.Expense, error)
+	// List retrieves paginated expenses by user ID using keyset pagination over the sort keys.
+	List(params ListParams) ([]*expensemodel.Expense, error)
+
+	// Count returns the number of expenses of a user that match the filter.
+	Count(userId uuid.UUID, filter ExpenseFilter) (int, error)
+
+	// Freshness returns the number of expenses of a user that match the filter together with
+	// the latest update time among them.
+	Freshness(userId uuid.UUID, filter ExpenseFilter) (*ExpenseFreshness, error)
+
+	// Select retrieves the expenses of a user matched by the selection, at most limit of them
+	// unless limit is zero.
+	Select(userId uuid.UUID, selection ExpenseSelection, limit int) ([]*expensemodel.Expense, error)
+
+	// UpdateSelected locks the expenses of a user matched by the selection, at most limit of
+	// them, passes them to apply and then updates them, all in a single transaction: either
+	// all of them are updated or none. An error returned by apply is returned unchanged.
+	UpdateSelected(userId uuid.UUID, selection ExpenseSelection, limit int, apply func(expenses []*expensemodel.Expense) error) error
+
+	// DeleteSelected locks the expenses of a user matched by the selection, at most limit of
+	// them, passes them to check and then deletes them, all in a single transaction, and
+	// returns how many were deleted. An error returned by check is returned unchanged.
+	DeleteSelected(userId uuid.UUID, selection ExpenseSelection, limit int, check func(expenses []*expensemodel.Expense) error) (int, error)
+
+	// Merge saves the category, account and external ID of the kept expense and deletes its
+	// duplicates with the given IDs in a single transaction: either both happen or neither.
+	Merge(kept *expensemodel.Expense, duplicateIds []uuid.UUID) error
+
+	// Stream calls each for every expense of the user that matches the filter, newest first,
+	// reading them from a database cursor so that they are never all held in memory. It
+	// stops at, and returns, the first error returned by each, or when ctx is cancelled.
+	Stream(ctx context.Context, userId uuid.UUID, filter ExpenseFilter, each func(expense *expensemodel.Expense) error) error
 
-	// ListByAmount retrieves paginated expenses by user ID based on amount.
-	ListByAmount(params ListByAmountParams) ([]*expensemodel.Expense, error)
+	// ExistingExternalIDs returns which of the given external IDs already belong to an
+	// expense of the user.
+	ExistingExternalIDs(userId uuid.UUID, externalIds []string) (map[string]bool, error)
 }
@@ -0,0 +1,27 @@
+package irepository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExternalIdentity links the identity of a user at an OpenID Connect provider to the user.
+type ExternalIdentity struct {
+	Issuer    string    // Issuer identifying the provider
+	Subject   string    // Identifier of the user at the provider
+	UserID    uuid.UUID // ID of the user the identity signs in as
+	CreatedAt time.Time // When the identity was linked
+}
+
+// IExternalIdentityRepository defines methods for storing the identities users sign in with
+// at OpenID Connect providers.
+type IExternalIdentityRepository interface {
+	// Link stores the identity, unless the subject is already linked at the issuer, and
+	// reports whether it did.
+	Link(identity *ExternalIdentity) (bool, error)
+
+	// ByIdentity retrieves the identity with the given subject at the issuer, or nil if
+	// there is none.
+	ByIdentity(issuer string, subject string) (*ExternalIdentity, error)
+}
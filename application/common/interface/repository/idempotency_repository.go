@@ -0,0 +1,31 @@
+package irepository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyRecord is the response to the first request a user made with an idempotency key.
+type IdempotencyRecord struct {
+	UserID      uuid.UUID // ID of the user who made the request
+	Key         string    // Idempotency key chosen by the client
+	RequestHash string    // Fingerprint of the request the key was first used for
+	Response    []byte    // Stored response; nil while the first request is in progress
+	CreatedAt   time.Time // When the key was first used
+	ExpiresAt   time.Time // When the key can be used for a new request
+}
+
+// IIdempotencyRepository defines methods for storing the responses to idempotent requests.
+type IIdempotencyRepository interface {
+	// Reserve saves the record unless the user already has a record for its key that is
+	// unexpired at the creation time of the record, which is then returned instead. An
+	// expired record is replaced; nil means the record was saved.
+	Reserve(record *IdempotencyRecord) (*IdempotencyRecord, error)
+
+	// Complete stores the response of the reserved record of the user and key.
+	Complete(userId uuid.UUID, key string, response []byte) error
+
+	// Release deletes the record of the user and key, so that the key can be used again.
+	Release(userId uuid.UUID, key string) error
+}
@@ -0,0 +1,23 @@
+package irepository
+
+import (
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+	importjobmodel "github.com/beka-birhanu/finance-go/domain/model/importjob"
+	"github.com/google/uuid"
+)
+
+// IImportJobRepository defines methods for accessing and managing import jobs.
+type IImportJobRepository interface {
+	// Save inserts or updates an import job.
+	Save(job *importjobmodel.ImportJob) error
+
+	// Commit inserts an import job together with the expenses it created, in a single
+	// transaction: either the job and all of its expenses are saved or nothing is.
+	Commit(job *importjobmodel.ImportJob, expenses []*expensemodel.Expense) error
+
+	// Undo saves an undone import job and deletes the expenses it created, in a single transaction.
+	Undo(job *importjobmodel.ImportJob) error
+
+	// ById retrieves an import job by its unique identifier and user ID.
+	ById(id uuid.UUID, userId uuid.UUID) (*importjobmodel.ImportJob, error)
+}
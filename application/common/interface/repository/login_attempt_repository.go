@@ -0,0 +1,27 @@
+package irepository
+
+import "time"
+
+// LoginAttempts is the record of the sign in attempts under a username that were not followed
+// by a successful one.
+type LoginAttempts struct {
+	Key           string    // Hash of the username; usernames that were tried are never stored
+	Failures      int       // Number of attempts counted since the count last started over
+	LastAttemptAt time.Time // When the last attempt was counted
+	BlockedUntil  time.Time // Until when no attempt under the username is accepted
+}
+
+// ILoginAttemptRepository defines methods for counting sign in attempts per username.
+type ILoginAttemptRepository interface {
+	// Attempt counts an attempt under the key at the given time and returns the record, unless
+	// attempts under the key are blocked at that time, in which case it returns the record
+	// unchanged and false. Attempts last counted at or before resetBefore are forgotten first.
+	// The check and the count are a single statement, so concurrent attempts are all counted.
+	Attempt(key string, at time.Time, resetBefore time.Time) (*LoginAttempts, bool, error)
+
+	// Block rejects attempts under the key until the given time.
+	Block(key string, until time.Time) error
+
+	// Reset forgets the attempts under the key.
+	Reset(key string) error
+}
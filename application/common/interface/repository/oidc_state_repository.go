@@ -0,0 +1,26 @@
+package irepository
+
+import "time"
+
+// OIDCState is a pending sign in through an OpenID Connect provider, kept from sending the
+// user to the provider until they come back with an authorization code.
+type OIDCState struct {
+	StateHash    string    // Hash of the state parameter; the state itself is never stored
+	Provider     string    // Name of the provider the user was sent to
+	Nonce        string    // Nonce the ID token must carry
+	CodeVerifier string    // PKCE code verifier of the code challenge sent to the provider
+	CreatedAt    time.Time // When the sign in started
+	ExpiresAt    time.Time // When the sign in can no longer be completed
+}
+
+// IOIDCStateRepository defines methods for storing pending sign ins through OpenID Connect
+// providers.
+type IOIDCStateRepository interface {
+	// Save stores a pending sign in, and removes the ones that expired.
+	Save(state *OIDCState) error
+
+	// Consume removes the pending sign in with the given state hash and returns it, or nil
+	// if there is none or it expired before the given time, so that each can be completed
+	// only once.
+	Consume(stateHash string, at time.Time) (*OIDCState, error)
+}
@@ -0,0 +1,32 @@
+package irepository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PasswordResetToken is a stored password reset token, which lets its holder set a new
+// password for the user it was issued to, once, before it expires.
+type PasswordResetToken struct {
+	ID        uuid.UUID  // ID of the token
+	UserID    uuid.UUID  // ID of the user the token was issued to
+	TokenHash string     // Hash of the token; the token itself is never stored
+	CreatedAt time.Time  // When the token was issued
+	ExpiresAt time.Time  // When the token stops being accepted
+	UsedAt    *time.Time // When the token was used or discarded; nil while it is unused
+}
+
+// IPasswordResetTokenRepository defines methods for storing password reset tokens.
+type IPasswordResetTokenRepository interface {
+	// Save inserts a new password reset token.
+	Save(token *PasswordResetToken) error
+
+	// Consume marks the unused, unexpired token with the given hash as used at the given
+	// time and returns it, or returns nil if there is no such token. The check and the update
+	// are atomic, so a token is consumed at most once.
+	Consume(tokenHash string, at time.Time) (*PasswordResetToken, error)
+
+	// DiscardUser marks every unused token of the user as used at the given time.
+	DiscardUser(userId uuid.UUID, at time.Time) error
+}
@@ -0,0 +1,39 @@
+package irepository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is a stored refresh token. The tokens issued by rotating one another, starting
+// from the token issued at login, form a family.
+type RefreshToken struct {
+	ID        uuid.UUID  // ID of the token
+	UserID    uuid.UUID  // ID of the user the token was issued to
+	FamilyID  uuid.UUID  // ID of the family of the token
+	TokenHash string     // Hash of the token; the token itself is never stored
+	CreatedAt time.Time  // When the token was issued
+	ExpiresAt time.Time  // When the token stops being accepted
+	RotatedAt *time.Time // When the token was exchanged for its successor; nil while it is current
+	RevokedAt *time.Time // When the token was revoked; nil while it is valid
+}
+
+// IRefreshTokenRepository defines methods for storing refresh tokens.
+type IRefreshTokenRepository interface {
+	// Save inserts a new refresh token.
+	Save(token *RefreshToken) error
+
+	// ByHash retrieves the refresh token with the given hash, or nil if there is none.
+	ByHash(tokenHash string) (*RefreshToken, error)
+
+	// Rotate marks the token with the given ID as rotated at the given time, unless it was
+	// already rotated or revoked, and reports whether it did.
+	Rotate(id uuid.UUID, at time.Time) (bool, error)
+
+	// RevokeFamily revokes every unrevoked token of the family at the given time.
+	RevokeFamily(familyId uuid.UUID, at time.Time) error
+
+	// RevokeUser revokes every unrevoked token of the user at the given time.
+	RevokeUser(userId uuid.UUID, at time.Time) error
+}
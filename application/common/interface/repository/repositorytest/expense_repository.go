@@ -0,0 +1,114 @@
+// Package repositorytest provides fakes of the repository interfaces for tests.
+package repositorytest
+
+import (
+	"context"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+	"github.com/google/uuid"
+)
+
+// ExpenseRepository is a fake implementation of the IExpenseRepository interface. Each
+// method calls the function of the same name when it is set, and otherwise finds nothing
+// and succeeds.
+type ExpenseRepository struct {
+	SaveFunc                func(expense *expensemodel.Expense) error
+	SaveManyFunc            func(expenses []*expensemodel.Expense) error
+	ByIdFunc                func(id uuid.UUID, userId uuid.UUID) (*expensemodel.Expense, error)
+	ListFunc                func(params irepository.ListParams) ([]*expensemodel.Expense, error)
+	CountFunc               func(userId uuid.UUID, filter irepository.ExpenseFilter) (int, error)
+	FreshnessFunc           func(userId uuid.UUID, filter irepository.ExpenseFilter) (*irepository.ExpenseFreshness, error)
+	SelectFunc              func(userId uuid.UUID, selection irepository.ExpenseSelection, limit int) ([]*expensemodel.Expense, error)
+	UpdateSelectedFunc      func(userId uuid.UUID, selection irepository.ExpenseSelection, limit int, apply func(expenses []*expensemodel.Expense) error) error
+	DeleteSelectedFunc      func(userId uuid.UUID, selection irepository.ExpenseSelection, limit int, check func(expenses []*expensemodel.Expense) error) (int, error)
+	MergeFunc               func(kept *expensemodel.Expense, duplicateIds []uuid.UUID) error
+	StreamFunc              func(ctx context.Context, userId uuid.UUID, filter irepository.ExpenseFilter, each func(expense *expensemodel.Expense) error) error
+	ExistingExternalIDsFunc func(userId uuid.UUID, externalIds []string) (map[string]bool, error)
+}
+
+var _ irepository.IExpenseRepository = &ExpenseRepository{}
+
+func (r *ExpenseRepository) Save(expense *expensemodel.Expense) error {
+	if r.SaveFunc == nil {
+		return nil
+	}
+	return r.SaveFunc(expense)
+}
+
+func (r *ExpenseRepository) SaveMany(expenses []*expensemodel.Expense) error {
+	if r.SaveManyFunc == nil {
+		return nil
+	}
+	return r.SaveManyFunc(expenses)
+}
+
+func (r *ExpenseRepository) ById(id uuid.UUID, userId uuid.UUID) (*expensemodel.Expense, error) {
+	if r.ByIdFunc == nil {
+		return nil, nil
+	}
+	return r.ByIdFunc(id, userId)
+}
+
+func (r *ExpenseRepository) List(params irepository.ListParams) ([]*expensemodel.Expense, error) {
+	if r.ListFunc == nil {
+		return nil, nil
+	}
+	return r.ListFunc(params)
+}
+
+func (r *ExpenseRepository) Count(userId uuid.UUID, filter irepository.ExpenseFilter) (int, error) {
+	if r.CountFunc == nil {
+		return 0, nil
+	}
+	return r.CountFunc(userId, filter)
+}
+
+func (r *ExpenseRepository) Freshness(userId uuid.UUID, filter irepository.ExpenseFilter) (*irepository.ExpenseFreshness, error) {
+	if r.FreshnessFunc == nil {
+		return &irepository.ExpenseFreshness{}, nil
+	}
+	return r.FreshnessFunc(userId, filter)
+}
+
+func (r *ExpenseRepository) Select(userId uuid.UUID, selection irepository.ExpenseSelection, limit int) ([]*expensemodel.Expense, error) {
+	if r.SelectFunc == nil {
+		return nil, nil
+	}
+	return r.SelectFunc(userId, selection, limit)
+}
+
+func (r *ExpenseRepository) UpdateSelected(userId uuid.UUID, selection irepository.ExpenseSelection, limit int, apply func(expenses []*expensemodel.Expense) error) error {
+	if r.UpdateSelectedFunc == nil {
+		return apply(nil)
+	}
+	return r.UpdateSelectedFunc(userId, selection, limit, apply)
+}
+
+func (r *ExpenseRepository) DeleteSelected(userId uuid.UUID, selection irepository.ExpenseSelection, limit int, check func(expenses []*expensemodel.Expense) error) (int, error) {
+	if r.DeleteSelectedFunc == nil {
+		return 0, check(nil)
+	}
+	return r.DeleteSelectedFunc(userId, selection, limit, check)
+}
+
+func (r *ExpenseRepository) Merge(kept *expensemodel.Expense, duplicateIds []uuid.UUID) error {
+	if r.MergeFunc == nil {
+		return nil
+	}
+	return r.MergeFunc(kept, duplicateIds)
+}
+
+func (r *ExpenseRepository) Stream(ctx context.Context, userId uuid.UUID, filter irepository.ExpenseFilter, each func(expense *expensemodel.Expense) error) error {
+	if r.StreamFunc == nil {
+		return nil
+	}
+	return r.StreamFunc(ctx, userId, filter, each)
+}
+
+func (r *ExpenseRepository) ExistingExternalIDs(userId uuid.UUID, externalIds []string) (map[string]bool, error) {
+	if r.ExistingExternalIDsFunc == nil {
+		return map[string]bool{}, nil
+	}
+	return r.ExistingExternalIDsFunc(userId, externalIds)
+}
@@ -0,0 +1,26 @@
+package irepository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ITokenRevocationRepository defines methods for storing revoked access tokens. Access tokens
+// are revoked one at a time by their ID (the jti claim), or all at once for a user by
+// revoking every token issued before a point in time.
+type ITokenRevocationRepository interface {
+	// RevokeToken revokes the access token with the given ID, issued to the user. The
+	// revocation can be forgotten once the token expires at expiresAt.
+	RevokeToken(tokenId string, userId uuid.UUID, expiresAt time.Time) error
+
+	// IsTokenRevoked reports whether the access token with the given ID was revoked.
+	IsTokenRevoked(tokenId string) (bool, error)
+
+	// RevokeAllBefore revokes every access token issued to the user before the given time.
+	RevokeAllBefore(userId uuid.UUID, at time.Time) error
+
+	// RevokedBefore returns the time before which every access token issued to the user is
+	// revoked, or nil if the user never revoked all tokens.
+	RevokedBefore(userId uuid.UUID) (*time.Time, error)
+}
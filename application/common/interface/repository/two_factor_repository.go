@@ -0,0 +1,65 @@
+package irepository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TwoFactor is the TOTP second factor of a user. It is enrolled unconfirmed, and only
+// required at sign in once the user confirmed it with a code.
+type TwoFactor struct {
+	UserID       uuid.UUID  // ID of the user
+	Secret       string     // TOTP secret shared with the authenticator app of the user
+	CreatedAt    time.Time  // When the secret was enrolled
+	ConfirmedAt  *time.Time // When the user confirmed the secret; nil while unconfirmed
+	LastUsedStep int64      // Time step of the last code used, so that no code is used twice
+}
+
+// TwoFactorChallenge is a pending second step of a sign in, which the user completes by
+// giving a code of their second factor.
+type TwoFactorChallenge struct {
+	ID        uuid.UUID // ID of the challenge
+	UserID    uuid.UUID // ID of the user signing in
+	TokenHash string    // Hash of the challenge token; the token itself is never stored
+	ExpiresAt time.Time // When the challenge stops being accepted
+	Attempts  int       // Number of codes given for the challenge so far
+}
+
+// ITwoFactorRepository defines methods for storing second factors and their recovery codes.
+type ITwoFactorRepository interface {
+	// ByUser retrieves the second factor of the user, or nil if there is none.
+	ByUser(userId uuid.UUID) (*TwoFactor, error)
+
+	// Save inserts or replaces the second factor of the user.
+	Save(factor *TwoFactor) error
+
+	// Delete removes the second factor of the user along with their recovery codes.
+	Delete(userId uuid.UUID) error
+
+	// UseStep records the step of a code used by the user, unless a code of that step or a
+	// later one was used before, and reports whether it did.
+	UseStep(userId uuid.UUID, step int64) (bool, error)
+
+	// ReplaceRecoveryCodes replaces the recovery codes of the user with the given hashes.
+	ReplaceRecoveryCodes(userId uuid.UUID, codeHashes []string) error
+
+	// UseRecoveryCode marks the unused recovery code of the user with the given hash as used
+	// at the given time, and reports whether there was such a code.
+	UseRecoveryCode(userId uuid.UUID, codeHash string, at time.Time) (bool, error)
+}
+
+// ITwoFactorChallengeRepository defines methods for storing pending second steps of sign ins.
+type ITwoFactorChallengeRepository interface {
+	// SaveChallenge inserts a new challenge.
+	SaveChallenge(challenge *TwoFactorChallenge) error
+
+	// AttemptChallenge counts an attempt at the unexpired challenge with the given hash and
+	// returns it, or returns nil if there is no such challenge or it had maxAttempts attempts
+	// already. The check and the count are atomic, so concurrent attempts cannot exceed
+	// the limit.
+	AttemptChallenge(tokenHash string, at time.Time, maxAttempts int) (*TwoFactorChallenge, error)
+
+	// DeleteChallenge removes the challenge with the given ID.
+	DeleteChallenge(id uuid.UUID) error
+}
@@ -0,0 +1,36 @@
+package irepository
+
+import (
+	"time"
+
+	usermodel "github.com/beka-birhanu/finance-go/domain/model/user"
+)
+
+// UserListParams defines parameters for retrieving a page of users.
+type UserListParams struct {
+	Search string // Case-insensitive substring of the username; empty matches every user
+	Limit  int    // Max number of users to return
+	Offset int    // Number of matching users to skip
+}
+
+// UsageStats summarizes the use of the service.
+type UsageStats struct {
+	Users         int // Number of users
+	DisabledUsers int // Number of disabled users
+	NewUsers      int // Number of users created since the start of the period
+	ActiveUsers   int // Number of users who added an expense since the start of the period
+	Expenses      int // Number of expenses
+	NewExpenses   int // Number of expenses added since the start of the period
+}
+
+// IUserAdminRepository defines methods for administering users across all of them.
+type IUserAdminRepository interface {
+	// List retrieves a page of the users whose username matches the search, newest first.
+	List(params UserListParams) ([]*usermodel.User, error)
+
+	// Count returns the number of users whose username matches the search.
+	Count(search string) (int, error)
+
+	// Stats returns the usage statistics of the service since the given time.
+	Stats(since time.Time) (*UsageStats, error)
+}
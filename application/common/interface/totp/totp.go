@@ -0,0 +1,22 @@
+/*
+Package itotp provides an interface for time-based one-time passwords (RFC 6238), the codes
+shown by authenticator apps that serve as a second factor when signing in.
+*/
+package itotp
+
+import "time"
+
+// IService generates TOTP secrets and verifies the codes derived from them.
+type IService interface {
+	// GenerateSecret returns a new random secret, base32 encoded as authenticator apps expect.
+	GenerateSecret() (string, error)
+
+	// URI returns the otpauth URI by which authenticator apps enroll the secret, usually
+	// shown as a QR code, for the account of the issuer.
+	URI(secret string, issuer string, accountName string) string
+
+	// Verify reports whether the code is valid for the secret at the given time, allowing for
+	// some clock drift, and returns the time step the code belongs to. Codes of a step must not
+	// be accepted twice, so callers keep track of the last step used.
+	Verify(secret string, code string, at time.Time) (int64, bool)
+}
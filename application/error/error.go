@@ -7,20 +7,41 @@ package apperror
 
 import (
 	"fmt"
+	"math"
+	"time"
 
 	ierr "github.com/beka-birhanu/finance-go/domain/common/error"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	"github.com/google/uuid"
 )
 
 // Predefined error types.
 const (
 	// AuthenticationErrorType is used for authentication-related errors.
 	Authentication = "Authentication"
+
+	// Unprocessable is used for well-formed requests that cannot be processed, such as
+	// reusing an idempotency key for a different request.
+	Unprocessable = "Unprocessable"
+
+	// PreconditionFailed is used for requests made on a condition that does not hold, such
+	// as editing a version of a resource that is no longer current.
+	PreconditionFailed = "PreconditionFailed"
+
+	// TooManyRequests is used for requests that are rejected until some time has passed, such
+	// as signing in after too many failed attempts.
+	TooManyRequests = "TooManyRequests"
+
+	// Forbidden is used for requests by a known user who is not allowed to make them, such
+	// as signing in to a disabled account.
+	Forbidden = "Forbidden"
 )
 
 // Error represents a combined application error with a type and message.
 type Error struct {
-	kind    string
-	Message string
+	kind       string
+	Message    string
+	RetryAfter time.Duration // How long to wait before trying again, for TooManyRequests errors
 }
 
 var _ ierr.IErr = Error{} // Making sure Error implements IErr
@@ -43,3 +64,78 @@ func new(kind string, message string) Error {
 func InvalidCredential(message string) Error {
 	return new(Authentication, "invalid credentials")
 }
+
+// IdempotencyKeyReused returns Error of type Unprocessable for an idempotency key that was
+// already used for a different request.
+func IdempotencyKeyReused() Error {
+	return new(Unprocessable, "the idempotency key was already used for a different request")
+}
+
+// VersionMismatch returns Error of type PreconditionFailed for a request that expected a
+// version of the resource other than its current one.
+func VersionMismatch() Error {
+	return new(PreconditionFailed, "the resource was changed since the expected version")
+}
+
+// TooManyAttempts returns Error of type TooManyRequests for a sign in under a username that
+// is blocked after failed attempts, telling how long to wait before trying again.
+func TooManyAttempts(retryAfter time.Duration) Error {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	err := new(TooManyRequests, fmt.Sprintf("too many failed sign in attempts, try again in %d seconds", seconds))
+	err.RetryAfter = retryAfter
+	return err
+}
+
+// AccountDisabled returns Error of type Forbidden for a sign in to an account that was
+// disabled by an administrator.
+func AccountDisabled() Error {
+	return new(Forbidden, "the account is disabled")
+}
+
+// ItemError is the error of a single item of a batch, identified by its position.
+type ItemError struct {
+	Index int   // Position of the item in the batch
+	Err   error // Why the item was rejected
+}
+
+// BatchError reports the items of a batch that were rejected. A batch is applied all or
+// nothing, so none of its items were applied.
+type BatchError struct {
+	Items []ItemError
+}
+
+var _ ierr.IErr = &BatchError{} // Making sure BatchError implements IErr
+
+// Error formats the BatchError as a string.
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("%s: %d batch item(s) are invalid", e.Type(), len(e.Items))
+}
+
+// Type returns the type of the BatchError, which is always a validation error.
+func (e *BatchError) Type() string {
+	return errdmn.Validation
+}
+
+// Duplicate lists the stored expenses an item likely duplicates.
+type Duplicate struct {
+	Index int         // Position of the item in the request, or its line for imports
+	IDs   []uuid.UUID // IDs of the stored expenses the item likely duplicates
+}
+
+// DuplicateError reports the items that were rejected because they likely duplicate stored
+// expenses. Nothing was saved.
+type DuplicateError struct {
+	Duplicates []Duplicate
+}
+
+var _ ierr.IErr = &DuplicateError{} // Making sure DuplicateError implements IErr
+
+// Error formats the DuplicateError as a string.
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("%s: %d item(s) likely duplicate existing expenses", e.Type(), len(e.Duplicates))
+}
+
+// Type returns the type of the DuplicateError, which is always a conflict.
+func (e *DuplicateError) Type() string {
+	return errdmn.Conflict
+}
@@ -3,6 +3,8 @@ package expensecmd
 import (
 	"time"
 
+	expensedup "github.com/beka-birhanu/finance-go/application/expense/duplicate"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
 	"github.com/google/uuid"
 )
 
@@ -19,4 +21,22 @@ type AddCommand struct {
 
 	// Amount: The amount of the expense. Must be a positive float value.
 	Amount float32
+
+	// Category: An optional category for the expense.
+	Category string
+
+	// Account: An optional account the expense was paid from.
+	Account string
+
+	// OnDuplicate: What happens when the expense likely duplicates a stored one; defaults to warn.
+	OnDuplicate expensedup.Policy
+}
+
+// AddResult is the outcome of an AddCommand.
+type AddResult struct {
+	// Expense: The created expense.
+	Expense *expensemodel.Expense
+
+	// PossibleDuplicates: IDs of the stored expenses the created expense likely duplicates.
+	PossibleDuplicates []uuid.UUID
 }
@@ -8,6 +8,8 @@ import (
 	icmd "github.com/beka-birhanu/finance-go/application/common/cqrs/command"
 	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
 	itimeservice "github.com/beka-birhanu/finance-go/application/common/interface/time_service"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	expensedup "github.com/beka-birhanu/finance-go/application/expense/duplicate"
 	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
 )
 
@@ -15,15 +17,17 @@ import (
 type AddHandler struct {
 	userRepo irepository.IUserRepository // Repository for user data
 	timeSvc  itimeservice.IService       // Service for time-related operations
+	detector *expensedup.Detector        // Detector of likely duplicate expenses
 }
 
-// Ensure AddHandler implements icmd.IHandler[*AddCommand, *expensemodel.Expense].
-var _ icmd.IHandler[*AddCommand, *expensemodel.Expense] = &AddHandler{}
+// Ensure AddHandler implements icmd.IHandler[*AddCommand, *AddResult].
+var _ icmd.IHandler[*AddCommand, *AddResult] = &AddHandler{}
 
 // Config holds dependencies required for creating an AddHandler.
 type Config struct {
-	UserRepository irepository.IUserRepository // Repository for user data
-	TimeService    itimeservice.IService       // Service for time-related operations
+	UserRepository    irepository.IUserRepository // Repository for user data
+	TimeService       itimeservice.IService       // Service for time-related operations
+	DuplicateDetector *expensedup.Detector        // Detector of likely duplicate expenses
 }
 
 // NewAddHandler creates a new AddHandler with the specified configuration.
@@ -31,16 +35,27 @@ func NewAddHandler(config Config) *AddHandler {
 	return &AddHandler{
 		userRepo: config.UserRepository,
 		timeSvc:  config.TimeService,
+		detector: config.DuplicateDetector,
 	}
 }
 
-// Handle processes an AddCommand to create a new expense and returns the expense.
-func (h *AddHandler) Handle(command *AddCommand) (*expensemodel.Expense, error) {
+// Handle processes an AddCommand to create a new expense and returns it together with the
+// stored expenses it likely duplicates. Under the reject policy an expense with likely
+// duplicates is not saved and an *apperror.DuplicateError listing them is returned instead.
+func (h *AddHandler) Handle(command *AddCommand) (*AddResult, error) {
 	newExpense, err := createExpense(command, h.timeSvc.NowUTC())
 	if err != nil {
 		return nil, err
 	}
 
+	candidates, err := h.detector.Candidates(command.UserId, []*expensemodel.Expense{newExpense})
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates[0]) > 0 && command.OnDuplicate == expensedup.PolicyReject {
+		return nil, &apperror.DuplicateError{Duplicates: []apperror.Duplicate{{Index: 0, IDs: candidates[0]}}}
+	}
+
 	user, err := h.userRepo.ById(command.UserId)
 	if err != nil {
 		return nil, err
@@ -54,7 +69,7 @@ func (h *AddHandler) Handle(command *AddCommand) (*expensemodel.Expense, error)
 		return nil, fmt.Errorf("unable to update user: %w", err)
 	}
 
-	return newExpense, nil
+	return &AddResult{Expense: newExpense, PossibleDuplicates: candidates[0]}, nil
 }
 
 // createExpense constructs an Expense instance using the command and current time.
@@ -64,6 +79,8 @@ func createExpense(command *AddCommand, currentTime time.Time) (*expensemodel.Ex
 		Amount:       command.Amount,
 		UserId:       command.UserId,
 		Date:         command.Date,
+		Category:     command.Category,
+		Account:      command.Account,
 		CreationTime: currentTime,
 	}
 	return expensemodel.New(config)
@@ -0,0 +1,25 @@
+package expensecmd
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BatchAddCommand represents the command to add several expenses of a user at once.
+type BatchAddCommand struct {
+	// UserId: The unique identifier of the user to whom the expenses belong.
+	UserId uuid.UUID
+
+	// Items: The expenses to add, in order.
+	Items []BatchAddItem
+}
+
+// BatchAddItem represents a single expense of a BatchAddCommand.
+type BatchAddItem struct {
+	Date        time.Time // The date when the expense occurred
+	Description string    // A brief description of the expense
+	Amount      float32   // The amount of the expense; must be positive
+	Category    string    // An optional category for the expense
+	Account     string    // An optional account the expense was paid from
+}
@@ -0,0 +1,88 @@
+// Package expensecmd provides functionality for handling commands related to expenses.
+package expensecmd
+
+import (
+	"fmt"
+
+	icmd "github.com/beka-birhanu/finance-go/application/common/cqrs/command"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	itimeservice "github.com/beka-birhanu/finance-go/application/common/interface/time_service"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+)
+
+// defaultMaxBatchSize is the batch size limit used when none is configured.
+const defaultMaxBatchSize = 100
+
+// BatchAddHandler handles commands for adding several expenses at once.
+type BatchAddHandler struct {
+	expenseRepository irepository.IExpenseRepository // Repository for expense data
+	timeSvc           itimeservice.IService          // Service for time-related operations
+	maxBatchSize      int                            // Maximum number of expenses per batch
+}
+
+// Ensure BatchAddHandler implements icmd.IHandler[*BatchAddCommand, []*expensemodel.Expense].
+var _ icmd.IHandler[*BatchAddCommand, []*expensemodel.Expense] = &BatchAddHandler{}
+
+// BatchAddConfig holds dependencies required for creating a BatchAddHandler.
+type BatchAddConfig struct {
+	ExpenseRepository irepository.IExpenseRepository // Repository for expense data
+	TimeService       itimeservice.IService          // Service for time-related operations
+	MaxBatchSize      int                            // Maximum number of expenses per batch; defaults to 100
+}
+
+// NewBatchAddHandler creates a new BatchAddHandler with the specified configuration.
+func NewBatchAddHandler(config BatchAddConfig) *BatchAddHandler {
+	maxBatchSize := config.MaxBatchSize
+	if maxBatchSize <= 0 {
+		maxBatchSize = defaultMaxBatchSize
+	}
+
+	return &BatchAddHandler{
+		expenseRepository: config.ExpenseRepository,
+		timeSvc:           config.TimeService,
+		maxBatchSize:      maxBatchSize,
+	}
+}
+
+// Handle processes a BatchAddCommand and returns the created expenses in the order of the
+// command items. Every item is validated before anything is saved; if any item is invalid
+// an *apperror.BatchError listing each rejected item by index is returned and nothing is
+// saved. The expenses are then saved together, so either all of them are added or none.
+func (h *BatchAddHandler) Handle(command *BatchAddCommand) ([]*expensemodel.Expense, error) {
+	if len(command.Items) == 0 {
+		return nil, errdmn.NewValidation("a batch must contain at least one expense.")
+	}
+	if len(command.Items) > h.maxBatchSize {
+		return nil, errdmn.NewValidation(fmt.Sprintf("a batch cannot contain more than %d expenses.", h.maxBatchSize))
+	}
+
+	now := h.timeSvc.NowUTC()
+	expenses := make([]*expensemodel.Expense, 0, len(command.Items))
+	batchErr := &apperror.BatchError{}
+	for i, item := range command.Items {
+		expense, err := createExpense(&AddCommand{
+			UserId:      command.UserId,
+			Date:        item.Date,
+			Description: item.Description,
+			Amount:      item.Amount,
+			Category:    item.Category,
+			Account:     item.Account,
+		}, now)
+		if err != nil {
+			batchErr.Items = append(batchErr.Items, apperror.ItemError{Index: i, Err: err})
+			continue
+		}
+		expenses = append(expenses, expense)
+	}
+	if len(batchErr.Items) > 0 {
+		return nil, batchErr
+	}
+
+	if err := h.expenseRepository.SaveMany(expenses); err != nil {
+		return nil, err
+	}
+
+	return expenses, nil
+}
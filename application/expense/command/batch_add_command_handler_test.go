@@ -0,0 +1,106 @@
+package expensecmd
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/beka-birhanu/finance-go/application/common/interface/repository/repositorytest"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+	"github.com/google/uuid"
+)
+
+type MockTimeService struct{}
+
+func (m *MockTimeService) NowUTC() time.Time {
+	return time.Now().UTC()
+}
+
+func TestBatchAddHandler_Handle(t *testing.T) {
+	userId := uuid.New()
+	valid := BatchAddItem{Description: "Coffee", Amount: 3.5, Date: time.Now().UTC()}
+
+	tests := []struct {
+		name            string
+		items           []BatchAddItem
+		expectedSaved   int
+		expectedIndexes []int
+		expectedError   bool
+	}{
+		{
+			name:          "all items valid",
+			items:         []BatchAddItem{valid, valid, valid},
+			expectedSaved: 3,
+		},
+		{
+			name:            "invalid items are reported by index",
+			items:           []BatchAddItem{{Description: "", Amount: 1}, valid, {Description: "Rent", Amount: -5}},
+			expectedIndexes: []int{0, 2},
+			expectedError:   true,
+		},
+		{
+			name:          "empty batch",
+			items:         nil,
+			expectedError: true,
+		},
+		{
+			name:          "batch over the limit",
+			items:         []BatchAddItem{valid, valid, valid, valid},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := 0
+			handler := NewBatchAddHandler(BatchAddConfig{
+				ExpenseRepository: &repositorytest.ExpenseRepository{
+					SaveManyFunc: func(expenses []*expensemodel.Expense) error {
+						saved = len(expenses)
+						return nil
+					},
+				},
+				TimeService:  &MockTimeService{},
+				MaxBatchSize: 3,
+			})
+
+			expenses, err := handler.Handle(&BatchAddCommand{UserId: userId, Items: tt.items})
+			if tt.expectedError {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+				if saved != 0 {
+					t.Errorf("expected nothing to be saved, got %d expenses", saved)
+				}
+				if domainErr, ok := err.(*errdmn.Error); ok && domainErr.Type() != errdmn.Validation {
+					t.Errorf("expected validation error, got %v", err)
+				}
+
+				var batchErr *apperror.BatchError
+				if tt.expectedIndexes != nil {
+					if !errors.As(err, &batchErr) {
+						t.Fatalf("expected batch error, got %v", err)
+					}
+					if len(batchErr.Items) != len(tt.expectedIndexes) {
+						t.Fatalf("expected %d item errors, got %d", len(tt.expectedIndexes), len(batchErr.Items))
+					}
+					for i, index := range tt.expectedIndexes {
+						if batchErr.Items[i].Index != index {
+							t.Errorf("expected item error %d at index %d, got %d", i, index, batchErr.Items[i].Index)
+						}
+					}
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if saved != tt.expectedSaved || len(expenses) != tt.expectedSaved {
+				t.Errorf("expected %d expenses saved and returned, got %d saved and %d returned", tt.expectedSaved, saved, len(expenses))
+			}
+		})
+	}
+}
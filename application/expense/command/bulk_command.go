@@ -0,0 +1,34 @@
+package expensecmd
+
+import (
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	"github.com/google/uuid"
+)
+
+// BulkPatchCommand represents a command to update every expense matched by a selection.
+type BulkPatchCommand struct {
+	UserId      uuid.UUID                    // Identifier of the user who owns the expenses
+	Selection   irepository.ExpenseSelection // Expenses to update, by ID and/or filter
+	DryRun      bool                         // Only report the matched expenses, without changing them
+	Description *string                      // Optional new description for the expenses
+	Amount      *float32                     // Optional new amount for the expenses
+	Date        *time.Time                   // Optional new date for the expenses
+	Category    *string                      // Optional new category for the expenses; empty clears it
+	Account     *string                      // Optional new account for the expenses; empty clears it
+}
+
+// BulkDeleteCommand represents a command to delete every expense matched by a selection.
+type BulkDeleteCommand struct {
+	UserId    uuid.UUID                    // Identifier of the user who owns the expenses
+	Selection irepository.ExpenseSelection // Expenses to delete, by ID and/or filter
+	DryRun    bool                         // Only report the matched expenses, without deleting them
+}
+
+// BulkResult is the outcome of a bulk command.
+type BulkResult struct {
+	Matched int         // Number of expenses matched by the selection
+	IDs     []uuid.UUID // IDs of the matched expenses
+	DryRun  bool        // Whether the matched expenses were left unchanged
+}
@@ -0,0 +1,172 @@
+// Package expensecmd provides functionality for handling commands related to expenses.
+package expensecmd
+
+import (
+	"fmt"
+
+	icmd "github.com/beka-birhanu/finance-go/application/common/cqrs/command"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+	"github.com/google/uuid"
+)
+
+// defaultMaxBulkSize is the bulk size limit used when none is configured.
+const defaultMaxBulkSize = 1000
+
+// BulkConfig holds dependencies required for creating bulk command handlers.
+type BulkConfig struct {
+	ExpenseRepository irepository.IExpenseRepository // Repository for expense data
+	MaxBulkSize       int                            // Maximum number of expenses changed at once; defaults to 1000
+}
+
+// BulkPatchHandler handles commands for updating the expenses matched by a selection.
+type BulkPatchHandler struct {
+	expenseRepository irepository.IExpenseRepository
+	maxBulkSize       int
+}
+
+// Ensure BulkPatchHandler implements icmd.IHandler[*BulkPatchCommand, *BulkResult].
+var _ icmd.IHandler[*BulkPatchCommand, *BulkResult] = &BulkPatchHandler{}
+
+// NewBulkPatchHandler creates a new BulkPatchHandler with the specified configuration.
+func NewBulkPatchHandler(config BulkConfig) *BulkPatchHandler {
+	return &BulkPatchHandler{
+		expenseRepository: config.ExpenseRepository,
+		maxBulkSize:       maxBulkSizeOrDefault(config.MaxBulkSize),
+	}
+}
+
+// Handle processes a BulkPatchCommand. The matched expenses are locked and updated in a
+// single transaction. Every one of them is updated in memory first, so an invalid change is
+// rejected before anything is saved. In dry-run mode the matched expenses are only reported.
+func (h *BulkPatchHandler) Handle(cmd *BulkPatchCommand) (*BulkResult, error) {
+	if cmd.Description == nil && cmd.Amount == nil && cmd.Date == nil && cmd.Category == nil && cmd.Account == nil {
+		return nil, errdmn.NewValidation("a bulk update must change at least one field.")
+	}
+	if err := checkSelection(cmd.Selection); err != nil {
+		return nil, err
+	}
+	if cmd.DryRun {
+		return previewBulk(h.expenseRepository, cmd.UserId, cmd.Selection, h.maxBulkSize)
+	}
+
+	patch := &PatchCommand{
+		Description: cmd.Description,
+		Amount:      cmd.Amount,
+		Date:        cmd.Date,
+		Category:    cmd.Category,
+		Account:     cmd.Account,
+	}
+	var result *BulkResult
+	// One more expense than allowed is selected, so that a selection over the limit is detected
+	// without reading all of it.
+	err := h.expenseRepository.UpdateSelected(cmd.UserId, cmd.Selection, h.maxBulkSize+1, func(expenses []*expensemodel.Expense) error {
+		if err := checkBulkSize(len(expenses), h.maxBulkSize); err != nil {
+			return err
+		}
+		for _, expense := range expenses {
+			if err := applyPatch(expense, patch); err != nil {
+				return err
+			}
+		}
+		result = newBulkResult(expenses, false)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// BulkDeleteHandler handles commands for deleting the expenses matched by a selection.
+type BulkDeleteHandler struct {
+	expenseRepository irepository.IExpenseRepository
+	maxBulkSize       int
+}
+
+// Ensure BulkDeleteHandler implements icmd.IHandler[*BulkDeleteCommand, *BulkResult].
+var _ icmd.IHandler[*BulkDeleteCommand, *BulkResult] = &BulkDeleteHandler{}
+
+// NewBulkDeleteHandler creates a new BulkDeleteHandler with the specified configuration.
+func NewBulkDeleteHandler(config BulkConfig) *BulkDeleteHandler {
+	return &BulkDeleteHandler{
+		expenseRepository: config.ExpenseRepository,
+		maxBulkSize:       maxBulkSizeOrDefault(config.MaxBulkSize),
+	}
+}
+
+// Handle processes a BulkDeleteCommand, locking and deleting the matched expenses in a
+// single transaction. In dry-run mode the matched expenses are only reported.
+func (h *BulkDeleteHandler) Handle(cmd *BulkDeleteCommand) (*BulkResult, error) {
+	if err := checkSelection(cmd.Selection); err != nil {
+		return nil, err
+	}
+	if cmd.DryRun {
+		return previewBulk(h.expenseRepository, cmd.UserId, cmd.Selection, h.maxBulkSize)
+	}
+
+	var result *BulkResult
+	deleted, err := h.expenseRepository.DeleteSelected(cmd.UserId, cmd.Selection, h.maxBulkSize+1, func(expenses []*expensemodel.Expense) error {
+		if err := checkBulkSize(len(expenses), h.maxBulkSize); err != nil {
+			return err
+		}
+		result = newBulkResult(expenses, false)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Matched = deleted
+
+	return result, nil
+}
+
+// checkSelection rejects empty selections, so that a bulk command never targets every
+// expense of a user by accident.
+func checkSelection(selection irepository.ExpenseSelection) error {
+	if selection.IsEmpty() {
+		return errdmn.NewValidation("a bulk command must select expenses by IDs or a filter.")
+	}
+	return nil
+}
+
+// previewBulk reports the expenses a bulk command would change, without changing them. Like
+// the command itself, it rejects selections matching more expenses than allowed, reading at
+// most one more than allowed.
+func previewBulk(repository irepository.IExpenseRepository, userId uuid.UUID, selection irepository.ExpenseSelection, maxBulkSize int) (*BulkResult, error) {
+	expenses, err := repository.Select(userId, selection, maxBulkSize+1)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBulkSize(len(expenses), maxBulkSize); err != nil {
+		return nil, err
+	}
+	return newBulkResult(expenses, true), nil
+}
+
+// checkBulkSize rejects bulk commands matching more expenses than allowed.
+func checkBulkSize(matched, maxBulkSize int) error {
+	if matched > maxBulkSize {
+		return errdmn.NewValidation(fmt.Sprintf("the selection matches more than %d expenses, the most that can be changed at once.", maxBulkSize))
+	}
+	return nil
+}
+
+// newBulkResult reports the matched expenses.
+func newBulkResult(expenses []*expensemodel.Expense, dryRun bool) *BulkResult {
+	ids := make([]uuid.UUID, 0, len(expenses))
+	for _, expense := range expenses {
+		ids = append(ids, expense.ID())
+	}
+	return &BulkResult{Matched: len(expenses), IDs: ids, DryRun: dryRun}
+}
+
+// maxBulkSizeOrDefault returns the configured bulk size limit, or the default one.
+func maxBulkSizeOrDefault(maxBulkSize int) int {
+	if maxBulkSize <= 0 {
+		return defaultMaxBulkSize
+	}
+	return maxBulkSize
+}
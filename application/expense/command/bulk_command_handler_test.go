@@ -0,0 +1,192 @@
+package expensecmd
+
+import (
+	"testing"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	"github.com/beka-birhanu/finance-go/application/common/interface/repository/repositorytest"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+	"github.com/google/uuid"
+)
+
+// newBulkRepository returns a repository holding the given number of expenses of a user
+// and records what the bulk handlers change. Like the database, it selects at most limit
+// expenses and changes nothing when apply or check fails.
+func newBulkRepository(t *testing.T, userId uuid.UUID, n int, updated *[]*expensemodel.Expense, deleted *[]uuid.UUID) *repositorytest.ExpenseRepository {
+	expenses := make([]*expensemodel.Expense, 0, n)
+	for i := 0; i < n; i++ {
+		expense, err := expensemodel.New(expensemodel.Config{
+			Description:  "Coffee",
+			Amount:       3.5,
+			UserId:       userId,
+			Date:         time.Now().UTC(),
+			CreationTime: time.Now().UTC(),
+		})
+		if err != nil {
+			t.Fatalf("failed to create expense: %v", err)
+		}
+		expenses = append(expenses, expense)
+	}
+
+	return &repositorytest.ExpenseRepository{
+		SelectFunc: func(userId uuid.UUID, selection irepository.ExpenseSelection, limit int) ([]*expensemodel.Expense, error) {
+			return expenses[:min(limit, len(expenses))], nil
+		},
+		UpdateSelectedFunc: func(userId uuid.UUID, selection irepository.ExpenseSelection, limit int, apply func(expenses []*expensemodel.Expense) error) error {
+			selected := expenses[:min(limit, len(expenses))]
+			if err := apply(selected); err != nil {
+				return err
+			}
+			*updated = selected
+			return nil
+		},
+		DeleteSelectedFunc: func(userId uuid.UUID, selection irepository.ExpenseSelection, limit int, check func(expenses []*expensemodel.Expense) error) (int, error) {
+			selected := expenses[:min(limit, len(expenses))]
+			if err := check(selected); err != nil {
+				return 0, err
+			}
+			for _, expense := range selected {
+				*deleted = append(*deleted, expense.ID())
+			}
+			return len(selected), nil
+		},
+	}
+}
+
+func TestBulkPatchHandler_Handle(t *testing.T) {
+	userId := uuid.New()
+	category := "coffee"
+	longCategory := string(make([]byte, 65))
+	selection := irepository.ExpenseSelection{Filter: irepository.ExpenseFilter{Description: "coffee"}}
+
+	tests := []struct {
+		name            string
+		command         *BulkPatchCommand
+		maxBulkSize     int
+		expectedMatched int
+		expectedUpdated int
+		expectedError   bool
+	}{
+		{
+			name:            "updates every matched expense",
+			command:         &BulkPatchCommand{UserId: userId, Selection: selection, Category: &category},
+			expectedMatched: 3,
+			expectedUpdated: 3,
+		},
+		{
+			name:            "dry run leaves expenses unchanged",
+			command:         &BulkPatchCommand{UserId: userId, Selection: selection, Category: &category, DryRun: true},
+			expectedMatched: 3,
+		},
+		{
+			name:          "over the limit",
+			command:       &BulkPatchCommand{UserId: userId, Selection: selection, Category: &category},
+			maxBulkSize:   2,
+			expectedError: true,
+		},
+		{
+			name:          "dry run over the limit",
+			command:       &BulkPatchCommand{UserId: userId, Selection: selection, Category: &category, DryRun: true},
+			maxBulkSize:   2,
+			expectedError: true,
+		},
+		{
+			name:          "empty selection",
+			command:       &BulkPatchCommand{UserId: userId, Category: &category},
+			expectedError: true,
+		},
+		{
+			name:          "no changes",
+			command:       &BulkPatchCommand{UserId: userId, Selection: selection},
+			expectedError: true,
+		},
+		{
+			name:          "invalid change",
+			command:       &BulkPatchCommand{UserId: userId, Selection: selection, Category: &longCategory},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated []*expensemodel.Expense
+			var deleted []uuid.UUID
+			handler := NewBulkPatchHandler(BulkConfig{
+				ExpenseRepository: newBulkRepository(t, userId, 3, &updated, &deleted),
+				MaxBulkSize:       tt.maxBulkSize,
+			})
+
+			result, err := handler.Handle(tt.command)
+			if tt.expectedError {
+				domainErr, ok := err.(*errdmn.Error)
+				if !ok || domainErr.Type() != errdmn.Validation {
+					t.Fatalf("expected validation error, got %v", err)
+				}
+				if updated != nil {
+					t.Error("expected nothing to be updated")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.Matched != tt.expectedMatched || result.DryRun != tt.command.DryRun {
+				t.Errorf("unexpected result: %+v", result)
+			}
+			if len(updated) != tt.expectedUpdated {
+				t.Errorf("expected %d expenses updated, got %d", tt.expectedUpdated, len(updated))
+			}
+			for _, expense := range updated {
+				if expense.Category() != category {
+					t.Errorf("expected category %q, got %q", category, expense.Category())
+				}
+			}
+		})
+	}
+}
+
+func TestBulkDeleteHandler_Handle(t *testing.T) {
+	userId := uuid.New()
+	selection := irepository.ExpenseSelection{IDs: []uuid.UUID{uuid.New()}}
+
+	t.Run("DeletesMatchedExpenses", func(t *testing.T) {
+		var updated []*expensemodel.Expense
+		var deleted []uuid.UUID
+		handler := NewBulkDeleteHandler(BulkConfig{ExpenseRepository: newBulkRepository(t, userId, 2, &updated, &deleted)})
+
+		result, err := handler.Handle(&BulkDeleteCommand{UserId: userId, Selection: selection})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Matched != 2 || len(deleted) != 2 {
+			t.Errorf("expected 2 expenses deleted, got result %+v and %d deleted", result, len(deleted))
+		}
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		var updated []*expensemodel.Expense
+		var deleted []uuid.UUID
+		handler := NewBulkDeleteHandler(BulkConfig{ExpenseRepository: newBulkRepository(t, userId, 2, &updated, &deleted)})
+
+		result, err := handler.Handle(&BulkDeleteCommand{UserId: userId, Selection: selection, DryRun: true})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Matched != 2 || deleted != nil {
+			t.Errorf("expected 2 matched and nothing deleted, got result %+v and %d deleted", result, len(deleted))
+		}
+	})
+
+	t.Run("OverTheLimit", func(t *testing.T) {
+		var updated []*expensemodel.Expense
+		var deleted []uuid.UUID
+		handler := NewBulkDeleteHandler(BulkConfig{ExpenseRepository: newBulkRepository(t, userId, 2, &updated, &deleted), MaxBulkSize: 1})
+
+		if _, err := handler.Handle(&BulkDeleteCommand{UserId: userId, Selection: selection}); err == nil || deleted != nil {
+			t.Errorf("expected the deletion to be rejected, got error %v and %d deleted", err, len(deleted))
+		}
+	})
+}
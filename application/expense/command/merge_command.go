@@ -0,0 +1,12 @@
+package expensecmd
+
+import (
+	"github.com/google/uuid"
+)
+
+// MergeCommand represents a command to merge duplicates of an expense into it.
+type MergeCommand struct {
+	UserId       uuid.UUID   // Identifier of the user who owns the expenses
+	KeepId       uuid.UUID   // Identifier of the expense to keep
+	DuplicateIds []uuid.UUID // Identifiers of the duplicates to merge into the kept expense and delete
+}
@@ -0,0 +1,82 @@
+// Package expensecmd provides functionality for handling commands related to expenses.
+package expensecmd
+
+import (
+	"fmt"
+
+	icmd "github.com/beka-birhanu/finance-go/application/common/cqrs/command"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	errexpense "github.com/beka-birhanu/finance-go/domain/error/expense"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+	"github.com/google/uuid"
+)
+
+// maxMergeSize is the maximum number of duplicates merged at once.
+const maxMergeSize = 100
+
+// MergeHandler handles commands for merging duplicates of an expense into it.
+type MergeHandler struct {
+	expenseRepository irepository.IExpenseRepository // Repository for expense data
+}
+
+// Ensure MergeHandler implements icmd.IHandler[*MergeCommand, *expensemodel.Expense].
+var _ icmd.IHandler[*MergeCommand, *expensemodel.Expense] = &MergeHandler{}
+
+// NewMergeHandler creates a new MergeHandler with the provided expense repository.
+func NewMergeHandler(expenseRepository irepository.IExpenseRepository) *MergeHandler {
+	return &MergeHandler{expenseRepository: expenseRepository}
+}
+
+// Handle processes a MergeCommand. The kept expense takes over the category, account and
+// external ID it lacks from the duplicates, newest first, and the duplicates are deleted
+// together with saving it.
+//
+// Returns:
+//   - *expensemodel.Expense: The kept expense.
+//   - error: A validation error if no or too many duplicates are given, or the kept expense
+//     is among them, and a not found error if any of the expenses does not exist.
+func (h *MergeHandler) Handle(cmd *MergeCommand) (*expensemodel.Expense, error) {
+	if len(cmd.DuplicateIds) == 0 {
+		return nil, errdmn.NewValidation("a merge must name at least one duplicate.")
+	}
+	if len(cmd.DuplicateIds) > maxMergeSize {
+		return nil, errdmn.NewValidation(fmt.Sprintf("at most %d duplicates can be merged at once.", maxMergeSize))
+	}
+
+	seen := map[uuid.UUID]bool{cmd.KeepId: true}
+	for _, id := range cmd.DuplicateIds {
+		if seen[id] {
+			return nil, errdmn.NewValidation(fmt.Sprintf("expense %s is named more than once.", id))
+		}
+		seen[id] = true
+	}
+
+	ids := append([]uuid.UUID{cmd.KeepId}, cmd.DuplicateIds...)
+	expenses, err := h.expenseRepository.Select(cmd.UserId, irepository.ExpenseSelection{IDs: ids}, len(ids))
+	if err != nil {
+		return nil, err
+	}
+	if len(expenses) != len(ids) {
+		return nil, errexpense.NotFound
+	}
+
+	var kept *expensemodel.Expense
+	duplicates := make([]*expensemodel.Expense, 0, len(cmd.DuplicateIds))
+	for _, expense := range expenses {
+		if expense.ID() == cmd.KeepId {
+			kept = expense
+		} else {
+			duplicates = append(duplicates, expense)
+		}
+	}
+	for _, duplicate := range duplicates {
+		kept.Absorb(duplicate)
+	}
+
+	if err := h.expenseRepository.Merge(kept, cmd.DuplicateIds); err != nil {
+		return nil, err
+	}
+
+	return kept, nil
+}
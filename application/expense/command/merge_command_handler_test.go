@@ -0,0 +1,111 @@
+package expensecmd
+
+import (
+	"testing"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	"github.com/beka-birhanu/finance-go/application/common/interface/repository/repositorytest"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+	"github.com/google/uuid"
+)
+
+func TestMergeHandler_Handle(t *testing.T) {
+	userId := uuid.New()
+	newExpense := func(category, account, externalID string) *expensemodel.Expense {
+		expense, err := expensemodel.New(expensemodel.Config{
+			Description:  "Coffee",
+			Amount:       3.5,
+			UserId:       userId,
+			Date:         time.Now().UTC(),
+			Category:     category,
+			Account:      account,
+			ExternalID:   externalID,
+			CreationTime: time.Now().UTC(),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return expense
+	}
+
+	kept := newExpense("", "wallet", "")
+	duplicate := newExpense("coffee", "checking", "fit-1")
+	stored := []*expensemodel.Expense{kept, duplicate}
+
+	tests := []struct {
+		name            string
+		command         *MergeCommand
+		expectedError   string
+		expectedDeleted int
+	}{
+		{
+			name:            "merges duplicates into the kept expense",
+			command:         &MergeCommand{UserId: userId, KeepId: kept.ID(), DuplicateIds: []uuid.UUID{duplicate.ID()}},
+			expectedDeleted: 1,
+		},
+		{
+			name:          "no duplicates",
+			command:       &MergeCommand{UserId: userId, KeepId: kept.ID()},
+			expectedError: errdmn.Validation,
+		},
+		{
+			name:          "kept expense among the duplicates",
+			command:       &MergeCommand{UserId: userId, KeepId: kept.ID(), DuplicateIds: []uuid.UUID{kept.ID()}},
+			expectedError: errdmn.Validation,
+		},
+		{
+			name:          "unknown duplicate",
+			command:       &MergeCommand{UserId: userId, KeepId: kept.ID(), DuplicateIds: []uuid.UUID{uuid.New()}},
+			expectedError: errdmn.NotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var merged *expensemodel.Expense
+			var deleted []uuid.UUID
+			handler := NewMergeHandler(&repositorytest.ExpenseRepository{
+				SelectFunc: func(userId uuid.UUID, selection irepository.ExpenseSelection, limit int) ([]*expensemodel.Expense, error) {
+					var selected []*expensemodel.Expense
+					for _, expense := range stored {
+						for _, id := range selection.IDs {
+							if expense.ID() == id {
+								selected = append(selected, expense)
+							}
+						}
+					}
+					return selected, nil
+				},
+				MergeFunc: func(kept *expensemodel.Expense, duplicateIds []uuid.UUID) error {
+					merged, deleted = kept, duplicateIds
+					return nil
+				},
+			})
+
+			result, err := handler.Handle(tt.command)
+			if tt.expectedError != "" {
+				domainErr, ok := err.(*errdmn.Error)
+				if !ok || domainErr.Type() != tt.expectedError {
+					t.Fatalf("expected %s error, got %v", tt.expectedError, err)
+				}
+				if merged != nil {
+					t.Error("expected nothing to be merged")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result != merged || len(deleted) != tt.expectedDeleted {
+				t.Fatalf("expected the kept expense to be saved and %d duplicates deleted", tt.expectedDeleted)
+			}
+			// Missing fields are taken over, but present ones are kept.
+			if result.Category() != "coffee" || result.Account() != "wallet" || result.ExternalID() != "fit-1" {
+				t.Errorf("unexpected merged expense: category %q, account %q, external ID %q", result.Category(), result.Account(), result.ExternalID())
+			}
+		})
+	}
+}
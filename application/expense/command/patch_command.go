@@ -8,9 +8,12 @@ import (
 
 // PatchCommand represents a command to update an existing expense.
 type PatchCommand struct {
-	Description *string    // Optional new description for the expense
-	Amount      *float32   // Optional new amount for the expense
-	Date        *time.Time // Optional new date for the expense
-	Id          uuid.UUID  // Unique identifier of the expense to be updated
-	UserId      uuid.UUID  // Identifier of the user who owns the expense
+	Description     *string    // Optional new description for the expense
+	Amount          *float32   // Optional new amount for the expense
+	Date            *time.Time // Optional new date for the expense
+	Category        *string    // Optional new category for the expense; empty clears it
+	Account         *string    // Optional new account for the expense; empty clears it
+	ExpectedVersion *int       // Optional version the expense must still have for the update to apply
+	Id              uuid.UUID  // Unique identifier of the expense to be updated
+	UserId          uuid.UUID  // Identifier of the user who owns the expense
 }
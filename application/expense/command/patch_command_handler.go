@@ -3,6 +3,8 @@ package expensecmd
 
 import (
 	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	errexpense "github.com/beka-birhanu/finance-go/domain/error/expense"
 	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
 )
 
@@ -20,36 +22,64 @@ func NewPatchHandler(expenseRepository irepository.IExpenseRepository) *PatchHan
 
 // Handle processes a PatchCommand to update an existing expense.
 // It retrieves the expense by its ID and user ID, updates the expense fields if provided,
-// and saves the changes to the repository.
+// and saves the changes to the repository. When the command expects a version, the update
+// only applies to the expense at that version.
 //
 // Returns:
 //   - *expensemodel.Expense: The updated expense.
 //   - error: An error if the update fails. Possible errors include issues with retrieving
-//     or updating the expense, or saving the changes to the repository.
+//     or updating the expense, or saving the changes to the repository. A precondition
+//     failed error is returned if the expense is not, or no longer, at the expected version.
 func (h *PatchHandler) Handle(cmd *PatchCommand) (*expensemodel.Expense, error) {
 	expense, err := h.expenseRepository.ById(cmd.Id, cmd.UserId)
 	if err != nil {
 		return nil, err
 	}
 
+	if cmd.ExpectedVersion != nil && *cmd.ExpectedVersion != expense.Version() {
+		return nil, apperror.VersionMismatch()
+	}
+
+	if err := applyPatch(expense, cmd); err != nil {
+		return nil, err
+	}
+
+	if err := h.expenseRepository.Save(expense); err != nil {
+		// The expense was changed between reading and saving it.
+		if err == errexpense.VersionConflict && cmd.ExpectedVersion != nil {
+			return nil, apperror.VersionMismatch()
+		}
+		return nil, err
+	}
+
+	// TODO: Publish an expense updated domain event
+	return expense, nil
+}
+
+// applyPatch updates the fields of the expense that are set in the command.
+func applyPatch(expense *expensemodel.Expense, cmd *PatchCommand) error {
 	if cmd.Amount != nil {
 		if err := expense.UpdateAmount(*cmd.Amount); err != nil {
-			return nil, err
+			return err
 		}
 	}
 	if cmd.Description != nil {
 		if err := expense.UpdateDescription(*cmd.Description); err != nil {
-			return nil, err
+			return err
 		}
 	}
 	if cmd.Date != nil {
 		expense.UpdateDate(*cmd.Date)
 	}
-
-	if err := h.expenseRepository.Save(expense); err != nil {
-		return nil, err
+	if cmd.Category != nil {
+		if err := expense.UpdateCategory(*cmd.Category); err != nil {
+			return err
+		}
 	}
-
-	// TODO: Publish an expense updated domain event
-	return expense, nil
+	if cmd.Account != nil {
+		if err := expense.UpdateAccount(*cmd.Account); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,103 @@
+package expensecmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/beka-birhanu/finance-go/application/common/interface/repository/repositorytest"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	ierr "github.com/beka-birhanu/finance-go/domain/common/error"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	errexpense "github.com/beka-birhanu/finance-go/domain/error/expense"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+	"github.com/google/uuid"
+)
+
+func TestPatchHandler_Handle(t *testing.T) {
+	userId := uuid.New()
+	description := "Groceries"
+	current, stale := 3, 2
+
+	tests := []struct {
+		name            string
+		expectedVersion *int
+		saveErr         error
+		expectedError   string
+		expectedSaved   bool
+	}{
+		{
+			name:          "without an expected version",
+			expectedSaved: true,
+		},
+		{
+			name:            "current expected version",
+			expectedVersion: &current,
+			expectedSaved:   true,
+		},
+		{
+			name:            "stale expected version",
+			expectedVersion: &stale,
+			expectedError:   apperror.PreconditionFailed,
+		},
+		{
+			name:            "changed while saving with an expected version",
+			expectedVersion: &current,
+			saveErr:         errexpense.VersionConflict,
+			expectedError:   apperror.PreconditionFailed,
+		},
+		{
+			name:          "changed while saving without an expected version",
+			saveErr:       errexpense.VersionConflict,
+			expectedError: errdmn.Conflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expense, err := expensemodel.NewWithID(uuid.New(), expensemodel.Config{
+				Description:  "Coffee",
+				Amount:       3.5,
+				UserId:       userId,
+				Date:         time.Now().UTC(),
+				CreationTime: time.Now().UTC(),
+				Version:      current,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			saved := false
+			handler := NewPatchHandler(&repositorytest.ExpenseRepository{
+				ByIdFunc: func(id uuid.UUID, userId uuid.UUID) (*expensemodel.Expense, error) {
+					return expense, nil
+				},
+				SaveFunc: func(expense *expensemodel.Expense) error {
+					if tt.saveErr != nil {
+						return tt.saveErr
+					}
+					saved = true
+					return nil
+				},
+			})
+
+			_, err = handler.Handle(&PatchCommand{
+				Description:     &description,
+				ExpectedVersion: tt.expectedVersion,
+				Id:              expense.ID(),
+				UserId:          userId,
+			})
+			if tt.expectedError != "" {
+				typedErr, ok := err.(ierr.IErr)
+				if !ok || typedErr.Type() != tt.expectedError {
+					t.Fatalf("expected %s error, got %v", tt.expectedError, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if saved != tt.expectedSaved {
+				t.Errorf("expected saved %v, got %v", tt.expectedSaved, saved)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+// Package expensedup provides functionality for detecting likely duplicate expenses: expenses
+// of the same kind and amount, dated within a few days of each other, whose descriptions are
+// similar.
+package expensedup
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+	"github.com/google/uuid"
+)
+
+// DefaultWindowDays is the number of days between the dates of duplicates used when none is configured.
+const DefaultWindowDays = 3
+
+// day is the length of the calendar days dates are compared by.
+const day = 24 * time.Hour
+
+// Policy tells what happens to a new expense that likely duplicates a stored one.
+type Policy string
+
+const (
+	// PolicyWarn saves the expense and reports the stored expenses it likely duplicates.
+	PolicyWarn Policy = "warn"
+
+	// PolicyReject refuses to save the expense with an *apperror.DuplicateError.
+	PolicyReject Policy = "reject"
+)
+
+// ParsePolicy parses the policy named by s. An empty name means PolicyWarn.
+func ParsePolicy(s string) (Policy, error) {
+	switch Policy(s) {
+	case "", PolicyWarn:
+		return PolicyWarn, nil
+	case PolicyReject:
+		return PolicyReject, nil
+	default:
+		return "", errdmn.NewValidation(fmt.Sprintf("unknown duplicate policy %q: use warn or reject.", s))
+	}
+}
+
+// Detector finds likely duplicates among the expenses of a user.
+type Detector struct {
+	expenseRepository irepository.IExpenseRepository // Repository for expense data
+	windowDays        int                            // Maximum number of days between the dates of duplicates
+}
+
+// Config holds dependencies required for creating a Detector.
+type Config struct {
+	ExpenseRepository irepository.IExpenseRepository // Repository for expense data
+	WindowDays        int                            // Maximum number of days between the dates of duplicates; defaults to 3 when negative
+}
+
+// NewDetector creates a new Detector with the specified configuration.
+func NewDetector(config Config) *Detector {
+	windowDays := config.WindowDays
+	if windowDays < 0 {
+		windowDays = DefaultWindowDays
+	}
+
+	return &Detector{
+		expenseRepository: config.ExpenseRepository,
+		windowDays:        windowDays,
+	}
+}
+
+// IsDuplicate reports whether two different expenses likely record the same transaction:
+// they have the same kind and amount, are dated at most the window apart in calendar days,
+// and have similar descriptions.
+func (d *Detector) IsDuplicate(a, b *expensemodel.Expense) bool {
+	return a.ID() != b.ID() &&
+		keyOf(a) == keyOf(b) &&
+		d.withinWindow(a.Date(), b.Date()) &&
+		similarDescriptions(a.Description(), b.Description())
+}
+
+// Candidates returns the IDs of the stored expenses of the user that each of the given
+// expenses likely duplicates, keyed by the position of the expense. Expenses without any
+// candidate are left out.
+func (d *Detector) Candidates(userId uuid.UUID, expenses []*expensemodel.Expense) (map[int][]uuid.UUID, error) {
+	candidates := make(map[int][]uuid.UUID)
+	if len(expenses) == 0 {
+		return candidates, nil
+	}
+
+	byKey := make(map[amountKey][]int)
+	from, to := expenses[0].Date(), expenses[0].Date()
+	for i, expense := range expenses {
+		byKey[keyOf(expense)] = append(byKey[keyOf(expense)], i)
+		if expense.Date().Before(from) {
+			from = expense.Date()
+		}
+		if expense.Date().After(to) {
+			to = expense.Date()
+		}
+	}
+
+	// Only stored expenses dated within the window of the new ones can match them.
+	window := time.Duration(d.windowDays) * day
+	dateFrom := calendarDay(from).Add(-window)
+	dateTo := calendarDay(to).Add(window + day - time.Nanosecond)
+	filter := irepository.ExpenseFilter{DateFrom: &dateFrom, DateTo: &dateTo}
+	if len(byKey) == 1 {
+		amount := math.Round(float64(expenses[0].Amount())*100) / 100
+		filter.MinAmount, filter.MaxAmount = &amount, &amount
+	}
+
+	err := d.expenseRepository.Stream(context.Background(), userId, filter, func(stored *expensemodel.Expense) error {
+		for _, i := range byKey[keyOf(stored)] {
+			if d.IsDuplicate(expenses[i], stored) {
+				candidates[i] = append(candidates[i], stored.ID())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return candidates, nil
+}
+
+// Groups scans the stored expenses of the user and returns the groups of likely duplicates
+// among them. Expenses are grouped transitively, so every expense of a group duplicates at
+// least one other expense of it. Groups and their expenses are ordered newest first.
+func (d *Detector) Groups(userId uuid.UUID) ([][]*expensemodel.Expense, error) {
+	type entry struct {
+		expense  *expensemodel.Expense
+		position int
+	}
+
+	recent := make(map[amountKey][]entry)
+	members := make(map[uuid.UUID]entry)
+	parent := make(map[uuid.UUID]uuid.UUID)
+	find := func(id uuid.UUID) uuid.UUID {
+		for parent[id] != id {
+			parent[id] = parent[parent[id]]
+			id = parent[id]
+		}
+		return id
+	}
+	join := func(a, b entry) {
+		for _, e := range []entry{a, b} {
+			if _, ok := members[e.expense.ID()]; !ok {
+				members[e.expense.ID()] = e
+				parent[e.expense.ID()] = e.expense.ID()
+			}
+		}
+		if rootA, rootB := find(a.expense.ID()), find(b.expense.ID()); rootA != rootB {
+			parent[rootB] = rootA
+		}
+	}
+
+	// Expenses are streamed newest first, so one that is out of the window of the current
+	// expense is out of the window of every later one too and can be forgotten.
+	position := 0
+	err := d.expenseRepository.Stream(context.Background(), userId, irepository.ExpenseFilter{}, func(expense *expensemodel.Expense) error {
+		current := entry{expense: expense, position: position}
+		position++
+
+		key := keyOf(expense)
+		kept := recent[key][:0]
+		for _, other := range recent[key] {
+			if !d.withinWindow(other.expense.Date(), expense.Date()) {
+				continue
+			}
+			kept = append(kept, other)
+			if d.IsDuplicate(other.expense, expense) {
+				join(other, current)
+			}
+		}
+		recent[key] = append(kept, current)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byRoot := make(map[uuid.UUID][]entry)
+	for id, member := range members {
+		root := find(id)
+		byRoot[root] = append(byRoot[root], member)
+	}
+
+	grouped := make([][]entry, 0, len(byRoot))
+	for _, group := range byRoot {
+		sort.Slice(group, func(i, j int) bool { return group[i].position < group[j].position })
+		grouped = append(grouped, group)
+	}
+	sort.Slice(grouped, func(i, j int) bool { return grouped[i][0].position < grouped[j][0].position })
+
+	groups := make([][]*expensemodel.Expense, 0, len(grouped))
+	for _, group := range grouped {
+		expenses := make([]*expensemodel.Expense, 0, len(group))
+		for _, member := range group {
+			expenses = append(expenses, member.expense)
+		}
+		groups = append(groups, expenses)
+	}
+	return groups, nil
+}
+
+// withinWindow reports whether two dates are at most the window apart in calendar days.
+func (d *Detector) withinWindow(a, b time.Time) bool {
+	distance := calendarDay(a).Sub(calendarDay(b))
+	if distance < 0 {
+		distance = -distance
+	}
+	return distance <= time.Duration(d.windowDays)*day
+}
+
+// calendarDay returns the start of the UTC calendar day of t.
+func calendarDay(t time.Time) time.Time {
+	return t.UTC().Truncate(day)
+}
+
+// amountKey identifies the expenses that can duplicate each other by kind and amount.
+type amountKey struct {
+	kind  expensemodel.Kind
+	cents int64
+}
+
+// keyOf returns the amount key of an expense. Amounts are compared in cents so that float
+// rounding never tells equal amounts apart.
+func keyOf(expense *expensemodel.Expense) amountKey {
+	return amountKey{kind: expense.Kind(), cents: int64(math.Round(float64(expense.Amount()) * 100))}
+}
@@ -0,0 +1,170 @@
+package expensedup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	"github.com/beka-birhanu/finance-go/application/common/interface/repository/repositorytest"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+	"github.com/google/uuid"
+)
+
+var (
+	testUserId = uuid.New()
+	testDay    = time.Date(2024, 3, 10, 9, 30, 0, 0, time.UTC)
+)
+
+func newTestExpense(t *testing.T, description string, amount float32, kind expensemodel.Kind, date time.Time) *expensemodel.Expense {
+	t.Helper()
+	expense, err := expensemodel.New(expensemodel.Config{
+		Description:  description,
+		Amount:       amount,
+		Kind:         kind,
+		UserId:       testUserId,
+		Date:         date,
+		CreationTime: testDay,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return expense
+}
+
+// streamOf returns a Stream mock yielding the expenses in the given order.
+func streamOf(expenses ...*expensemodel.Expense) func(ctx context.Context, userId uuid.UUID, filter irepository.ExpenseFilter, each func(expense *expensemodel.Expense) error) error {
+	return func(ctx context.Context, userId uuid.UUID, filter irepository.ExpenseFilter, each func(expense *expensemodel.Expense) error) error {
+		for _, expense := range expenses {
+			if err := each(expense); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    Policy
+		expectedErr bool
+	}{
+		{input: "", expected: PolicyWarn},
+		{input: "warn", expected: PolicyWarn},
+		{input: "reject", expected: PolicyReject},
+		{input: "ignore", expectedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			policy, err := ParsePolicy(tt.input)
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("expected error: %v, got %v", tt.expectedErr, err)
+			}
+			if policy != tt.expected {
+				t.Errorf("expected policy %q, got %q", tt.expected, policy)
+			}
+		})
+	}
+}
+
+func TestDetector_IsDuplicate(t *testing.T) {
+	detector := NewDetector(Config{WindowDays: 3})
+	coffee := newTestExpense(t, "Coffee", 3.5, expensemodel.KindExpense, testDay)
+
+	tests := []struct {
+		name     string
+		other    *expensemodel.Expense
+		expected bool
+	}{
+		{name: "same description", other: newTestExpense(t, "coffee!", 3.5, expensemodel.KindExpense, testDay), expected: true},
+		{name: "contained description", other: newTestExpense(t, "Coffee at Bean Co", 3.5, expensemodel.KindExpense, testDay), expected: true},
+		{name: "typo", other: newTestExpense(t, "Cofee", 3.5, expensemodel.KindExpense, testDay), expected: true},
+		{name: "edge of the window", other: newTestExpense(t, "Coffee", 3.5, expensemodel.KindExpense, testDay.AddDate(0, 0, 3).Add(14*time.Hour)), expected: true},
+		{name: "outside the window", other: newTestExpense(t, "Coffee", 3.5, expensemodel.KindExpense, testDay.AddDate(0, 0, -4)), expected: false},
+		{name: "different amount", other: newTestExpense(t, "Coffee", 3.25, expensemodel.KindExpense, testDay), expected: false},
+		{name: "different kind", other: newTestExpense(t, "Coffee", 3.5, expensemodel.KindIncome, testDay), expected: false},
+		{name: "different description", other: newTestExpense(t, "Bus ticket", 3.5, expensemodel.KindExpense, testDay), expected: false},
+		{name: "itself", other: coffee, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detector.IsDuplicate(coffee, tt.other); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDetector_Candidates(t *testing.T) {
+	stored := newTestExpense(t, "Coffee", 3.5, expensemodel.KindExpense, testDay)
+	other := newTestExpense(t, "Lunch", 12, expensemodel.KindExpense, testDay)
+
+	var usedFilter irepository.ExpenseFilter
+	detector := NewDetector(Config{
+		ExpenseRepository: &repositorytest.ExpenseRepository{
+			StreamFunc: func(ctx context.Context, userId uuid.UUID, filter irepository.ExpenseFilter, each func(expense *expensemodel.Expense) error) error {
+				usedFilter = filter
+				return streamOf(stored, other)(ctx, userId, filter, each)
+			},
+		},
+		WindowDays: 2,
+	})
+
+	newCoffee := newTestExpense(t, "coffee", 3.5, expensemodel.KindExpense, testDay.AddDate(0, 0, 1))
+	candidates, err := detector.Candidates(testUserId, []*expensemodel.Expense{newCoffee})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(candidates) != 1 || len(candidates[0]) != 1 || candidates[0][0] != stored.ID() {
+		t.Errorf("expected the stored coffee as the only candidate, got %v", candidates)
+	}
+
+	expectedFrom := time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC)
+	if usedFilter.DateFrom == nil || !usedFilter.DateFrom.Equal(expectedFrom) {
+		t.Errorf("expected the stream to start at %v, got %v", expectedFrom, usedFilter.DateFrom)
+	}
+	if usedFilter.MinAmount == nil || *usedFilter.MinAmount != 3.5 || usedFilter.MaxAmount == nil || *usedFilter.MaxAmount != 3.5 {
+		t.Errorf("expected the stream to be narrowed to the amount of the expense")
+	}
+}
+
+func TestDetector_Groups(t *testing.T) {
+	// Streamed newest first, as the repository does.
+	first := newTestExpense(t, "Coffee", 3.5, expensemodel.KindExpense, testDay.AddDate(0, 0, 4))
+	second := newTestExpense(t, "coffee", 3.5, expensemodel.KindExpense, testDay.AddDate(0, 0, 2))
+	lunch := newTestExpense(t, "Lunch", 12, expensemodel.KindExpense, testDay.AddDate(0, 0, 1))
+	third := newTestExpense(t, "Coffee shop", 3.5, expensemodel.KindExpense, testDay)
+	lunchAgain := newTestExpense(t, "lunch", 12, expensemodel.KindExpense, testDay)
+	alone := newTestExpense(t, "Coffee", 3.5, expensemodel.KindExpense, testDay.AddDate(0, 0, -10))
+
+	detector := NewDetector(Config{
+		ExpenseRepository: &repositorytest.ExpenseRepository{StreamFunc: streamOf(first, second, lunch, third, lunchAgain, alone)},
+		WindowDays:        2,
+	})
+
+	groups, err := detector.Groups(testUserId)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := [][]uuid.UUID{
+		{first.ID(), second.ID(), third.ID()},
+		{lunch.ID(), lunchAgain.ID()},
+	}
+	if len(groups) != len(expected) {
+		t.Fatalf("expected %d groups, got %d", len(expected), len(groups))
+	}
+	for i, group := range groups {
+		if len(group) != len(expected[i]) {
+			t.Fatalf("expected group %d to have %d expenses, got %d", i, len(expected[i]), len(group))
+		}
+		for j, expense := range group {
+			if expense.ID() != expected[i][j] {
+				t.Errorf("unexpected expense %d of group %d", j, i)
+			}
+		}
+	}
+}
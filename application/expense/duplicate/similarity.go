@@ -0,0 +1,84 @@
+package expensedup
+
+import (
+	"strings"
+	"unicode"
+)
+
+// minSimilarity is the smallest edit similarity, from 0 to 1, of similar descriptions.
+const minSimilarity = 0.8
+
+// similarDescriptions reports whether two descriptions likely name the same transaction.
+// After ignoring case and punctuation they must be equal, the words of one must all appear
+// in the other (e.g., "Coffee" and "Coffee at Bean Co"), or they must differ by few edits.
+func similarDescriptions(a, b string) bool {
+	a, b = normalize(a), normalize(b)
+	if a == b {
+		return true
+	}
+	if a == "" || b == "" {
+		return false
+	}
+
+	wordsA, wordsB := strings.Fields(a), strings.Fields(b)
+	if containsAll(wordsA, wordsB) || containsAll(wordsB, wordsA) {
+		return true
+	}
+
+	return similarity(a, b) >= minSimilarity
+}
+
+// normalize lowercases a description and reduces it to its words separated by single spaces.
+func normalize(description string) string {
+	words := strings.FieldsFunc(strings.ToLower(description), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// containsAll reports whether every word of subset appears in words.
+func containsAll(words, subset []string) bool {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+	for _, word := range subset {
+		if !set[word] {
+			return false
+		}
+	}
+	return true
+}
+
+// similarity returns one minus the edit distance of a and b relative to the longer of them.
+func similarity(a, b string) float64 {
+	runesA, runesB := []rune(a), []rune(b)
+	longest := max(len(runesA), len(runesB))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(runesA, runesB))/float64(longest)
+}
+
+// levenshtein returns the minimum number of single rune insertions, deletions and
+// substitutions turning a into b.
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,10 @@
+package expensqry
+
+import (
+	"github.com/google/uuid"
+)
+
+// DuplicatesQuery represents a query for the groups of likely duplicates among the expenses of a user.
+type DuplicatesQuery struct {
+	UserID uuid.UUID // ID of the user whose expenses are scanned
+}
@@ -0,0 +1,29 @@
+package expensqry
+
+import (
+	iquery "github.com/beka-birhanu/finance-go/application/common/cqrs/query"
+	expensedup "github.com/beka-birhanu/finance-go/application/expense/duplicate"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+)
+
+// DuplicatesHandler handles queries for the likely duplicates among the expenses of a user.
+type DuplicatesHandler struct {
+	detector *expensedup.Detector // Detector of likely duplicate expenses
+}
+
+// Ensure DuplicatesHandler implements iquery.IHandler interface for DuplicatesQuery.
+var _ iquery.IHandler[*DuplicatesQuery, [][]*expensemodel.Expense] = &DuplicatesHandler{}
+
+// NewDuplicatesHandler creates a new instance of DuplicatesHandler with the given detector.
+func NewDuplicatesHandler(detector *expensedup.Detector) *DuplicatesHandler {
+	return &DuplicatesHandler{detector: detector}
+}
+
+// Handle processes a DuplicatesQuery by scanning every expense of the user.
+//
+// Returns:
+// - [][]*expensemodel.Expense: The groups of likely duplicates, newest first.
+// - error: An error if the retrieval fails.
+func (h *DuplicatesHandler) Handle(query *DuplicatesQuery) ([][]*expensemodel.Expense, error) {
+	return h.detector.Groups(query.UserID)
+}
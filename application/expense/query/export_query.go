@@ -0,0 +1,20 @@
+package expensqry
+
+import (
+	"context"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+	"github.com/google/uuid"
+)
+
+// ExportQuery represents a query for exporting every expense of a user that matches a filter.
+type ExportQuery struct {
+	Context context.Context           // Context of the export, e.g. of its request; cancelling it stops the export
+	UserID  uuid.UUID                 // ID of the user whose expenses are exported
+	Filter  irepository.ExpenseFilter // Optional filters, the same as for listing
+
+	// Each is called for every exported expense, newest first. Returning an error stops
+	// the export.
+	Each func(expense *expensemodel.Expense) error
+}
@@ -0,0 +1,51 @@
+package expensqry
+
+import (
+	"context"
+
+	iquery "github.com/beka-birhanu/finance-go/application/common/cqrs/query"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+)
+
+// ExportHandler handles queries for exporting expenses. Unlike GetMultipleHandler it is
+// not limited to a page: expenses are streamed one at a time to the caller.
+type ExportHandler struct {
+	expenseRepository irepository.IExpenseRepository // Repository for accessing expense data
+}
+
+// Ensure ExportHandler implements iquery.IHandler interface for ExportQuery.
+var _ iquery.IHandler[*ExportQuery, int] = &ExportHandler{}
+
+// NewExportHandler creates a new instance of ExportHandler with the given repository.
+func NewExportHandler(expenseRepository irepository.IExpenseRepository) *ExportHandler {
+	return &ExportHandler{expenseRepository: expenseRepository}
+}
+
+// Handle processes an ExportQuery, calling query.Each for every matching expense until
+// query.Context, or the background context if none is given, is cancelled.
+//
+// Returns:
+// - int: The number of expenses exported.
+// - error: An error if the filter is contradictory, the retrieval fails, or query.Each
+// fails; in the latter case the error of query.Each is returned as is.
+func (h *ExportHandler) Handle(query *ExportQuery) (int, error) {
+	if err := validateFilter(query.Filter); err != nil {
+		return 0, err
+	}
+
+	ctx := query.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	exported := 0
+	err := h.expenseRepository.Stream(ctx, query.UserID, query.Filter, func(expense *expensemodel.Expense) error {
+		if err := query.Each(expense); err != nil {
+			return err
+		}
+		exported++
+		return nil
+	})
+	return exported, err
+}
@@ -0,0 +1,80 @@
+package expensqry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	"github.com/beka-birhanu/finance-go/application/common/interface/repository/repositorytest"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+	"github.com/google/uuid"
+)
+
+func TestExportHandler_Handle(t *testing.T) {
+	userId := uuid.New()
+	errWrite := errors.New("client went away")
+	from := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		filter           irepository.ExpenseFilter
+		failAfter        int
+		expectedExported int
+		expectedError    error
+	}{
+		{name: "every expense", failAfter: -1, expectedExported: 3},
+		{name: "write failure", failAfter: 1, expectedExported: 1, expectedError: errWrite},
+		{name: "contradictory date range", filter: irepository.ExpenseFilter{DateFrom: &from, DateTo: &to}, expectedError: errdmn.NewValidation("")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			available := newExpenses(t, userId, 3)
+			handler := NewExportHandler(&repositorytest.ExpenseRepository{
+				StreamFunc: func(ctx context.Context, userId uuid.UUID, filter irepository.ExpenseFilter, each func(expense *expensemodel.Expense) error) error {
+					for _, expense := range available {
+						if err := each(expense); err != nil {
+							return err
+						}
+					}
+					return nil
+				},
+			})
+
+			var written []*expensemodel.Expense
+			exported, err := handler.Handle(&ExportQuery{
+				UserID: userId,
+				Filter: tt.filter,
+				Each: func(expense *expensemodel.Expense) error {
+					if len(written) == tt.failAfter {
+						return errWrite
+					}
+					written = append(written, expense)
+					return nil
+				},
+			})
+
+			switch {
+			case tt.expectedError == errWrite:
+				if !errors.Is(err, errWrite) {
+					t.Fatalf("expected the write error, got %v", err)
+				}
+			case tt.expectedError != nil:
+				domainErr, ok := err.(*errdmn.Error)
+				if !ok || domainErr.Type() != errdmn.Validation {
+					t.Fatalf("expected validation error, got %v", err)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if exported != tt.expectedExported || len(written) != tt.expectedExported {
+				t.Errorf("expected %d exported expenses, got %d (%d written)", tt.expectedExported, exported, len(written))
+			}
+		})
+	}
+}
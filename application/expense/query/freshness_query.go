@@ -0,0 +1,13 @@
+package expensqry
+
+import (
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	"github.com/google/uuid"
+)
+
+// FreshnessQuery represents a query for summarizing how recently the expenses matching a
+// filter changed, e.g., to validate a cached listing of them.
+type FreshnessQuery struct {
+	UserID uuid.UUID                 // ID of the user whose expenses are summarized
+	Filter irepository.ExpenseFilter // Optional filter the expenses must match
+}
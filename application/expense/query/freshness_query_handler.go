@@ -0,0 +1,33 @@
+package expensqry
+
+import (
+	iquery "github.com/beka-birhanu/finance-go/application/common/cqrs/query"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+)
+
+// FreshnessHandler handles queries for the freshness of the expenses matching a filter.
+type FreshnessHandler struct {
+	expenseRepository irepository.IExpenseRepository // Repository for accessing expense data
+}
+
+// Ensure FreshnessHandler implements iquery.IHandler interface for FreshnessQuery.
+var _ iquery.IHandler[*FreshnessQuery, *irepository.ExpenseFreshness] = &FreshnessHandler{}
+
+// NewFreshnessHandler creates a new instance of FreshnessHandler with the given repository.
+func NewFreshnessHandler(expenseRepository irepository.IExpenseRepository) *FreshnessHandler {
+	return &FreshnessHandler{expenseRepository: expenseRepository}
+}
+
+// Handle processes a FreshnessQuery. It is much cheaper than listing the expenses, so it
+// can tell whether a cached listing is still current before the listing is built again.
+//
+// Returns:
+//   - *irepository.ExpenseFreshness: The number of matching expenses and the latest update
+//     time among them.
+//   - error: An error if the filter is contradictory or the retrieval fails.
+func (h *FreshnessHandler) Handle(query *FreshnessQuery) (*irepository.ExpenseFreshness, error) {
+	if err := validateFilter(query.Filter); err != nil {
+		return nil, err
+	}
+	return h.expenseRepository.Freshness(query.UserID, query.Filter)
+}
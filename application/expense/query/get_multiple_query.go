@@ -1,18 +1,18 @@
 package expensqry
 
 import (
-	"time"
-
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
 	"github.com/google/uuid"
 )
 
 // GetMultipleQuery represents a query for retrieving multiple expenses.
 type GetMultipleQuery struct {
-	UserID       uuid.UUID  // ID of the user whose expenses are to be retrieved
-	Limit        int        // Maximum number of expenses to retrieve
-	By           string     // Field to sort by (e.g., "date", "amount")
-	LastSeenID   *uuid.UUID // ID of the last seen expense (for pagination)
-	LastSeenDate *time.Time // Time of the last seen expense (for pagination)
-	LastSeenAmt  float64    // Amount of the last seen expense (for pagination)
-	Ascending    bool       // Whether to sort in ascending order
+	UserID         uuid.UUID             // ID of the user whose expenses are to be retrieved
+	Limit          int                   // Maximum number of expenses to retrieve
+	Sort           []irepository.SortKey // Keys to sort by, most significant first; defaults to date descending
+	LastSeenID     *uuid.UUID            // ID of the last seen expense (for pagination)
+	LastSeenValues []interface{}         // Sort key values of the last seen expense (for pagination)
+	Backward       bool                  // Whether to page backwards from the last seen expense
+
+	Filter irepository.ExpenseFilter // Optional filters applied on top of pagination
 }
@@ -2,33 +2,56 @@
 package expensqry
 
 import (
+	"fmt"
+
+	iquery "github.com/beka-birhanu/finance-go/application/common/cqrs/query"
 	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
-	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	"github.com/google/uuid"
 )
 
 const (
-	defaultLimit = 10       // Default limit for the number of expenses to retrieve
-	minLimit     = 5        // Minimum limit for the number of expenses
-	maxLimit     = 100      // Maximum limit for the number of expenses
-	sortByAmount = "amount" // Field used for sorting by amount
+	defaultLimit = 10  // Default limit for the number of expenses to retrieve
+	minLimit     = 5   // Minimum limit for the number of expenses
+	maxLimit     = 100 // Maximum limit for the number of expenses
+	maxSortKeys  = 2   // Maximum number of sort keys: a primary and a secondary one
 )
 
+// defaultSort is the order used when a query does not specify one.
+var defaultSort = []irepository.SortKey{{Field: irepository.SortByDate, Ascending: false}}
+
 // GetMultipleHandler handles queries for retrieving multiple expenses.
 type GetMultipleHandler struct {
 	expenseRepository irepository.IExpenseRepository // Repository for accessing expense data
 }
 
+// Ensure GetMultipleHandler implements iquery.IHandler interface for GetMultipleQuery.
+var _ iquery.IHandler[*GetMultipleQuery, *Page] = &GetMultipleHandler{}
+
 // NewGetMultipleHandler creates a new instance of GetMultipleHandler with the given repository.
 func NewGetMultipleHandler(expenseRepository irepository.IExpenseRepository) *GetMultipleHandler {
 	return &GetMultipleHandler{expenseRepository: expenseRepository}
 }
 
-// Handle processes a GetMultipleQuery to retrieve multiple expenses based on the provided query parameters.
+// Handle processes a GetMultipleQuery to retrieve a page of expenses based on the provided query parameters.
 //
 // Returns:
-// - []*expensemodel.Expense: A slice of pointers to Expense models that match the query.
-// - error: An error if the retrieval fails, such as issues with accessing the repository.
-func (h *GetMultipleHandler) Handle(query *GetMultipleQuery) ([]*expensemodel.Expense, error) {
+// - *Page: The expenses that match the query along with paging information and the total count.
+// - error: An error if the filter is contradictory or the retrieval fails, such as issues
+// with accessing the repository.
+func (h *GetMultipleHandler) Handle(query *GetMultipleQuery) (*Page, error) {
+	if err := validateFilter(query.Filter); err != nil {
+		return nil, err
+	}
+
+	sort := query.Sort
+	if len(sort) == 0 {
+		sort = defaultSort
+	}
+	if err := validateSort(sort); err != nil {
+		return nil, err
+	}
+
 	// Set default limit if not provided
 	limit := defaultLimit
 	if query.Limit > 0 {
@@ -41,23 +64,74 @@ func (h *GetMultipleHandler) Handle(query *GetMultipleQuery) ([]*expensemodel.Ex
 		}
 	}
 
-	// Use amount-based pagination if specified
-	if query.By == sortByAmount {
-		return h.expenseRepository.ListByAmount(irepository.ListByAmountParams{
-			UserID:      query.UserID,
-			Limit:       limit,
-			LastSeenID:  query.LastSeenID,
-			LastSeenAmt: query.LastSeenAmt,
-			Ascending:   query.Ascending,
-		})
-	}
-
-	// Default to time-based pagination
-	return h.expenseRepository.ListByTime(irepository.ListByTimeParams{
-		UserID:       query.UserID,
-		Limit:        limit,
-		LastSeenID:   query.LastSeenID,
-		LastSeenDate: query.LastSeenDate,
-		Ascending:    query.Ascending,
+	// Fetch one extra expense to learn whether there is more to page through.
+	expenses, err := h.expenseRepository.List(irepository.ListParams{
+		UserID:         query.UserID,
+		Limit:          limit + 1,
+		Sort:           sort,
+		LastSeenID:     query.LastSeenID,
+		LastSeenValues: query.LastSeenValues,
+		Backward:       query.Backward,
+		Filter:         query.Filter,
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	totalCount, err := h.expenseRepository.Count(query.UserID, query.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	hasMore := len(expenses) > limit
+	if hasMore {
+		if query.Backward {
+			expenses = expenses[1:]
+		} else {
+			expenses = expenses[:limit]
+		}
+	}
+
+	hasCursor := query.LastSeenID != nil && *query.LastSeenID != uuid.Nil
+	page := &Page{Expenses: expenses, TotalCount: totalCount}
+	if query.Backward {
+		page.HasPreviousPage, page.HasNextPage = hasMore, hasCursor
+	} else {
+		page.HasNextPage, page.HasPreviousPage = hasMore, hasCursor
+	}
+	return page, nil
+}
+
+// validateSort rejects orders with too many or repeated sort keys.
+func validateSort(sort []irepository.SortKey) error {
+	if len(sort) > maxSortKeys {
+		return errdmn.NewValidation(fmt.Sprintf("at most %d sort keys are allowed.", maxSortKeys))
+	}
+
+	seen := make(map[string]bool, len(sort))
+	for _, key := range sort {
+		if seen[key.Field] {
+			return errdmn.NewValidation(fmt.Sprintf("sort key %q is repeated.", key.Field))
+		}
+		seen[key.Field] = true
+	}
+
+	return nil
+}
+
+// validateFilter rejects filters whose bounds can never match any expense.
+func validateFilter(filter irepository.ExpenseFilter) error {
+	if filter.DateFrom != nil && filter.DateTo != nil && filter.DateFrom.After(*filter.DateTo) {
+		return errdmn.NewValidation("filter date range is invalid: from is after to.")
+	}
+
+	if (filter.MinAmount != nil && *filter.MinAmount < 0) || (filter.MaxAmount != nil && *filter.MaxAmount < 0) {
+		return errdmn.NewValidation("filter amount bounds cannot be negative.")
+	}
+
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount {
+		return errdmn.NewValidation("filter amount range is invalid: minAmount is greater than maxAmount.")
+	}
+
+	return nil
 }
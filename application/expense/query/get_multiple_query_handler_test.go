@@ -0,0 +1,128 @@
+package expensqry
+
+import (
+	"testing"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	"github.com/beka-birhanu/finance-go/application/common/interface/repository/repositorytest"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+	"github.com/google/uuid"
+)
+
+// newExpenses creates n valid expenses for the given user.
+func newExpenses(t *testing.T, userId uuid.UUID, n int) []*expensemodel.Expense {
+	expenses := make([]*expensemodel.Expense, 0, n)
+	for i := 0; i < n; i++ {
+		expense, err := expensemodel.New(expensemodel.Config{
+			Description:  "Coffee",
+			Amount:       float32(i + 1),
+			UserId:       userId,
+			Date:         time.Now().UTC(),
+			CreationTime: time.Now().UTC(),
+		})
+		if err != nil {
+			t.Fatalf("failed to create expense: %v", err)
+		}
+		expenses = append(expenses, expense)
+	}
+	return expenses
+}
+
+func TestGetMultipleHandler_Handle(t *testing.T) {
+	userId := uuid.New()
+	lastSeenID := uuid.New()
+	from := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name                string
+		query               *GetMultipleQuery
+		available           int
+		expectedLen         int
+		expectedHasNext     bool
+		expectedHasPrevious bool
+		expectedError       error
+	}{
+		{
+			name:            "first page with more expenses",
+			query:           &GetMultipleQuery{UserID: userId, Limit: 5},
+			available:       6,
+			expectedLen:     5,
+			expectedHasNext: true,
+		},
+		{
+			name:                "last page after a cursor",
+			query:               &GetMultipleQuery{UserID: userId, Limit: 5, LastSeenID: &lastSeenID},
+			available:           3,
+			expectedLen:         3,
+			expectedHasPrevious: true,
+		},
+		{
+			name:                "backward page with more expenses",
+			query:               &GetMultipleQuery{UserID: userId, Limit: 5, LastSeenID: &lastSeenID, Backward: true},
+			available:           6,
+			expectedLen:         5,
+			expectedHasNext:     true,
+			expectedHasPrevious: true,
+		},
+		{
+			name: "repeated sort key",
+			query: &GetMultipleQuery{UserID: userId, Sort: []irepository.SortKey{
+				{Field: irepository.SortByAmount}, {Field: irepository.SortByAmount, Ascending: true},
+			}},
+			expectedError: errdmn.NewValidation(""),
+		},
+		{
+			name:          "contradictory date range",
+			query:         &GetMultipleQuery{UserID: userId, Filter: irepository.ExpenseFilter{DateFrom: &from, DateTo: &to}},
+			expectedError: errdmn.NewValidation(""),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			available := newExpenses(t, userId, tt.available)
+			handler := NewGetMultipleHandler(&repositorytest.ExpenseRepository{
+				ListFunc: func(params irepository.ListParams) ([]*expensemodel.Expense, error) {
+					if params.Limit < len(available) {
+						return available[:params.Limit], nil
+					}
+					return available, nil
+				},
+				CountFunc: func(userId uuid.UUID, filter irepository.ExpenseFilter) (int, error) {
+					return 42, nil
+				},
+			})
+
+			page, err := handler.Handle(tt.query)
+			if tt.expectedError != nil {
+				domainErr, ok := err.(*errdmn.Error)
+				if !ok || domainErr.Type() != errdmn.Validation {
+					t.Fatalf("expected validation error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(page.Expenses) != tt.expectedLen {
+				t.Errorf("expected %d expenses, got %d", tt.expectedLen, len(page.Expenses))
+			}
+			if page.HasNextPage != tt.expectedHasNext {
+				t.Errorf("expected hasNextPage %v, got %v", tt.expectedHasNext, page.HasNextPage)
+			}
+			if page.HasPreviousPage != tt.expectedHasPrevious {
+				t.Errorf("expected hasPreviousPage %v, got %v", tt.expectedHasPrevious, page.HasPreviousPage)
+			}
+			if page.TotalCount != 42 {
+				t.Errorf("expected total count 42, got %d", page.TotalCount)
+			}
+			if tt.query.Backward && len(page.Expenses) > 0 && page.Expenses[0] != available[1] {
+				t.Errorf("expected the extra expense to be trimmed from the start of a backward page")
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package expensqry
+
+import expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+
+// Page holds one page of expenses together with the information needed to keep paging
+// in either direction.
+type Page struct {
+	Expenses        []*expensemodel.Expense // Expenses in the requested sort order
+	HasNextPage     bool                    // Whether more expenses follow the last one
+	HasPreviousPage bool                    // Whether more expenses precede the first one
+	TotalCount      int                     // Number of expenses matching the filter, across all pages
+}
@@ -0,0 +1,124 @@
+// Package idempotency provides functionality for making requests idempotent: the response to
+// the first request a user makes with an idempotency key is stored, and repeating the
+// request with the same key replays it instead of processing the request again.
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	itimeservice "github.com/beka-birhanu/finance-go/application/common/interface/time_service"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultTTL is how long responses are stored when no time window is configured.
+	DefaultTTL = 24 * time.Hour
+
+	// maxKeyLength is the maximum length of an idempotency key.
+	maxKeyLength = 255
+)
+
+// Service stores and replays the responses to idempotent requests.
+type Service struct {
+	repository irepository.IIdempotencyRepository // Repository for stored responses
+	timeSvc    itimeservice.IService              // Service for time-related operations
+	ttl        time.Duration                      // How long responses are stored
+}
+
+// Config holds dependencies required for creating a Service.
+type Config struct {
+	Repository  irepository.IIdempotencyRepository // Repository for stored responses
+	TimeService itimeservice.IService              // Service for time-related operations
+	TTL         time.Duration                      // How long responses are stored; defaults to 24 hours
+}
+
+// NewService creates a new Service with the specified configuration.
+func NewService(config Config) *Service {
+	ttl := config.TTL
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	return &Service{
+		repository: config.Repository,
+		timeSvc:    config.TimeService,
+		ttl:        ttl,
+	}
+}
+
+// Begin claims the key of the user for a request, identified by its scope (e.g., the
+// operation it performs) and payload.
+//
+// Returns:
+//   - []byte: The stored response when the key was already used for the same request, to be
+//     replayed; nil when the request is to be processed, after which Complete or Release
+//     must be called.
+//   - error: A validation error if the key is empty or too long, an unprocessable error if
+//     the key was used for a different request, and a conflict error if the first request
+//     with the key is still in progress.
+func (s *Service) Begin(userId uuid.UUID, key string, scope string, payload []byte) ([]byte, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	hash := requestHash(scope, payload)
+	now := s.timeSvc.NowUTC()
+	existing, err := s.repository.Reserve(&irepository.IdempotencyRecord{
+		UserID:      userId,
+		Key:         key,
+		RequestHash: hash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.ttl),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, nil
+	}
+
+	if existing.RequestHash != hash {
+		return nil, apperror.IdempotencyKeyReused()
+	}
+	if existing.Response == nil {
+		return nil, errdmn.NewConflict("a request with this idempotency key is still in progress.")
+	}
+	return existing.Response, nil
+}
+
+// Complete stores the response to the request the key of the user was claimed for.
+func (s *Service) Complete(userId uuid.UUID, key string, response []byte) error {
+	return s.repository.Complete(userId, key, response)
+}
+
+// Release frees the key of the user after its request failed, so that it can be retried.
+func (s *Service) Release(userId uuid.UUID, key string) error {
+	return s.repository.Release(userId, key)
+}
+
+// validateKey rejects empty and overlong idempotency keys.
+func validateKey(key string) error {
+	if strings.TrimSpace(key) == "" {
+		return errdmn.NewValidation("the idempotency key cannot be empty.")
+	}
+	if len(key) > maxKeyLength {
+		return errdmn.NewValidation(fmt.Sprintf("the idempotency key cannot be longer than %d characters.", maxKeyLength))
+	}
+	return nil
+}
+
+// requestHash returns the fingerprint of a request.
+func requestHash(scope string, payload []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(scope))
+	hash.Write([]byte{0})
+	hash.Write(payload)
+	return hex.EncodeToString(hash.Sum(nil))
+}
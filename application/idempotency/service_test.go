@@ -0,0 +1,122 @@
+package idempotency
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	ierr "github.com/beka-birhanu/finance-go/domain/common/error"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	"github.com/google/uuid"
+)
+
+type MockIdempotencyRepository struct {
+	ReserveFunc  func(record *irepository.IdempotencyRecord) (*irepository.IdempotencyRecord, error)
+	CompleteFunc func(userId uuid.UUID, key string, response []byte) error
+	ReleaseFunc  func(userId uuid.UUID, key string) error
+}
+
+func (m *MockIdempotencyRepository) Reserve(record *irepository.IdempotencyRecord) (*irepository.IdempotencyRecord, error) {
+	return m.ReserveFunc(record)
+}
+
+func (m *MockIdempotencyRepository) Complete(userId uuid.UUID, key string, response []byte) error {
+	return m.CompleteFunc(userId, key, response)
+}
+
+func (m *MockIdempotencyRepository) Release(userId uuid.UUID, key string) error {
+	return m.ReleaseFunc(userId, key)
+}
+
+var _ irepository.IIdempotencyRepository = &MockIdempotencyRepository{}
+
+type MockTimeService struct{}
+
+func (m *MockTimeService) NowUTC() time.Time {
+	return time.Now().UTC()
+}
+
+func TestService_Begin(t *testing.T) {
+	userId := uuid.New()
+	scope := "POST /users/expenses"
+	payload := []byte(`{"description":"Coffee"}`)
+	response := []byte(`{"id":"1"}`)
+
+	tests := []struct {
+		name             string
+		key              string
+		existing         *irepository.IdempotencyRecord
+		expectedResponse []byte
+		expectedError    string
+	}{
+		{
+			name: "new key",
+			key:  "key-1",
+		},
+		{
+			name:             "same request replays the stored response",
+			key:              "key-1",
+			existing:         &irepository.IdempotencyRecord{RequestHash: requestHash(scope, payload), Response: response},
+			expectedResponse: response,
+		},
+		{
+			name:          "different request",
+			key:           "key-1",
+			existing:      &irepository.IdempotencyRecord{RequestHash: requestHash(scope, []byte(`{}`)), Response: response},
+			expectedError: apperror.Unprocessable,
+		},
+		{
+			name:          "first request still in progress",
+			key:           "key-1",
+			existing:      &irepository.IdempotencyRecord{RequestHash: requestHash(scope, payload)},
+			expectedError: errdmn.Conflict,
+		},
+		{
+			name:          "empty key",
+			key:           " ",
+			expectedError: errdmn.Validation,
+		},
+		{
+			name:          "overlong key",
+			key:           strings.Repeat("k", maxKeyLength+1),
+			expectedError: errdmn.Validation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reserved *irepository.IdempotencyRecord
+			service := NewService(Config{
+				Repository: &MockIdempotencyRepository{
+					ReserveFunc: func(record *irepository.IdempotencyRecord) (*irepository.IdempotencyRecord, error) {
+						reserved = record
+						return tt.existing, nil
+					},
+				},
+				TimeService: &MockTimeService{},
+			})
+
+			stored, err := service.Begin(userId, tt.key, scope, payload)
+			if tt.expectedError != "" {
+				appErr, ok := err.(ierr.IErr)
+				if !ok || appErr.Type() != tt.expectedError {
+					t.Fatalf("expected %s error, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !bytes.Equal(stored, tt.expectedResponse) {
+				t.Errorf("expected response %q, got %q", tt.expectedResponse, stored)
+			}
+			if reserved.UserID != userId || reserved.Key != tt.key || reserved.ExpiresAt.Sub(reserved.CreatedAt) != DefaultTTL {
+				t.Errorf("unexpected reserved record: %+v", reserved)
+			}
+		})
+	}
+}
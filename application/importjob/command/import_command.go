@@ -0,0 +1,30 @@
+package importcmd
+
+import (
+	"io"
+
+	iimporter "github.com/beka-birhanu/finance-go/application/common/interface/importer"
+	expensedup "github.com/beka-birhanu/finance-go/application/expense/duplicate"
+	"github.com/google/uuid"
+)
+
+// ImportCommand represents the command to import a file of expenses.
+type ImportCommand struct {
+	// UserId: The unique identifier of the user to whom the expenses belong.
+	UserId uuid.UUID
+
+	// Format: The format of the file (e.g., "csv").
+	Format string
+
+	// File: The content of the file.
+	File io.Reader
+
+	// Options: How the file is parsed.
+	Options iimporter.Options
+
+	// DryRun: Only validate the rows, without inserting any expense.
+	DryRun bool
+
+	// OnDuplicate: What happens when rows likely duplicate stored expenses; defaults to warn.
+	OnDuplicate expensedup.Policy
+}
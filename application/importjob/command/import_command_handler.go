@@ -0,0 +1,193 @@
+// Package importcmd provides functionality for handling commands related to imports.
+package importcmd
+
+import (
+	"fmt"
+	"time"
+
+	icmd "github.com/beka-birhanu/finance-go/application/common/cqrs/command"
+	iimporter "github.com/beka-birhanu/finance-go/application/common/interface/importer"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	itimeservice "github.com/beka-birhanu/finance-go/application/common/interface/time_service"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	expensedup "github.com/beka-birhanu/finance-go/application/expense/duplicate"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+	importjobmodel "github.com/beka-birhanu/finance-go/domain/model/importjob"
+	"github.com/google/uuid"
+)
+
+// maxImportRows is the maximum number of rows of an imported file.
+const maxImportRows = 10000
+
+// ImportHandler handles commands for importing files of expenses.
+type ImportHandler struct {
+	importJobRepository irepository.IImportJobRepository // Repository for import jobs
+	expenseRepository   irepository.IExpenseRepository   // Repository for expenses
+	timeSvc             itimeservice.IService            // Service for time-related operations
+	parsers             map[string]iimporter.IParser     // Parsers by file format
+	detector            *expensedup.Detector             // Detector of likely duplicate expenses
+}
+
+// Ensure ImportHandler implements icmd.IHandler[*ImportCommand, *importjobmodel.ImportJob].
+var _ icmd.IHandler[*ImportCommand, *importjobmodel.ImportJob] = &ImportHandler{}
+
+// Config holds dependencies required for creating an ImportHandler.
+type Config struct {
+	ImportJobRepository irepository.IImportJobRepository // Repository for import jobs
+	ExpenseRepository   irepository.IExpenseRepository   // Repository for expenses
+	TimeService         itimeservice.IService            // Service for time-related operations
+	Parsers             map[string]iimporter.IParser     // Parsers by file format
+	DuplicateDetector   *expensedup.Detector             // Detector of likely duplicate expenses
+}
+
+// NewImportHandler creates a new ImportHandler with the specified configuration.
+func NewImportHandler(config Config) *ImportHandler {
+	return &ImportHandler{
+		importJobRepository: config.ImportJobRepository,
+		expenseRepository:   config.ExpenseRepository,
+		timeSvc:             config.TimeService,
+		parsers:             config.Parsers,
+		detector:            config.DuplicateDetector,
+	}
+}
+
+// Handle processes an ImportCommand. Every row of the file is validated as an expense and
+// its outcome recorded in an import job. In dry-run mode nothing else happens; otherwise
+// the valid rows are inserted as expenses together with the job, while invalid rows are
+// skipped. Rows whose transaction was already imported, or appears earlier in the same
+// file, are marked as duplicates and skipped as well. Valid rows that likely duplicate
+// stored expenses are imported with a warning listing them, or, under the reject policy,
+// make the whole import fail with an *apperror.DuplicateError.
+func (h *ImportHandler) Handle(command *ImportCommand) (*importjobmodel.ImportJob, error) {
+	parser, ok := h.parsers[command.Format]
+	if !ok {
+		return nil, errdmn.NewValidation(fmt.Sprintf("unsupported import format %q.", command.Format))
+	}
+
+	parsedRows, err := parser.Parse(command.File, command.Options)
+	if err != nil {
+		return nil, err
+	}
+	if len(parsedRows) > maxImportRows {
+		return nil, errdmn.NewValidation(fmt.Sprintf("an import cannot contain more than %d rows.", maxImportRows))
+	}
+
+	imported, err := h.importedExternalIDs(command.UserId, parsedRows)
+	if err != nil {
+		return nil, err
+	}
+
+	now := h.timeSvc.NowUTC()
+	rows := make([]importjobmodel.Row, 0, len(parsedRows))
+	var expenses []*expensemodel.Expense
+	var expenseRows []int // Position in rows of the row of each expense
+	for _, parsedRow := range parsedRows {
+		row := importjobmodel.Row{Line: parsedRow.Line}
+		expense, err := newExpense(command, parsedRow, now)
+		switch {
+		case err != nil:
+			row.Error = err.Error()
+		case expense.ExternalID() != "" && imported[expense.ExternalID()]:
+			row.Duplicate = true
+		default:
+			if expense.ExternalID() != "" {
+				imported[expense.ExternalID()] = true
+			}
+			expenses = append(expenses, expense)
+			expenseRows = append(expenseRows, len(rows))
+		}
+		rows = append(rows, row)
+	}
+
+	if err := h.flagPossibleDuplicates(command, rows, expenses, expenseRows); err != nil {
+		return nil, err
+	}
+	if !command.DryRun {
+		for i, expense := range expenses {
+			rows[expenseRows[i]].ExpenseID = expense.ID()
+		}
+	}
+
+	job, err := importjobmodel.New(importjobmodel.Config{
+		UserId:       command.UserId,
+		Format:       command.Format,
+		Rows:         rows,
+		DryRun:       command.DryRun,
+		CreationTime: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if command.DryRun {
+		err = h.importJobRepository.Save(job)
+	} else {
+		err = h.importJobRepository.Commit(job, expenses)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// flagPossibleDuplicates records on their rows the stored expenses the expenses likely
+// duplicate. Under the reject policy any likely duplicate fails the import instead.
+func (h *ImportHandler) flagPossibleDuplicates(command *ImportCommand, rows []importjobmodel.Row, expenses []*expensemodel.Expense, expenseRows []int) error {
+	candidates, err := h.detector.Candidates(command.UserId, expenses)
+	if err != nil {
+		return err
+	}
+
+	duplicateErr := &apperror.DuplicateError{}
+	for i := range expenses {
+		ids, ok := candidates[i]
+		if !ok {
+			continue
+		}
+		row := &rows[expenseRows[i]]
+		row.PossibleDuplicates = ids
+		duplicateErr.Duplicates = append(duplicateErr.Duplicates, apperror.Duplicate{Index: row.Line, IDs: ids})
+	}
+
+	if len(duplicateErr.Duplicates) > 0 && command.OnDuplicate == expensedup.PolicyReject {
+		return duplicateErr
+	}
+	return nil
+}
+
+// importedExternalIDs returns which external IDs of the parsed rows already belong to an
+// expense of the user.
+func (h *ImportHandler) importedExternalIDs(userId uuid.UUID, parsedRows []iimporter.Row) (map[string]bool, error) {
+	var externalIds []string
+	for _, row := range parsedRows {
+		if row.Err == nil && row.Record.ExternalID != "" {
+			externalIds = append(externalIds, row.Record.ExternalID)
+		}
+	}
+	if len(externalIds) == 0 {
+		return map[string]bool{}, nil
+	}
+
+	return h.expenseRepository.ExistingExternalIDs(userId, externalIds)
+}
+
+// newExpense validates a parsed row as an expense of the importing user.
+func newExpense(command *ImportCommand, row iimporter.Row, currentTime time.Time) (*expensemodel.Expense, error) {
+	if row.Err != nil {
+		return nil, row.Err
+	}
+
+	return expensemodel.New(expensemodel.Config{
+		Description:  row.Record.Description,
+		Amount:       row.Record.Amount,
+		Kind:         row.Record.Kind,
+		ExternalID:   row.Record.ExternalID,
+		UserId:       command.UserId,
+		Date:         row.Record.Date,
+		Category:     row.Record.Category,
+		Account:      row.Record.Account,
+		CreationTime: currentTime,
+	})
+}
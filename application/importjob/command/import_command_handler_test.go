@@ -0,0 +1,200 @@
+package importcmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	iimporter "github.com/beka-birhanu/finance-go/application/common/interface/importer"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	"github.com/beka-birhanu/finance-go/application/common/interface/repository/repositorytest"
+	apperror "github.com/beka-birhanu/finance-go/application/error"
+	expensedup "github.com/beka-birhanu/finance-go/application/expense/duplicate"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+	importjobmodel "github.com/beka-birhanu/finance-go/domain/model/importjob"
+	"github.com/google/uuid"
+)
+
+// MockImportJobRepository is a mock implementation of the IImportJobRepository interface.
+type MockImportJobRepository struct {
+	SaveFunc   func(job *importjobmodel.ImportJob) error
+	CommitFunc func(job *importjobmodel.ImportJob, expenses []*expensemodel.Expense) error
+}
+
+func (m *MockImportJobRepository) Save(job *importjobmodel.ImportJob) error {
+	return m.SaveFunc(job)
+}
+
+func (m *MockImportJobRepository) Commit(job *importjobmodel.ImportJob, expenses []*expensemodel.Expense) error {
+	return m.CommitFunc(job, expenses)
+}
+
+func (m *MockImportJobRepository) Undo(job *importjobmodel.ImportJob) error {
+	return nil
+}
+
+func (m *MockImportJobRepository) ById(id uuid.UUID, userId uuid.UUID) (*importjobmodel.ImportJob, error) {
+	return nil, nil
+}
+
+var _ irepository.IImportJobRepository = &MockImportJobRepository{}
+
+// MockParser is a mock implementation of the IParser interface.
+type MockParser struct {
+	Rows []iimporter.Row
+}
+
+func (m *MockParser) Parse(r io.Reader, options iimporter.Options) ([]iimporter.Row, error) {
+	return m.Rows, nil
+}
+
+type MockTimeService struct{}
+
+func (m *MockTimeService) NowUTC() time.Time {
+	return time.Now().UTC()
+}
+
+func TestImportHandler_Handle(t *testing.T) {
+	userId := uuid.New()
+	rows := []iimporter.Row{
+		{Line: 2, Record: iimporter.Record{Date: time.Now().UTC(), Description: "Coffee", Amount: 3.5, ExternalID: "fit-1"}},
+		{Line: 3, Err: errors.New("invalid amount \"abc\"")},
+		{Line: 4, Record: iimporter.Record{Date: time.Now().UTC(), Description: "", Amount: 3.5}},
+		{Line: 5, Record: iimporter.Record{Date: time.Now().UTC(), Description: "Salary", Amount: 2500, Kind: expensemodel.KindIncome, ExternalID: "fit-2"}},
+		{Line: 6, Record: iimporter.Record{Date: time.Now().UTC(), Description: "Coffee", Amount: 3.5, ExternalID: "fit-1"}},
+	}
+
+	storedCoffee, err := expensemodel.New(expensemodel.Config{
+		Description:  "coffee",
+		Amount:       3.5,
+		UserId:       userId,
+		Date:         time.Now().UTC().AddDate(0, 0, -1),
+		CreationTime: time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name               string
+		format             string
+		dryRun             bool
+		stored             []*expensemodel.Expense
+		onDuplicate        expensedup.Policy
+		expectedStatus     importjobmodel.Status
+		expectedCommitted  int
+		expectedPossible   int
+		expectedValidation bool
+		expectedDuplicate  bool
+	}{
+		{name: "dry run", format: "csv", dryRun: true, expectedStatus: importjobmodel.StatusValidated},
+		{name: "commit", format: "csv", expectedStatus: importjobmodel.StatusCommitted, expectedCommitted: 1},
+		{name: "unsupported format", format: "xlsx", expectedValidation: true},
+		{
+			name:              "warn about likely duplicate",
+			format:            "csv",
+			stored:            []*expensemodel.Expense{storedCoffee},
+			onDuplicate:       expensedup.PolicyWarn,
+			expectedStatus:    importjobmodel.StatusCommitted,
+			expectedCommitted: 1,
+			expectedPossible:  1,
+		},
+		{
+			name:              "reject likely duplicate",
+			format:            "csv",
+			stored:            []*expensemodel.Expense{storedCoffee},
+			onDuplicate:       expensedup.PolicyReject,
+			expectedDuplicate: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved, committed := false, -1
+			expenseRepository := &repositorytest.ExpenseRepository{
+				ExistingExternalIDsFunc: func(userId uuid.UUID, externalIds []string) (map[string]bool, error) {
+					return map[string]bool{"fit-2": true}, nil
+				},
+				StreamFunc: func(ctx context.Context, userId uuid.UUID, filter irepository.ExpenseFilter, each func(expense *expensemodel.Expense) error) error {
+					for _, expense := range tt.stored {
+						if err := each(expense); err != nil {
+							return err
+						}
+					}
+					return nil
+				},
+			}
+			handler := NewImportHandler(Config{
+				ImportJobRepository: &MockImportJobRepository{
+					SaveFunc: func(job *importjobmodel.ImportJob) error {
+						saved = true
+						return nil
+					},
+					CommitFunc: func(job *importjobmodel.ImportJob, expenses []*expensemodel.Expense) error {
+						committed = len(expenses)
+						return nil
+					},
+				},
+				ExpenseRepository: expenseRepository,
+				TimeService:       &MockTimeService{},
+				Parsers:           map[string]iimporter.IParser{"csv": &MockParser{Rows: rows}},
+				DuplicateDetector: expensedup.NewDetector(expensedup.Config{
+					ExpenseRepository: expenseRepository,
+					WindowDays:        expensedup.DefaultWindowDays,
+				}),
+			})
+
+			job, err := handler.Handle(&ImportCommand{
+				UserId:      userId,
+				Format:      tt.format,
+				File:        strings.NewReader(""),
+				DryRun:      tt.dryRun,
+				OnDuplicate: tt.onDuplicate,
+			})
+			if tt.expectedDuplicate {
+				var duplicateErr *apperror.DuplicateError
+				if !errors.As(err, &duplicateErr) || len(duplicateErr.Duplicates) != 1 || duplicateErr.Duplicates[0].Index != 2 {
+					t.Fatalf("expected a duplicate error for line 2, got %v", err)
+				}
+				if saved || committed != -1 {
+					t.Errorf("expected a rejected import to save nothing")
+				}
+				return
+			}
+			if tt.expectedValidation {
+				domainErr, ok := err.(*errdmn.Error)
+				if !ok || domainErr.Type() != errdmn.Validation {
+					t.Fatalf("expected validation error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if job.Status() != tt.expectedStatus {
+				t.Errorf("expected status %s, got %s", tt.expectedStatus, job.Status())
+			}
+			// Line 5 was imported before and line 6 repeats line 2.
+			if job.ValidRows() != 3 || job.InvalidRows() != 2 || job.DuplicateRows() != 2 {
+				t.Errorf("unexpected row counts: %d valid, %d invalid, %d duplicates", job.ValidRows(), job.InvalidRows(), job.DuplicateRows())
+			}
+			if tt.dryRun {
+				if !saved || committed != -1 {
+					t.Errorf("expected a dry run to be saved without committing expenses")
+				}
+				return
+			}
+			if committed != tt.expectedCommitted || job.Rows()[0].ExpenseID == uuid.Nil {
+				t.Errorf("expected %d committed expenses, got %d", tt.expectedCommitted, committed)
+			}
+			if possible := len(job.Rows()[0].PossibleDuplicates); possible != tt.expectedPossible {
+				t.Errorf("expected %d possible duplicates, got %d", tt.expectedPossible, possible)
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package importcmd
+
+import "github.com/google/uuid"
+
+// UndoCommand represents the command to undo a committed import.
+type UndoCommand struct {
+	UserId   uuid.UUID // Identifier of the user who owns the import
+	ImportId uuid.UUID // Identifier of the import to undo
+}
@@ -0,0 +1,51 @@
+// Package importcmd provides functionality for handling commands related to imports.
+package importcmd
+
+import (
+	icmd "github.com/beka-birhanu/finance-go/application/common/cqrs/command"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	itimeservice "github.com/beka-birhanu/finance-go/application/common/interface/time_service"
+	importjobmodel "github.com/beka-birhanu/finance-go/domain/model/importjob"
+)
+
+// UndoHandler handles commands for undoing committed imports.
+type UndoHandler struct {
+	importJobRepository irepository.IImportJobRepository // Repository for import jobs
+	timeSvc             itimeservice.IService            // Service for time-related operations
+}
+
+// Ensure UndoHandler implements icmd.IHandler[*UndoCommand, *importjobmodel.ImportJob].
+var _ icmd.IHandler[*UndoCommand, *importjobmodel.ImportJob] = &UndoHandler{}
+
+// UndoConfig holds dependencies required for creating an UndoHandler.
+type UndoConfig struct {
+	ImportJobRepository irepository.IImportJobRepository // Repository for import jobs
+	TimeService         itimeservice.IService            // Service for time-related operations
+}
+
+// NewUndoHandler creates a new UndoHandler with the specified configuration.
+func NewUndoHandler(config UndoConfig) *UndoHandler {
+	return &UndoHandler{
+		importJobRepository: config.ImportJobRepository,
+		timeSvc:             config.TimeService,
+	}
+}
+
+// Handle processes an UndoCommand, deleting the expenses created by the import and
+// marking it undone. Only committed imports can be undone.
+func (h *UndoHandler) Handle(command *UndoCommand) (*importjobmodel.ImportJob, error) {
+	job, err := h.importJobRepository.ById(command.ImportId, command.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := job.Undo(h.timeSvc.NowUTC()); err != nil {
+		return nil, err
+	}
+
+	if err := h.importJobRepository.Undo(job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
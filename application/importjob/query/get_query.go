@@ -0,0 +1,9 @@
+package importqry
+
+import "github.com/google/uuid"
+
+// GetQuery represents the query to retrieve an import job.
+type GetQuery struct {
+	UserId   uuid.UUID // Identifier of the user who owns the import
+	ImportId uuid.UUID // Identifier of the import
+}
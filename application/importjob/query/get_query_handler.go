@@ -0,0 +1,30 @@
+// Package importqry provides functionality for handling queries related to imports.
+package importqry
+
+import (
+	iquery "github.com/beka-birhanu/finance-go/application/common/cqrs/query"
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	importjobmodel "github.com/beka-birhanu/finance-go/domain/model/importjob"
+)
+
+// GetHandler processes queries to retrieve a specific import job.
+type GetHandler struct {
+	importJobRepository irepository.IImportJobRepository
+}
+
+// Ensure GetHandler implements iquery.IHandler interface for GetQuery.
+var _ iquery.IHandler[*GetQuery, *importjobmodel.ImportJob] = &GetHandler{}
+
+// NewGetHandler creates a new instance of GetHandler with the provided import job repository.
+func NewGetHandler(importJobRepository irepository.IImportJobRepository) *GetHandler {
+	return &GetHandler{importJobRepository: importJobRepository}
+}
+
+// Handle retrieves an import job based on the provided query parameters.
+//
+// Returns:
+//   - *importjobmodel.ImportJob: The retrieved import job if found.
+//   - error: An error if the retrieval fails.
+func (h *GetHandler) Handle(query *GetQuery) (*importjobmodel.ImportJob, error) {
+	return h.importJobRepository.ById(query.ImportId, query.UserId)
+}
@@ -0,0 +1,110 @@
+// Command journal exports the expenses of a user as a beancount or ledger-cli journal.
+//
+// Usage:
+//
+//	journal -user <id> [-format beancount|ledger] [-currency USD] [-from 2024-01-01] [-to 2024-12-31]
+//	        [-category name] [-account name] [-o file]
+//
+// The database is configured through the same environment variables as the server. The
+// journal is written to standard output unless -o names a file.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	expensqry "github.com/beka-birhanu/finance-go/application/expense/query"
+	"github.com/beka-birhanu/finance-go/config"
+	"github.com/beka-birhanu/finance-go/infrastructure/db"
+	journalexporter "github.com/beka-birhanu/finance-go/infrastructure/exporter/journal"
+	expenserepo "github.com/beka-birhanu/finance-go/infrastructure/repository/expense"
+	"github.com/google/uuid"
+)
+
+func main() {
+	var (
+		userID   = flag.String("user", "", "ID of the user whose expenses are exported (required)")
+		format   = flag.String("format", journalexporter.Beancount, "journal format: beancount or ledger")
+		currency = flag.String("currency", journalexporter.DefaultCurrency, "currency of the amounts")
+		from     = flag.String("from", "", "only export expenses on or after this date (YYYY-MM-DD)")
+		to       = flag.String("to", "", "only export expenses on or before this date (YYYY-MM-DD)")
+		category = flag.String("category", "", "only export expenses of this category")
+		account  = flag.String("account", "", "only export expenses of this account")
+		output   = flag.String("o", "", "file to write the journal to; standard output by default")
+	)
+	flag.Parse()
+
+	userId, err := uuid.Parse(*userID)
+	if err != nil {
+		log.Fatalf("invalid -user %q: must be a user ID", *userID)
+	}
+
+	filter := irepository.ExpenseFilter{Category: *category, Account: *account}
+	if filter.DateFrom, err = parseDate(*from, false); err != nil {
+		log.Fatalf("invalid -from: %v", err)
+	}
+	if filter.DateTo, err = parseDate(*to, true); err != nil {
+		log.Fatalf("invalid -to: %v", err)
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			log.Fatalf("could not create %s: %v", *output, err)
+		}
+		defer file.Close()
+		out = file
+	}
+	writer, err := journalexporter.New(out, *format, *currency)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	database := db.Connect(db.Config{
+		DbUser:     config.Envs.DBUser,
+		DbPassword: config.Envs.DBPassword,
+		DbName:     config.Envs.DBName,
+		DbHost:     config.Envs.DBHost,
+		DbPort:     config.Envs.DBPort,
+	})
+	defer database.Close()
+
+	exportHandler := expensqry.NewExportHandler(expenserepo.New(database))
+	exported, err := exportHandler.Handle(&expensqry.ExportQuery{
+		UserID: userId,
+		Filter: filter,
+		Each:   writer.Write,
+	})
+	if err != nil {
+		log.Fatalf("export failed after %d expenses: %v", exported, err)
+	}
+
+	if err := writer.Close(); err != nil {
+		log.Fatalf("could not write the journal: %v", err)
+	}
+
+	log.Printf("exported %d expenses", exported)
+}
+
+// parseDate parses an optional YYYY-MM-DD date. With endOfDay set, the date covers the
+// whole day.
+func parseDate(raw string, endOfDay bool) (*time.Time, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	date, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a YYYY-MM-DD date", raw)
+	}
+	if endOfDay {
+		date = date.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &date, nil
+}
@@ -1,29 +1,74 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/beka-birhanu/finance-go/api/cursor"
 	"github.com/beka-birhanu/finance-go/api/graph"
 	"github.com/beka-birhanu/finance-go/api/middleware"
 	ratelimiter "github.com/beka-birhanu/finance-go/api/rate_limiter"
 	api "github.com/beka-birhanu/finance-go/api/rest"
+	"github.com/beka-birhanu/finance-go/api/rest/accesstoken"
+	"github.com/beka-birhanu/finance-go/api/rest/admin"
 	"github.com/beka-birhanu/finance-go/api/rest/expense"
+	"github.com/beka-birhanu/finance-go/api/rest/importjob"
+	"github.com/beka-birhanu/finance-go/api/rest/twofactor"
 	"github.com/beka-birhanu/finance-go/api/rest/user"
+	"github.com/beka-birhanu/finance-go/api/rest/wellknown"
 	"github.com/beka-birhanu/finance-go/api/router"
+	admincmd "github.com/beka-birhanu/finance-go/application/admin"
+	accesstokencmd "github.com/beka-birhanu/finance-go/application/authentication/accesstoken"
 	registercmd "github.com/beka-birhanu/finance-go/application/authentication/command"
+	"github.com/beka-birhanu/finance-go/application/authentication/lockout"
+	oidccmd "github.com/beka-birhanu/finance-go/application/authentication/oidc"
+	passwordcmd "github.com/beka-birhanu/finance-go/application/authentication/password"
 	loginqry "github.com/beka-birhanu/finance-go/application/authentication/query"
+	refreshcmd "github.com/beka-birhanu/finance-go/application/authentication/refresh"
+	sessioncmd "github.com/beka-birhanu/finance-go/application/authentication/session"
+	twofactorcmd "github.com/beka-birhanu/finance-go/application/authentication/twofactor"
+	iexporter "github.com/beka-birhanu/finance-go/application/common/interface/exporter"
+	iimporter "github.com/beka-birhanu/finance-go/application/common/interface/importer"
+	ioidc "github.com/beka-birhanu/finance-go/application/common/interface/oidc"
 	expensecmd "github.com/beka-birhanu/finance-go/application/expense/command"
+	expensedup "github.com/beka-birhanu/finance-go/application/expense/duplicate"
 	expensqry "github.com/beka-birhanu/finance-go/application/expense/query"
+	"github.com/beka-birhanu/finance-go/application/idempotency"
+	importcmd "github.com/beka-birhanu/finance-go/application/importjob/command"
+	importqry "github.com/beka-birhanu/finance-go/application/importjob/query"
 	"github.com/beka-birhanu/finance-go/config"
+	usermodel "github.com/beka-birhanu/finance-go/domain/model/user"
 	"github.com/beka-birhanu/finance-go/infrastructure/db"
+	journalexporter "github.com/beka-birhanu/finance-go/infrastructure/exporter/journal"
 	"github.com/beka-birhanu/finance-go/infrastructure/hash"
+	csvimporter "github.com/beka-birhanu/finance-go/infrastructure/importer/csv"
+	ofximporter "github.com/beka-birhanu/finance-go/infrastructure/importer/ofx"
+	qifimporter "github.com/beka-birhanu/finance-go/infrastructure/importer/qif"
 	"github.com/beka-birhanu/finance-go/infrastructure/jwt"
+	"github.com/beka-birhanu/finance-go/infrastructure/mailer"
+	"github.com/beka-birhanu/finance-go/infrastructure/oidc"
+	accesstokenrepo "github.com/beka-birhanu/finance-go/infrastructure/repository/accesstoken"
 	expenserepo "github.com/beka-birhanu/finance-go/infrastructure/repository/expense"
+	idempotencyrepo "github.com/beka-birhanu/finance-go/infrastructure/repository/idempotency"
+	identityrepo "github.com/beka-birhanu/finance-go/infrastructure/repository/identity"
+	importjobrepo "github.com/beka-birhanu/finance-go/infrastructure/repository/importjob"
+	loginattemptrepo "github.com/beka-birhanu/finance-go/infrastructure/repository/loginattempt"
+	oidcstaterepo "github.com/beka-birhanu/finance-go/infrastructure/repository/oidcstate"
+	passwordresetrepo "github.com/beka-birhanu/finance-go/infrastructure/repository/passwordreset"
+	refreshtokenrepo "github.com/beka-birhanu/finance-go/infrastructure/repository/refreshtoken"
+	revocationrepo "github.com/beka-birhanu/finance-go/infrastructure/repository/revocation"
+	twofactorrepo "github.com/beka-birhanu/finance-go/infrastructure/repository/twofactor"
 	userrepo "github.com/beka-birhanu/finance-go/infrastructure/repository/user"
 	timeservice "github.com/beka-birhanu/finance-go/infrastructure/time_service"
+	"github.com/beka-birhanu/finance-go/infrastructure/totp"
 	"golang.org/x/time/rate"
 )
 
@@ -53,42 +98,193 @@ func main() {
 	timeService := timeservice.New()
 	userRepository := userrepo.New(database)
 	expenseRepository := expenserepo.New(database)
+	importJobRepository := importjobrepo.New(database)
 	jwtService := initializeJWTService(timeService)
 	hashService := hash.SingletonService()
 	ipRateLimiter := ratelimiter.NewIPRateLimiter(rate.Limit(rateLimit), rateBurst, timeService)
+	cursorCodec := cursor.NewCodec(cursor.Config{
+		Secret:       config.Envs.CursorSecret,
+		AcceptLegacy: config.Envs.CursorAcceptLegacy,
+	})
+	duplicateDetector := expensedup.NewDetector(expensedup.Config{
+		ExpenseRepository: expenseRepository,
+		WindowDays:        config.Envs.DuplicateWindowDays,
+	})
+	idempotencyService := idempotency.NewService(idempotency.Config{
+		Repository:  idempotencyrepo.New(database),
+		TimeService: timeService,
+		TTL:         time.Duration(config.Envs.IdempotencyTTLInSeconds) * time.Second,
+	})
+	refreshTokenService := refreshcmd.NewTokenService(refreshcmd.TokenServiceConfig{
+		Repository:  refreshtokenrepo.New(database),
+		TimeService: timeService,
+		TTL:         time.Duration(config.Envs.RefreshTTLInSeconds) * time.Second,
+	})
+	revoker := sessioncmd.NewRevoker(sessioncmd.RevokerConfig{
+		Revocations: revocationrepo.NewCached(revocationrepo.CachedConfig{
+			Repository:  revocationrepo.New(database),
+			TimeService: timeService,
+			TTL:         time.Duration(config.Envs.RevocationCacheSeconds) * time.Second,
+		}),
+		RefreshTokens: refreshTokenService,
+		TimeService:   timeService,
+	})
+	passwordResetRepository := passwordresetrepo.New(database)
+	twoFactorRepository := twofactorrepo.New(database)
+	twoFactorService := twofactorcmd.NewService(twofactorcmd.Config{
+		Repository:   twoFactorRepository,
+		Challenges:   twoFactorRepository,
+		TOTP:         totp.New(totp.Config{Skew: 1}),
+		TimeService:  timeService,
+		Issuer:       config.Envs.TwoFactorIssuer,
+		ChallengeTTL: time.Duration(config.Envs.TwoFactorTTLSeconds) * time.Second,
+	})
+	accessTokenConfig := accesstokencmd.Config{
+		Repository:  accesstokenrepo.New(database),
+		TimeService: timeService,
+	}
+	loginGuard := lockout.NewGuard(lockout.Config{
+		Repository:  loginattemptrepo.New(database),
+		TimeService: timeService,
+		MaxFailures: config.Envs.LoginMaxFailures,
+		Duration:    time.Duration(config.Envs.LoginLockoutSeconds) * time.Second,
+		Delay:       time.Duration(config.Envs.LoginDelaySeconds) * time.Second,
+	})
 
 	// Initialize middlewares
-	authorizationMiddleware := middleware.Authorization(jwtService, true)
-	populateClaimsMiddleware := middleware.Authorization(jwtService, false)
+	accessTokenAuthenticator := accesstokencmd.NewAuthenticator(accessTokenConfig)
+	authorizationMiddleware := middleware.Authorization(jwtService, revoker, accessTokenAuthenticator, true)
+	populateClaimsMiddleware := middleware.Authorization(jwtService, revoker, accessTokenAuthenticator, false)
+	adminMiddleware := middleware.RequireRole(usermodel.RoleAdmin)
 	rateLimitingMiddleware := middleware.RateLimitMiddleware(ipRateLimiter)
+	idempotencyMiddleware := middleware.Idempotency(idempotencyService)
 
 	// Initialize command and query handlers
-	userRegisterCommandHandler := initializeUserRegisterHandler(userRepository, jwtService, hashService, timeService)
-	userLoginQueryHandler := initializeUserLoginQueryHandler(userRepository, jwtService, hashService)
-	addExpenseHandler := initializeAddExpenseHandler(userRepository, timeService)
+	userRegisterCommandHandler := initializeUserRegisterHandler(userRepository, jwtService, hashService, timeService, refreshTokenService)
+	userLoginQueryHandler := initializeUserLoginQueryHandler(userRepository, jwtService, hashService, refreshTokenService, twoFactorService, loginGuard)
+	refreshHandler := refreshcmd.NewHandler(refreshcmd.Config{
+		UserRepository: userRepository,
+		JwtService:     jwtService,
+		TokenService:   refreshTokenService,
+	})
+	addExpenseHandler := initializeAddExpenseHandler(userRepository, timeService, duplicateDetector)
+	batchAddExpenseHandler := initializeBatchAddExpenseHandler(expenseRepository, timeService)
 	getExpenseHandler := initializeGetExpenseHandler(expenseRepository)
 	getExpensesHandler := initializeGetExpensesHandler(expenseRepository)
 	patchExpenseHandler := initializePatchExpenseHandler(expenseRepository)
+	bulkPatchExpenseHandler, bulkDeleteExpenseHandler := initializeBulkExpenseHandlers(expenseRepository)
+	importHandler, undoImportHandler := initializeImportHandlers(importJobRepository, expenseRepository, timeService, duplicateDetector)
+	requestResetHandler := passwordcmd.NewRequestResetHandler(passwordcmd.RequestResetConfig{
+		UserRepository:  userRepository,
+		ResetRepository: passwordResetRepository,
+		Mailer:          mailer.NewFileMailer(mailer.FileConfig{Path: config.Envs.MailFile}),
+		TimeService:     timeService,
+		TTL:             time.Duration(config.Envs.PasswordResetTTLSeconds) * time.Second,
+	})
+	oidcStartHandler, oidcCallbackHandler := initializeOIDCHandlers(database, userRepository, jwtService, refreshTokenService, twoFactorService, timeService)
 
 	userHandler := user.NewHandler(user.Config{
 		UserRepository:  userRepository,
 		RegisterHandler: userRegisterCommandHandler,
 		LoginHandler:    userLoginQueryHandler,
+		RefreshHandler:  refreshHandler,
+		LogoutHandler:   sessioncmd.NewHandler(revoker),
+		PasswordHandler: passwordcmd.NewChangeHandler(passwordcmd.ChangeConfig{
+			UserRepository: userRepository,
+			HashService:    hashService,
+			TimeService:    timeService,
+			Sessions:       revoker,
+			AccessTokens:   accessTokenConfig.Repository,
+		}),
+		RequestReset: requestResetHandler,
+		ResetHandler: passwordcmd.NewResetHandler(passwordcmd.ResetConfig{
+			UserRepository:  userRepository,
+			ResetRepository: passwordResetRepository,
+			HashService:     hashService,
+			TimeService:     timeService,
+			Sessions:        revoker,
+			AccessTokens:    accessTokenConfig.Repository,
+		}),
+		TwoFactorLogin: twofactorcmd.NewVerifyHandler(twofactorcmd.VerifyConfig{
+			UserRepository: userRepository,
+			JwtService:     jwtService,
+			RefreshTokens:  refreshTokenService,
+			Service:        twoFactorService,
+		}),
+		OIDCStart:       oidcStartHandler,
+		OIDCCallback:    oidcCallbackHandler,
+		RefreshTokenTTL: refreshTokenService.TTL(),
+	})
+
+	// Two-factor authentication routes
+	twoFactorHandler := twofactor.NewHandler(twofactor.Config{
+		EnrollHandler:     twofactorcmd.NewEnrollHandler(userRepository, twoFactorService),
+		ConfirmHandler:    twofactorcmd.NewConfirmHandler(twoFactorService),
+		DisableHandler:    twofactorcmd.NewDisableHandler(twoFactorService),
+		RegenerateHandler: twofactorcmd.NewRegenerateHandler(twoFactorService),
+	})
+
+	// Personal access token routes
+	accessTokenHandler := accesstoken.NewHandler(accesstoken.Config{
+		CreateHandler: accesstokencmd.NewCreateHandler(accessTokenConfig),
+		ListHandler:   accesstokencmd.NewListHandler(accessTokenConfig.Repository),
+		RevokeHandler: accesstokencmd.NewRevokeHandler(accessTokenConfig),
+	})
+
+	// Admin routes
+	adminConfig := admincmd.Config{
+		UserRepository:  userRepository,
+		AdminRepository: userRepository,
+		AccessTokens:    accessTokenConfig.Repository,
+		Sessions:        revoker,
+		TimeService:     timeService,
+	}
+	adminHandler := admin.NewHandler(admin.Config{
+		ListUsersHandler: admincmd.NewListUsersHandler(adminConfig),
+		DisableHandler:   admincmd.NewDisableHandler(adminConfig),
+		EnableHandler:    admincmd.NewEnableHandler(adminConfig),
+		StatsHandler:     admincmd.NewStatsHandler(adminConfig),
 	})
 
 	// Expense routes
 	expenseHandler := expense.NewHandler(expense.Config{
-		AddHandler:         addExpenseHandler,
-		GetHandler:         getExpenseHandler,
-		PatchHandler:       patchExpenseHandler,
-		GetMultipleHandler: getExpensesHandler,
+		AddHandler:            addExpenseHandler,
+		BatchAddHandler:       batchAddExpenseHandler,
+		GetHandler:            getExpenseHandler,
+		PatchHandler:          patchExpenseHandler,
+		BulkPatchHandler:      bulkPatchExpenseHandler,
+		BulkDeleteHandler:     bulkDeleteExpenseHandler,
+		GetMultipleHandler:    getExpensesHandler,
+		FreshnessHandler:      expensqry.NewFreshnessHandler(expenseRepository),
+		ExportHandler:         expensqry.NewExportHandler(expenseRepository),
+		DuplicatesHandler:     expensqry.NewDuplicatesHandler(duplicateDetector),
+		MergeHandler:          expensecmd.NewMergeHandler(expenseRepository),
+		CursorCodec:           cursorCodec,
+		IdempotencyMiddleware: idempotencyMiddleware,
+		Journals: map[string]iexporter.IJournal{
+			journalexporter.Beancount: journalexporter.NewJournal(journalexporter.Beancount),
+			journalexporter.Ledger:    journalexporter.NewJournal(journalexporter.Ledger),
+		},
+	})
+
+	// Import routes
+	importsHandler := importjob.NewHandler(importjob.Config{
+		ImportHandler: importHandler,
+		UndoHandler:   undoImportHandler,
+		GetHandler:    importqry.NewGetHandler(importJobRepository),
+		MaxUploadSize: config.Envs.ImportMaxUploadSize,
 	})
 
 	resolver := graph.NewResolver(graph.ResolverConfig{
 		GetExpenseHandler:         getExpenseHandler,
 		GetMultipleExpenseHandler: getExpensesHandler,
 		AddExpenseHandler:         addExpenseHandler,
+		BatchAddExpenseHandler:    batchAddExpenseHandler,
 		PatchExpenseHandler:       patchExpenseHandler,
+		BulkPatchExpenseHandler:   bulkPatchExpenseHandler,
+		BulkDeleteExpenseHandler:  bulkDeleteExpenseHandler,
+		CursorCodec:               cursorCodec,
+		IdempotencyService:        idempotencyService,
 	})
 
 	graphHandler := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: resolver}))
@@ -96,22 +292,63 @@ func main() {
 	// Create and run the server
 	server := router.NewRouter(router.Config{
 		Addr:                     fmt.Sprintf(":%s", serverPort),
-		RestfullControllers:      []api.IController{userHandler, expenseHandler},
+		RestfullControllers:      []api.IController{userHandler, twoFactorHandler, accessTokenHandler, expenseHandler, importsHandler},
+		AdminControllers:         []api.IAdminController{adminHandler},
 		GraphQlController:        graphHandler,
+		JWKSHandler:              wellknown.NewJWKSHandler(jwtService),
 		AuthorizationMiddleware:  authorizationMiddleware,
+		AdminMiddleware:          adminMiddleware,
 		PopulateClaimsMiddleware: populateClaimsMiddleware,
 		RateLimitMiddleware:      rateLimitingMiddleware,
 	})
 
-	if err := server.Run(); err != nil {
-		log.Fatal(err)
-	}
+	go func() {
+		if err := server.Run(); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	// On shutdown, deliver the password reset tokens of requests that were already answered.
+	shutdown, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-shutdown.Done()
+	log.Println("Shutting down")
+	requestResetHandler.Wait()
 }
 
 func initializePatchExpenseHandler(expenseRepository *expenserepo.Repository) *expensecmd.PatchHandler {
 	return expensecmd.NewPatchHandler(expenseRepository)
 }
 
+// initializeBulkExpenseHandlers initializes and returns the bulk update and bulk delete expense command handlers.
+func initializeBulkExpenseHandlers(expenseRepository *expenserepo.Repository) (*expensecmd.BulkPatchHandler, *expensecmd.BulkDeleteHandler) {
+	bulkConfig := expensecmd.BulkConfig{
+		ExpenseRepository: expenseRepository,
+		MaxBulkSize:       config.Envs.ExpenseBulkMaxSize,
+	}
+	return expensecmd.NewBulkPatchHandler(bulkConfig), expensecmd.NewBulkDeleteHandler(bulkConfig)
+}
+
+// initializeImportHandlers initializes and returns the import and undo import command handlers.
+func initializeImportHandlers(importJobRepository *importjobrepo.Repository, expenseRepository *expenserepo.Repository, timeService *timeservice.Service, duplicateDetector *expensedup.Detector) (*importcmd.ImportHandler, *importcmd.UndoHandler) {
+	importHandler := importcmd.NewImportHandler(importcmd.Config{
+		ImportJobRepository: importJobRepository,
+		ExpenseRepository:   expenseRepository,
+		TimeService:         timeService,
+		DuplicateDetector:   duplicateDetector,
+		Parsers: map[string]iimporter.IParser{
+			csvimporter.Format: csvimporter.New(),
+			ofximporter.Format: ofximporter.New(),
+			qifimporter.Format: qifimporter.New(),
+		},
+	})
+	undoHandler := importcmd.NewUndoHandler(importcmd.UndoConfig{
+		ImportJobRepository: importJobRepository,
+		TimeService:         timeService,
+	})
+	return importHandler, undoHandler
+}
+
 func initializeGetExpenseHandler(expenseRepository *expenserepo.Repository) *expensqry.GetHandler {
 	return expensqry.NewGetHandler(expenseRepository)
 }
@@ -122,38 +359,99 @@ func initializeGetExpensesHandler(expenseRepository *expenserepo.Repository) *ex
 
 // initializeJWTService initializes and returns a new JWT service instance.
 func initializeJWTService(timeService *timeservice.Service) *jwt.Service {
+	var keys *jwt.KeySet
+	if config.Envs.JWTSigningKeyFile != "" {
+		var err error
+		keys, err = jwt.LoadKeySet(config.Envs.JWTSigningKeyFile, config.Envs.JWTVerificationKeyFiles)
+		if err != nil {
+			log.Fatalf("loading JWT keys: %v", err)
+		}
+	}
+
 	return jwt.New(
 		jwt.Config{
 			SecretKey:   config.Envs.JWTSecret,
+			Keys:        keys,
 			Issuer:      config.Envs.ServerHost,
+			Audience:    config.Envs.JWTAudience,
 			ExpTime:     time.Duration(config.Envs.JWTExpirationInSeconds) * time.Second,
+			ClockSkew:   time.Duration(config.Envs.JWTClockSkewInSeconds) * time.Second,
 			TimeService: timeService,
 		})
 }
 
 // initializeUserRegisterHandler initializes and returns a new user register command handler.
-func initializeUserRegisterHandler(userRepo *userrepo.Repository, jwtService *jwt.Service, hashService *hash.Service, timeService *timeservice.Service) *registercmd.Handler {
+func initializeUserRegisterHandler(userRepo *userrepo.Repository, jwtService *jwt.Service, hashService *hash.Service, timeService *timeservice.Service, refreshTokens *refreshcmd.TokenService) *registercmd.Handler {
 	return registercmd.NewHandler(registercmd.Config{
-		UserRepo: userRepo,
-		JwtSvc:   jwtService,
-		HashSvc:  hashService,
-		TimeSvc:  timeService,
+		UserRepo:      userRepo,
+		JwtSvc:        jwtService,
+		HashSvc:       hashService,
+		TimeSvc:       timeService,
+		RefreshTokens: refreshTokens,
 	})
 }
 
 // initializeUserLoginQueryHandler initializes and returns a new user login query handler.
-func initializeUserLoginQueryHandler(userRepo *userrepo.Repository, jwtService *jwt.Service, hashService *hash.Service) *loginqry.Handler {
+func initializeUserLoginQueryHandler(userRepo *userrepo.Repository, jwtService *jwt.Service, hashService *hash.Service, refreshTokens *refreshcmd.TokenService, twoFactor *twofactorcmd.Service, guard *lockout.Guard) *loginqry.Handler {
 	return loginqry.NewHandler(loginqry.Config{
 		UserRepository: userRepo,
 		JwtService:     jwtService,
 		HashService:    hashService,
+		RefreshTokens:  refreshTokens,
+		TwoFactor:      twoFactor,
+		Guard:          guard,
 	})
 }
 
-// initializeAddExpenseHandler initializes and returns a new add expense command handler.
-func initializeAddExpenseHandler(userRepo *userrepo.Repository, timeService *timeservice.Service) *expensecmd.AddHandler {
-	return expensecmd.NewAddHandler(expensecmd.Config{
+// initializeOIDCHandlers initializes and returns the command handlers starting and completing
+// sign ins through the configured OpenID Connect providers.
+func initializeOIDCHandlers(database *sql.DB, userRepo *userrepo.Repository, jwtService *jwt.Service, refreshTokens *refreshcmd.TokenService, twoFactor *twofactorcmd.Service, timeService *timeservice.Service) (*oidccmd.StartHandler, *oidccmd.CallbackHandler) {
+	providers := make(map[string]ioidc.IProvider, len(config.Envs.OIDCProviders))
+	for _, provider := range config.Envs.OIDCProviders {
+		providers[provider.Name] = oidc.New(oidc.Config{
+			Issuer:       provider.Issuer,
+			ClientID:     provider.ClientID,
+			ClientSecret: provider.ClientSecret,
+			RedirectURL:  strings.TrimSuffix(config.Envs.OIDCRedirectBaseURL, "/") + "/api/v1/users/oidc/" + provider.Name + "/callback",
+			Scopes:       provider.Scopes,
+			TimeService:  timeService,
+		})
+	}
+	states := oidcstaterepo.New(database)
+
+	startHandler := oidccmd.NewStartHandler(oidccmd.StartConfig{
+		Providers:   providers,
+		States:      states,
+		TimeService: timeService,
+		StateTTL:    time.Duration(config.Envs.OIDCStateTTLSeconds) * time.Second,
+	})
+	callbackHandler := oidccmd.NewCallbackHandler(oidccmd.CallbackConfig{
+		Providers:      providers,
+		States:         states,
+		Identities:     identityrepo.New(database),
 		UserRepository: userRepo,
+		JwtService:     jwtService,
+		RefreshTokens:  refreshTokens,
+		TwoFactor:      twoFactor,
 		TimeService:    timeService,
 	})
+	return startHandler, callbackHandler
+}
+
+// initializeAddExpenseHandler initializes and returns a new add expense command handler.
+func initializeAddExpenseHandler(userRepo *userrepo.Repository, timeService *timeservice.Service, duplicateDetector *expensedup.Detector) *expensecmd.AddHandler {
+	return expensecmd.NewAddHandler(expensecmd.Config{
+		UserRepository:    userRepo,
+		TimeService:       timeService,
+		DuplicateDetector: duplicateDetector,
+	})
+}
+
+// initializeBatchAddExpenseHandler initializes and returns a new batch add expense command handler.
+func initializeBatchAddExpenseHandler(expenseRepository *expenserepo.Repository, timeService *timeservice.Service) *expensecmd.BatchAddHandler {
+	return expensecmd.NewBatchAddHandler(expensecmd.BatchAddConfig{
+		ExpenseRepository: expenseRepository,
+		TimeService:       timeService,
+		MaxBatchSize:      config.Envs.ExpenseBatchMaxSize,
+	})
 }
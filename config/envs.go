@@ -5,28 +5,61 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
 // Config holds the application's configuration values.
 type Config struct {
-	ServerHost             string // Hostname or IP address for the server
-	ServerPort             string // Port number for the server
-	APIRate                int    // Rate/Sec for public routes
-	RateBurst              int    // Rate/Sec for Protected routes
-	DBHost                 string // Hostname or IP address for the database
-	DBPort                 string // Port number for the database
-	DBUser                 string // Username for the database
-	DBPassword             string // Password for the database
-	DBName                 string // Name of the database
-	JWTSecret              string // Secret key for JWT signing
-	JWTExpirationInSeconds int64  // JWT expiration time in seconds
-	TestDBHost             string // Hostname or IP address for the test database
-	TestDBPort             string // Port number for the test database
-	TestDBUser             string // Username for the test database
-	TestDBPassword         string // Password for the test database
-	TestDBName             string // Name of the test database
+	ServerHost              string   // Hostname or IP address for the server
+	ServerPort              string   // Port number for the server
+	APIRate                 int      // Rate/Sec for public routes
+	RateBurst               int      // Rate/Sec for Protected routes
+	DBHost                  string   // Hostname or IP address for the database
+	DBPort                  string   // Port number for the database
+	DBUser                  string   // Username for the database
+	DBPassword              string   // Password for the database
+	DBName                  string   // Name of the database
+	JWTSecret               string   // Secret key for JWT signing
+	JWTExpirationInSeconds  int64    // JWT expiration time in seconds
+	JWTSigningKeyFile       string   // PEM file of the RSA or Ed25519 key JWTs are signed with; HS256 with JWTSecret when empty
+	JWTVerificationKeyFiles []string // PEM files of retired public keys JWTs are still verified with
+	JWTAudience             string   // Audience JWTs are issued to and required to carry; not checked when empty
+	JWTClockSkewInSeconds   int64    // Leeway allowed between clocks when checking the validity period of JWTs
+	CursorSecret            string   // Secret key for signing pagination cursors
+	CursorAcceptLegacy      bool     // Whether unsigned pagination cursors are still accepted; temporary, for migration
+	ExpenseBatchMaxSize     int      // Maximum number of expenses created by one batch request
+	ExpenseBulkMaxSize      int      // Maximum number of expenses changed by one bulk request
+	DuplicateWindowDays     int      // Maximum number of days between the dates of likely duplicate expenses
+	ImportMaxUploadSize     int64    // Maximum size of an imported file in bytes
+	IdempotencyTTLInSeconds int64    // How long responses to idempotent requests are stored in seconds
+	RefreshTTLInSeconds     int64    // How long refresh tokens are accepted in seconds
+	RevocationCacheSeconds  int64    // How long token revocation lookups are cached in seconds
+	PasswordResetTTLSeconds int64    // How long password reset tokens are accepted in seconds
+	MailFile                string   // File mail to users is written to; the log when empty
+	TwoFactorIssuer         string   // Name authenticator apps list two-factor secrets under
+	TwoFactorTTLSeconds     int64    // How long a sign in waits for its second factor in seconds
+	LoginMaxFailures        int      // Failed sign ins under a username after which it is locked
+	LoginLockoutSeconds     int64    // How long a username is locked after too many failed sign ins in seconds
+	LoginDelaySeconds       int64    // Wait after the first failed sign in in seconds; doubles with each further failure
+	OIDCProviders           []IdP    // OpenID Connect providers users can sign in with
+	OIDCRedirectBaseURL     string   // Public URL of the API that providers send users back to
+	OIDCStateTTLSeconds     int64    // How long a sign in through a provider can be completed in seconds
+	TestDBHost              string   // Hostname or IP address for the test database
+	TestDBPort              string   // Port number for the test database
+	TestDBUser              string   // Username for the test database
+	TestDBPassword          string   // Password for the test database
+	TestDBName              string   // Name of the test database
+}
+
+// IdP holds the settings of an OpenID Connect identity provider users can sign in with.
+type IdP struct {
+	Name         string   // Name of the provider in the sign in routes
+	Issuer       string   // Issuer identifier of the provider
+	ClientID     string   // Client ID registered with the provider
+	ClientSecret string   // Client secret; empty for public clients
+	Scopes       []string // Scopes to ask for; openid, profile and email when empty
 }
 
 // Envs holds the application's configuration loaded from environment variables.
@@ -40,23 +73,69 @@ func initConfig() Config {
 	}
 
 	return Config{
-		ServerHost:             getEnv("PUBLIC_HOST", "http://localhost"),
-		ServerPort:             getEnv("PORT", "8080"),
-		APIRate:                int(getEnvAsInt("RATE_PER_SEC", 60)),
-		RateBurst:              int(getEnvAsInt("RATE_BURST", 2)),
-		DBHost:                 getEnv("DB_HOST", "romareo"),
-		DBPort:                 getEnv("DB_PORT", "5432"),
-		DBUser:                 getEnv("DB_USER", "romareo"),
-		DBPassword:             getEnv("DB_PASSWORD", "PythonIsTheGOAT"),
-		DBName:                 getEnv("DB_NAME", "finance"),
-		JWTSecret:              getEnv("JWT_SECRET", "not-so-secret-now-is-it?"),
-		JWTExpirationInSeconds: getEnvAsInt("JWT_EXPIRATION_IN_SECONDS", 60*24),
-		TestDBHost:             getEnv("TEST_DB_HOST", "localhost"),
-		TestDBPort:             getEnv("TEST_DB_PORT", "5432"),
-		TestDBUser:             getEnv("TEST_DB_USER", "test_user"),
-		TestDBPassword:         getEnv("TEST_DB_PASSWORD", "test_password"),
-		TestDBName:             getEnv("TEST_DB_NAME", "test_finance"),
+		ServerHost:              getEnv("PUBLIC_HOST", "http://localhost"),
+		ServerPort:              getEnv("PORT", "8080"),
+		APIRate:                 int(getEnvAsInt("RATE_PER_SEC", 60)),
+		RateBurst:               int(getEnvAsInt("RATE_BURST", 2)),
+		DBHost:                  getEnv("DB_HOST", "romareo"),
+		DBPort:                  getEnv("DB_PORT", "5432"),
+		DBUser:                  getEnv("DB_USER", "romareo"),
+		DBPassword:              getEnv("DB_PASSWORD", "PythonIsTheGOAT"),
+		DBName:                  getEnv("DB_NAME", "finance"),
+		JWTSecret:               getEnv("JWT_SECRET", "not-so-secret-now-is-it?"),
+		JWTExpirationInSeconds:  getEnvAsInt("JWT_EXPIRATION_IN_SECONDS", 60*24),
+		JWTSigningKeyFile:       getEnv("JWT_SIGNING_KEY_FILE", ""),
+		JWTVerificationKeyFiles: getEnvAsList("JWT_VERIFICATION_KEY_FILES"),
+		JWTAudience:             getEnv("JWT_AUDIENCE", "finance-go-api"),
+		JWTClockSkewInSeconds:   getEnvAsInt("JWT_CLOCK_SKEW_IN_SECONDS", 30),
+		CursorSecret:            getEnv("CURSOR_SECRET", "not-so-secret-cursor-key"),
+		CursorAcceptLegacy:      getEnvAsBool("CURSOR_ACCEPT_LEGACY", false),
+		ExpenseBatchMaxSize:     int(getEnvAsInt("EXPENSE_BATCH_MAX_SIZE", 100)),
+		ExpenseBulkMaxSize:      int(getEnvAsInt("EXPENSE_BULK_MAX_SIZE", 1000)),
+		DuplicateWindowDays:     int(getEnvAsInt("EXPENSE_DUPLICATE_WINDOW_DAYS", 3)),
+		ImportMaxUploadSize:     getEnvAsInt("IMPORT_MAX_UPLOAD_SIZE", 10<<20),
+		IdempotencyTTLInSeconds: getEnvAsInt("IDEMPOTENCY_TTL_IN_SECONDS", 60*60*24),
+		RefreshTTLInSeconds:     getEnvAsInt("REFRESH_TOKEN_TTL_IN_SECONDS", 60*60*24*30),
+		RevocationCacheSeconds:  getEnvAsInt("REVOCATION_CACHE_TTL_IN_SECONDS", 30),
+		PasswordResetTTLSeconds: getEnvAsInt("PASSWORD_RESET_TTL_IN_SECONDS", 60*60),
+		MailFile:                getEnv("MAIL_FILE", ""),
+		TwoFactorIssuer:         getEnv("TWO_FACTOR_ISSUER", "finance-go"),
+		TwoFactorTTLSeconds:     getEnvAsInt("TWO_FACTOR_CHALLENGE_TTL_IN_SECONDS", 5*60),
+		LoginMaxFailures:        int(getEnvAsInt("LOGIN_MAX_FAILURES", 5)),
+		LoginLockoutSeconds:     getEnvAsInt("LOGIN_LOCKOUT_IN_SECONDS", 15*60),
+		LoginDelaySeconds:       getEnvAsInt("LOGIN_DELAY_IN_SECONDS", 1),
+		OIDCProviders:           getOIDCProviders(),
+		OIDCRedirectBaseURL:     getEnv("OIDC_REDIRECT_BASE_URL", getEnv("PUBLIC_HOST", "http://localhost")+":"+getEnv("PORT", "8080")),
+		OIDCStateTTLSeconds:     getEnvAsInt("OIDC_STATE_TTL_IN_SECONDS", 10*60),
+		TestDBHost:              getEnv("TEST_DB_HOST", "localhost"),
+		TestDBPort:              getEnv("TEST_DB_PORT", "5432"),
+		TestDBUser:              getEnv("TEST_DB_USER", "test_user"),
+		TestDBPassword:          getEnv("TEST_DB_PASSWORD", "test_password"),
+		TestDBName:              getEnv("TEST_DB_NAME", "test_finance"),
+	}
+}
+
+// getOIDCProviders retrieves the OpenID Connect providers named in OIDC_PROVIDERS, a
+// comma-separated list, each configured by OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID,
+// OIDC_<NAME>_CLIENT_SECRET and OIDC_<NAME>_SCOPES. It panics if a provider lacks an issuer or
+// a client ID.
+func getOIDCProviders() []IdP {
+	var providers []IdP
+	for _, name := range getEnvAsList("OIDC_PROVIDERS") {
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		provider := IdP{
+			Name:         name,
+			Issuer:       getEnv(prefix+"ISSUER", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			Scopes:       getEnvAsList(prefix + "SCOPES"),
+		}
+		if provider.Issuer == "" || provider.ClientID == "" {
+			log.Panicf("OpenID Connect provider %s needs %sISSUER and %sCLIENT_ID", name, prefix, prefix)
+		}
+		providers = append(providers, provider)
 	}
+	return providers
 }
 
 // getEnv retrieves the value of an environment variable or returns a fallback value if the variable is not set.
@@ -78,3 +157,26 @@ func getEnvAsInt(key string, fallback int64) int64 {
 	}
 	return fallback
 }
+
+// getEnvAsBool retrieves the value of an environment variable as a boolean or returns a fallback value if the variable is not set or cannot be parsed.
+func getEnvAsBool(key string, fallback bool) bool {
+	if value, ok := os.LookupEnv(key); ok {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fallback
+		}
+		return b
+	}
+	return fallback
+}
+
+// getEnvAsList retrieves the value of an environment variable as a comma-separated list, skipping empty items.
+func getEnvAsList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
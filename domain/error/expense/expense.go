@@ -1,7 +1,7 @@
 /*
 Package errexpense defines expense-related errors for the application.
 
-It provides a set of predefined errors related to expense not-found, and validation
+It provides a set of predefined errors related to expense not-found, conflicting and validation
 issues. These errors are used throughout the application to handle variours error conditions
 specfic to expense operations.
 */
@@ -19,6 +19,18 @@ var (
 
 	// Description is empty.
 	EmptyDescription = errdmn.NewValidation("Expense.Description cannot be empty.")
+
+	// Category is longer than allowed.
+	CategoryTooLong = errdmn.NewValidation("Expense.Category is too long.")
+
+	// Account is longer than allowed.
+	AccountTooLong = errdmn.NewValidation("Expense.Account is too long.")
+
+	// Kind is neither expense nor income.
+	InvalidKind = errdmn.NewValidation("Expense.Kind must be expense or income.")
+
+	// ExternalID is longer than allowed.
+	ExternalIDTooLong = errdmn.NewValidation("Expense.ExternalID is too long.")
 )
 
 // NotFound errors
@@ -26,3 +38,9 @@ var (
 	// Expense is does not exist.
 	NotFound = errdmn.NewNotFound("Expense not found")
 )
+
+// Conflict errors
+var (
+	// Expense was changed since it was read.
+	VersionConflict = errdmn.NewConflict("Expense was changed by another request.")
+)
@@ -0,0 +1,31 @@
+/*
+Package errimportjob defines import-job-related errors for the application.
+
+It provides a set of predefined errors related to import jobs that do not exist, are
+malformed, or cannot change state. These errors are used throughout the application to
+handle error conditions specific to imports.
+*/
+package errimportjob
+
+import "github.com/beka-birhanu/finance-go/domain/error/common"
+
+// Validation errors
+var (
+	// Import has no rows.
+	NoRows = errdmn.NewValidation("ImportJob.Rows cannot be empty.")
+
+	// Import format is empty.
+	EmptyFormat = errdmn.NewValidation("ImportJob.Format cannot be empty.")
+)
+
+// Conflict errors
+var (
+	// Import did not insert expenses, or they were already removed.
+	NotUndoable = errdmn.NewConflict("only committed imports can be undone.")
+)
+
+// NotFound errors
+var (
+	// Import job does not exist.
+	NotFound = errdmn.NewNotFound("Import job not found.")
+)
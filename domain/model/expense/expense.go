@@ -3,8 +3,9 @@ Package expensemodel includes the definition of the Expense aggregate, which rep
 an individual expense, and provides functions for creating and interacting with expenses.
 
 Key Components:
-- Expense: Represents an expense with details such as description, amount, and
-associated user.
+- Expense: Represents an expense with details such as description, amount, kind, optional
+category and account, and associated user.
+- Kind: Tells spending apart from income.
 - Config: Holds the mandatory parameters required to create a new Expense.
 - New: Creates a new Expense instance based on the provided configuration.
 - validateDescription: Validates that the expense description meets length constraints.
@@ -16,7 +17,9 @@ Dependencies:
 package expensemodel
 
 import (
+	"fmt"
 	"math"
+	"strconv"
 	"strings"
 	"time"
 
@@ -26,17 +29,36 @@ import (
 
 const (
 	maxDescriptionLength = 255
+	maxCategoryLength    = 64
+	maxAccountLength     = 64
+	maxExternalIDLength  = 255
+)
+
+// Kind tells whether an expense records money spent or money received.
+type Kind string
+
+const (
+	// KindExpense marks money spent.
+	KindExpense Kind = "expense"
+
+	// KindIncome marks money received.
+	KindIncome Kind = "income"
 )
 
 // Expense represents an expense aggregate.
 type Expense struct {
 	id          uuid.UUID
 	description string
-	amount      float32
+	cents       int64 // The amount in cents, which holds it exactly
+	kind        Kind
 	date        time.Time
+	category    string
+	account     string
+	externalID  string
 	userId      uuid.UUID
 	createdAt   time.Time
 	updatedAt   time.Time
+	version     int
 }
 
 // Config holds all mandatory parameters for creating a new Expense.
@@ -47,14 +69,39 @@ type Config struct {
 	// Amount must be a positive number.
 	Amount float32
 
+	// AmountCents optionally gives the amount in cents and takes precedence over Amount
+	// when non-zero. Repositories use it to load amounts that a float32 cannot hold exactly.
+	AmountCents int64
+
 	// UserId is the ID of the owner user for the expense.
 	UserId uuid.UUID
 
 	// Date is the timestamp when the expense occurred.
 	Date time.Time
 
+	// Category optionally groups the expense (e.g., "groceries"). Empty means uncategorized.
+	Category string
+
+	// Account optionally names the account the expense was paid from (e.g., "checking").
+	Account string
+
+	// Kind tells spending apart from income. Empty means KindExpense.
+	Kind Kind
+
+	// ExternalID optionally identifies the transaction the expense was imported from
+	// (e.g., a bank's FITID), so that it is not imported twice.
+	ExternalID string
+
 	// CreationTime is the timestamp when the expense is created.
 	CreationTime time.Time
+
+	// UpdateTime is the timestamp of the last update of an existing expense. It is only
+	// used by NewWithID and defaults to CreationTime when zero.
+	UpdateTime time.Time
+
+	// Version is the number of times an existing expense was stored, starting at 1. It is
+	// only used by NewWithID and defaults to 1 when zero.
+	Version int
 }
 
 // New creates a new Expense with the provided configuration.
@@ -65,6 +112,8 @@ type Config struct {
 //   - Any field in the config is missing or invalid.
 //   - The description does not meet length constraints.
 //   - The amount is not positive.
+//   - The category or account is too long.
+//   - The kind is unknown or the external ID is too long.
 //
 // NOTE: it rounds the amount to two decimal places
 func New(config Config) (*Expense, error) {
@@ -73,21 +122,41 @@ func New(config Config) (*Expense, error) {
 		return nil, err
 	}
 
-	// Round the amount to two decimal places
-	roundedAmount := float32(math.Round(float64(config.Amount)*100)) / 100
+	config.Category = strings.TrimSpace(config.Category)
+	config.Account = strings.TrimSpace(config.Account)
+	if err := validateClassification(config.Category, config.Account); err != nil {
+		return nil, err
+	}
+
+	kind, err := normalizeKind(config.Kind)
+	if err != nil {
+		return nil, err
+	}
+	config.Kind = kind
 
-	if roundedAmount <= 0 {
+	config.ExternalID = strings.TrimSpace(config.ExternalID)
+	if len(config.ExternalID) > maxExternalIDLength {
+		return nil, errexpense.ExternalIDTooLong
+	}
+
+	cents := config.cents()
+	if cents <= 0 {
 		return nil, errexpense.NegativeAmount
 	}
 
 	return &Expense{
 		id:          uuid.New(),
 		description: config.Description,
-		amount:      roundedAmount,
+		cents:       cents,
+		kind:        config.Kind,
+		category:    config.Category,
+		account:     config.Account,
+		externalID:  config.ExternalID,
 		userId:      config.UserId,
 		date:        config.Date,
 		createdAt:   config.CreationTime,
 		updatedAt:   config.CreationTime,
+		version:     1,
 	}, nil
 }
 
@@ -99,27 +168,126 @@ func New(config Config) (*Expense, error) {
 //   - Any field in the config is missing or invalid.
 //   - The description does not meet length constraints.
 //   - The amount is not positive.
+//   - The category or account is too long.
+//   - The kind is unknown or the external ID is too long.
 func NewWithID(id uuid.UUID, config Config) (*Expense, error) {
 	config.Description = strings.TrimSpace(config.Description)
 	if err := validateDescription(config.Description); err != nil {
 		return nil, err
 	}
 
-	if config.Amount <= 0 {
+	config.Category = strings.TrimSpace(config.Category)
+	config.Account = strings.TrimSpace(config.Account)
+	if err := validateClassification(config.Category, config.Account); err != nil {
+		return nil, err
+	}
+
+	kind, err := normalizeKind(config.Kind)
+	if err != nil {
+		return nil, err
+	}
+	config.Kind = kind
+
+	config.ExternalID = strings.TrimSpace(config.ExternalID)
+	if len(config.ExternalID) > maxExternalIDLength {
+		return nil, errexpense.ExternalIDTooLong
+	}
+
+	cents := config.cents()
+	if cents <= 0 {
 		return nil, errexpense.NegativeAmount
 	}
 
+	if config.UpdateTime.IsZero() {
+		config.UpdateTime = config.CreationTime
+	}
+
+	if config.Version <= 0 {
+		config.Version = 1
+	}
+
 	return &Expense{
 		id:          id, // Use the provided ID
 		description: config.Description,
-		amount:      config.Amount,
+		cents:       cents,
+		kind:        config.Kind,
+		category:    config.Category,
+		account:     config.Account,
+		externalID:  config.ExternalID,
 		userId:      config.UserId,
 		date:        config.Date,
 		createdAt:   config.CreationTime,
-		updatedAt:   config.CreationTime,
+		updatedAt:   config.UpdateTime,
+		version:     config.Version,
 	}, nil
 }
 
+// cents returns the amount of the config in cents, rounding Amount to two decimal places.
+func (c Config) cents() int64 {
+	if c.AmountCents != 0 {
+		return c.AmountCents
+	}
+	return toCents(c.Amount)
+}
+
+// toCents rounds an amount to a whole number of cents.
+func toCents(amount float32) int64 {
+	return int64(math.Round(float64(amount) * 100))
+}
+
+// FormatCents formats an amount in cents as a decimal with two decimal places, e.g. "12.34".
+func FormatCents(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// ParseCents parses a decimal with at most two decimal places, such as a DECIMAL(10,2)
+// column or the output of FormatCents, into cents without going through a float.
+// Returns false if the value is malformed.
+func ParseCents(value string) (int64, bool) {
+	negative := strings.HasPrefix(value, "-")
+	whole, fraction, _ := strings.Cut(strings.TrimPrefix(value, "-"), ".")
+	if whole == "" || len(fraction) > 2 || strings.ContainsAny(whole+fraction, "+-") {
+		return 0, false
+	}
+
+	cents, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || cents > (math.MaxInt64-99)/100 {
+		return 0, false
+	}
+	cents *= 100
+	if fraction != "" {
+		f, err := strconv.ParseUint(fraction, 10, 8)
+		if err != nil {
+			return 0, false
+		}
+		if len(fraction) == 1 {
+			f *= 10
+		}
+		cents += int64(f)
+	}
+
+	if negative {
+		cents = -cents
+	}
+	return cents, true
+}
+
+// normalizeKind defaults an empty kind to KindExpense and rejects unknown kinds.
+func normalizeKind(kind Kind) (Kind, error) {
+	switch kind {
+	case "":
+		return KindExpense, nil
+	case KindExpense, KindIncome:
+		return kind, nil
+	default:
+		return "", errexpense.InvalidKind
+	}
+}
+
 func validateDescription(desc string) error {
 	if desc == "" {
 		return errexpense.EmptyDescription
@@ -132,6 +300,18 @@ func validateDescription(desc string) error {
 	return nil
 }
 
+func validateClassification(category, account string) error {
+	if len(category) > maxCategoryLength {
+		return errexpense.CategoryTooLong
+	}
+
+	if len(account) > maxAccountLength {
+		return errexpense.AccountTooLong
+	}
+
+	return nil
+}
+
 // ID returns the ID of the expense.
 func (e *Expense) ID() uuid.UUID {
 	return e.id
@@ -144,7 +324,18 @@ func (e *Expense) Description() string {
 
 // Amount returns the amount of the expense.
 func (e *Expense) Amount() float32 {
-	return e.amount
+	return float32(float64(e.cents) / 100)
+}
+
+// AmountCents returns the amount of the expense in cents. Unlike Amount, it is exact for
+// every amount.
+func (e *Expense) AmountCents() int64 {
+	return e.cents
+}
+
+// Kind returns whether the expense records money spent or money received.
+func (e *Expense) Kind() Kind {
+	return e.kind
 }
 
 // Date returns the date of the expense.
@@ -152,6 +343,22 @@ func (e *Expense) Date() time.Time {
 	return e.date
 }
 
+// Category returns the category of the expense, or an empty string if uncategorized.
+func (e *Expense) Category() string {
+	return e.category
+}
+
+// Account returns the account the expense was paid from, or an empty string if unset.
+func (e *Expense) Account() string {
+	return e.account
+}
+
+// ExternalID returns the ID of the transaction the expense was imported from, or an
+// empty string if it was not imported.
+func (e *Expense) ExternalID() string {
+	return e.externalID
+}
+
 // UserID returns the ID of the user associated with the expense.
 func (e *Expense) UserID() uuid.UUID {
 	return e.userId
@@ -167,6 +374,18 @@ func (e *Expense) UpdatedAt() time.Time {
 	return e.updatedAt
 }
 
+// Version returns the version of the expense, which is incremented every time a change to
+// it is stored. It tells apart the states of an expense for optimistic concurrency control.
+func (e *Expense) Version() int {
+	return e.version
+}
+
+// SetVersion sets the version of the expense. It is meant for repositories, which increment
+// the version when they store a change.
+func (e *Expense) SetVersion(version int) {
+	e.version = version
+}
+
 // UpdateDescription updates the description of the expense.
 // Returns an error if the new description is invalid.
 func (e *Expense) UpdateDescription(newDescription string) error {
@@ -184,12 +403,11 @@ func (e *Expense) UpdateDescription(newDescription string) error {
 //
 // NOTE: it rounds the amount to two decimal places
 func (e *Expense) UpdateAmount(newAmount float32) error {
-	// Round the amount to two decimal places
-	newAmount = float32(math.Round(float64(newAmount)*100)) / 100
-	if newAmount <= 0 {
+	cents := toCents(newAmount)
+	if cents <= 0 {
 		return errexpense.NegativeAmount
 	}
-	e.amount = newAmount
+	e.cents = cents
 	e.updatedAt = time.Now()
 	return nil
 }
@@ -199,3 +417,48 @@ func (e *Expense) UpdateDate(newDate time.Time) {
 	e.date = newDate
 	e.updatedAt = time.Now()
 }
+
+// UpdateCategory updates the category of the expense. An empty category clears it.
+// Returns an error if the new category is too long.
+func (e *Expense) UpdateCategory(newCategory string) error {
+	newCategory = strings.TrimSpace(newCategory)
+	if err := validateClassification(newCategory, ""); err != nil {
+		return err
+	}
+	e.category = newCategory
+	e.updatedAt = time.Now()
+	return nil
+}
+
+// UpdateAccount updates the account of the expense. An empty account clears it.
+// Returns an error if the new account is too long.
+func (e *Expense) UpdateAccount(newAccount string) error {
+	newAccount = strings.TrimSpace(newAccount)
+	if err := validateClassification("", newAccount); err != nil {
+		return err
+	}
+	e.account = newAccount
+	e.updatedAt = time.Now()
+	return nil
+}
+
+// Absorb merges a duplicate of the expense into it by taking over the category, account and
+// external ID the expense lacks. The duplicate itself is left unchanged.
+func (e *Expense) Absorb(duplicate *Expense) {
+	changed := false
+	if e.category == "" && duplicate.category != "" {
+		e.category = duplicate.category
+		changed = true
+	}
+	if e.account == "" && duplicate.account != "" {
+		e.account = duplicate.account
+		changed = true
+	}
+	if e.externalID == "" && duplicate.externalID != "" {
+		e.externalID = duplicate.externalID
+		changed = true
+	}
+	if changed {
+		e.updatedAt = time.Now()
+	}
+}
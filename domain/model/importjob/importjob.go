@@ -0,0 +1,215 @@
+/*
+Package importjobmodel includes the definition of the ImportJob aggregate, which records
+the outcome of importing a file of expenses so that it can be reviewed and undone later.
+
+Key Components:
+- ImportJob: Represents an import with its format, status and the outcome of every row.
+- Row: The outcome of a single row of the imported file.
+- Config: Holds the mandatory parameters required to create a new ImportJob.
+- New: Creates a new ImportJob instance based on the provided configuration.
+
+Dependencies:
+- github.com/google/uuid: Used for generating unique IDs.
+- time: Used for timestamps.
+*/
+package importjobmodel
+
+import (
+	"strings"
+	"time"
+
+	"github.com/beka-birhanu/finance-go/domain/error/importjob"
+	"github.com/google/uuid"
+)
+
+// Status is the state of an import job.
+type Status string
+
+const (
+	// StatusValidated marks a dry run: rows were validated but nothing was inserted.
+	StatusValidated Status = "validated"
+
+	// StatusCommitted marks an import whose valid rows were inserted as expenses.
+	StatusCommitted Status = "committed"
+
+	// StatusUndone marks a committed import whose expenses were removed again.
+	StatusUndone Status = "undone"
+)
+
+// Row is the outcome of a single row of an imported file.
+type Row struct {
+	Line               int         // Line of the row in the file
+	Error              string      // Why the row was rejected; empty for valid rows
+	Duplicate          bool        // Whether the row was skipped because its transaction was already imported
+	PossibleDuplicates []uuid.UUID // Stored expenses the row likely duplicates, although it was imported
+	ExpenseID          uuid.UUID   // Expense created from the row; uuid.Nil unless the import was committed
+}
+
+// Valid reports whether the row passed validation.
+func (r Row) Valid() bool {
+	return r.Error == ""
+}
+
+// ImportJob represents an import job aggregate.
+type ImportJob struct {
+	id        uuid.UUID
+	userId    uuid.UUID
+	format    string
+	status    Status
+	rows      []Row
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+// Config holds all mandatory parameters for creating a new ImportJob.
+type Config struct {
+	// UserId is the ID of the user who imported the file.
+	UserId uuid.UUID
+
+	// Format is the format of the imported file (e.g., "csv").
+	Format string
+
+	// Rows is the outcome of every row of the file; it must not be empty.
+	Rows []Row
+
+	// DryRun marks an import that only validated its rows.
+	DryRun bool
+
+	// CreationTime is the timestamp when the import job is created.
+	CreationTime time.Time
+}
+
+// New creates a new ImportJob with the provided configuration. Dry runs start out
+// validated, other imports committed.
+//
+// Returns:
+// - A pointer to the newly created ImportJob if successful.
+// - An error if the format is empty or there are no rows.
+func New(config Config) (*ImportJob, error) {
+	status := StatusCommitted
+	if config.DryRun {
+		status = StatusValidated
+	}
+
+	return NewWithID(uuid.New(), config, status, config.CreationTime)
+}
+
+// NewWithID creates an ImportJob with an existing ID, status and last update time.
+//
+// Returns:
+// - A pointer to the ImportJob if successful.
+// - An error if the format is empty or there are no rows.
+func NewWithID(id uuid.UUID, config Config, status Status, updateTime time.Time) (*ImportJob, error) {
+	config.Format = strings.TrimSpace(config.Format)
+	if config.Format == "" {
+		return nil, errimportjob.EmptyFormat
+	}
+
+	if len(config.Rows) == 0 {
+		return nil, errimportjob.NoRows
+	}
+
+	rows := make([]Row, len(config.Rows))
+	copy(rows, config.Rows)
+
+	return &ImportJob{
+		id:        id,
+		userId:    config.UserId,
+		format:    config.Format,
+		status:    status,
+		rows:      rows,
+		createdAt: config.CreationTime,
+		updatedAt: updateTime,
+	}, nil
+}
+
+// ID returns the ID of the import job.
+func (j *ImportJob) ID() uuid.UUID {
+	return j.id
+}
+
+// UserID returns the ID of the user who imported the file.
+func (j *ImportJob) UserID() uuid.UUID {
+	return j.userId
+}
+
+// Format returns the format of the imported file.
+func (j *ImportJob) Format() string {
+	return j.format
+}
+
+// Status returns the status of the import job.
+func (j *ImportJob) Status() Status {
+	return j.status
+}
+
+// Rows returns a copy of the outcome of every row.
+func (j *ImportJob) Rows() []Row {
+	rowsCopy := make([]Row, len(j.rows))
+	copy(rowsCopy, j.rows)
+	return rowsCopy
+}
+
+// ValidRows returns the number of rows that passed validation.
+func (j *ImportJob) ValidRows() int {
+	valid := 0
+	for _, row := range j.rows {
+		if row.Valid() {
+			valid++
+		}
+	}
+	return valid
+}
+
+// DuplicateRows returns the number of valid rows that were skipped because their
+// transaction was already imported.
+func (j *ImportJob) DuplicateRows() int {
+	duplicates := 0
+	for _, row := range j.rows {
+		if row.Valid() && row.Duplicate {
+			duplicates++
+		}
+	}
+	return duplicates
+}
+
+// InvalidRows returns the number of rows that were rejected.
+func (j *ImportJob) InvalidRows() int {
+	return len(j.rows) - j.ValidRows()
+}
+
+// ExpenseIDs returns the IDs of the expenses created by the import.
+func (j *ImportJob) ExpenseIDs() []uuid.UUID {
+	var ids []uuid.UUID
+	for _, row := range j.rows {
+		if row.ExpenseID != uuid.Nil {
+			ids = append(ids, row.ExpenseID)
+		}
+	}
+	return ids
+}
+
+// CreatedAt returns the creation timestamp of the import job.
+func (j *ImportJob) CreatedAt() time.Time {
+	return j.createdAt
+}
+
+// UpdatedAt returns the last update timestamp of the import job.
+func (j *ImportJob) UpdatedAt() time.Time {
+	return j.updatedAt
+}
+
+// Undo marks a committed import as undone. The caller is responsible for removing the
+// expenses it created.
+//
+// Returns:
+// - An error if the import was not committed or was already undone.
+func (j *ImportJob) Undo(currentUTCTime time.Time) error {
+	if j.status != StatusCommitted {
+		return errimportjob.NotUndoable
+	}
+
+	j.status = StatusUndone
+	j.updatedAt = currentUTCTime
+	return nil
+}
@@ -8,6 +8,10 @@ Key Components:
     expenses.
   - Config: Holds the mandatory parameters required to create a new User.
   - New: Creates a new User instance based on the provided configuration.
+  - NewWithoutPassword: Creates a new User who signs in through an external identity
+    provider and has no local password.
+  - Roles: Users may be granted roles, such as RoleAdmin, and may be disabled so that they
+    cannot sign in.
 
 Dependencies:
 - github.com/google/uuid: Used for generating unique IDs.
@@ -35,6 +39,9 @@ const (
 	maxUsernameLength = 20
 )
 
+// RoleAdmin is the role of users who manage other users.
+const RoleAdmin = "admin"
+
 var (
 	usernameRegex = regexp.MustCompile(usernamePattern)
 )
@@ -46,6 +53,8 @@ type User struct {
 	passwordHash string
 	createdAt    time.Time
 	updatedAt    time.Time
+	roles        []string
+	disabledAt   *time.Time
 	expenses     []expensemodel.Expense
 }
 
@@ -66,11 +75,13 @@ type Config struct {
 
 // ConfigForExistingHash holds all parameters for creating a User with an existing password hash.
 type ConfigForExistingHash struct {
-	ID           uuid.UUID // Unique identifier for the user
-	Username     string    // Username of the user
-	PasswordHash string    // Pre-hashed password for the user
-	CreationTime time.Time // Timestamp when the user was created
-	UpdatedAt    time.Time // Timestamp when the user was last updated
+	ID           uuid.UUID  // Unique identifier for the user
+	Username     string     // Username of the user
+	PasswordHash string     // Pre-hashed password for the user; empty if they have none
+	CreationTime time.Time  // Timestamp when the user was created
+	UpdatedAt    time.Time  // Timestamp when the user was last updated
+	Roles        []string   // Roles granted to the user
+	DisabledAt   *time.Time // Timestamp when the user was disabled; nil if they are not
 }
 
 // New creates a new User with the provided configuration.
@@ -87,7 +98,7 @@ func New(config Config) (*User, error) {
 		return nil, err
 	}
 
-	if err := validatePassword(config.PlainPassword); err != nil {
+	if err := ValidatePassword(config.PlainPassword); err != nil {
 		return nil, err
 	}
 
@@ -106,6 +117,34 @@ func New(config Config) (*User, error) {
 	}, nil
 }
 
+// ConfigWithoutPassword holds all mandatory parameters for creating a new User without a
+// password.
+type ConfigWithoutPassword struct {
+	Username     string    // Username of the user
+	CreationTime time.Time // Timestamp when the user is created
+}
+
+// NewWithoutPassword creates a new User with no local password, for users who sign in through
+// an external identity provider. Such a user cannot sign in with a password until they set one,
+// for instance through a password reset.
+//
+// Returns:
+// - A pointer to the newly created User if successful.
+// - An error if the username does not meet format, length, or validity constraints.
+func NewWithoutPassword(config ConfigWithoutPassword) (*User, error) {
+	if err := validateUsername(config.Username); err != nil {
+		return nil, err
+	}
+
+	return &User{
+		id:        uuid.New(), // New ID for the user
+		username:  config.Username,
+		createdAt: config.CreationTime,
+		updatedAt: config.CreationTime,
+		expenses:  []expensemodel.Expense{}, // Ensure slice is initialized
+	}, nil
+}
+
 // NewWithExistingHash creates a new User with the provided configuration, where the password is already hashed.
 //
 // Returns:
@@ -125,6 +164,8 @@ func NewWithExistingHash(config ConfigForExistingHash) (*User, error) {
 		passwordHash: config.PasswordHash,
 		createdAt:    config.CreationTime,
 		updatedAt:    config.UpdatedAt,
+		roles:        append([]string(nil), config.Roles...),
+		disabledAt:   config.DisabledAt,
 		expenses:     []expensemodel.Expense{}, // Ensure slice is initialized
 	}, nil
 }
@@ -143,8 +184,8 @@ func validateUsername(username string) error {
 	return nil
 }
 
-// validatePassword checks the strength of the password.
-func validatePassword(password string) error {
+// ValidatePassword checks that the password is strong enough to be set.
+func ValidatePassword(password string) error {
 	result := zxcvbn.PasswordStrength(password, nil)
 	if result.Score < minPasswordStrengthScore {
 		return erruser.WeakPassword
@@ -167,6 +208,11 @@ func (u *User) PasswordHash() string {
 	return u.passwordHash
 }
 
+// HasPassword reports whether the user has a local password they can sign in with.
+func (u *User) HasPassword() bool {
+	return u.passwordHash != ""
+}
+
 // CreatedAt returns the user's creation timestamp.
 func (u *User) CreatedAt() time.Time {
 	return u.createdAt
@@ -177,6 +223,53 @@ func (u *User) UpdatedAt() time.Time {
 	return u.updatedAt
 }
 
+// Roles returns a copy of the user's roles.
+func (u *User) Roles() []string {
+	rolesCopy := make([]string, len(u.roles))
+	copy(rolesCopy, u.roles)
+	return rolesCopy
+}
+
+// HasRole reports whether the user has been granted the role.
+func (u *User) HasRole(role string) bool {
+	for _, r := range u.roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// DisabledAt returns the timestamp when the user was disabled, or nil if they are not.
+func (u *User) DisabledAt() *time.Time {
+	return u.disabledAt
+}
+
+// Disabled reports whether the user is disabled and so cannot sign in.
+func (u *User) Disabled() bool {
+	return u.disabledAt != nil
+}
+
+// Disable disables the user and updates the user's last updated timestamp. Disabling a
+// disabled user keeps the time they were first disabled.
+func (u *User) Disable(currentUTCTime time.Time) {
+	if u.disabledAt != nil {
+		return
+	}
+	disabledAt := currentUTCTime
+	u.disabledAt = &disabledAt
+	u.updatedAt = currentUTCTime
+}
+
+// Enable enables a disabled user and updates the user's last updated timestamp.
+func (u *User) Enable(currentUTCTime time.Time) {
+	if u.disabledAt == nil {
+		return
+	}
+	u.disabledAt = nil
+	u.updatedAt = currentUTCTime
+}
+
 // Expenses returns a copy of the user's expenses.
 func (u *User) Expenses() []expensemodel.Expense {
 	expensesCopy := make([]expensemodel.Expense, len(u.expenses))
@@ -184,6 +277,26 @@ func (u *User) Expenses() []expensemodel.Expense {
 	return expensesCopy
 }
 
+// ChangePassword replaces the user's password and updates the user's last updated timestamp.
+//
+// Returns:
+// - An error if the new password does not meet the minimum strength requirements or an
+// error occurs during password hashing, otherwise returns nil.
+func (u *User) ChangePassword(plainPassword string, passwordHasher hash.IService, currentUTCTime time.Time) error {
+	if err := ValidatePassword(plainPassword); err != nil {
+		return err
+	}
+
+	passwordHash, err := passwordHasher.Hash(plainPassword)
+	if err != nil {
+		return erruser.Hash
+	}
+
+	u.passwordHash = passwordHash
+	u.updatedAt = currentUTCTime
+	return nil
+}
+
 // AddExpense adds an expense to the user and updates the user's last updated timestamp.
 // It ensures that the expense's user ID matches the user's ID.
 //
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_expenses_user_id_account;
+DROP INDEX IF EXISTS idx_expenses_user_id_category;
+
+ALTER TABLE expenses DROP COLUMN IF EXISTS account;
+ALTER TABLE expenses DROP COLUMN IF EXISTS category;
@@ -0,0 +1,5 @@
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS category VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS account VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_expenses_user_id_category ON expenses (user_id, category);
+CREATE INDEX IF NOT EXISTS idx_expenses_user_id_account ON expenses (user_id, account);
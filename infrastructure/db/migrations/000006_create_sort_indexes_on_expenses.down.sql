@@ -0,0 +1,8 @@
+CREATE INDEX IF NOT EXISTS idx_expenses_id_created_at ON expenses (id, created_at);
+CREATE INDEX IF NOT EXISTS idx_expenses_id_amount ON expenses (id, amount);
+
+DROP INDEX IF EXISTS idx_expenses_user_id_updated_at_id;
+DROP INDEX IF EXISTS idx_expenses_user_id_created_at_id;
+DROP INDEX IF EXISTS idx_expenses_user_id_description_id;
+DROP INDEX IF EXISTS idx_expenses_user_id_amount_id;
+DROP INDEX IF EXISTS idx_expenses_user_id_date_id;
@@ -0,0 +1,8 @@
+CREATE INDEX IF NOT EXISTS idx_expenses_user_id_date_id ON expenses (user_id, date, id);
+CREATE INDEX IF NOT EXISTS idx_expenses_user_id_amount_id ON expenses (user_id, amount, id);
+CREATE INDEX IF NOT EXISTS idx_expenses_user_id_description_id ON expenses (user_id, description, id);
+CREATE INDEX IF NOT EXISTS idx_expenses_user_id_created_at_id ON expenses (user_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_expenses_user_id_updated_at_id ON expenses (user_id, updated_at, id);
+
+DROP INDEX IF EXISTS idx_expenses_id_created_at;
+DROP INDEX IF EXISTS idx_expenses_id_amount;
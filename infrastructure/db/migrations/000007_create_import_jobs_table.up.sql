@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id UUID NOT NULL UNIQUE,
+    user_id UUID NOT NULL,
+    format VARCHAR(16) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    rows JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, user_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
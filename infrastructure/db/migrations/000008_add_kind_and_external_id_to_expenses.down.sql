@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_expenses_user_id_external_id;
+
+ALTER TABLE expenses DROP COLUMN IF EXISTS external_id;
+ALTER TABLE expenses DROP COLUMN IF EXISTS kind;
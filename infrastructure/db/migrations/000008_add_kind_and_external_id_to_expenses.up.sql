@@ -0,0 +1,4 @@
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS kind VARCHAR(16) NOT NULL DEFAULT 'expense';
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS external_id VARCHAR(255);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_expenses_user_id_external_id ON expenses (user_id, external_id) WHERE external_id IS NOT NULL;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS session_revocations;
+
+DROP INDEX IF EXISTS idx_revoked_tokens_expires_at;
+
+DROP TABLE IF EXISTS revoked_tokens;
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_two_factor_challenges_expires_at;
+
+DROP TABLE IF EXISTS two_factor_challenges;
+
+DROP INDEX IF EXISTS idx_two_factor_recovery_codes_user_id;
+
+DROP TABLE IF EXISTS two_factor_recovery_codes;
+
+DROP TABLE IF EXISTS two_factor_secrets;
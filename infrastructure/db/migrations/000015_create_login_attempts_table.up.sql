@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS login_attempts (
+    username_hash VARCHAR(64) PRIMARY KEY,
+    failures INT NOT NULL DEFAULT 0,
+    last_attempt_at TIMESTAMP NOT NULL,
+    blocked_until TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_last_attempt_at ON login_attempts (last_attempt_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_access_tokens_user_id;
+
+DROP TABLE IF EXISTS access_tokens;
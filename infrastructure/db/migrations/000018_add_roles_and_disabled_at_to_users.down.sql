@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_users_created_at;
+
+ALTER TABLE users DROP COLUMN IF EXISTS disabled_at;
+
+ALTER TABLE users DROP COLUMN IF EXISTS roles;
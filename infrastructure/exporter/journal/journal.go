@@ -0,0 +1,250 @@
+// Package journalexporter writes expenses as plain-text accounting journals for
+// beancount and ledger-cli.
+//
+// Every expense becomes a balanced transaction between two accounts. Spending moves
+// money from the asset account it was paid from (Assets:<Account>) to an expense account
+// (Expenses:<Category>); income moves money from an income account (Income:<Category>)
+// to the asset account it was received in. Expenses without an account use Assets:Cash,
+// and those without a category are filed as Uncategorized. Category and account names
+// may use ":" to nest, e.g. "Food:Coffee" becomes Expenses:Food:Coffee.
+//
+// Transactions are written as they come, and the accounts they used are declared once
+// the journal is closed, dated on the first day each account was used. Both beancount
+// and ledger-cli accept declarations anywhere in a file.
+package journalexporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	iexporter "github.com/beka-birhanu/finance-go/application/common/interface/exporter"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+)
+
+const (
+	// Beancount is the name of the beancount format.
+	Beancount = "beancount"
+
+	// Ledger is the name of the ledger-cli format.
+	Ledger = "ledger"
+
+	// DefaultCurrency is the currency of the amounts when none is given.
+	DefaultCurrency = "USD"
+
+	defaultAssetAccount = "Cash"
+	uncategorized       = "Uncategorized"
+)
+
+// currencyPattern matches the commodity names accepted by beancount, which ledger-cli
+// accepts as well.
+var currencyPattern = regexp.MustCompile(`^[A-Z]([A-Z0-9'._-]{0,22}[A-Z0-9])?$`)
+
+// Journal creates writers of one journal format.
+type Journal struct {
+	format string
+}
+
+var _ iexporter.IJournal = &Journal{}
+
+// NewJournal creates a Journal of the named format, Beancount or Ledger.
+func NewJournal(format string) *Journal {
+	return &Journal{format: format}
+}
+
+// NewWriter creates a Writer of the journal format to w.
+func (j *Journal) NewWriter(w io.Writer, currency string) (iexporter.IWriter, error) {
+	return New(w, j.format, currency)
+}
+
+// Writer writes expenses as a journal in one format.
+type Writer struct {
+	out      *bufio.Writer
+	format   string
+	currency string
+	opened   map[string]time.Time // First use of every account
+	started  bool
+}
+
+var _ iexporter.IWriter = &Writer{}
+
+// New creates a Writer of the named format, Beancount or Ledger, for amounts in the
+// given currency, or DefaultCurrency when empty.
+//
+// Returns:
+// - A pointer to the Writer if successful.
+// - A validation error if the format is unknown or the currency is not a valid commodity name.
+func New(w io.Writer, format, currency string) (*Writer, error) {
+	if format != Beancount && format != Ledger {
+		return nil, errdmn.NewValidation(fmt.Sprintf("unsupported journal format %q.", format))
+	}
+
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	if !currencyPattern.MatchString(currency) {
+		return nil, errdmn.NewValidation(fmt.Sprintf("invalid currency %q: must be an upper-case commodity name such as USD.", currency))
+	}
+
+	return &Writer{
+		out:      bufio.NewWriter(w),
+		format:   format,
+		currency: currency,
+		opened:   make(map[string]time.Time),
+	}, nil
+}
+
+// Write writes the transaction of an expense.
+func (j *Writer) Write(expense *expensemodel.Expense) error {
+	if err := j.begin(); err != nil {
+		return err
+	}
+
+	asset := AccountName("Assets", expense.Account(), defaultAssetAccount)
+	var debit, credit string
+	if expense.Kind() == expensemodel.KindIncome {
+		debit, credit = asset, AccountName("Income", expense.Category(), uncategorized)
+	} else {
+		debit, credit = AccountName("Expenses", expense.Category(), uncategorized), asset
+	}
+	j.use(debit, expense.Date())
+	j.use(credit, expense.Date())
+
+	amount := strconv.FormatFloat(float64(expense.Amount()), 'f', 2, 32)
+	var err error
+	switch j.format {
+	case Beancount:
+		_, err = fmt.Fprintf(j.out, "%s * %s\n  id: %s\n  %s  %s %s\n  %s  -%s %s\n\n",
+			expense.Date().Format("2006-01-02"), quote(expense.Description()), quote(expense.ID().String()),
+			debit, amount, j.currency, credit, amount, j.currency)
+	case Ledger:
+		_, err = fmt.Fprintf(j.out, "%s %s\n    ; id: %s\n    %s  %s %s\n    %s  -%s %s\n\n",
+			expense.Date().Format("2006/01/02"), singleLine(expense.Description()), expense.ID(),
+			debit, amount, j.currency, credit, amount, j.currency)
+	}
+	return err
+}
+
+// Flush writes any buffered output to the underlying writer.
+func (j *Writer) Flush() error {
+	return j.out.Flush()
+}
+
+// Close declares every account used by the written transactions and flushes the journal.
+// It does not close the underlying writer.
+func (j *Writer) Close() error {
+	if err := j.begin(); err != nil {
+		return err
+	}
+
+	accounts := make([]string, 0, len(j.opened))
+	for account := range j.opened {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+
+	for _, account := range accounts {
+		var err error
+		switch j.format {
+		case Beancount:
+			_, err = fmt.Fprintf(j.out, "%s open %s %s\n", j.opened[account].Format("2006-01-02"), account, j.currency)
+		case Ledger:
+			_, err = fmt.Fprintf(j.out, "account %s\n", account)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return j.out.Flush()
+}
+
+// begin writes the options of the journal before its first entry.
+func (j *Writer) begin() error {
+	if j.started {
+		return nil
+	}
+	j.started = true
+
+	var err error
+	switch j.format {
+	case Beancount:
+		_, err = fmt.Fprintf(j.out, "option \"operating_currency\" %s\n\n", quote(j.currency))
+	case Ledger:
+		_, err = fmt.Fprintf(j.out, "commodity %s\n\n", j.currency)
+	}
+	return err
+}
+
+// use records that the account was used on the given date.
+func (j *Writer) use(account string, date time.Time) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	if first, ok := j.opened[account]; !ok || day.Before(first) {
+		j.opened[account] = day
+	}
+}
+
+// AccountName builds a journal account name under the root account from a category or
+// account name, using fallback when the name is empty. Each ":"-separated component is
+// capitalized and stripped of characters that account names cannot hold, so that the
+// result is valid in both beancount and ledger-cli, e.g. "food:coffee & tea" under
+// "Expenses" becomes "Expenses:Food:Coffee-tea".
+func AccountName(root, name, fallback string) string {
+	components := []string{root}
+	for _, component := range strings.Split(name, ":") {
+		if component = sanitizeComponent(component); component != "" {
+			components = append(components, component)
+		}
+	}
+	if len(components) == 1 {
+		components = append(components, fallback)
+	}
+	return strings.Join(components, ":")
+}
+
+// sanitizeComponent turns a component of a name into a valid account name component:
+// runs of characters other than letters and digits become a single dash, and the first
+// character is upper-cased, or an "X" is put in front when it cannot be.
+func sanitizeComponent(component string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.TrimSpace(component) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+			continue
+		}
+		dash = true
+	}
+
+	sanitized := []rune(b.String())
+	if len(sanitized) == 0 {
+		return ""
+	}
+	sanitized[0] = unicode.ToUpper(sanitized[0])
+	if !unicode.IsUpper(sanitized[0]) && !unicode.IsDigit(sanitized[0]) {
+		return "X" + string(sanitized)
+	}
+	return string(sanitized)
+}
+
+// quote returns s as a beancount string literal on a single line.
+func quote(s string) string {
+	s = strings.ReplaceAll(singleLine(s), `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// singleLine replaces line breaks and tabs with spaces.
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,115 @@
+package journalexporter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+	"github.com/google/uuid"
+)
+
+func newExpense(t *testing.T, config expensemodel.Config) *expensemodel.Expense {
+	config.UserId = uuid.New()
+	config.CreationTime = time.Now().UTC()
+	expense, err := expensemodel.New(config)
+	if err != nil {
+		t.Fatalf("failed to create expense: %v", err)
+	}
+	return expense
+}
+
+func TestWriter(t *testing.T) {
+	expenses := []*expensemodel.Expense{
+		newExpense(t, expensemodel.Config{
+			Description: `Coffee "to go"`, Amount: 3.5, Category: "food:coffee & tea", Account: "checking",
+			Date: time.Date(2024, 6, 8, 8, 0, 0, 0, time.UTC),
+		}),
+		newExpense(t, expensemodel.Config{
+			Description: "Salary", Amount: 2500, Kind: expensemodel.KindIncome, Account: "checking",
+			Date: time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC),
+		}),
+		newExpense(t, expensemodel.Config{
+			Description: "Bus fare", Amount: 2.5,
+			Date: time.Date(2024, 6, 9, 8, 0, 0, 0, time.UTC),
+		}),
+	}
+
+	tests := []struct {
+		format   string
+		expected []string
+	}{
+		{
+			format: Beancount,
+			expected: []string{
+				`option "operating_currency" "EUR"`,
+				"2024-06-08 * \"Coffee \\\"to go\\\"\"\n  id: \"" + expenses[0].ID().String() + "\"\n  Expenses:Food:Coffee-tea  3.50 EUR\n  Assets:Checking  -3.50 EUR\n",
+				"2024-06-01 * \"Salary\"\n  id: \"" + expenses[1].ID().String() + "\"\n  Assets:Checking  2500.00 EUR\n  Income:Uncategorized  -2500.00 EUR\n",
+				"  Expenses:Uncategorized  2.50 EUR\n  Assets:Cash  -2.50 EUR\n",
+				"2024-06-01 open Assets:Checking EUR\n",
+				"2024-06-08 open Expenses:Food:Coffee-tea EUR\n",
+			},
+		},
+		{
+			format: Ledger,
+			expected: []string{
+				"commodity EUR",
+				"2024/06/08 Coffee \"to go\"\n    ; id: " + expenses[0].ID().String() + "\n    Expenses:Food:Coffee-tea  3.50 EUR\n    Assets:Checking  -3.50 EUR\n",
+				"account Assets:Cash\n",
+				"account Income:Uncategorized\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var out strings.Builder
+			writer, err := New(&out, tt.format, "EUR")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, expense := range expenses {
+				if err := writer.Write(expense); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, expected := range tt.expected {
+				if !strings.Contains(out.String(), expected) {
+					t.Errorf("expected journal to contain %q, got:\n%s", expected, out.String())
+				}
+			}
+		})
+	}
+
+	t.Run("InvalidOptions", func(t *testing.T) {
+		for _, c := range []struct{ format, currency string }{{"gnucash", "USD"}, {Beancount, "usd"}, {Ledger, "$"}} {
+			_, err := New(&strings.Builder{}, c.format, c.currency)
+			domainErr, ok := err.(*errdmn.Error)
+			if !ok || domainErr.Type() != errdmn.Validation {
+				t.Errorf("%s/%s: expected validation error, got %v", c.format, c.currency, err)
+			}
+		}
+	})
+}
+
+func TestAccountName(t *testing.T) {
+	cases := []struct{ root, name, expected string }{
+		{"Expenses", "groceries", "Expenses:Groceries"},
+		{"Expenses", " food : coffee ", "Expenses:Food:Coffee"},
+		{"Assets", "N26 (main)", "Assets:N26-main"},
+		{"Expenses", "::", "Expenses:Uncategorized"},
+		{"Expenses", "ça va", "Expenses:Ça-va"},
+		{"Expenses", "日本", "Expenses:X日本"},
+	}
+
+	for _, c := range cases {
+		if got := AccountName(c.root, c.name, uncategorized); got != c.expected {
+			t.Errorf("AccountName(%q, %q) = %q, expected %q", c.root, c.name, got, c.expected)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+// Package csvimporter provides the implementation of the iimporter.IParser interface for
+// CSV files, with user-supplied column mappings and date and number formats.
+package csvimporter
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	iimporter "github.com/beka-birhanu/finance-go/application/common/interface/importer"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+)
+
+// Format is the name of the format handled by the Parser.
+const Format = "csv"
+
+const defaultDateFormat = "YYYY-MM-DD"
+
+// dateTokens converts the tokens of a date pattern to the reference time layout.
+var dateTokens = strings.NewReplacer(
+	"YYYY", "2006",
+	"YY", "06",
+	"MM", "01",
+	"DD", "02",
+	"HH", "15",
+	"mm", "04",
+	"ss", "05",
+)
+
+// Parser parses CSV files.
+type Parser struct{}
+
+var _ iimporter.IParser = &Parser{}
+
+// New creates a new CSV Parser.
+func New() *Parser {
+	return &Parser{}
+}
+
+// columns holds the 0-based positions of the mapped columns; -1 marks an unmapped column.
+type columns struct {
+	date, description, amount, category, account int
+}
+
+// Parse reads every row of the CSV file according to the options. It fails when the
+// options are invalid, the mapping does not match the file, or the file is not valid CSV.
+func (p *Parser) Parse(r io.Reader, options iimporter.Options) ([]iimporter.Row, error) {
+	decimalSeparator := options.DecimalSeparator
+	if decimalSeparator == "" {
+		decimalSeparator = "."
+	}
+	if decimalSeparator != "." && decimalSeparator != "," {
+		return nil, errdmn.NewValidation(fmt.Sprintf("unsupported decimal separator %q.", decimalSeparator))
+	}
+
+	delimiter, err := resolveDelimiter(options.Delimiter, decimalSeparator)
+	if err != nil {
+		return nil, err
+	}
+
+	dateFormat := options.DateFormat
+	if dateFormat == "" {
+		dateFormat = defaultDateFormat
+	}
+	layout := DateLayout(dateFormat)
+
+	reader := csv.NewReader(r)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var header []string
+	if options.HasHeader {
+		header, err = reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil, errdmn.NewValidation("the file is empty.")
+		}
+		if err != nil {
+			return nil, errdmn.NewValidation(fmt.Sprintf("malformed CSV: %v", err))
+		}
+	}
+
+	cols, err := resolveColumns(options.Mapping, header)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []iimporter.Row
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errdmn.NewValidation(fmt.Sprintf("malformed CSV: %v", err))
+		}
+
+		line, _ := reader.FieldPos(0)
+		if isBlank(record) {
+			continue
+		}
+
+		parsed, err := parseRecord(record, cols, layout, decimalSeparator)
+		rows = append(rows, iimporter.Row{Line: line, Record: parsed, Err: err})
+	}
+
+	return rows, nil
+}
+
+// resolveDelimiter returns the field delimiter, defaulting to a semicolon when commas
+// separate decimals.
+func resolveDelimiter(delimiter, decimalSeparator string) (rune, error) {
+	if delimiter == "" {
+		if decimalSeparator == "," {
+			return ';', nil
+		}
+		return ',', nil
+	}
+
+	if delimiter == `\t` {
+		delimiter = "\t"
+	}
+	d, size := utf8.DecodeRuneInString(delimiter)
+	if size != len(delimiter) || d == utf8.RuneError || d == '"' || d == '\r' || d == '\n' {
+		return 0, errdmn.NewValidation(fmt.Sprintf("unsupported delimiter %q.", delimiter))
+	}
+	if string(d) == decimalSeparator {
+		return 0, errdmn.NewValidation("the delimiter cannot be the decimal separator.")
+	}
+	return d, nil
+}
+
+// defaultMapping is used for files with a header when no mapping is given.
+var defaultMapping = iimporter.ColumnMapping{
+	Date:        "date",
+	Description: "description",
+	Amount:      "amount",
+	Category:    "category",
+	Account:     "account",
+}
+
+// resolveColumns finds the positions of the mapped columns, by name in the header or by
+// 1-based column number when there is none. Without a mapping, the header is expected
+// to name the columns after the expense fields; the optional ones may be missing.
+func resolveColumns(mapping iimporter.ColumnMapping, header []string) (columns, error) {
+	if mapping == (iimporter.ColumnMapping{}) && header != nil {
+		mapping = defaultMapping
+		if !hasColumn(header, mapping.Category) {
+			mapping.Category = ""
+		}
+		if !hasColumn(header, mapping.Account) {
+			mapping.Account = ""
+		}
+	}
+
+	if mapping.Date == "" || mapping.Description == "" || mapping.Amount == "" {
+		return columns{}, errdmn.NewValidation("the column mapping must name the date, description and amount columns.")
+	}
+
+	resolve := func(name string) (int, error) {
+		if name == "" {
+			return -1, nil
+		}
+		if header == nil {
+			n, err := strconv.Atoi(name)
+			if err != nil || n < 1 {
+				return 0, errdmn.NewValidation(fmt.Sprintf("column %q must be a column number when the file has no header.", name))
+			}
+			return n - 1, nil
+		}
+		if i := columnIndex(header, name); i >= 0 {
+			return i, nil
+		}
+		return 0, errdmn.NewValidation(fmt.Sprintf("column %q is not in the header.", name))
+	}
+
+	var cols columns
+	var err error
+	for _, c := range []struct {
+		name string
+		pos  *int
+	}{
+		{mapping.Date, &cols.date},
+		{mapping.Description, &cols.description},
+		{mapping.Amount, &cols.amount},
+		{mapping.Category, &cols.category},
+		{mapping.Account, &cols.account},
+	} {
+		if *c.pos, err = resolve(c.name); err != nil {
+			return columns{}, err
+		}
+	}
+	return cols, nil
+}
+
+// columnIndex returns the position of the named column in the header, ignoring case and
+// a leading byte order mark, or -1 when there is no such column.
+func columnIndex(header []string, name string) int {
+	for i, column := range header {
+		if strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")), strings.TrimSpace(name)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// hasColumn reports whether the header has the named column.
+func hasColumn(header []string, name string) bool {
+	return columnIndex(header, name) >= 0
+}
+
+// parseRecord reads the mapped fields of a CSV record.
+func parseRecord(record []string, cols columns, layout, decimalSeparator string) (iimporter.Record, error) {
+	field := func(pos int) (string, error) {
+		if pos < 0 {
+			return "", nil
+		}
+		if pos >= len(record) {
+			return "", fmt.Errorf("row has %d columns, column %d is missing", len(record), pos+1)
+		}
+		return strings.TrimSpace(record[pos]), nil
+	}
+
+	var parsed iimporter.Record
+	rawDate, err := field(cols.date)
+	if err != nil {
+		return iimporter.Record{}, err
+	}
+	if parsed.Date, err = time.Parse(layout, rawDate); err != nil {
+		return iimporter.Record{}, fmt.Errorf("invalid date %q", rawDate)
+	}
+
+	rawAmount, err := field(cols.amount)
+	if err != nil {
+		return iimporter.Record{}, err
+	}
+	if parsed.Amount, err = ParseAmount(rawAmount, decimalSeparator); err != nil {
+		return iimporter.Record{}, err
+	}
+
+	if parsed.Description, err = field(cols.description); err != nil {
+		return iimporter.Record{}, err
+	}
+	if parsed.Category, err = field(cols.category); err != nil {
+		return iimporter.Record{}, err
+	}
+	if parsed.Account, err = field(cols.account); err != nil {
+		return iimporter.Record{}, err
+	}
+
+	return parsed, nil
+}
+
+// maxAmount bounds the magnitude of amounts, which are stored as numeric(10,2).
+const maxAmount = 1e8
+
+// ParseAmount parses a number written with the given decimal separator, ignoring
+// thousands separators, e.g. "1.234,56" with a comma decimal separator. Amounts that are
+// not finite or too large to be stored are rejected.
+func ParseAmount(raw, decimalSeparator string) (float32, error) {
+	normalized := strings.ReplaceAll(raw, " ", "")
+	normalized = strings.ReplaceAll(normalized, "\u00a0", "")
+	if decimalSeparator == "," {
+		normalized = strings.ReplaceAll(normalized, ".", "")
+		normalized = strings.ReplaceAll(normalized, ",", ".")
+	} else {
+		normalized = strings.ReplaceAll(normalized, ",", "")
+	}
+
+	amount, err := strconv.ParseFloat(normalized, 32)
+	if err != nil || normalized == "" {
+		return 0, fmt.Errorf("invalid amount %q", raw)
+	}
+	// The check is made after rounding to float32, which can round up to the bound.
+	if rounded := float64(float32(amount)); math.IsNaN(rounded) || math.Abs(rounded) >= maxAmount {
+		return 0, fmt.Errorf("amount %q is out of range", raw)
+	}
+	return float32(amount), nil
+}
+
+// DateLayout converts a date pattern such as "DD.MM.YYYY" to the reference time layout.
+func DateLayout(pattern string) string {
+	return dateTokens.Replace(pattern)
+}
+
+// isBlank reports whether every field of the record is empty.
+func isBlank(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+package csvimporter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	iimporter "github.com/beka-birhanu/finance-go/application/common/interface/importer"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+)
+
+func TestParser_Parse(t *testing.T) {
+	parser := New()
+
+	t.Run("MappingByHeader", func(t *testing.T) {
+		file := "\ufeffBooked On,Payee,Value,Notes\n" +
+			"2024-06-01,Coffee,3.50,ignored\n" +
+			"\n" +
+			"2024-06-02,Rent,\"1,200.00\",\n"
+
+		rows, err := parser.Parse(strings.NewReader(file), iimporter.Options{
+			Mapping:   iimporter.ColumnMapping{Date: "booked on", Description: "Payee", Amount: "VALUE"},
+			HasHeader: true,
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(rows) != 2 {
+			t.Fatalf("expected 2 rows, got %d", len(rows))
+		}
+		if rows[1].Line != 4 || rows[1].Err != nil || rows[1].Record.Amount != 1200 || rows[1].Record.Description != "Rent" {
+			t.Errorf("unexpected row: %+v", rows[1])
+		}
+		if !rows[0].Record.Date.Equal(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected date: %v", rows[0].Record.Date)
+		}
+	})
+
+	t.Run("DefaultMapping", func(t *testing.T) {
+		file := "date,description,amount\n2024-06-01,Coffee,3.50\n"
+
+		rows, err := parser.Parse(strings.NewReader(file), iimporter.Options{HasHeader: true})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(rows) != 1 || rows[0].Err != nil || rows[0].Record.Amount != 3.5 {
+			t.Errorf("unexpected rows: %+v", rows)
+		}
+	})
+
+	t.Run("MappingByColumnNumberWithCommaDecimals", func(t *testing.T) {
+		file := "01.06.2024;Coffee;3,50;Food\n" +
+			"02.06.2024;Rent;1.200,00;Home\n"
+
+		rows, err := parser.Parse(strings.NewReader(file), iimporter.Options{
+			Mapping:          iimporter.ColumnMapping{Date: "1", Description: "2", Amount: "3", Category: "4"},
+			DateFormat:       "DD.MM.YYYY",
+			DecimalSeparator: ",",
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(rows) != 2 || rows[0].Record.Amount != 3.5 || rows[1].Record.Amount != 1200 || rows[1].Record.Category != "Home" {
+			t.Errorf("unexpected rows: %+v", rows)
+		}
+	})
+
+	t.Run("RowErrors", func(t *testing.T) {
+		file := "date,description,amount\n" +
+			"2024-13-01,Coffee,3.50\n" +
+			"2024-06-01,Coffee,abc\n" +
+			"2024-06-01,Coffee\n" +
+			"2024-06-01,Coffee,NaN\n" +
+			"2024-06-01,Coffee,-Inf\n" +
+			"2024-06-01,Coffee,100000000\n" +
+			"2024-06-01,Coffee,99999999.99\n"
+
+		rows, err := parser.Parse(strings.NewReader(file), iimporter.Options{HasHeader: true})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(rows) != 7 {
+			t.Fatalf("expected 7 rows, got %d", len(rows))
+		}
+		for _, row := range rows {
+			if row.Err == nil {
+				t.Errorf("expected an error for line %d", row.Line)
+			}
+		}
+	})
+
+	t.Run("InvalidOptions", func(t *testing.T) {
+		cases := []struct {
+			name    string
+			file    string
+			options iimporter.Options
+		}{
+			{name: "unknown column", file: "date,amount\n", options: iimporter.Options{HasHeader: true, Mapping: iimporter.ColumnMapping{Date: "date", Description: "payee", Amount: "amount"}}},
+			{name: "column name without header", file: "2024-06-01,Coffee,3.50\n", options: iimporter.Options{Mapping: iimporter.ColumnMapping{Date: "date", Description: "2", Amount: "3"}}},
+			{name: "delimiter is decimal separator", file: "", options: iimporter.Options{DecimalSeparator: ",", Delimiter: ","}},
+			{name: "empty file", file: "", options: iimporter.Options{HasHeader: true}},
+		}
+
+		for _, c := range cases {
+			_, err := parser.Parse(strings.NewReader(c.file), c.options)
+			domainErr, ok := err.(*errdmn.Error)
+			if !ok || domainErr.Type() != errdmn.Validation {
+				t.Errorf("%s: expected validation error, got %v", c.name, err)
+			}
+		}
+	})
+}
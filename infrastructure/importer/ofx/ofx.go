@@ -0,0 +1,192 @@
+// Package ofximporter provides the implementation of the iimporter.IParser interface for
+// OFX bank statements, in both the SGML (1.x) and the XML (2.x) flavours.
+package ofximporter
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	iimporter "github.com/beka-birhanu/finance-go/application/common/interface/importer"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+)
+
+// Format is the name of the format handled by the Parser.
+const Format = "ofx"
+
+// Parser parses OFX files.
+type Parser struct{}
+
+var _ iimporter.IParser = &Parser{}
+
+// New creates a new OFX Parser.
+func New() *Parser {
+	return &Parser{}
+}
+
+// transaction holds the elements of a STMTTRN aggregate.
+type transaction struct {
+	line   int
+	fields map[string]string
+}
+
+// Parse reads every statement transaction of the OFX file. Debits become expenses and
+// credits income; the transaction's FITID, scoped by the account it belongs to, becomes
+// the external ID of the record so that it is not imported twice. The options do not
+// apply to OFX files and are ignored.
+func (p *Parser) Parse(r io.Reader, options iimporter.Options) ([]iimporter.Row, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errdmn.NewValidation(fmt.Sprintf("unreadable file: %v", err))
+	}
+
+	content := string(data)
+	start := strings.Index(strings.ToUpper(content), "<OFX>")
+	if start < 0 {
+		return nil, errdmn.NewValidation("the file is not an OFX statement.")
+	}
+
+	var (
+		rows      []iimporter.Row
+		accountID string
+		current   *transaction
+	)
+	line := 1 + strings.Count(content[:start], "\n")
+	rest := content[start:]
+	for {
+		open := strings.IndexByte(rest, '<')
+		if open < 0 {
+			break
+		}
+		line += strings.Count(rest[:open], "\n")
+		rest = rest[open+1:]
+
+		end := strings.IndexByte(rest, '>')
+		if end < 0 {
+			return nil, errdmn.NewValidation(fmt.Sprintf("malformed OFX: unterminated tag on line %d.", line))
+		}
+		tag := strings.ToUpper(strings.TrimSpace(rest[:end]))
+		rest = rest[end+1:]
+
+		next := strings.IndexByte(rest, '<')
+		if next < 0 {
+			next = len(rest)
+		}
+		value := strings.TrimSpace(html.UnescapeString(rest[:next]))
+
+		switch tag {
+		case "STMTTRN":
+			if current != nil {
+				rows = append(rows, newRow(current, accountID))
+			}
+			current = &transaction{line: line, fields: make(map[string]string)}
+		case "/STMTTRN", "/BANKTRANLIST":
+			if current != nil {
+				rows = append(rows, newRow(current, accountID))
+				current = nil
+			}
+		case "ACCTID":
+			// Inside a transaction, ACCTID names the other side of a transfer.
+			if current == nil {
+				accountID = value
+			}
+		default:
+			if current != nil && !strings.HasPrefix(tag, "/") {
+				current.fields[tag] = value
+			}
+		}
+	}
+
+	if current != nil {
+		rows = append(rows, newRow(current, accountID))
+	}
+
+	return rows, nil
+}
+
+// newRow reads the record of a statement transaction.
+func newRow(txn *transaction, accountID string) iimporter.Row {
+	record, err := newRecord(txn.fields, accountID)
+	return iimporter.Row{Line: txn.line, Record: record, Err: err}
+}
+
+// newRecord maps the elements of a statement transaction to a record.
+func newRecord(fields map[string]string, accountID string) (iimporter.Record, error) {
+	date, err := parseDate(fields["DTPOSTED"])
+	if err != nil {
+		return iimporter.Record{}, err
+	}
+
+	amount, err := parseAmount(fields["TRNAMT"])
+	if err != nil {
+		return iimporter.Record{}, err
+	}
+
+	kind := expensemodel.KindExpense
+	if amount > 0 {
+		kind = expensemodel.KindIncome
+	}
+
+	description := fields["NAME"]
+	if description == "" {
+		description = fields["MEMO"]
+	}
+
+	var externalID string
+	if fitID := fields["FITID"]; fitID != "" {
+		externalID = fitID
+		if accountID != "" {
+			externalID = accountID + ":" + fitID
+		}
+	}
+
+	return iimporter.Record{
+		Date:        date,
+		Description: description,
+		Amount:      float32(math.Abs(amount)),
+		Kind:        kind,
+		Account:     accountID,
+		ExternalID:  externalID,
+	}, nil
+}
+
+// parseDate reads the day of an OFX datetime, "YYYYMMDD" optionally followed by the time
+// and time zone, e.g. "20240601120000.000[-5:EST]".
+func parseDate(raw string) (time.Time, error) {
+	if len(raw) < 8 {
+		return time.Time{}, fmt.Errorf("invalid date %q", raw)
+	}
+
+	date, err := time.Parse("20060102", raw[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", raw)
+	}
+	return date, nil
+}
+
+// maxAmount bounds the magnitude of amounts, which are stored as numeric(10,2).
+const maxAmount = 1e8
+
+// parseAmount reads a signed OFX amount, which may use a comma as decimal separator.
+// Amounts that are not finite or too large to be stored are rejected.
+func parseAmount(raw string) (float64, error) {
+	normalized := raw
+	if !strings.Contains(normalized, ".") {
+		normalized = strings.Replace(normalized, ",", ".", 1)
+	}
+
+	amount, err := strconv.ParseFloat(normalized, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", raw)
+	}
+	// Amounts are stored as float32, which can round up to the bound.
+	if rounded := float64(float32(amount)); math.IsNaN(rounded) || math.Abs(rounded) >= maxAmount {
+		return 0, fmt.Errorf("amount %q is out of range", raw)
+	}
+	return amount, nil
+}
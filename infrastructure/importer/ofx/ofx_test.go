@@ -0,0 +1,133 @@
+package ofximporter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	iimporter "github.com/beka-birhanu/finance-go/application/common/interface/importer"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+)
+
+const sgmlStatement = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>USD
+<BANKACCTFROM>
+<BANKID>121000248
+<ACCTID>12345678
+<ACCTTYPE>CHECKING
+</BANKACCTFROM>
+<BANKTRANLIST>
+<DTSTART>20240601
+<DTEND>20240630
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20240601120000.000[-5:EST]
+<TRNAMT>-3.50
+<FITID>2024060101
+<NAME>Coffee &amp; Bagel
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20240602
+<TRNAMT>2500,00
+<FITID>2024060201
+<MEMO>Salary
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>June 3rd
+<TRNAMT>-10.00
+<FITID>2024060301
+<NAME>Books
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+const xmlStatement = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX><CREDITCARDMSGSRSV1><CCSTMTTRNRS><CCSTMTRS>
+<CCACCTFROM><ACCTID>4111</ACCTID></CCACCTFROM>
+<BANKTRANLIST>
+<STMTTRN><TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20240605</DTPOSTED><TRNAMT>-42.10</TRNAMT><FITID>A1</FITID><NAME>Groceries</NAME></STMTTRN>
+</BANKTRANLIST>
+</CCSTMTRS></CCSTMTTRNRS></CREDITCARDMSGSRSV1></OFX>
+`
+
+func TestParser_Parse(t *testing.T) {
+	parser := New()
+
+	t.Run("SGML", func(t *testing.T) {
+		rows, err := parser.Parse(strings.NewReader(sgmlStatement), iimporter.Options{})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(rows) != 3 {
+			t.Fatalf("expected 3 rows, got %d", len(rows))
+		}
+
+		debit := rows[0].Record
+		if rows[0].Err != nil || rows[0].Line != 16 || debit.Amount != 3.5 || debit.Kind != expensemodel.KindExpense ||
+			debit.Description != "Coffee & Bagel" || debit.ExternalID != "12345678:2024060101" || debit.Account != "12345678" ||
+			!debit.Date.Equal(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected debit: %+v", rows[0])
+		}
+
+		credit := rows[1].Record
+		if rows[1].Err != nil || credit.Amount != 2500 || credit.Kind != expensemodel.KindIncome || credit.Description != "Salary" {
+			t.Errorf("unexpected credit: %+v", rows[1])
+		}
+
+		if rows[2].Err == nil {
+			t.Errorf("expected an invalid date error, got %+v", rows[2])
+		}
+	})
+
+	t.Run("XML", func(t *testing.T) {
+		rows, err := parser.Parse(strings.NewReader(xmlStatement), iimporter.Options{})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(rows) != 1 || rows[0].Err != nil || rows[0].Record.Amount != 42.1 || rows[0].Record.ExternalID != "4111:A1" {
+			t.Errorf("unexpected rows: %+v", rows)
+		}
+	})
+
+	t.Run("NotOFX", func(t *testing.T) {
+		if _, err := parser.Parse(strings.NewReader("date,amount\n"), iimporter.Options{}); err == nil {
+			t.Error("expected an error for a file that is not OFX")
+		}
+	})
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected float64
+		valid    bool
+	}{
+		{raw: "-3.50", expected: -3.5, valid: true},
+		{raw: "2500,00", expected: 2500, valid: true},
+		{raw: "abc"},
+		{raw: "NaN"},
+		{raw: "Inf"},
+		{raw: "-100000000"},
+		{raw: "99999999.99"},
+	}
+
+	for _, tt := range tests {
+		amount, err := parseAmount(tt.raw)
+		if tt.valid && (err != nil || amount != tt.expected) {
+			t.Errorf("%s: expected %v, got %v (%v)", tt.raw, tt.expected, amount, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%s: expected an error, got %v", tt.raw, amount)
+		}
+	}
+}
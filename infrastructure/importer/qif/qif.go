@@ -0,0 +1,215 @@
+// Package qifimporter provides the implementation of the iimporter.IParser interface for
+// QIF bank statements.
+package qifimporter
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	iimporter "github.com/beka-birhanu/finance-go/application/common/interface/importer"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+	csvimporter "github.com/beka-birhanu/finance-go/infrastructure/importer/csv"
+)
+
+// Format is the name of the format handled by the Parser.
+const Format = "qif"
+
+// defaultDateLayouts are tried in order when no date format is given. QIF files written
+// by US software use month-first dates, with an apostrophe before the year after 1999.
+var defaultDateLayouts = []string{"1/2/2006", "1/2/06", "2006-01-02"}
+
+// transactionTypes are the account types whose records are bank transactions.
+var transactionTypes = map[string]bool{
+	"bank":  true,
+	"cash":  true,
+	"ccard": true,
+	"oth a": true,
+	"oth l": true,
+}
+
+// Parser parses QIF files.
+type Parser struct{}
+
+var _ iimporter.IParser = &Parser{}
+
+// New creates a new QIF Parser.
+func New() *Parser {
+	return &Parser{}
+}
+
+// entry holds the fields of a QIF record.
+type entry struct {
+	line   int
+	fields map[byte]string
+}
+
+// Parse reads every bank transaction of the QIF file. Payments become expenses and
+// deposits income. QIF has no transaction IDs, so the external ID of a record is derived
+// from the account, date, amount, payee and check number of the transaction, together
+// with how many identical transactions precede it in the file; importing the same
+// statement again therefore skips its transactions. Only the date format and decimal
+// separator options apply to QIF files.
+func (p *Parser) Parse(r io.Reader, options iimporter.Options) ([]iimporter.Row, error) {
+	decimalSeparator := options.DecimalSeparator
+	if decimalSeparator == "" {
+		decimalSeparator = "."
+	}
+	if decimalSeparator != "." && decimalSeparator != "," {
+		return nil, errdmn.NewValidation(fmt.Sprintf("unsupported decimal separator %q.", decimalSeparator))
+	}
+
+	layouts := defaultDateLayouts
+	if options.DateFormat != "" {
+		layouts = []string{csvimporter.DateLayout(options.DateFormat)}
+	}
+
+	var (
+		rows        []iimporter.Row
+		account     string
+		section     string
+		current     *entry
+		occurrences = make(map[string]int)
+	)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		if strings.HasPrefix(text, "!") {
+			section = headerSection(text)
+			current = nil
+			continue
+		}
+
+		if text[0] != '^' {
+			if current == nil {
+				current = &entry{line: line, fields: make(map[byte]string)}
+			}
+			// Split lines (S, E and $) repeat; only the first of each field is kept.
+			if _, ok := current.fields[text[0]]; !ok {
+				current.fields[text[0]] = strings.TrimSpace(text[1:])
+			}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+		switch {
+		case section == "account":
+			account = current.fields['N']
+		case transactionTypes[section]:
+			record, err := newRecord(current.fields, account, layouts, decimalSeparator)
+			if err == nil {
+				record.ExternalID = externalID(record, current.fields, occurrences)
+			}
+			rows = append(rows, iimporter.Row{Line: current.line, Record: record, Err: err})
+		}
+		current = nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errdmn.NewValidation(fmt.Sprintf("malformed QIF: %v", err))
+	}
+	if section == "" {
+		return nil, errdmn.NewValidation("the file is not a QIF statement.")
+	}
+
+	return rows, nil
+}
+
+// headerSection returns the section started by a header line, e.g. "bank" for
+// "!Type:Bank" or "account" for "!Account". Option lines do not change the section.
+func headerSection(header string) string {
+	header = strings.ToLower(strings.TrimSpace(header))
+	switch {
+	case header == "!account":
+		return "account"
+	case strings.HasPrefix(header, "!type:"):
+		return strings.TrimSpace(strings.TrimPrefix(header, "!type:"))
+	default:
+		return "option"
+	}
+}
+
+// newRecord maps the fields of a QIF transaction to a record.
+func newRecord(fields map[byte]string, account string, layouts []string, decimalSeparator string) (iimporter.Record, error) {
+	date, err := parseDate(fields['D'], layouts)
+	if err != nil {
+		return iimporter.Record{}, err
+	}
+
+	rawAmount, ok := fields['T']
+	if !ok {
+		rawAmount = fields['U']
+	}
+	amount, err := csvimporter.ParseAmount(rawAmount, decimalSeparator)
+	if err != nil {
+		return iimporter.Record{}, err
+	}
+
+	kind := expensemodel.KindExpense
+	if amount > 0 {
+		kind = expensemodel.KindIncome
+	}
+	if amount < 0 {
+		amount = -amount
+	}
+
+	description := fields['P']
+	if description == "" {
+		description = fields['M']
+	}
+
+	return iimporter.Record{
+		Date:        date,
+		Description: description,
+		Amount:      amount,
+		Kind:        kind,
+		Category:    strings.Trim(fields['L'], "[]"),
+		Account:     account,
+	}, nil
+}
+
+// parseDate reads a QIF date with the first layout that fits. The apostrophe some
+// programs put before the year is read as a slash.
+func parseDate(raw string, layouts []string) (time.Time, error) {
+	normalized := strings.ReplaceAll(strings.ReplaceAll(raw, "'", "/"), " ", "")
+	for _, layout := range layouts {
+		if date, err := time.Parse(layout, normalized); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", raw)
+}
+
+// externalID derives a stable ID for a transaction. occurrences counts the transactions
+// seen so far with the same details, so that identical transactions on the same day get
+// distinct IDs.
+func externalID(record iimporter.Record, fields map[byte]string, occurrences map[string]int) string {
+	key := strings.Join([]string{
+		record.Account,
+		record.Date.Format("2006-01-02"),
+		fmt.Sprintf("%s%.2f", record.Kind, record.Amount),
+		record.Description,
+		fields['N'],
+	}, "\x1f")
+	occurrences[key]++
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x1f%d", key, occurrences[key])))
+	return "qif:" + hex.EncodeToString(sum[:16])
+}
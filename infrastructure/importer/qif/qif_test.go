@@ -0,0 +1,79 @@
+package qifimporter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	iimporter "github.com/beka-birhanu/finance-go/application/common/interface/importer"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+)
+
+const statement = `!Account
+NChecking
+TBank
+^
+!Type:Bank
+D6/ 1'24
+T-3.50
+PCoffee
+LFood:Coffee
+^
+D6/ 1'24
+T-3.50
+PCoffee
+^
+D06/02/2024
+T2,500.00
+PEmployer
+LSalary
+^
+D6/3'24
+T-abc
+PBooks
+^
+!Type:Cat
+NFood
+^
+`
+
+func TestParser_Parse(t *testing.T) {
+	parser := New()
+
+	rows, err := parser.Parse(strings.NewReader(statement), iimporter.Options{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("expected 4 rows, got %d", len(rows))
+	}
+
+	coffee := rows[0].Record
+	if rows[0].Err != nil || rows[0].Line != 6 || coffee.Amount != 3.5 || coffee.Kind != expensemodel.KindExpense ||
+		coffee.Category != "Food:Coffee" || coffee.Account != "Checking" ||
+		!coffee.Date.Equal(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected row: %+v", rows[0])
+	}
+
+	if coffee.ExternalID == "" || coffee.ExternalID == rows[1].Record.ExternalID {
+		t.Errorf("expected identical transactions to get distinct external IDs, got %q and %q", coffee.ExternalID, rows[1].Record.ExternalID)
+	}
+
+	again, _ := parser.Parse(strings.NewReader(statement), iimporter.Options{})
+	if again[0].Record.ExternalID != coffee.ExternalID {
+		t.Error("expected external IDs to be stable across imports")
+	}
+
+	salary := rows[2].Record
+	if rows[2].Err != nil || salary.Amount != 2500 || salary.Kind != expensemodel.KindIncome {
+		t.Errorf("unexpected row: %+v", rows[2])
+	}
+
+	if rows[3].Err == nil {
+		t.Errorf("expected an invalid amount error, got %+v", rows[3])
+	}
+
+	if _, err := parser.Parse(strings.NewReader("date,amount\n"), iimporter.Options{}); err == nil {
+		t.Error("expected an error for a file that is not QIF")
+	}
+}
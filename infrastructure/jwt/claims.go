@@ -0,0 +1,81 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	ijwt "github.com/beka-birhanu/finance-go/application/common/interface/jwt"
+	"github.com/google/uuid"
+)
+
+// tokenClaims are the claims of a token as they are encoded in it.
+type tokenClaims struct {
+	Subject   string   `json:"sub"`
+	Username  string   `json:"username,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+	Scopes    []string `json:"scopes,omitempty"`
+	ID        string   `json:"jti"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud,omitempty"`
+	IssuedAt  int64    `json:"iat"`
+	NotBefore int64    `json:"nbf"`
+	ExpiresAt int64    `json:"exp"`
+}
+
+// Valid satisfies jwt.Claims. The claims are validated by Service.validate instead, which
+// knows the expected issuer and audience and reads the time from the time service.
+func (c *tokenClaims) Valid() error {
+	return nil
+}
+
+// typed converts the encoded claims to ijwt.Claims.
+func (c *tokenClaims) typed() (*ijwt.Claims, error) {
+	subject, err := uuid.Parse(c.Subject)
+	if err != nil {
+		return nil, errors.New("token subject is not a user ID")
+	}
+
+	return &ijwt.Claims{
+		Subject:   subject,
+		Username:  c.Username,
+		Roles:     c.Roles,
+		Scopes:    c.Scopes,
+		ID:        c.ID,
+		Issuer:    c.Issuer,
+		Audience:  c.Audience,
+		IssuedAt:  time.Unix(c.IssuedAt, 0).UTC(),
+		NotBefore: time.Unix(c.NotBefore, 0).UTC(),
+		ExpiresAt: time.Unix(c.ExpiresAt, 0).UTC(),
+	}, nil
+}
+
+// audience is the aud claim, which RFC 7519 allows to be a single string or an array of
+// strings. It is always encoded as an array.
+type audience []string
+
+// UnmarshalJSON decodes an audience given as a string or an array of strings.
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return errors.New("aud must be a string or an array of strings")
+	}
+	*a = list
+	return nil
+}
+
+// contains reports whether the audience includes the recipient.
+func (a audience) contains(recipient string) bool {
+	for _, aud := range a {
+		if aud == recipient {
+			return true
+		}
+	}
+	return false
+}
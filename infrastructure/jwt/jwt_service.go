@@ -9,23 +9,37 @@ import (
 	itimeservice "github.com/beka-birhanu/finance-go/application/common/interface/time_service"
 	usermodel "github.com/beka-birhanu/finance-go/domain/model/user"
 	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
 )
 
 // Service implements the ijwt.IService interface for handling JWT operations.
 type Service struct {
 	secretKey   string
+	keys        *KeySet
 	issuer      string
+	audience    string
 	expTime     time.Duration
+	clockSkew   time.Duration
 	timeService itimeservice.IService
 }
 
-var _ ijwt.IService = &Service{}
+var (
+	_ ijwt.IService      = &Service{}
+	_ ijwt.IKeyPublisher = &Service{}
+)
 
 // Config holds the configuration for creating a new JWT Service.
+// Tokens are signed with the signing key of Keys when it is set, and with SecretKey via
+// HS256 otherwise. Tokens are issued by Issuer to Audience, and only tokens with that issuer
+// and, unless Audience is empty, that audience are accepted. ClockSkew is the leeway allowed
+// between the clocks of the issuer and the verifier when checking exp, nbf and iat.
 type Config struct {
 	SecretKey   string
+	Keys        *KeySet
 	Issuer      string
+	Audience    string
 	ExpTime     time.Duration
+	ClockSkew   time.Duration
 	TimeService itimeservice.IService
 }
 
@@ -33,43 +47,113 @@ type Config struct {
 func New(config Config) *Service {
 	return &Service{
 		secretKey:   config.SecretKey,
+		keys:        config.Keys,
 		issuer:      config.Issuer,
+		audience:    config.Audience,
 		expTime:     config.ExpTime,
+		clockSkew:   config.ClockSkew,
 		timeService: config.TimeService,
 	}
 }
 
-// Generate creates a new JWT token for the given user.
+// Generate creates a new JWT token for the given user, carrying the roles of the user. Every
+// token gets a unique ID (jti) and its issue time (iat), by which it can be revoked before it
+// expires.
 func (s *Service) Generate(user *usermodel.User) (string, error) {
-	expirationTime := s.timeService.NowUTC().Add(s.expTime).Unix()
-	claims := jwt.MapClaims{
-		"user_id": user.ID().String(),
-		"exp":     expirationTime,
-		"iss":     s.issuer,
+	now := s.timeService.NowUTC()
+	claims := &tokenClaims{
+		Subject:   user.ID().String(),
+		Username:  user.Username(),
+		Roles:     user.Roles(),
+		ID:        uuid.New().String(),
+		Issuer:    s.issuer,
+		IssuedAt:  now.Unix(),
+		NotBefore: now.Unix(),
+		ExpiresAt: now.Add(s.expTime).Unix(),
+	}
+	if s.audience != "" {
+		claims.Audience = audience{s.audience}
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.secretKey))
+	if s.keys == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(s.secretKey))
+	}
+
+	signing := s.keys.Signing()
+	token := jwt.NewWithClaims(signing.Method, claims)
+	token.Header["kid"] = signing.ID
+	return token.SignedString(signing.private)
+}
+
+// PublicKeys returns the public keys tokens are verified with, which is none for tokens
+// signed with a shared secret.
+func (s *Service) PublicKeys() []ijwt.PublicKey {
+	if s.keys == nil {
+		return []ijwt.PublicKey{}
+	}
+	return s.keys.PublicKeys()
 }
 
-// Decode parses and validates a JWT token, returning its claims if valid.
-func (s *Service) Decode(tokenString string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, s.getSigningKey)
+// Decode parses and validates a JWT token, returning its claims if valid. Besides the
+// signature, it checks that the token was issued by the service's issuer to its audience, and
+// that it is within its validity period, allowing for the configured clock skew.
+func (s *Service) Decode(tokenString string) (*ijwt.Claims, error) {
+	var claims tokenClaims
+	token, err := jwt.ParseWithClaims(tokenString, &claims, s.getSigningKey)
 	if err != nil {
 		return nil, err
 	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return claims, nil
+	if err := s.validate(&claims); err != nil {
+		return nil, err
 	}
+	return claims.typed()
+}
 
-	return nil, errors.New("invalid token")
+// validate checks the registered claims of a token whose signature was verified.
+func (s *Service) validate(claims *tokenClaims) error {
+	if claims.Issuer != s.issuer {
+		return errors.New("token has an unexpected issuer")
+	}
+	if s.audience != "" && !claims.Audience.contains(s.audience) {
+		return errors.New("token is not intended for this audience")
+	}
+
+	now := s.timeService.NowUTC()
+	if claims.ExpiresAt == 0 || !now.Add(-s.clockSkew).Before(time.Unix(claims.ExpiresAt, 0)) {
+		return errors.New("token is expired")
+	}
+	if time.Unix(claims.NotBefore, 0).After(now.Add(s.clockSkew)) {
+		return errors.New("token is not valid yet")
+	}
+	if time.Unix(claims.IssuedAt, 0).After(now.Add(s.clockSkew)) {
+		return errors.New("token was issued in the future")
+	}
+	return nil
 }
 
-// getSigningKey is a helper function to validate the token's signing method and provide the secret key.
+// getSigningKey is a helper function to validate the token's signing method and provide the key
+// to verify it with: the secret key for HS256, or the key named by the kid header of the token,
+// whose signing method the token must use, when a key set is configured.
 func (s *Service) getSigningKey(token *jwt.Token) (interface{}, error) {
-	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+	if s.keys == nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return []byte(s.secretKey), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key := s.keys.ByID(kid)
+	if key == nil {
+		return nil, errors.New("unknown signing key")
+	}
+	if token.Method.Alg() != key.Method.Alg() {
 		return nil, errors.New("unexpected signing method")
 	}
-	return []byte(s.secretKey), nil
+	return key.Public, nil
 }
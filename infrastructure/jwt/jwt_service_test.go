@@ -7,6 +7,7 @@ import (
 	timeservice "github.com/beka-birhanu/finance-go/application/common/interface/time_service"
 	"github.com/beka-birhanu/finance-go/domain/common/hash"
 	usermodel "github.com/beka-birhanu/finance-go/domain/model/user"
+	"github.com/dgrijalva/jwt-go"
 )
 
 // MockHashService mocks the hash service for testing.
@@ -76,17 +77,59 @@ func TestJwtService(t *testing.T) {
 		if err != nil {
 			t.Errorf("expected no error, got %v", err)
 		}
-		if claims["user_id"] != testUser.ID().String() {
-			t.Errorf("expected user_id to be %v, got %v", testUser.ID().String(), claims["user_id"])
+		if claims.Subject != testUser.ID() {
+			t.Errorf("expected subject to be %v, got %v", testUser.ID(), claims.Subject)
 		}
-		if claims["iss"] != issuer {
-			t.Errorf("expected issuer to be %v, got %v", issuer, claims["iss"])
+		if claims.Username != testUser.Username() {
+			t.Errorf("expected username to be %v, got %v", testUser.Username(), claims.Username)
 		}
-
-		exp := int64(claims["exp"].(float64))
-		if !time.Unix(exp, 0).After(time.Now()) {
+		if claims.Issuer != issuer {
+			t.Errorf("expected issuer to be %v, got %v", issuer, claims.Issuer)
+		}
+		if !claims.ExpiresAt.After(time.Now()) {
 			t.Error("expected exp to be in the future")
 		}
+		if claims.ID == "" {
+			t.Error("expected jti to be set")
+		}
+		if claims.IssuedAt.IsZero() || claims.NotBefore.IsZero() {
+			t.Errorf("expected iat and nbf to be set, got %v and %v", claims.IssuedAt, claims.NotBefore)
+		}
+	})
+
+	t.Run("Roles", func(t *testing.T) {
+		admin, _ := usermodel.NewWithExistingHash(usermodel.ConfigForExistingHash{
+			ID:           testUser.ID(),
+			Username:     testUser.Username(),
+			PasswordHash: testUser.PasswordHash(),
+			Roles:        []string{usermodel.RoleAdmin},
+		})
+
+		token, _ := jwtService.Generate(admin)
+		claims, err := jwtService.Decode(token)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !claims.HasRole(usermodel.RoleAdmin) {
+			t.Errorf("expected the admin role, got %v", claims.Roles)
+		}
+
+		token, _ = jwtService.Generate(testUser)
+		claims, _ = jwtService.Decode(token)
+		if claims.HasRole(usermodel.RoleAdmin) {
+			t.Errorf("expected no roles, got %v", claims.Roles)
+		}
+	})
+
+	t.Run("UniqueTokenIDs", func(t *testing.T) {
+		first, _ := jwtService.Generate(testUser)
+		second, _ := jwtService.Generate(testUser)
+
+		firstClaims, _ := jwtService.Decode(first)
+		secondClaims, _ := jwtService.Decode(second)
+		if firstClaims.ID == secondClaims.ID {
+			t.Errorf("expected distinct token IDs, got %v twice", firstClaims.ID)
+		}
 	})
 
 	t.Run("DecodeInvalidToken", func(t *testing.T) {
@@ -97,3 +140,90 @@ func TestJwtService(t *testing.T) {
 	})
 }
 
+// FixedTimeService returns a fixed time for testing.
+type FixedTimeService struct {
+	now time.Time
+}
+
+func (m *FixedTimeService) NowUTC() time.Time {
+	return m.now
+}
+
+func TestJwtService_DecodeValidatesClaims(t *testing.T) {
+	secretKey := "secret"
+	now := time.Now().UTC().Truncate(time.Second)
+	skew := 30 * time.Second
+
+	sign := func(claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secretKey))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return token
+	}
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub": testUser.ID().String(),
+			"jti": "token-1",
+			"iss": "finance-go",
+			"aud": []string{"finance-go-api"},
+			"iat": now.Unix(),
+			"nbf": now.Unix(),
+			"exp": now.Add(time.Minute).Unix(),
+		}
+	}
+
+	tests := []struct {
+		name        string
+		override    jwt.MapClaims
+		expectValid bool
+	}{
+		{name: "valid claims", expectValid: true},
+		{name: "audience as a string", override: jwt.MapClaims{"aud": "finance-go-api"}, expectValid: true},
+		{name: "one of several audiences", override: jwt.MapClaims{"aud": []string{"other", "finance-go-api"}}, expectValid: true},
+		{name: "unexpected issuer", override: jwt.MapClaims{"iss": "someone-else"}},
+		{name: "unexpected audience", override: jwt.MapClaims{"aud": "other"}},
+		{name: "missing audience", override: jwt.MapClaims{"aud": nil}},
+		{name: "expired within the clock skew", override: jwt.MapClaims{"exp": now.Add(-10 * time.Second).Unix()}, expectValid: true},
+		{name: "expired beyond the clock skew", override: jwt.MapClaims{"exp": now.Add(-time.Minute).Unix()}},
+		{name: "missing expiry", override: jwt.MapClaims{"exp": nil}},
+		{name: "not valid yet within the clock skew", override: jwt.MapClaims{"nbf": now.Add(10 * time.Second).Unix()}, expectValid: true},
+		{name: "not valid yet beyond the clock skew", override: jwt.MapClaims{"nbf": now.Add(time.Minute).Unix()}},
+		{name: "issued in the future", override: jwt.MapClaims{"iat": now.Add(time.Minute).Unix()}},
+		{name: "subject is not a user ID", override: jwt.MapClaims{"sub": "admin"}},
+	}
+
+	jwtService := New(Config{
+		SecretKey:   secretKey,
+		Issuer:      "finance-go",
+		Audience:    "finance-go-api",
+		ExpTime:     time.Minute,
+		ClockSkew:   skew,
+		TimeService: &FixedTimeService{now: now},
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims()
+			for name, value := range tt.override {
+				if value == nil {
+					delete(claims, name)
+				} else {
+					claims[name] = value
+				}
+			}
+
+			decoded, err := jwtService.Decode(sign(claims))
+			if tt.expectValid {
+				if err != nil {
+					t.Fatalf("expected the token to be valid, got %v", err)
+				}
+				if decoded.Subject != testUser.ID() || decoded.ID != "token-1" {
+					t.Errorf("unexpected claims: %+v", decoded)
+				}
+			} else if err == nil {
+				t.Error("expected the token to be rejected")
+			}
+		})
+	}
+}
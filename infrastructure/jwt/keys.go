@@ -0,0 +1,186 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	ijwt "github.com/beka-birhanu/finance-go/application/common/interface/jwt"
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Key is a public key tokens are verified with, and, for the signing key, the private key
+// tokens are signed with.
+type Key struct {
+	ID      string            // Key ID (kid), the RFC 7638 thumbprint of the public key
+	Method  jwt.SigningMethod // Signing method of the key: RS256 for RSA keys, EdDSA for Ed25519 keys
+	Public  crypto.PublicKey  // Public key, used to verify tokens
+	private crypto.Signer     // Private key, used to sign tokens; nil for verification-only keys
+}
+
+// KeySet holds the key new tokens are signed with and every key tokens are verified with.
+// Keeping retired keys in the set for verification lets tokens signed with them stay valid
+// until they expire, so a signing key can be rotated without ending sessions.
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+	order   []string
+}
+
+// NewKeySet creates a KeySet signing with the given RSA or Ed25519 private key, and also
+// verifying with the given public keys.
+func NewKeySet(signing crypto.Signer, verification ...crypto.PublicKey) (*KeySet, error) {
+	signingKey, err := newKey(signing.Public())
+	if err != nil {
+		return nil, err
+	}
+	signingKey.private = signing
+
+	set := &KeySet{signing: signingKey, keys: make(map[string]*Key)}
+	set.add(signingKey)
+	for _, public := range verification {
+		key, err := newKey(public)
+		if err != nil {
+			return nil, err
+		}
+		set.add(key)
+	}
+	return set, nil
+}
+
+// LoadKeySet creates a KeySet from PEM files: a PKCS #8 (or PKCS #1 for RSA) private key to
+// sign with, and PKIX public keys of retired keys to keep verifying with.
+func LoadKeySet(signingKeyFile string, verificationKeyFiles []string) (*KeySet, error) {
+	block, err := readPEM(signingKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	signing, err := parsePrivateKey(block)
+	if err != nil {
+		return nil, fmt.Errorf("parsing signing key %s: %w", signingKeyFile, err)
+	}
+
+	verification := make([]crypto.PublicKey, 0, len(verificationKeyFiles))
+	for _, file := range verificationKeyFiles {
+		block, err := readPEM(file)
+		if err != nil {
+			return nil, err
+		}
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing verification key %s: %w", file, err)
+		}
+		verification = append(verification, public)
+	}
+
+	return NewKeySet(signing, verification...)
+}
+
+// Signing returns the key new tokens are signed with.
+func (s *KeySet) Signing() *Key {
+	return s.signing
+}
+
+// ByID returns the verification key with the given ID, or nil if there is none.
+func (s *KeySet) ByID(id string) *Key {
+	return s.keys[id]
+}
+
+// PublicKeys returns every verification key as a JSON Web Key, signing key first.
+func (s *KeySet) PublicKeys() []ijwt.PublicKey {
+	publicKeys := make([]ijwt.PublicKey, 0, len(s.order))
+	for _, id := range s.order {
+		publicKeys = append(publicKeys, s.keys[id].jwk())
+	}
+	return publicKeys
+}
+
+// add adds a verification key, ignoring keys already in the set.
+func (s *KeySet) add(key *Key) {
+	if _, ok := s.keys[key.ID]; ok {
+		return
+	}
+	s.keys[key.ID] = key
+	s.order = append(s.order, key.ID)
+}
+
+// newKey creates the Key of an RSA or Ed25519 public key.
+func newKey(public crypto.PublicKey) (*Key, error) {
+	key := &Key{Public: public}
+	switch public.(type) {
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T: only RSA and Ed25519 keys are supported", public)
+	}
+
+	thumbprint := sha256.Sum256([]byte(thumbprintJSON(key.jwk())))
+	key.ID = base64.RawURLEncoding.EncodeToString(thumbprint[:])
+	return key, nil
+}
+
+// jwk returns the key as a JSON Web Key.
+func (k *Key) jwk() ijwt.PublicKey {
+	jwk := ijwt.PublicKey{KeyID: k.ID, Algorithm: k.Method.Alg(), Use: "sig"}
+	switch public := k.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.Modulus = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.Exponent = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+	return jwk
+}
+
+// thumbprintJSON returns the JSON the RFC 7638 thumbprint of a key is the hash of: its
+// required members in lexicographic order.
+func thumbprintJSON(jwk ijwt.PublicKey) string {
+	if jwk.KeyType == "RSA" {
+		return `{"e":"` + jwk.Exponent + `","kty":"RSA","n":"` + jwk.Modulus + `"}`
+	}
+	return `{"crv":"` + jwk.Curve + `","kty":"` + jwk.KeyType + `","x":"` + jwk.X + `"}`
+}
+
+// readPEM reads the first PEM block of a file.
+func readPEM(file string) (*pem.Block, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading key file: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in key file %s", file)
+	}
+	return block, nil
+}
+
+// parsePrivateKey parses a PKCS #8 or PKCS #1 private key.
+func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("private key cannot sign")
+	}
+	return signer, nil
+}
@@ -0,0 +1,158 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestKeySet(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generating Ed25519 key: %v", err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating RSA key: %v", err)
+	}
+	_, retiredKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, unknownKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	newService := func(t *testing.T, signing crypto.Signer, verification ...crypto.PublicKey) *Service {
+		keys, err := NewKeySet(signing, verification...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return New(Config{SecretKey: "secret", Keys: keys, Issuer: "test_issuer", ExpTime: time.Minute, TimeService: &MockTimeService{}})
+	}
+
+	tests := []struct {
+		name        string
+		issuer      func(t *testing.T) *Service
+		verifier    func(t *testing.T) *Service
+		expectedAlg string
+		expectValid bool
+	}{
+		{
+			name:        "EdDSA",
+			issuer:      func(t *testing.T) *Service { return newService(t, edKey) },
+			verifier:    func(t *testing.T) *Service { return newService(t, edKey) },
+			expectedAlg: "EdDSA",
+			expectValid: true,
+		},
+		{
+			name:        "RS256",
+			issuer:      func(t *testing.T) *Service { return newService(t, rsaKey) },
+			verifier:    func(t *testing.T) *Service { return newService(t, rsaKey) },
+			expectedAlg: "RS256",
+			expectValid: true,
+		},
+		{
+			name:        "Retired key still verifies",
+			issuer:      func(t *testing.T) *Service { return newService(t, retiredKey) },
+			verifier:    func(t *testing.T) *Service { return newService(t, edKey, retiredKey.Public()) },
+			expectedAlg: "EdDSA",
+			expectValid: true,
+		},
+		{
+			name:        "Unknown key",
+			issuer:      func(t *testing.T) *Service { return newService(t, unknownKey) },
+			verifier:    func(t *testing.T) *Service { return newService(t, edKey, retiredKey.Public()) },
+			expectedAlg: "EdDSA",
+		},
+		{
+			name: "Shared secret is rejected once keys are configured",
+			issuer: func(t *testing.T) *Service {
+				return New(Config{SecretKey: "secret", Issuer: "test_issuer", ExpTime: time.Minute, TimeService: &MockTimeService{}})
+			},
+			verifier:    func(t *testing.T) *Service { return newService(t, edKey) },
+			expectedAlg: "HS256",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := tt.issuer(t).Generate(testUser)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			parsed, _, err := new(jwt.Parser).ParseUnverified(token, jwt.MapClaims{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if parsed.Method.Alg() != tt.expectedAlg {
+				t.Errorf("expected alg %s, got %s", tt.expectedAlg, parsed.Method.Alg())
+			}
+
+			claims, err := tt.verifier(t).Decode(token)
+			if tt.expectValid && (err != nil || claims.Subject != testUser.ID()) {
+				t.Errorf("expected token to be valid, got %v", err)
+			}
+			if !tt.expectValid && err == nil {
+				t.Error("expected token to be rejected")
+			}
+		})
+	}
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir := t.TempDir()
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating RSA key: %v", err)
+	}
+
+	edDER, _ := x509.MarshalPKCS8PrivateKey(edKey)
+	signingFile := writePEM(t, dir, "signing.pem", "PRIVATE KEY", edDER)
+	rsaPublicDER, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	retiredFile := writePEM(t, dir, "retired.pem", "PUBLIC KEY", rsaPublicDER)
+
+	keys, err := LoadKeySet(signingFile, []string{retiredFile})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	publicKeys := keys.PublicKeys()
+	if len(publicKeys) != 2 {
+		t.Fatalf("expected 2 public keys, got %d", len(publicKeys))
+	}
+	if signing := publicKeys[0]; signing.KeyType != "OKP" || signing.Curve != "Ed25519" || signing.Algorithm != "EdDSA" || signing.KeyID != keys.Signing().ID {
+		t.Errorf("unexpected signing key: %+v", signing)
+	}
+	if retired := publicKeys[1]; retired.KeyType != "RSA" || retired.Algorithm != "RS256" || retired.Modulus == "" || retired.Exponent != "AQAB" {
+		t.Errorf("unexpected retired key: %+v", retired)
+	}
+
+	rsaDER := x509.MarshalPKCS1PrivateKey(rsaKey)
+	if _, err := LoadKeySet(writePEM(t, dir, "rsa.pem", "RSA PRIVATE KEY", rsaDER), nil); err != nil {
+		t.Errorf("expected PKCS #1 RSA key to load, got %v", err)
+	}
+	if _, err := LoadKeySet(filepath.Join(dir, "missing.pem"), nil); err == nil {
+		t.Error("expected an error for a missing key file")
+	}
+	if _, err := LoadKeySet(retiredFile, nil); err == nil || !strings.Contains(err.Error(), "signing key") {
+		t.Errorf("expected an error for a public signing key, got %v", err)
+	}
+}
+
+// writePEM writes a PEM file to the directory and returns its path.
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("writing key file: %v", err)
+	}
+	return path
+}
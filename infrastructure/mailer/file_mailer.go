@@ -0,0 +1,61 @@
+// Package mailer provides mailers delivering messages to users. FileMailer, meant for local
+// use, writes the messages to a file or to the log instead of sending them.
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	imailer "github.com/beka-birhanu/finance-go/application/common/interface/mailer"
+)
+
+// FileMailer implements imailer.IMailer by appending messages to a file, or writing them to
+// the log when no file is configured.
+type FileMailer struct {
+	path string
+	mu   sync.Mutex // Serializes writes, so that messages are not interleaved
+}
+
+// Ensure FileMailer implements imailer.IMailer.
+var _ imailer.IMailer = &FileMailer{}
+
+// FileConfig holds the configuration for creating a new FileMailer.
+type FileConfig struct {
+	Path string // File the messages are appended to; the log when empty
+}
+
+// NewFileMailer creates a new FileMailer with the given configuration.
+func NewFileMailer(config FileConfig) *FileMailer {
+	return &FileMailer{path: config.Path}
+}
+
+// SendPasswordReset writes the message delivering a password reset token.
+func (m *FileMailer) SendPasswordReset(message imailer.PasswordReset) error {
+	return m.write(fmt.Sprintf(
+		"To: %s\nSubject: Reset your password\n\nUse this token to set a new password before %s:\n\n%s\n",
+		message.Username, message.ExpiresAt.UTC().Format(time.RFC1123), message.Token))
+}
+
+// write appends a message to the file, or writes it to the log.
+func (m *FileMailer) write(message string) error {
+	if m.path == "" {
+		log.Printf("mail:\n%s", message)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	file, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(file, "%s\n", message); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,39 @@
+package mailer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	imailer "github.com/beka-birhanu/finance-go/application/common/interface/mailer"
+	"github.com/google/uuid"
+)
+
+func TestFileMailer_SendPasswordReset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.txt")
+	mailer := NewFileMailer(FileConfig{Path: path})
+
+	for _, token := range []string{"first-token", "second-token"} {
+		err := mailer.SendPasswordReset(imailer.PasswordReset{
+			UserID:    uuid.New(),
+			Username:  "validUser",
+			Token:     token,
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range []string{"To: validUser", "first-token", "second-token"} {
+		if !strings.Contains(string(contents), expected) {
+			t.Errorf("expected the mail file to contain %q, got %q", expected, contents)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+
+	jwtsvc "github.com/beka-birhanu/finance-go/infrastructure/jwt"
+	"github.com/dgrijalva/jwt-go"
+)
+
+// signingMethods are the signing methods of ID tokens that are accepted. Tokens that are not
+// signed, or signed with a shared secret, are rejected.
+var signingMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", jwtsvc.SigningMethodEdDSA.Alg()}
+
+// key is a signing key of a provider.
+type key struct {
+	method jwt.SigningMethod
+	public crypto.PublicKey
+}
+
+// jsonWebKey is a public key in the JSON Web Key format (RFC 7517).
+type jsonWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	Modulus   string `json:"n"`   // RSA modulus
+	Exponent  string `json:"e"`   // RSA public exponent
+	Curve     string `json:"crv"` // Curve of EC and OKP keys
+	X         string `json:"x"`   // X coordinate of EC keys, public key of OKP keys
+	Y         string `json:"y"`   // Y coordinate of EC keys
+}
+
+// keyFunc returns the key the ID token is verified with: the signing key of the provider with
+// the key ID of the token, which must be of the signing method of the token. The keys are
+// fetched again when the token is signed with a key that is unknown, as after the provider
+// rotated its keys, or when they are no longer fresh.
+func (p *Provider) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.config.TimeService.NowUTC()
+	k := p.lookup(kid)
+	if (k == nil && now.Sub(p.keysFetchedAt) >= keysRefreshInterval) || now.Sub(p.keysFetchedAt) >= metadataTTL {
+		if err := p.fetchKeys(); err != nil {
+			return nil, err
+		}
+		p.keysFetchedAt = now
+		k = p.lookup(kid)
+	}
+
+	if k == nil {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if k.method.Alg() != token.Method.Alg() {
+		return nil, fmt.Errorf("signing key %q is not for %s", kid, token.Method.Alg())
+	}
+	return k.public, nil
+}
+
+// lookup returns the signing key with the given ID, or the only key when tokens do not say
+// which key signed them; nil if there is no such key.
+func (p *Provider) lookup(kid string) *key {
+	if kid == "" && len(p.keys) == 1 {
+		for _, k := range p.keys {
+			return k
+		}
+	}
+	return p.keys[kid]
+}
+
+// fetchKeys fetches the signing keys of the provider, skipping keys that are not for signatures
+// or of an unsupported type.
+func (p *Provider) fetchKeys() error {
+	if p.metadata == nil {
+		return errors.New("provider not discovered")
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(p.metadata.JWKSURI, &set); err != nil {
+		return fmt.Errorf("fetching signing keys: %w", err)
+	}
+
+	keys := make(map[string]*key, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if k, err := jwk.key(); err == nil {
+			keys[jwk.KeyID] = k
+		}
+	}
+	p.keys = keys
+	return nil
+}
+
+// key returns the signing key of the JSON Web Key.
+func (jwk jsonWebKey) key() (*key, error) {
+	switch {
+	case jwk.KeyType == "RSA":
+		n, err := decodeInt(jwk.Modulus)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(jwk.Exponent)
+		if err != nil {
+			return nil, err
+		}
+		method := jwt.GetSigningMethod(jwk.Algorithm)
+		if _, ok := method.(*jwt.SigningMethodRSA); !ok {
+			method = jwt.SigningMethodRS256
+		}
+		return &key{method: method, public: &rsa.PublicKey{N: n, E: int(e.Int64())}}, nil
+
+	case jwk.KeyType == "EC" && (jwk.Curve == "P-256" || jwk.Curve == "P-384"):
+		x, err := decodeInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		public := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		method := jwt.SigningMethod(jwt.SigningMethodES256)
+		if jwk.Curve == "P-384" {
+			public.Curve, method = elliptic.P384(), jwt.SigningMethodES384
+		}
+		if !public.Curve.IsOnCurve(x, y) {
+			return nil, errors.New("EC key is not on its curve")
+		}
+		return &key{method: method, public: public}, nil
+
+	case jwk.KeyType == "OKP" && jwk.Curve == "Ed25519":
+		public, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || len(public) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return &key{method: jwtsvc.SigningMethodEdDSA, public: ed25519.PublicKey(public)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", jwk.KeyType)
+}
+
+// decodeInt decodes a base64url encoded big-endian unsigned integer.
+func decodeInt(encoded string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
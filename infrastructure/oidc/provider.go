@@ -0,0 +1,293 @@
+// Package oidc provides a client of OpenID Connect providers for the authorization code flow
+// with PKCE. The endpoints and signing keys of a provider are discovered from its issuer
+// (OpenID Connect Discovery 1.0), and the ID tokens it issues are validated as OpenID Connect
+// Core 1.0 requires: signature, issuer, audience, expiry and nonce.
+package oidc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	ioidc "github.com/beka-birhanu/finance-go/application/common/interface/oidc"
+	itimeservice "github.com/beka-birhanu/finance-go/application/common/interface/time_service"
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	// discoveryPath is the path of the discovery document under the issuer.
+	discoveryPath = "/.well-known/openid-configuration"
+
+	// metadataTTL is how long the discovery document and the signing keys are cached.
+	metadataTTL = time.Hour
+
+	// keysRefreshInterval is the minimum time between fetches of the signing keys for ID
+	// tokens signed with an unknown key, so that such tokens cannot flood the provider.
+	keysRefreshInterval = time.Minute
+
+	// defaultClockSkew is the clock skew allowed when the configuration does not say.
+	defaultClockSkew = time.Minute
+
+	// maxResponseBytes limits the size of the responses read from a provider.
+	maxResponseBytes = 1 << 20
+)
+
+// defaultScopes are the scopes asked for when the configuration does not say.
+var defaultScopes = []string{"openid", "profile", "email"}
+
+// Config holds the settings of a Provider.
+type Config struct {
+	Issuer       string                // Issuer identifier of the provider, as in its ID tokens
+	ClientID     string                // Client ID registered with the provider
+	ClientSecret string                // Client secret; empty for public clients
+	RedirectURL  string                // URL the provider sends users back to with a code
+	Scopes       []string              // Scopes to ask for; openid, profile and email if empty
+	HTTPClient   *http.Client          // Client for requests to the provider; a 10s timeout client if nil
+	TimeService  itimeservice.IService // Service for time-related operations
+	ClockSkew    time.Duration         // Clock skew allowed validating ID tokens; a minute if zero
+}
+
+// Provider is an OpenID Connect provider.
+type Provider struct {
+	config Config
+
+	mu            sync.Mutex
+	metadata      *metadata
+	metadataAt    time.Time
+	keys          map[string]*key
+	keysFetchedAt time.Time
+}
+
+var _ ioidc.IProvider = &Provider{}
+
+// metadata is the part of the discovery document of a provider in use.
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// New creates a Provider with the given configuration. The provider is not contacted until
+// it is first used, so that an unreachable provider does not stop the application starting.
+func New(config Config) *Provider {
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = defaultScopes
+	}
+	if config.ClockSkew == 0 {
+		config.ClockSkew = defaultClockSkew
+	}
+	return &Provider{config: config}
+}
+
+// AuthCodeURL returns the URL of the authorization endpoint asking for an authorization code
+// bound to the state, the nonce and the S256 code challenge.
+func (p *Provider) AuthCodeURL(state, nonce, codeChallenge string) (string, error) {
+	md, err := p.discover()
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := url.Parse(md.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization endpoint: %w", err)
+	}
+
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientID)
+	query.Set("redirect_uri", p.config.RedirectURL)
+	query.Set("scope", strings.Join(p.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+	return authURL.String(), nil
+}
+
+// tokenResponse is the response of the token endpoint, successful or not (RFC 6749 5.1, 5.2).
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Exchange redeems the authorization code with the code verifier at the token endpoint,
+// validates the ID token it returns and returns the identity of the user. Errors of the
+// provider refusing the code, and of ID tokens failing validation, wrap ioidc.ErrRejected.
+func (p *Provider) Exchange(code, codeVerifier, nonce string) (*ioidc.Identity, error) {
+	md, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"code_verifier": {codeVerifier},
+		"client_id":     {p.config.ClientID},
+	}
+	request, err := http.NewRequest(http.MethodPost, md.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("creating token request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		request.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	response, err := p.config.HTTPClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("requesting tokens: %w", err)
+	}
+	defer response.Body.Close()
+
+	var tokens tokenResponse
+	if err := json.NewDecoder(io.LimitReader(response.Body, maxResponseBytes)).Decode(&tokens); err != nil {
+		return nil, fmt.Errorf("decoding token response with status %d: %w", response.StatusCode, err)
+	}
+	if response.StatusCode == http.StatusBadRequest || response.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("token request refused, %s %s: %w", tokens.Error, tokens.ErrorDescription, ioidc.ErrRejected)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token request failed with status %d", response.StatusCode)
+	}
+	if tokens.IDToken == "" {
+		return nil, fmt.Errorf("no ID token in token response: %w", ioidc.ErrRejected)
+	}
+
+	return p.validate(tokens.IDToken, nonce)
+}
+
+// discover returns the discovery document of the provider, fetching it if it is not cached.
+func (p *Provider) discover() (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.config.TimeService.NowUTC()
+	if p.metadata != nil && now.Sub(p.metadataAt) < metadataTTL {
+		return p.metadata, nil
+	}
+
+	var md metadata
+	if err := p.getJSON(strings.TrimSuffix(p.config.Issuer, "/")+discoveryPath, &md); err != nil {
+		return nil, fmt.Errorf("discovering provider: %w", err)
+	}
+	// Discovery 4.3: the issuer of the document must be the one it was retrieved from.
+	if md.Issuer != p.config.Issuer {
+		return nil, fmt.Errorf("discovery document is of issuer %q instead of %q", md.Issuer, p.config.Issuer)
+	}
+	if md.AuthorizationEndpoint == "" || md.TokenEndpoint == "" || md.JWKSURI == "" {
+		return nil, errors.New("discovery document lacks the authorization, token or keys endpoint")
+	}
+
+	if p.metadata == nil || p.metadata.JWKSURI != md.JWKSURI {
+		p.keys = nil
+	}
+	p.metadata, p.metadataAt = &md, now
+	return p.metadata, nil
+}
+
+// getJSON fetches the JSON document at the given URL into v.
+func (p *Provider) getJSON(documentURL string, v any) error {
+	response, err := p.config.HTTPClient.Get(documentURL)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", documentURL, response.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(response.Body, maxResponseBytes)).Decode(v)
+}
+
+// validate validates the ID token and returns the identity it asserts.
+func (p *Provider) validate(idToken, nonce string) (*ioidc.Identity, error) {
+	claims := &idTokenClaims{}
+	parser := &jwt.Parser{ValidMethods: signingMethods}
+	if _, err := parser.ParseWithClaims(idToken, claims, p.keyFunc); err != nil {
+		return nil, fmt.Errorf("invalid ID token, %v: %w", err, ioidc.ErrRejected)
+	}
+
+	now := p.config.TimeService.NowUTC()
+	var problem string
+	switch {
+	case claims.Issuer != p.config.Issuer:
+		problem = "issued by another issuer"
+	case !slices.Contains(claims.Audience, p.config.ClientID):
+		problem = "not issued to this client"
+	case claims.AuthorizedParty != "" && claims.AuthorizedParty != p.config.ClientID,
+		claims.AuthorizedParty == "" && len(claims.Audience) > 1:
+		problem = "not authorized for this client"
+	case claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0).Add(p.config.ClockSkew)):
+		problem = "expired"
+	case time.Unix(claims.IssuedAt, 0).After(now.Add(p.config.ClockSkew)):
+		problem = "issued in the future"
+	case claims.Nonce == "" || claims.Nonce != nonce:
+		problem = "not issued for this sign in"
+	case claims.Subject == "":
+		problem = "without a subject"
+	}
+	if problem != "" {
+		return nil, fmt.Errorf("ID token %s: %w", problem, ioidc.ErrRejected)
+	}
+
+	return &ioidc.Identity{
+		Issuer:            claims.Issuer,
+		Subject:           claims.Subject,
+		Email:             claims.Email,
+		PreferredUsername: claims.PreferredUsername,
+	}, nil
+}
+
+// idTokenClaims are the claims of an ID token in use. They are validated by validate rather
+// than by jwt-go, which neither allows for clock skew nor accepts several audiences.
+type idTokenClaims struct {
+	Issuer            string   `json:"iss"`
+	Subject           string   `json:"sub"`
+	Audience          audience `json:"aud"`
+	AuthorizedParty   string   `json:"azp"`
+	ExpiresAt         int64    `json:"exp"`
+	IssuedAt          int64    `json:"iat"`
+	Nonce             string   `json:"nonce"`
+	Email             string   `json:"email"`
+	PreferredUsername string   `json:"preferred_username"`
+}
+
+// Valid implements jwt.Claims; the claims are validated by validate.
+func (c *idTokenClaims) Valid() error {
+	return nil
+}
+
+// audience is the aud claim, which is either a single audience or an array of them.
+type audience []string
+
+// UnmarshalJSON decodes a single audience or an array of them.
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var several []string
+	if err := json.Unmarshal(data, &several); err != nil {
+		return err
+	}
+	*a = several
+	return nil
+}
@@ -0,0 +1,415 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ioidc "github.com/beka-birhanu/finance-go/application/common/interface/oidc"
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	clientID     = "finance-go"
+	clientSecret = "s3cret"
+	redirectURL  = "https://finance.example.com/api/v1/users/oidc/company/callback"
+)
+
+type MockTimeService struct {
+	now time.Time
+}
+
+func (m *MockTimeService) NowUTC() time.Time {
+	return m.now
+}
+
+// stubKey is a signing key of the stub provider.
+type stubKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.Signer
+}
+
+// stubGrant is an authorization code issued by the stub provider.
+type stubGrant struct {
+	codeChallenge string
+	nonce         string
+	redirectURI   string
+}
+
+// stubProvider is a local OpenID Connect provider that issues codes to everyone, for the
+// subject "248289761001", and ID tokens for them signed with its current key.
+type stubProvider struct {
+	server *httptest.Server
+	now    time.Time
+
+	mu     sync.Mutex
+	keys   []*stubKey
+	grants map[string]stubGrant
+	issuer string                     // Issuer in the discovery document; the server URL if empty
+	claims func(claims jwt.MapClaims) // Changes the claims of ID tokens before they are signed
+	signer *stubKey                   // Key ID tokens are signed with; the first key if nil
+}
+
+func newStubProvider(t *testing.T) *stubProvider {
+	t.Helper()
+	stub := &stubProvider{now: time.Now().UTC(), grants: map[string]stubGrant{}}
+	stub.rotate(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", stub.discovery)
+	mux.HandleFunc("/authorize", stub.authorize)
+	mux.HandleFunc("/token", stub.token)
+	mux.HandleFunc("/jwks", stub.jwks)
+	stub.server = httptest.NewServer(mux)
+	t.Cleanup(stub.server.Close)
+	return stub
+}
+
+// rotate makes a new RSA key the current signing key, keeping the previous ones published.
+func (s *stubProvider) rotate(t *testing.T) *stubKey {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating RSA key: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := &stubKey{id: base64.RawURLEncoding.EncodeToString(private.N.Bytes()[:8]), method: jwt.SigningMethodRS256, private: private}
+	s.keys = append([]*stubKey{k}, s.keys...)
+	return k
+}
+
+func (s *stubProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	issuer := s.issuer
+	if issuer == "" {
+		issuer = s.server.URL
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                           issuer,
+		"authorization_endpoint":           s.server.URL + "/authorize?prompt=login",
+		"token_endpoint":                   s.server.URL + "/token",
+		"jwks_uri":                         s.server.URL + "/jwks",
+		"code_challenge_methods_supported": []string{"S256"},
+	})
+}
+
+// authorize signs everyone in, and sends them back to the redirect URI with a code.
+func (s *stubProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("response_type") != "code" || query.Get("client_id") != clientID || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	code := base64.RawURLEncoding.EncodeToString(b)
+	s.mu.Lock()
+	s.grants[code] = stubGrant{codeChallenge: query.Get("code_challenge"), nonce: query.Get("nonce"), redirectURI: query.Get("redirect_uri")}
+	s.mu.Unlock()
+
+	http.Redirect(w, r, query.Get("redirect_uri")+"?"+url.Values{"code": {code}, "state": {query.Get("state")}}.Encode(), http.StatusFound)
+}
+
+// token redeems codes, once, for ID tokens, checking the client and the PKCE code verifier.
+func (s *stubProvider) token(w http.ResponseWriter, r *http.Request) {
+	user, password, _ := r.BasicAuth()
+	if user != clientID || password != clientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	code := r.PostFormValue("code")
+	grant, ok := s.grants[code]
+	delete(s.grants, code)
+	verifier := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok || r.PostFormValue("grant_type") != "authorization_code" || r.PostFormValue("redirect_uri") != grant.redirectURI ||
+		base64.RawURLEncoding.EncodeToString(verifier[:]) != grant.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":                s.server.URL,
+		"sub":                "248289761001",
+		"aud":                clientID,
+		"exp":                s.now.Add(5 * time.Minute).Unix(),
+		"iat":                s.now.Unix(),
+		"nonce":              grant.nonce,
+		"email":              "jane.doe@example.com",
+		"preferred_username": "jane.doe",
+	}
+	if s.claims != nil {
+		s.claims(claims)
+	}
+	signer := s.signer
+	if signer == nil {
+		signer = s.keys[0]
+	}
+	idToken := jwt.NewWithClaims(signer.method, claims)
+	idToken.Header["kid"] = signer.id
+	signed, err := idToken.SignedString(signer.private)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"access_token": "opaque", "token_type": "Bearer", "id_token": signed})
+}
+
+func (s *stubProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := []map[string]string{}
+	for _, k := range s.keys {
+		switch public := k.private.Public().(type) {
+		case *rsa.PublicKey:
+			keys = append(keys, map[string]string{
+				"kty": "RSA", "kid": k.id, "use": "sig", "alg": "RS256",
+				"n": base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case *ecdsa.PublicKey:
+			keys = append(keys, map[string]string{
+				"kty": "EC", "kid": k.id, "use": "sig", "crv": "P-256",
+				"x": base64.RawURLEncoding.EncodeToString(public.X.FillBytes(make([]byte, 32))),
+				"y": base64.RawURLEncoding.EncodeToString(public.Y.FillBytes(make([]byte, 32))),
+			})
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"keys": keys})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// newProvider returns a Provider of the stub provider.
+func newProvider(stub *stubProvider) *Provider {
+	return New(Config{
+		Issuer:       stub.server.URL,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		HTTPClient:   stub.server.Client(),
+		TimeService:  &MockTimeService{now: stub.now},
+	})
+}
+
+// signIn goes through the authorization code flow against the stub provider: it follows the
+// authorization URL and redeems the code the stub sends the user back with.
+func signIn(t *testing.T, provider *Provider, stub *stubProvider, nonce string) (*ioidc.Identity, error) {
+	t.Helper()
+	codeVerifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	sum := sha256.Sum256([]byte(codeVerifier))
+
+	authURL, err := provider.AuthCodeURL("af0ifjsldkj", "n-0S6_WzA2Mj", base64.RawURLEncoding.EncodeToString(sum[:]))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client := stub.server.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	response, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	response.Body.Close()
+	callback, err := url.Parse(response.Header.Get("Location"))
+	if err != nil || response.StatusCode != http.StatusFound || !strings.HasPrefix(callback.String(), redirectURL) {
+		t.Fatalf("expected a redirect to the callback, got %d %q", response.StatusCode, callback)
+	}
+	if callback.Query().Get("state") != "af0ifjsldkj" {
+		t.Fatalf("expected the state to come back, got %q", callback.Query().Get("state"))
+	}
+
+	return provider.Exchange(callback.Query().Get("code"), codeVerifier, nonce)
+}
+
+func TestProvider_AuthCodeURL(t *testing.T) {
+	stub := newStubProvider(t)
+
+	authURL, err := newProvider(stub).AuthCodeURL("state", "nonce", "challenge")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	parsed, _ := url.Parse(authURL)
+	expected := url.Values{
+		"prompt":                {"login"},
+		"response_type":         {"code"},
+		"client_id":             {clientID},
+		"redirect_uri":          {redirectURL},
+		"scope":                 {"openid profile email"},
+		"state":                 {"state"},
+		"nonce":                 {"nonce"},
+		"code_challenge":        {"challenge"},
+		"code_challenge_method": {"S256"},
+	}
+	if parsed.Path != "/authorize" || parsed.Query().Encode() != expected.Encode() {
+		t.Errorf("expected the authorization endpoint with %v, got %s", expected, authURL)
+	}
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	stub := newStubProvider(t)
+
+	identity, err := signIn(t, newProvider(stub), stub, "n-0S6_WzA2Mj")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := ioidc.Identity{
+		Issuer:            stub.server.URL,
+		Subject:           "248289761001",
+		Email:             "jane.doe@example.com",
+		PreferredUsername: "jane.doe",
+	}
+	if *identity != expected {
+		t.Errorf("expected %+v, got %+v", expected, *identity)
+	}
+}
+
+func TestProvider_ExchangeRejected(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating EC key: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		nonce  string
+		claims func(claims jwt.MapClaims)
+		signer *stubKey
+	}{
+		{
+			name:  "other nonce",
+			nonce: "replayed",
+		},
+		{
+			name:   "other issuer",
+			claims: func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" },
+		},
+		{
+			name:   "other audience",
+			claims: func(claims jwt.MapClaims) { claims["aud"] = "another-client" },
+		},
+		{
+			name:   "several audiences without authorized party",
+			claims: func(claims jwt.MapClaims) { claims["aud"] = []string{clientID, "another-client"} },
+		},
+		{
+			name:   "other authorized party",
+			claims: func(claims jwt.MapClaims) { claims["azp"] = "another-client" },
+		},
+		{
+			name: "expired",
+			claims: func(claims jwt.MapClaims) {
+				claims["exp"] = time.Now().Add(-2 * time.Minute).Unix()
+			},
+		},
+		{
+			name: "issued in the future",
+			claims: func(claims jwt.MapClaims) {
+				claims["iat"] = time.Now().Add(2 * time.Minute).Unix()
+			},
+		},
+		{
+			name:   "without subject",
+			claims: func(claims jwt.MapClaims) { delete(claims, "sub") },
+		},
+		{
+			name:   "signed with an unpublished key",
+			signer: &stubKey{id: "unpublished", method: jwt.SigningMethodES256, private: ecKey},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubProvider(t)
+			stub.claims, stub.signer = tt.claims, tt.signer
+			nonce := tt.nonce
+			if nonce == "" {
+				nonce = "n-0S6_WzA2Mj"
+			}
+
+			_, err := signIn(t, newProvider(stub), stub, nonce)
+			if !errors.Is(err, ioidc.ErrRejected) {
+				t.Errorf("expected the sign in to be rejected, got %v", err)
+			}
+		})
+	}
+}
+
+func TestProvider_ExchangeCodeRejected(t *testing.T) {
+	stub := newStubProvider(t)
+	provider := newProvider(stub)
+
+	if _, err := provider.Exchange("unknown", "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk", "nonce"); !errors.Is(err, ioidc.ErrRejected) {
+		t.Errorf("expected an unknown code to be rejected, got %v", err)
+	}
+
+	if _, err := signIn(t, provider, stub, "n-0S6_WzA2Mj"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// A code verifier that does not match the code challenge is refused by the provider.
+	authURL, _ := provider.AuthCodeURL("state", "nonce", "challenge")
+	client := stub.server.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	response, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	response.Body.Close()
+	callback, _ := url.Parse(response.Header.Get("Location"))
+	if _, err := provider.Exchange(callback.Query().Get("code"), "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk", "nonce"); !errors.Is(err, ioidc.ErrRejected) {
+		t.Errorf("expected a wrong code verifier to be rejected, got %v", err)
+	}
+}
+
+func TestProvider_KeyRotation(t *testing.T) {
+	stub := newStubProvider(t)
+	provider := newProvider(stub)
+
+	if _, err := signIn(t, provider, stub, "n-0S6_WzA2Mj"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The keys are fetched again for a token signed with a new key, at most once a minute.
+	stub.rotate(t)
+	if _, err := signIn(t, provider, stub, "n-0S6_WzA2Mj"); !errors.Is(err, ioidc.ErrRejected) {
+		t.Fatalf("expected the keys not to be fetched again within a minute, got %v", err)
+	}
+	provider.config.TimeService.(*MockTimeService).now = stub.now.Add(keysRefreshInterval)
+	if _, err := signIn(t, provider, stub, "n-0S6_WzA2Mj"); err != nil {
+		t.Fatalf("expected a token signed with the new key to be accepted, got %v", err)
+	}
+}
+
+func TestProvider_DiscoveryIssuerMismatch(t *testing.T) {
+	stub := newStubProvider(t)
+	stub.issuer = "https://evil.example.com"
+
+	if _, err := newProvider(stub).AuthCodeURL("state", "nonce", "challenge"); err == nil {
+		t.Errorf("expected a discovery document of another issuer to be rejected")
+	}
+}
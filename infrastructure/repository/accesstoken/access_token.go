@@ -0,0 +1,147 @@
+// Package accesstokenrepo provides the implementation of the IAccessTokenRepository interface for storing personal access tokens in a PostgreSQL database.
+package accesstokenrepo
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	"github.com/google/uuid"
+)
+
+// Repository implements the IAccessTokenRepository interface for interacting with the access_tokens table in the database.
+type Repository struct {
+	db *sql.DB
+}
+
+var _ irepository.IAccessTokenRepository = &Repository{}
+
+// New creates a new instance of Repository with the given database connection.
+func New(db *sql.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// columns are the columns of a token, in the order scan reads them.
+const columns = `id, user_id, name, token_hash, scopes, created_at, expires_at, last_used_at, revoked_at`
+
+// Save inserts a new personal access token. Its scopes are stored separated by spaces.
+func (r *Repository) Save(token *irepository.AccessToken) error {
+	_, err := r.db.Exec(`
+		INSERT INTO access_tokens (id, user_id, name, token_hash, scopes, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		token.ID, token.UserID, token.Name, token.TokenHash, strings.Join(token.Scopes, " "), token.CreatedAt, token.ExpiresAt)
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error saving access token: %v", err))
+	}
+	return nil
+}
+
+// ByHash retrieves the personal access token with the given hash, or nil if there is none.
+func (r *Repository) ByHash(tokenHash string) (*irepository.AccessToken, error) {
+	token, err := scan(r.db.QueryRow(`SELECT `+columns+` FROM access_tokens WHERE token_hash = $1`, tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("error retrieving access token: %v", err))
+	}
+	return token, nil
+}
+
+// ByUser retrieves the unrevoked personal access tokens of the user, newest first.
+func (r *Repository) ByUser(userId uuid.UUID) ([]*irepository.AccessToken, error) {
+	rows, err := r.db.Query(`
+		SELECT `+columns+`
+		FROM access_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC, id`, userId)
+	if err != nil {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("error retrieving access tokens: %v", err))
+	}
+	defer rows.Close()
+
+	tokens := []*irepository.AccessToken{}
+	for rows.Next() {
+		token, err := scan(rows)
+		if err != nil {
+			return nil, errdmn.NewUnexpected(fmt.Sprintf("error scanning access token: %v", err))
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("error iterating access tokens: %v", err))
+	}
+	return tokens, nil
+}
+
+// Revoke revokes the unrevoked token of the user with the given ID and reports whether it did.
+func (r *Repository) Revoke(userId uuid.UUID, id uuid.UUID, at time.Time) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE access_tokens
+		SET revoked_at = $3
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, id, userId, at)
+	if err != nil {
+		return false, errdmn.NewUnexpected(fmt.Sprintf("error revoking access token: %v", err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, errdmn.NewUnexpected(fmt.Sprintf("error revoking access token: %v", err))
+	}
+	return affected > 0, nil
+}
+
+// RevokeAll revokes every unrevoked token of the user.
+func (r *Repository) RevokeAll(userId uuid.UUID, at time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE access_tokens
+		SET revoked_at = $2
+		WHERE user_id = $1 AND revoked_at IS NULL`, userId, at)
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error revoking access tokens: %v", err))
+	}
+	return nil
+}
+
+// Touch records the use of the token, unless its last use was recorded after notBefore.
+func (r *Repository) Touch(id uuid.UUID, at time.Time, notBefore time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE access_tokens
+		SET last_used_at = $2
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at <= $3)`, id, at, notBefore)
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error recording use of access token: %v", err))
+	}
+	return nil
+}
+
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scan reads a token from the columns of a row.
+func scan(row scanner) (*irepository.AccessToken, error) {
+	token := &irepository.AccessToken{}
+	var scopes string
+	var lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.TokenHash, &scopes,
+		&token.CreatedAt, &token.ExpiresAt, &lastUsedAt, &revokedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	token.Scopes = strings.Fields(scopes)
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return token, nil
+}
@@ -2,8 +2,11 @@
 package expenserepo
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"log"
+	"slices"
 
 	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
 	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
@@ -20,8 +23,11 @@ type Repository struct {
 
 var _ irepository.IExpenseRepository = &Repository{}
 
+// streamBatchSize is the number of expenses fetched at once by Stream.
+const streamBatchSize = 500
+
 const listBaseQuery = `
-	SELECT id, description, amount, date, user_id, created_at, updated_at
+	SELECT id, description, amount, kind, date, category, account, external_id, user_id, created_at, updated_at, version
 	FROM expenses
 	WHERE user_id = $1
 `
@@ -33,31 +39,73 @@ func New(db *sql.DB) *Repository {
 	}
 }
 
-// Save inserts or updates an expense in the database.
+// Save inserts or updates an expense in the database. An update only applies while the
+// stored version of the expense is still the version it was read at, and increments it;
+// otherwise errexpense.VersionConflict is returned. The stored version is set on the expense.
 func (e *Repository) Save(expense *expensemodel.Expense) error {
-	_, err := e.db.Exec(`
-		INSERT INTO expenses (id, description, amount, date, user_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	var version int
+	err := e.db.QueryRow(`
+		INSERT INTO expenses (id, description, amount, kind, date, category, account, external_id, user_id, created_at, updated_at, version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		ON CONFLICT (id, user_id) DO UPDATE
 		SET description = EXCLUDED.description,
 			amount = EXCLUDED.amount,
+			kind = EXCLUDED.kind,
 			date = EXCLUDED.date,
-			updated_at = EXCLUDED.updated_at`,
-		expense.ID(), expense.Description(), expense.Amount(), expense.Date(), expense.UserID(), expense.CreatedAt(), expense.UpdatedAt())
+			category = EXCLUDED.category,
+			account = EXCLUDED.account,
+			updated_at = EXCLUDED.updated_at,
+			version = expenses.version + 1
+		WHERE expenses.version = EXCLUDED.version
+		RETURNING version`,
+		expense.ID(), expense.Description(), amount(expense), string(expense.Kind()), expense.Date(), expense.Category(),
+		expense.Account(), externalID(expense), expense.UserID(), expense.CreatedAt(), expense.UpdatedAt(), expense.Version()).
+		Scan(&version)
 
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return errexpense.VersionConflict
+		}
 		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
 			return fmt.Errorf("conflict error: expense with ID %s and user_id %s already exists", expense.ID(), expense.UserID())
 		}
 		return errdmn.NewUnexpected(fmt.Sprintf("error saving expense: %v", err))
 	}
+
+	expense.SetVersion(version)
+	return nil
+}
+
+// SaveMany inserts the expenses in a single transaction. If any insert fails the
+// transaction is rolled back, so either all expenses are saved or none.
+func (e *Repository) SaveMany(expenses []*expensemodel.Expense) (err error) {
+	tx, err := e.db.Begin()
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error starting transaction: %v", err))
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("error rolling back transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	if err = InsertExpenses(tx, expenses); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error committing transaction: %v", err))
+	}
 	return nil
 }
 
 // ById retrieves an expense by its unique identifier and user ID.
 func (e *Repository) ById(id uuid.UUID, userId uuid.UUID) (*expensemodel.Expense, error) {
 	row := e.db.QueryRow(`
-		SELECT id, description, amount, date, user_id, created_at, updated_at
+		SELECT id, description, amount, kind, date, category, account, external_id, user_id, created_at, updated_at, version
 		FROM expenses
 		WHERE id = $1 AND user_id = $2`, id, userId)
 
@@ -72,43 +120,339 @@ func (e *Repository) ById(id uuid.UUID, userId uuid.UUID) (*expensemodel.Expense
 	return expense, nil
 }
 
-// ListByTime retrieves paginated expenses for a user based on creation time.
-func (e *Repository) ListByTime(params irepository.ListByTimeParams) ([]*expensemodel.Expense, error) {
+// List retrieves paginated expenses for a user, ordered by the sort keys with the ID as
+// the final tie-breaker.
+func (e *Repository) List(params irepository.ListParams) ([]*expensemodel.Expense, error) {
+	columns, err := SortColumns(params.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	lastSeenID := uuid.Nil
+	if params.LastSeenID != nil {
+		lastSeenID = *params.LastSeenID
+	}
+
 	queryParams := []interface{}{params.UserID}
-	additionalWhere := BuildExpenseListWhereClause(params.Ascending, *params.LastSeenID, params.LastSeenDate, "date", &queryParams)
-	orderBy := BuildExpenseListOrderByClause(params.Ascending, "date")
+	filterWhere := BuildExpenseFilterClause(params.Filter, &queryParams)
+	additionalWhere, err := BuildExpenseListWhereClause(params.Sort, columns, params.Backward, lastSeenID, params.LastSeenValues, &queryParams)
+	if err != nil {
+		return nil, err
+	}
+	orderBy := BuildExpenseListOrderByClause(params.Sort, columns, params.Backward)
 	limitClause := BuildLimitClause(params.Limit, &queryParams)
 
-	query := fmt.Sprintf("%s %s %s %s", listBaseQuery, additionalWhere, orderBy, limitClause)
-	rows, err := e.db.Query(query, queryParams...)
+	query := fmt.Sprintf("%s %s %s %s %s", listBaseQuery, filterWhere, additionalWhere, orderBy, limitClause)
+	return e.list(query, queryParams, params.Backward)
+}
+
+// Count returns the number of expenses of a user that match the filter.
+func (e *Repository) Count(userId uuid.UUID, filter irepository.ExpenseFilter) (int, error) {
+	queryParams := []interface{}{userId}
+	filterWhere := BuildExpenseFilterClause(filter, &queryParams)
+
+	var count int
+	query := fmt.Sprintf("SELECT COUNT(*) FROM expenses WHERE user_id = $1 %s", filterWhere)
+	if err := e.db.QueryRow(query, queryParams...).Scan(&count); err != nil {
+		return 0, errdmn.NewUnexpected(fmt.Sprintf("error counting expenses: %v", err))
+	}
+	return count, nil
+}
+
+// Freshness returns the number of expenses of a user that match the filter together with
+// the latest update time among them.
+func (e *Repository) Freshness(userId uuid.UUID, filter irepository.ExpenseFilter) (*irepository.ExpenseFreshness, error) {
+	queryParams := []interface{}{userId}
+	filterWhere := BuildExpenseFilterClause(filter, &queryParams)
+
+	var freshness irepository.ExpenseFreshness
+	var lastUpdatedAt sql.NullTime
+	query := fmt.Sprintf("SELECT COUNT(*), MAX(updated_at) FROM expenses WHERE user_id = $1 %s", filterWhere)
+	if err := e.db.QueryRow(query, queryParams...).Scan(&freshness.Count, &lastUpdatedAt); err != nil {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("error summarizing expenses: %v", err))
+	}
+	freshness.LastUpdatedAt = lastUpdatedAt.Time
+	return &freshness, nil
+}
+
+// Select retrieves the expenses of a user matched by the selection, newest first. At most
+// limit expenses are retrieved unless limit is zero.
+func (e *Repository) Select(userId uuid.UUID, selection irepository.ExpenseSelection, limit int) ([]*expensemodel.Expense, error) {
+	query, queryParams := selectQuery(userId, selection, limit)
+	return e.list(query, queryParams, false)
+}
+
+// selectQuery builds the query retrieving the expenses of a user matched by the selection,
+// newest first, and at most limit of them unless limit is zero.
+func selectQuery(userId uuid.UUID, selection irepository.ExpenseSelection, limit int) (string, []interface{}) {
+	queryParams := []interface{}{userId}
+	idWhere := BuildExpenseIDsClause(selection.IDs, &queryParams)
+	filterWhere := BuildExpenseFilterClause(selection.Filter, &queryParams)
+	limitClause := ""
+	if limit > 0 {
+		limitClause = BuildLimitClause(limit, &queryParams)
+	}
+
+	query := fmt.Sprintf("%s %s %s ORDER BY date DESC, id DESC %s", listBaseQuery, idWhere, filterWhere, limitClause)
+	return query, queryParams
+}
+
+// UpdateSelected locks the expenses of a user matched by the selection, at most limit of
+// them, and passes them to apply in a single transaction. The expenses are then updated; like
+// Save, every update checks and increments the version of its expense. If apply or any update
+// fails the transaction is rolled back, so either all expenses are updated or none.
+func (e *Repository) UpdateSelected(userId uuid.UUID, selection irepository.ExpenseSelection, limit int, apply func(expenses []*expensemodel.Expense) error) (err error) {
+	tx, err := e.db.Begin()
 	if err != nil {
-		return nil, errdmn.NewUnexpected(fmt.Sprintf("error listing expenses: %v", err))
+		return errdmn.NewUnexpected(fmt.Sprintf("error starting transaction: %v", err))
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("error rolling back transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	query, queryParams := selectQuery(userId, selection, limit)
+	expenses, err := queryExpenses(tx, query+" FOR UPDATE", queryParams, false)
+	if err != nil {
+		return err
+	}
+	if err = apply(expenses); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`
+		UPDATE expenses
+		SET description = $3, amount = $4, kind = $5, date = $6, category = $7, account = $8, updated_at = $9, version = version + 1
+		WHERE id = $1 AND user_id = $2 AND version = $10
+		RETURNING version`)
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error preparing expense update: %v", err))
+	}
+	defer stmt.Close()
+
+	versions := make([]int, len(expenses))
+	for i, expense := range expenses {
+		err = stmt.QueryRow(expense.ID(), expense.UserID(), expense.Description(), amount(expense), string(expense.Kind()),
+			expense.Date(), expense.Category(), expense.Account(), expense.UpdatedAt(), expense.Version()).Scan(&versions[i])
+		if err == sql.ErrNoRows {
+			// The expense is locked by this transaction, so it can only have been moved to another user.
+			err = errexpense.VersionConflict
+			return err
+		}
+		if err != nil {
+			return errdmn.NewUnexpected(fmt.Sprintf("error updating expense: %v", err))
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error committing transaction: %v", err))
+	}
+
+	for i, expense := range expenses {
+		expense.SetVersion(versions[i])
+	}
+	return nil
+}
+
+// DeleteSelected locks the expenses of a user matched by the selection, at most limit of
+// them, and passes them to check in a single transaction. Unless check fails, the expenses
+// are then deleted and their number is returned.
+func (e *Repository) DeleteSelected(userId uuid.UUID, selection irepository.ExpenseSelection, limit int, check func(expenses []*expensemodel.Expense) error) (deleted int, err error) {
+	tx, err := e.db.Begin()
+	if err != nil {
+		return 0, errdmn.NewUnexpected(fmt.Sprintf("error starting transaction: %v", err))
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("error rolling back transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	query, queryParams := selectQuery(userId, selection, limit)
+	expenses, err := queryExpenses(tx, query+" FOR UPDATE", queryParams, false)
+	if err != nil {
+		return 0, err
+	}
+	if err = check(expenses); err != nil {
+		return 0, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(expenses))
+	for _, expense := range expenses {
+		ids = append(ids, expense.ID())
+	}
+	deleted, err = DeleteExpenses(tx, userId, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, errdmn.NewUnexpected(fmt.Sprintf("error committing transaction: %v", err))
+	}
+	return deleted, nil
+}
+
+// Merge deletes the duplicates and saves the category, account and external ID of the kept
+// expense in a single transaction, checking and incrementing its version like Save. The duplicates are deleted first so that the kept expense
+// can take over their external ID.
+func (e *Repository) Merge(kept *expensemodel.Expense, duplicateIds []uuid.UUID) (err error) {
+	tx, err := e.db.Begin()
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error starting transaction: %v", err))
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("error rolling back transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	deleted, err := DeleteExpenses(tx, kept.UserID(), duplicateIds)
+	if err != nil {
+		return err
+	}
+	if deleted != len(duplicateIds) {
+		err = errexpense.NotFound
+		return err
+	}
+
+	var version int
+	err = tx.QueryRow(`
+		UPDATE expenses
+		SET category = $3, account = $4, external_id = $5, updated_at = $6, version = version + 1
+		WHERE id = $1 AND user_id = $2 AND version = $7
+		RETURNING version`,
+		kept.ID(), kept.UserID(), kept.Category(), kept.Account(), externalID(kept), kept.UpdatedAt(), kept.Version()).
+		Scan(&version)
+	if err == sql.ErrNoRows {
+		err = errexpense.VersionConflict
+		return err
+	}
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error updating expense: %v", err))
+	}
+
+	if err = tx.Commit(); err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error committing transaction: %v", err))
+	}
+
+	kept.SetVersion(version)
+	return nil
+}
+
+// Stream calls each for every expense of the user that matches the filter, newest first.
+// The expenses are fetched in batches from a cursor declared in a read-only transaction, so
+// that at most streamBatchSize of them are held in memory at once. Cancelling ctx rolls the
+// transaction back, which stops the stream.
+func (e *Repository) Stream(ctx context.Context, userId uuid.UUID, filter irepository.ExpenseFilter, each func(expense *expensemodel.Expense) error) error {
+	tx, err := e.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error starting transaction: %v", err))
+	}
+	// The transaction only reads, so it is always rolled back, which also closes the cursor.
+	defer func() {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			log.Printf("error rolling back transaction: %v", rbErr)
+		}
+	}()
+
+	queryParams := []interface{}{userId}
+	filterWhere := BuildExpenseFilterClause(filter, &queryParams)
+	query := fmt.Sprintf("DECLARE expense_stream NO SCROLL CURSOR FOR %s %s ORDER BY date DESC, id DESC", listBaseQuery, filterWhere)
+	if _, err := tx.Exec(query, queryParams...); err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error declaring expense cursor: %v", err))
+	}
+
+	for {
+		fetched, err := fetchExpenses(tx, each)
+		if err != nil {
+			return err
+		}
+		if fetched < streamBatchSize {
+			return nil
+		}
+	}
+}
+
+// fetchExpenses fetches the next batch of the expense stream cursor, calls each for every
+// expense of it, and returns how many were fetched.
+func fetchExpenses(tx *sql.Tx, each func(expense *expensemodel.Expense) error) (int, error) {
+	rows, err := tx.Query(fmt.Sprintf("FETCH %d FROM expense_stream", streamBatchSize))
+	if err != nil {
+		return 0, errdmn.NewUnexpected(fmt.Sprintf("error fetching expenses: %v", err))
 	}
 	defer rows.Close()
 
-	var expenses []*expensemodel.Expense
+	fetched := 0
 	for rows.Next() {
 		expense, err := ScanExpense(rows)
 		if err != nil {
-			return nil, errdmn.NewUnexpected(fmt.Sprintf("error scanning expense: %v", err))
+			return fetched, errdmn.NewUnexpected(fmt.Sprintf("error scanning expense: %v", err))
+		}
+		fetched++
+		if err := each(expense); err != nil {
+			return fetched, err
 		}
-		expenses = append(expenses, expense)
 	}
-	if err = rows.Err(); err != nil {
-		return nil, errdmn.NewUnexpected(fmt.Sprintf("error with rows: %v", err))
+	if err := rows.Err(); err != nil {
+		return fetched, errdmn.NewUnexpected(fmt.Sprintf("error fetching expenses: %v", err))
 	}
-	return expenses, nil
+
+	return fetched, nil
 }
 
-// ListByAmount retrieves paginated expenses for a user based on amount.
-func (e *Repository) ListByAmount(params irepository.ListByAmountParams) ([]*expensemodel.Expense, error) {
-	queryParams := []interface{}{params.UserID}
-	additionalWhere := BuildExpenseListWhereClause(params.Ascending, *params.LastSeenID, params.LastSeenAmt, "amount", &queryParams)
-	orderBy := BuildExpenseListOrderByClause(params.Ascending, "amount")
-	limitClause := BuildLimitClause(params.Limit, &queryParams)
+// ExistingExternalIDs returns which of the given external IDs already belong to an expense
+// of the user.
+func (e *Repository) ExistingExternalIDs(userId uuid.UUID, externalIds []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if len(externalIds) == 0 {
+		return existing, nil
+	}
 
-	query := fmt.Sprintf("%s %s %s %s", listBaseQuery, additionalWhere, orderBy, limitClause)
-	rows, err := e.db.Query(query, queryParams...)
+	rows, err := e.db.Query(`
+		SELECT external_id
+		FROM expenses
+		WHERE user_id = $1 AND external_id = ANY($2::text[])`, userId, pq.Array(externalIds))
+	if err != nil {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("error looking up external IDs: %v", err))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var externalID string
+		if err := rows.Scan(&externalID); err != nil {
+			return nil, errdmn.NewUnexpected(fmt.Sprintf("error scanning external ID: %v", err))
+		}
+		existing[externalID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("error looking up external IDs: %v", err))
+	}
+
+	return existing, nil
+}
+
+// list runs a listing query against the database.
+func (e *Repository) list(query string, queryParams []interface{}, backward bool) ([]*expensemodel.Expense, error) {
+	return queryExpenses(e.db, query, queryParams, backward)
+}
+
+// queryExpenses runs a listing query through the given querier, a database or a
+// transaction, and scans its rows. Backward queries are run in reverse order, so their rows
+// are flipped back before returning.
+func queryExpenses(querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}, query string, queryParams []interface{}, backward bool) ([]*expensemodel.Expense, error) {
+	rows, err := querier.Query(query, queryParams...)
 	if err != nil {
 		return nil, errdmn.NewUnexpected(fmt.Sprintf("error listing expenses: %v", err))
 	}
@@ -125,5 +469,9 @@ func (e *Repository) ListByAmount(params irepository.ListByAmountParams) ([]*exp
 	if err = rows.Err(); err != nil {
 		return nil, errdmn.NewUnexpected(fmt.Sprintf("error with rows: %v", err))
 	}
+
+	if backward {
+		slices.Reverse(expenses)
+	}
 	return expenses, nil
 }
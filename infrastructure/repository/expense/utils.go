@@ -2,12 +2,16 @@
 package expenserepo
 
 import (
+	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
 	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
 	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 // ScanExpense converts a database row into an Expense model.
@@ -15,21 +19,35 @@ func ScanExpense(scanner interface {
 	Scan(dest ...interface{}) error
 }) (*expensemodel.Expense, error) {
 	var id, userId uuid.UUID
-	var description string
-	var amount float32
+	var description, kind, category, account string
+	var externalID sql.NullString
+	var amount string
 	var date, createdAt, updatedAt time.Time
+	var version int
 
-	err := scanner.Scan(&id, &description, &amount, &date, &userId, &createdAt, &updatedAt)
+	err := scanner.Scan(&id, &description, &amount, &kind, &date, &category, &account, &externalID, &userId, &createdAt, &updatedAt, &version)
 	if err != nil {
 		return nil, err
 	}
 
+	// The amount is scanned as text so that it is not rounded through a float.
+	cents, ok := expensemodel.ParseCents(amount)
+	if !ok {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("error scanning expense amount %q", amount))
+	}
+
 	config := expensemodel.Config{
 		Description:  description,
-		Amount:       amount,
+		AmountCents:  cents,
 		UserId:       userId,
 		Date:         date,
+		Category:     category,
+		Account:      account,
+		Kind:         expensemodel.Kind(kind),
+		ExternalID:   externalID.String,
 		CreationTime: createdAt,
+		UpdateTime:   updatedAt,
+		Version:      version,
 	}
 
 	expense, err := expensemodel.NewWithID(id, config)
@@ -37,40 +55,175 @@ func ScanExpense(scanner interface {
 		return nil, errdmn.NewUnexpected(fmt.Sprintf("error creating expense model: %v", err))
 	}
 
-	expense.UpdateDate(date)
-	_ = expense.UpdateDescription(description)
-	_ = expense.UpdateAmount(amount)
-
 	return expense, nil
 }
 
-// BuildExpenseListWhereClause creates the WHERE clause for expense pagination queries.
-func BuildExpenseListWhereClause(ascending bool, id uuid.UUID, value interface{}, field string, params *[]interface{}) string {
+// sortColumns maps the sortable fields to their columns. Only these columns may ever be
+// interpolated into a listing query.
+var sortColumns = map[string]string{
+	irepository.SortByDate:        "date",
+	irepository.SortByAmount:      "amount",
+	irepository.SortByDescription: "description",
+	irepository.SortByCreatedAt:   "created_at",
+	irepository.SortByUpdatedAt:   "updated_at",
+}
+
+// SortColumns resolves the columns of the sort keys, rejecting fields that are not sortable.
+func SortColumns(sort []irepository.SortKey) ([]string, error) {
+	columns := make([]string, 0, len(sort))
+	for _, key := range sort {
+		column, ok := sortColumns[key.Field]
+		if !ok {
+			return nil, errdmn.NewValidation(fmt.Sprintf("cannot sort expenses by %q.", key.Field))
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// BuildExpenseListWhereClause creates the WHERE clause for expense pagination queries. It
+// selects the rows that come after the last seen row in the order of the sort keys, which
+// is a lexicographic comparison over the keys followed by the ID:
+//
+//	k1 > v1 OR (k1 = v1 AND k2 > v2) OR (k1 = v1 AND k2 = v2 AND id < last_id)
+//
+// with each inequality following the direction of its key. When backward is set, it selects
+// the rows that come before the cursor instead.
+func BuildExpenseListWhereClause(sort []irepository.SortKey, columns []string, backward bool, id uuid.UUID, values []interface{}, params *[]interface{}) (string, error) {
 	if id == uuid.Nil {
+		return "", nil
+	}
+	if len(values) != len(sort) {
+		return "", errdmn.NewValidation("pagination cursor does not match the sort keys.")
+	}
+
+	var alternatives []string
+	var equalities []string
+	for i, key := range sort {
+		*params = append(*params, values[i])
+		placeholder := len(*params)
+
+		inequalitySign := "<"
+		if key.Ascending {
+			inequalitySign = ">"
+		}
+		if backward {
+			inequalitySign = flipInequality(inequalitySign)
+		}
+
+		alternative := append(append([]string{}, equalities...), fmt.Sprintf("%s %s $%d", columns[i], inequalitySign, placeholder))
+		alternatives = append(alternatives, "("+strings.Join(alternative, " AND ")+")")
+		equalities = append(equalities, fmt.Sprintf("%s = $%d", columns[i], placeholder))
+	}
+
+	idInequalitySign := "<"
+	if backward {
+		idInequalitySign = flipInequality(idInequalitySign)
+	}
+	*params = append(*params, id)
+	alternative := append(equalities, fmt.Sprintf("id %s $%d", idInequalitySign, len(*params)))
+	alternatives = append(alternatives, "("+strings.Join(alternative, " AND ")+")")
+
+	return fmt.Sprintf(`
+		AND (%s) 
+		`, strings.Join(alternatives, " OR ")), nil
+}
+
+// BuildExpenseIDsClause creates the AND condition narrowing an expense query to the given
+// IDs. It returns an empty clause when there are no IDs.
+func BuildExpenseIDsClause(ids []uuid.UUID, params *[]interface{}) string {
+	if len(ids) == 0 {
 		return ""
 	}
 
-	inequalitySign := "<"
-	if ascending {
-		inequalitySign = ">"
+	strIds := make([]string, 0, len(ids))
+	for _, id := range ids {
+		strIds = append(strIds, id.String())
 	}
+	*params = append(*params, pq.Array(strIds))
+	return fmt.Sprintf("AND id = ANY($%d::uuid[])", len(*params))
+}
 
-	clause := fmt.Sprintf(`
-		AND (%s %s $%d OR (%s = $%d AND id < $%d)) 
-		`, field, inequalitySign, len(*params)+1, field, len(*params)+1, len(*params)+2)
+// BuildExpenseFilterClause creates the AND conditions narrowing an expense listing to the given filter.
+// The conditions only restrict the row set, so they compose with the keyset pagination clause.
+func BuildExpenseFilterClause(filter irepository.ExpenseFilter, params *[]interface{}) string {
+	var conditions []string
+	addCondition := func(format string, value interface{}) {
+		*params = append(*params, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(*params)))
+	}
 
-	*params = append(*params, value, id)
-	return clause
+	if filter.DateFrom != nil {
+		addCondition("date >= $%d", *filter.DateFrom)
+	}
+	if filter.DateTo != nil {
+		addCondition("date <= $%d", *filter.DateTo)
+	}
+	if filter.MinAmount != nil {
+		addCondition("amount >= $%d", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		addCondition("amount <= $%d", *filter.MaxAmount)
+	}
+	if filter.Description != "" {
+		addCondition(`description ILIKE $%d ESCAPE '\'`, "%"+escapeLikePattern(filter.Description)+"%")
+	}
+	if filter.Category != "" {
+		addCondition("category = $%d", filter.Category)
+	}
+	if filter.Account != "" {
+		addCondition("account = $%d", filter.Account)
+	}
+
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "AND " + strings.Join(conditions, " AND ")
+}
+
+// escapeLikePattern escapes the LIKE wildcards so user input is matched literally.
+func escapeLikePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
 }
 
 // BuildExpenseListOrderByClause creates the ORDER BY clause for expense pagination queries.
-func BuildExpenseListOrderByClause(ascending bool, field string) string {
-	order := "DESC"
-	if ascending {
-		order = "ASC"
+// When backward is set, the ordering is reversed so the rows nearest to the cursor come first.
+func BuildExpenseListOrderByClause(sort []irepository.SortKey, columns []string, backward bool) string {
+	terms := make([]string, 0, len(sort)+1)
+	for i, key := range sort {
+		order := "DESC"
+		if key.Ascending {
+			order = "ASC"
+		}
+		if backward {
+			order = flipOrder(order)
+		}
+		terms = append(terms, fmt.Sprintf("%s %s", columns[i], order))
+	}
+
+	idOrder := "DESC"
+	if backward {
+		idOrder = flipOrder(idOrder)
+	}
+	terms = append(terms, fmt.Sprintf("id %s", idOrder))
+
+	return "ORDER BY " + strings.Join(terms, ", ")
+}
+
+// flipInequality turns a strict inequality sign around.
+func flipInequality(sign string) string {
+	if sign == "<" {
+		return ">"
 	}
+	return "<"
+}
 
-	return fmt.Sprintf("ORDER BY %s %s, id DESC", field, order)
+// flipOrder turns a sort direction around.
+func flipOrder(order string) string {
+	if order == "ASC" {
+		return "DESC"
+	}
+	return "ASC"
 }
 
 // BuildLimitClause creates the LIMIT clause for expense pagination queries.
@@ -80,3 +233,67 @@ func BuildLimitClause(limit int, params *[]interface{}) string {
 
 	return clause
 }
+
+// InsertExpenses inserts the expenses through the given transaction. A duplicate ID is
+// reported as a conflict.
+func InsertExpenses(tx *sql.Tx, expenses []*expensemodel.Expense) error {
+	stmt, err := tx.Prepare(`
+		INSERT INTO expenses (id, description, amount, kind, date, category, account, external_id, user_id, created_at, updated_at, version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`)
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error preparing expense insert: %v", err))
+	}
+	defer stmt.Close()
+
+	for _, expense := range expenses {
+		_, err = stmt.Exec(expense.ID(), expense.Description(), amount(expense), string(expense.Kind()), expense.Date(),
+			expense.Category(), expense.Account(), externalID(expense), expense.UserID(), expense.CreatedAt(), expense.UpdatedAt(),
+			expense.Version())
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+				if pqErr.Constraint == "idx_expenses_user_id_external_id" {
+					return errdmn.NewConflict(fmt.Sprintf("transaction %s was already imported", expense.ExternalID()))
+				}
+				return errdmn.NewConflict(fmt.Sprintf("expense with ID %s already exists", expense.ID()))
+			}
+			return errdmn.NewUnexpected(fmt.Sprintf("error saving expense: %v", err))
+		}
+	}
+	return nil
+}
+
+// DeleteExpenses deletes the expenses of a user with the given IDs through the given
+// executor, a database or a transaction, and returns how many were deleted.
+func DeleteExpenses(executor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}, userId uuid.UUID, ids []uuid.UUID) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	queryParams := []interface{}{userId}
+	idWhere := BuildExpenseIDsClause(ids, &queryParams)
+
+	result, err := executor.Exec(fmt.Sprintf("DELETE FROM expenses WHERE user_id = $1 %s", idWhere), queryParams...)
+	if err != nil {
+		return 0, errdmn.NewUnexpected(fmt.Sprintf("error deleting expenses: %v", err))
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, errdmn.NewUnexpected(fmt.Sprintf("error counting deleted expenses: %v", err))
+	}
+	return int(deleted), nil
+}
+
+// externalID returns the external ID of the expense, or NULL when it has none so that
+// expenses without one never collide on the unique index.
+func externalID(expense *expensemodel.Expense) sql.NullString {
+	return sql.NullString{String: expense.ExternalID(), Valid: expense.ExternalID() != ""}
+}
+
+// amount returns the amount of the expense as an exact decimal, which Postgres converts to
+// DECIMAL(10,2) without rounding.
+func amount(expense *expensemodel.Expense) string {
+	return expensemodel.FormatCents(expense.AmountCents())
+}
@@ -0,0 +1,85 @@
+// Package idempotencyrepo provides the implementation of the IIdempotencyRepository interface for storing the responses to idempotent requests in a PostgreSQL database.
+package idempotencyrepo
+
+import (
+	"database/sql"
+	"fmt"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	"github.com/google/uuid"
+)
+
+// Repository implements the IIdempotencyRepository interface for interacting with the idempotency_keys table in the database.
+type Repository struct {
+	db *sql.DB
+}
+
+var _ irepository.IIdempotencyRepository = &Repository{}
+
+// New creates a new instance of Repository with the given database connection.
+func New(db *sql.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// Reserve inserts the record, replacing an expired record of the same user and key. When an
+// unexpired record exists the insert does nothing and that record is returned instead.
+func (r *Repository) Reserve(record *irepository.IdempotencyRecord) (*irepository.IdempotencyRecord, error) {
+	result, err := r.db.Exec(`
+		INSERT INTO idempotency_keys (user_id, key, request_hash, response, created_at, expires_at)
+		VALUES ($1, $2, $3, NULL, $4, $5)
+		ON CONFLICT (user_id, key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+			response = NULL,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= EXCLUDED.created_at`,
+		record.UserID, record.Key, record.RequestHash, record.CreatedAt, record.ExpiresAt)
+	if err != nil {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("error reserving idempotency key: %v", err))
+	}
+	if affected, _ := result.RowsAffected(); affected > 0 {
+		return nil, nil
+	}
+
+	existing := &irepository.IdempotencyRecord{UserID: record.UserID, Key: record.Key}
+	err = r.db.QueryRow(`
+		SELECT request_hash, response, created_at, expires_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2`, record.UserID, record.Key).
+		Scan(&existing.RequestHash, &existing.Response, &existing.CreatedAt, &existing.ExpiresAt)
+	if err == sql.ErrNoRows {
+		// The record was released in between; the key is free again.
+		return r.Reserve(record)
+	}
+	if err != nil {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("error retrieving idempotency key: %v", err))
+	}
+
+	return existing, nil
+}
+
+// Complete stores the response of the reserved record of the user and key.
+func (r *Repository) Complete(userId uuid.UUID, key string, response []byte) error {
+	_, err := r.db.Exec(`
+		UPDATE idempotency_keys
+		SET response = $3
+		WHERE user_id = $1 AND key = $2`, userId, key, response)
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error storing idempotent response: %v", err))
+	}
+	return nil
+}
+
+// Release deletes the record of the user and key.
+func (r *Repository) Release(userId uuid.UUID, key string) error {
+	_, err := r.db.Exec(`
+		DELETE FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2`, userId, key)
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error releasing idempotency key: %v", err))
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// Package identityrepo provides the implementation of the IExternalIdentityRepository interface for storing the identities users sign in with at OpenID Connect providers in a PostgreSQL database.
+package identityrepo
+
+import (
+	"database/sql"
+	"fmt"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+)
+
+// Repository implements the IExternalIdentityRepository interface for interacting with the external_identities table in the database.
+type Repository struct {
+	db *sql.DB
+}
+
+var _ irepository.IExternalIdentityRepository = &Repository{}
+
+// New creates a new instance of Repository with the given database connection.
+func New(db *sql.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// Link inserts the identity, unless the subject is already linked at the issuer, and reports
+// whether it did.
+func (r *Repository) Link(identity *irepository.ExternalIdentity) (bool, error) {
+	result, err := r.db.Exec(`
+		INSERT INTO external_identities (issuer, subject, user_id, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (issuer, subject) DO NOTHING`,
+		identity.Issuer, identity.Subject, identity.UserID, identity.CreatedAt)
+	if err != nil {
+		return false, errdmn.NewUnexpected(fmt.Sprintf("error linking identity: %v", err))
+	}
+
+	linked, err := result.RowsAffected()
+	if err != nil {
+		return false, errdmn.NewUnexpected(fmt.Sprintf("error linking identity: %v", err))
+	}
+	return linked == 1, nil
+}
+
+// ByIdentity retrieves the identity with the given subject at the issuer, or nil if there is
+// none.
+func (r *Repository) ByIdentity(issuer string, subject string) (*irepository.ExternalIdentity, error) {
+	identity := &irepository.ExternalIdentity{Issuer: issuer, Subject: subject}
+	err := r.db.QueryRow(`
+		SELECT user_id, created_at
+		FROM external_identities
+		WHERE issuer = $1 AND subject = $2`, issuer, subject).
+		Scan(&identity.UserID, &identity.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("error retrieving identity: %v", err))
+	}
+	return identity, nil
+}
@@ -0,0 +1,170 @@
+// Package importjobrepo provides the implementation of the IImportJobRepository interface for managing import jobs in a PostgreSQL database.
+package importjobrepo
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	errimportjob "github.com/beka-birhanu/finance-go/domain/error/importjob"
+	expensemodel "github.com/beka-birhanu/finance-go/domain/model/expense"
+	importjobmodel "github.com/beka-birhanu/finance-go/domain/model/importjob"
+	expenserepo "github.com/beka-birhanu/finance-go/infrastructure/repository/expense"
+	"github.com/google/uuid"
+)
+
+// Repository implements the IImportJobRepository interface for interacting with the import_jobs table in the database.
+type Repository struct {
+	db *sql.DB
+}
+
+var _ irepository.IImportJobRepository = &Repository{}
+
+// New creates a new instance of Repository with the given database connection.
+func New(db *sql.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// row is the stored JSON representation of an import row.
+type row struct {
+	Line               int         `json:"line"`
+	Error              string      `json:"error,omitempty"`
+	Duplicate          bool        `json:"duplicate,omitempty"`
+	PossibleDuplicates []uuid.UUID `json:"possibleDuplicates,omitempty"`
+	ExpenseID          uuid.UUID   `json:"expenseId,omitempty"`
+}
+
+// Save inserts or updates an import job.
+func (r *Repository) Save(job *importjobmodel.ImportJob) error {
+	return upsertImportJob(r.db, job)
+}
+
+// Commit inserts the import job together with the expenses it created in a single
+// transaction, so either the job and all of its expenses are saved or nothing is.
+func (r *Repository) Commit(job *importjobmodel.ImportJob, expenses []*expensemodel.Expense) error {
+	return r.inTransaction(func(tx *sql.Tx) error {
+		if err := expenserepo.InsertExpenses(tx, expenses); err != nil {
+			return err
+		}
+		return upsertImportJob(tx, job)
+	})
+}
+
+// Undo saves the undone import job and deletes the expenses it created in a single transaction.
+func (r *Repository) Undo(job *importjobmodel.ImportJob) error {
+	return r.inTransaction(func(tx *sql.Tx) error {
+		if _, err := expenserepo.DeleteExpenses(tx, job.UserID(), job.ExpenseIDs()); err != nil {
+			return err
+		}
+		return upsertImportJob(tx, job)
+	})
+}
+
+// ById retrieves an import job by its unique identifier and user ID.
+func (r *Repository) ById(id uuid.UUID, userId uuid.UUID) (*importjobmodel.ImportJob, error) {
+	var (
+		format, status       string
+		rawRows              []byte
+		createdAt, updatedAt time.Time
+	)
+
+	err := r.db.QueryRow(`
+		SELECT format, status, rows, created_at, updated_at
+		FROM import_jobs
+		WHERE id = $1 AND user_id = $2`, id, userId).Scan(&format, &status, &rawRows, &createdAt, &updatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errimportjob.NotFound
+		}
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("error retrieving import job: %v", err))
+	}
+
+	var storedRows []row
+	if err := json.Unmarshal(rawRows, &storedRows); err != nil {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("error decoding import rows: %v", err))
+	}
+
+	rows := make([]importjobmodel.Row, 0, len(storedRows))
+	for _, stored := range storedRows {
+		rows = append(rows, importjobmodel.Row{
+			Line:               stored.Line,
+			Error:              stored.Error,
+			Duplicate:          stored.Duplicate,
+			PossibleDuplicates: stored.PossibleDuplicates,
+			ExpenseID:          stored.ExpenseID,
+		})
+	}
+
+	job, err := importjobmodel.NewWithID(id, importjobmodel.Config{
+		UserId:       userId,
+		Format:       format,
+		Rows:         rows,
+		CreationTime: createdAt,
+	}, importjobmodel.Status(status), updatedAt)
+	if err != nil {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("error creating import job model: %v", err))
+	}
+
+	return job, nil
+}
+
+// inTransaction runs fn in a transaction, committing it if fn succeeds and rolling it back otherwise.
+func (r *Repository) inTransaction(fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error starting transaction: %v", err))
+	}
+
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			log.Printf("error rolling back transaction: %v", rbErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error committing transaction: %v", err))
+	}
+	return nil
+}
+
+// upsertImportJob inserts or updates an import job through the given executor, a
+// database or a transaction.
+func upsertImportJob(executor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}, job *importjobmodel.ImportJob) error {
+	storedRows := make([]row, 0, len(job.Rows()))
+	for _, r := range job.Rows() {
+		storedRows = append(storedRows, row{
+			Line:               r.Line,
+			Error:              r.Error,
+			Duplicate:          r.Duplicate,
+			PossibleDuplicates: r.PossibleDuplicates,
+			ExpenseID:          r.ExpenseID,
+		})
+	}
+
+	rawRows, err := json.Marshal(storedRows)
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error encoding import rows: %v", err))
+	}
+
+	_, err = executor.Exec(`
+		INSERT INTO import_jobs (id, user_id, format, status, rows, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (id, user_id) DO UPDATE
+		SET status = EXCLUDED.status,
+			rows = EXCLUDED.rows,
+			updated_at = EXCLUDED.updated_at`,
+		job.ID(), job.UserID(), job.Format(), string(job.Status()), rawRows, job.CreatedAt(), job.UpdatedAt())
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error saving import job: %v", err))
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+// Package loginattemptrepo provides the implementation of the ILoginAttemptRepository interface for counting sign in attempts in a PostgreSQL database.
+package loginattemptrepo
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+)
+
+// Repository implements the ILoginAttemptRepository interface for interacting with the login_attempts table in the database.
+type Repository struct {
+	db *sql.DB
+}
+
+var _ irepository.ILoginAttemptRepository = &Repository{}
+
+// New creates a new instance of Repository with the given database connection.
+func New(db *sql.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// Attempt counts an attempt under the key unless attempts under it are blocked. Records that
+// are neither blocked nor recent are deleted along the way, so that the table does not grow
+// with every username that was ever tried.
+func (r *Repository) Attempt(key string, at time.Time, resetBefore time.Time) (*irepository.LoginAttempts, bool, error) {
+	_, err := r.db.Exec(`
+		DELETE FROM login_attempts
+		WHERE last_attempt_at <= $1 AND blocked_until <= $2`, resetBefore, at)
+	if err != nil {
+		return nil, false, errdmn.NewUnexpected(fmt.Sprintf("error deleting stale login attempts: %v", err))
+	}
+
+	attempts := &irepository.LoginAttempts{Key: key}
+	err = r.db.QueryRow(`
+		INSERT INTO login_attempts (username_hash, failures, last_attempt_at, blocked_until)
+		VALUES ($1, 1, $2, $2)
+		ON CONFLICT (username_hash) DO UPDATE
+		SET failures = CASE
+				WHEN login_attempts.last_attempt_at <= $3 THEN 1
+				ELSE login_attempts.failures + 1
+			END,
+			last_attempt_at = $2
+		WHERE login_attempts.blocked_until <= $2
+		RETURNING failures, last_attempt_at, blocked_until`, key, at, resetBefore).
+		Scan(&attempts.Failures, &attempts.LastAttemptAt, &attempts.BlockedUntil)
+	if err == nil {
+		return attempts, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, errdmn.NewUnexpected(fmt.Sprintf("error counting login attempt: %v", err))
+	}
+
+	err = r.db.QueryRow(`
+		SELECT failures, last_attempt_at, blocked_until
+		FROM login_attempts
+		WHERE username_hash = $1`, key).
+		Scan(&attempts.Failures, &attempts.LastAttemptAt, &attempts.BlockedUntil)
+	if err != nil {
+		return nil, false, errdmn.NewUnexpected(fmt.Sprintf("error retrieving login attempts: %v", err))
+	}
+	return attempts, false, nil
+}
+
+// Block rejects attempts under the key until the given time.
+func (r *Repository) Block(key string, until time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE login_attempts
+		SET blocked_until = GREATEST(blocked_until, $2)
+		WHERE username_hash = $1`, key, until)
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error blocking login attempts: %v", err))
+	}
+	return nil
+}
+
+// Reset forgets the attempts under the key.
+func (r *Repository) Reset(key string) error {
+	_, err := r.db.Exec(`DELETE FROM login_attempts WHERE username_hash = $1`, key)
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error resetting login attempts: %v", err))
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+// Package oidcstaterepo provides the implementation of the IOIDCStateRepository interface for storing pending sign ins through OpenID Connect providers in a PostgreSQL database.
+package oidcstaterepo
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+)
+
+// Repository implements the IOIDCStateRepository interface for interacting with the oidc_states table in the database.
+type Repository struct {
+	db *sql.DB
+}
+
+var _ irepository.IOIDCStateRepository = &Repository{}
+
+// New creates a new instance of Repository with the given database connection.
+func New(db *sql.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// Save inserts a pending sign in. Sign ins that expired are deleted along the way, so that the
+// table does not grow with the ones users never came back from.
+func (r *Repository) Save(state *irepository.OIDCState) error {
+	_, err := r.db.Exec(`DELETE FROM oidc_states WHERE expires_at <= $1`, state.CreatedAt)
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error deleting expired sign ins: %v", err))
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO oidc_states (state_hash, provider, nonce, code_verifier, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		state.StateHash, state.Provider, state.Nonce, state.CodeVerifier, state.CreatedAt, state.ExpiresAt)
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error saving sign in: %v", err))
+	}
+	return nil
+}
+
+// Consume deletes the pending sign in with the given state hash and returns it, or nil if
+// there is none or it expired. Deleting and returning it is a single statement, so of two
+// concurrent attempts to complete a sign in only one succeeds.
+func (r *Repository) Consume(stateHash string, at time.Time) (*irepository.OIDCState, error) {
+	state := &irepository.OIDCState{StateHash: stateHash}
+	err := r.db.QueryRow(`
+		DELETE FROM oidc_states
+		WHERE state_hash = $1
+		RETURNING provider, nonce, code_verifier, created_at, expires_at`, stateHash).
+		Scan(&state.Provider, &state.Nonce, &state.CodeVerifier, &state.CreatedAt, &state.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("error consuming sign in: %v", err))
+	}
+	if !state.ExpiresAt.After(at) {
+		return nil, nil
+	}
+	return state, nil
+}
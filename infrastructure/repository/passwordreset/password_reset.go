@@ -0,0 +1,78 @@
+// Package passwordresetrepo provides the implementation of the IPasswordResetTokenRepository interface for storing password reset tokens in a PostgreSQL database.
+package passwordresetrepo
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	"github.com/google/uuid"
+)
+
+// Repository implements the IPasswordResetTokenRepository interface for interacting with the password_reset_tokens table in the database.
+type Repository struct {
+	db *sql.DB
+}
+
+var _ irepository.IPasswordResetTokenRepository = &Repository{}
+
+// New creates a new instance of Repository with the given database connection.
+func New(db *sql.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// Save inserts a new password reset token. Tokens of the user that expired are deleted along
+// the way, so that the table does not grow with tokens that can no longer be used.
+func (r *Repository) Save(token *irepository.PasswordResetToken) error {
+	_, err := r.db.Exec(`
+		DELETE FROM password_reset_tokens
+		WHERE user_id = $1 AND expires_at <= $2`, token.UserID, token.CreatedAt)
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error deleting expired password reset tokens: %v", err))
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO password_reset_tokens (id, user_id, token_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		token.ID, token.UserID, token.TokenHash, token.CreatedAt, token.ExpiresAt)
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error saving password reset token: %v", err))
+	}
+	return nil
+}
+
+// Consume marks the unused, unexpired token with the given hash as used and returns it, or
+// returns nil if there is no such token. The check and the update are a single statement, so
+// of two concurrent attempts to use a token only one succeeds.
+func (r *Repository) Consume(tokenHash string, at time.Time) (*irepository.PasswordResetToken, error) {
+	token := &irepository.PasswordResetToken{TokenHash: tokenHash, UsedAt: &at}
+	err := r.db.QueryRow(`
+		UPDATE password_reset_tokens
+		SET used_at = $2
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
+		RETURNING id, user_id, created_at, expires_at`, tokenHash, at).
+		Scan(&token.ID, &token.UserID, &token.CreatedAt, &token.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("error consuming password reset token: %v", err))
+	}
+	return token, nil
+}
+
+// DiscardUser marks every unused token of the user as used.
+func (r *Repository) DiscardUser(userId uuid.UUID, at time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE password_reset_tokens
+		SET used_at = $2
+		WHERE user_id = $1 AND used_at IS NULL`, userId, at)
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error discarding password reset tokens: %v", err))
+	}
+	return nil
+}
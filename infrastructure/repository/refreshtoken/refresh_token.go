@@ -0,0 +1,109 @@
+// Package refreshtokenrepo provides the implementation of the IRefreshTokenRepository interface for storing refresh tokens in a PostgreSQL database.
+package refreshtokenrepo
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	"github.com/google/uuid"
+)
+
+// Repository implements the IRefreshTokenRepository interface for interacting with the refresh_tokens table in the database.
+type Repository struct {
+	db *sql.DB
+}
+
+var _ irepository.IRefreshTokenRepository = &Repository{}
+
+// New creates a new instance of Repository with the given database connection.
+func New(db *sql.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// Save inserts a new refresh token.
+func (r *Repository) Save(token *irepository.RefreshToken) error {
+	_, err := r.db.Exec(`
+		INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		token.ID, token.UserID, token.FamilyID, token.TokenHash, token.CreatedAt, token.ExpiresAt)
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error saving refresh token: %v", err))
+	}
+	return nil
+}
+
+// ByHash retrieves the refresh token with the given hash, or nil if there is none.
+func (r *Repository) ByHash(tokenHash string) (*irepository.RefreshToken, error) {
+	token := &irepository.RefreshToken{TokenHash: tokenHash}
+	var rotatedAt, revokedAt sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT id, user_id, family_id, created_at, expires_at, rotated_at, revoked_at
+		FROM refresh_tokens
+		WHERE token_hash = $1`, tokenHash).
+		Scan(&token.ID, &token.UserID, &token.FamilyID, &token.CreatedAt, &token.ExpiresAt, &rotatedAt, &revokedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("error retrieving refresh token: %v", err))
+	}
+
+	token.RotatedAt = nullableTime(rotatedAt)
+	token.RevokedAt = nullableTime(revokedAt)
+	return token, nil
+}
+
+// Rotate marks the token as rotated unless it was already rotated or revoked. The check and
+// the update are a single statement, so of two concurrent rotations only one succeeds.
+func (r *Repository) Rotate(id uuid.UUID, at time.Time) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE refresh_tokens
+		SET rotated_at = $2
+		WHERE id = $1 AND rotated_at IS NULL AND revoked_at IS NULL`, id, at)
+	if err != nil {
+		return false, errdmn.NewUnexpected(fmt.Sprintf("error rotating refresh token: %v", err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, errdmn.NewUnexpected(fmt.Sprintf("error rotating refresh token: %v", err))
+	}
+	return affected > 0, nil
+}
+
+// RevokeFamily revokes every unrevoked token of the family.
+func (r *Repository) RevokeFamily(familyId uuid.UUID, at time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE refresh_tokens
+		SET revoked_at = $2
+		WHERE family_id = $1 AND revoked_at IS NULL`, familyId, at)
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error revoking refresh tokens: %v", err))
+	}
+	return nil
+}
+
+// RevokeUser revokes every unrevoked token of the user.
+func (r *Repository) RevokeUser(userId uuid.UUID, at time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE refresh_tokens
+		SET revoked_at = $2
+		WHERE user_id = $1 AND revoked_at IS NULL`, userId, at)
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error revoking refresh tokens: %v", err))
+	}
+	return nil
+}
+
+// nullableTime converts a nullable column to a time pointer.
+func nullableTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
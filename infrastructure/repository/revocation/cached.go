@@ -0,0 +1,194 @@
+package revocationrepo
+
+import (
+	"sync"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	itimeservice "github.com/beka-birhanu/finance-go/application/common/interface/time_service"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultCacheTTL is how long lookups are cached when no lifetime is configured.
+	DefaultCacheTTL = 30 * time.Second
+
+	// maxCachedEntries is the maximum number of cached lookups.
+	maxCachedEntries = 10000
+)
+
+// Cached decorates a token revocation repository, caching its lookups in memory so that
+// authorizing a request does not cost a database round trip. Revocations made through the
+// decorator take effect immediately; those made by other instances of the application are
+// seen once the cached lookup expires, which bounds how long a revoked token stays usable.
+type Cached struct {
+	repository irepository.ITokenRevocationRepository
+	timeSvc    itimeservice.IService
+	ttl        time.Duration
+
+	mu      sync.RWMutex
+	tokens  map[string]cachedToken
+	cutoffs map[uuid.UUID]cachedCutoff
+}
+
+var _ irepository.ITokenRevocationRepository = &Cached{}
+
+// cachedToken is a cached revocation lookup of a token.
+type cachedToken struct {
+	revoked bool
+	until   time.Time
+}
+
+// cachedCutoff is a cached lookup of the time before which the tokens of a user are revoked.
+type cachedCutoff struct {
+	revokedBefore *time.Time
+	until         time.Time
+}
+
+// CachedConfig holds dependencies required for creating a Cached repository.
+type CachedConfig struct {
+	Repository  irepository.ITokenRevocationRepository // Repository the lookups of which are cached
+	TimeService itimeservice.IService                  // Service for time-related operations
+	TTL         time.Duration                          // How long lookups are cached; defaults to 30 seconds
+}
+
+// NewCached creates a new Cached repository with the specified configuration.
+func NewCached(config CachedConfig) *Cached {
+	ttl := config.TTL
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+
+	return &Cached{
+		repository: config.Repository,
+		timeSvc:    config.TimeService,
+		ttl:        ttl,
+		tokens:     make(map[string]cachedToken),
+		cutoffs:    make(map[uuid.UUID]cachedCutoff),
+	}
+}
+
+// RevokeToken revokes the access token and caches the revocation until the token expires.
+func (c *Cached) RevokeToken(tokenId string, userId uuid.UUID, expiresAt time.Time) error {
+	if err := c.repository.RevokeToken(tokenId, userId, expiresAt); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evict()
+	// A revocation is never undone, so it can be cached for as long as it matters.
+	c.tokens[tokenId] = cachedToken{revoked: true, until: expiresAt}
+	return nil
+}
+
+// IsTokenRevoked reports whether the access token was revoked, from the cache when possible.
+func (c *Cached) IsTokenRevoked(tokenId string) (bool, error) {
+	now := c.timeSvc.NowUTC()
+	c.mu.RLock()
+	cached, ok := c.tokens[tokenId]
+	c.mu.RUnlock()
+	if ok && now.Before(cached.until) {
+		return cached.revoked, nil
+	}
+
+	revoked, err := c.repository.IsTokenRevoked(tokenId)
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// A revocation cached while the token was being looked up is newer than the lookup.
+	if cached, ok := c.tokens[tokenId]; ok && cached.revoked && now.Before(cached.until) {
+		return true, nil
+	}
+	c.evict()
+	c.tokens[tokenId] = cachedToken{revoked: revoked, until: now.Add(c.ttl)}
+	return revoked, nil
+}
+
+// RevokeAllBefore revokes every access token issued to the user before the given time and
+// caches the new cutoff, so that the revocation takes effect immediately.
+func (c *Cached) RevokeAllBefore(userId uuid.UUID, at time.Time) error {
+	if err := c.repository.RevokeAllBefore(userId, at); err != nil {
+		return err
+	}
+
+	now := c.timeSvc.NowUTC()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.storeCutoff(userId, &at, now)
+	return nil
+}
+
+// RevokedBefore returns the time before which the tokens of the user are revoked, from the
+// cache when possible.
+func (c *Cached) RevokedBefore(userId uuid.UUID) (*time.Time, error) {
+	now := c.timeSvc.NowUTC()
+	c.mu.RLock()
+	cached, ok := c.cutoffs[userId]
+	c.mu.RUnlock()
+	if ok && now.Before(cached.until) {
+		return cached.revokedBefore, nil
+	}
+
+	revokedBefore, err := c.repository.RevokedBefore(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.storeCutoff(userId, revokedBefore, now), nil
+}
+
+// storeCutoff caches the cutoff of the user and returns it. Cutoffs only ever move forward,
+// so a later cutoff cached meanwhile, e.g. by a revocation made during a lookup, is kept
+// instead. The caller must hold the write lock.
+func (c *Cached) storeCutoff(userId uuid.UUID, revokedBefore *time.Time, now time.Time) *time.Time {
+	if cached, ok := c.cutoffs[userId]; ok && now.Before(cached.until) && cached.revokedBefore != nil &&
+		(revokedBefore == nil || cached.revokedBefore.After(*revokedBefore)) {
+		revokedBefore = cached.revokedBefore
+	}
+
+	c.evict()
+	c.cutoffs[userId] = cachedCutoff{revokedBefore: revokedBefore, until: now.Add(c.ttl)}
+	return revokedBefore
+}
+
+// evict drops expired lookups once the cache grows to maxCachedEntries, and then arbitrary
+// ones while it is still too large; a dropped lookup is only made again. Room is made for a
+// tenth of the capacity at once, so that a cache full of live lookups is not scanned on every
+// insertion. The caller must hold the write lock.
+func (c *Cached) evict() {
+	if len(c.tokens)+len(c.cutoffs) < maxCachedEntries {
+		return
+	}
+
+	now := c.timeSvc.NowUTC()
+	for tokenId, cached := range c.tokens {
+		if !now.Before(cached.until) {
+			delete(c.tokens, tokenId)
+		}
+	}
+	for userId, cached := range c.cutoffs {
+		if !now.Before(cached.until) {
+			delete(c.cutoffs, userId)
+		}
+	}
+
+	target := maxCachedEntries - maxCachedEntries/10
+	for tokenId := range c.tokens {
+		if len(c.tokens)+len(c.cutoffs) <= target {
+			return
+		}
+		delete(c.tokens, tokenId)
+	}
+	for userId := range c.cutoffs {
+		if len(c.tokens)+len(c.cutoffs) <= target {
+			return
+		}
+		delete(c.cutoffs, userId)
+	}
+}
@@ -0,0 +1,177 @@
+package revocationrepo
+
+import (
+	"testing"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	"github.com/google/uuid"
+)
+
+// MockTokenRevocationRepository keeps revocations in memory and counts lookups. When set,
+// duringLookup is called after a lookup read the revocations and before it returns.
+type MockTokenRevocationRepository struct {
+	revokedTokens map[string]bool
+	revokedBefore map[uuid.UUID]time.Time
+	lookups       int
+	duringLookup  func()
+}
+
+func (m *MockTokenRevocationRepository) afterRead() {
+	if hook := m.duringLookup; hook != nil {
+		m.duringLookup = nil
+		hook()
+	}
+}
+
+func (m *MockTokenRevocationRepository) RevokeToken(tokenId string, userId uuid.UUID, expiresAt time.Time) error {
+	m.revokedTokens[tokenId] = true
+	return nil
+}
+
+func (m *MockTokenRevocationRepository) IsTokenRevoked(tokenId string) (bool, error) {
+	m.lookups++
+	revoked := m.revokedTokens[tokenId]
+	m.afterRead()
+	return revoked, nil
+}
+
+func (m *MockTokenRevocationRepository) RevokeAllBefore(userId uuid.UUID, at time.Time) error {
+	m.revokedBefore[userId] = at
+	return nil
+}
+
+func (m *MockTokenRevocationRepository) RevokedBefore(userId uuid.UUID) (*time.Time, error) {
+	m.lookups++
+	at, ok := m.revokedBefore[userId]
+	m.afterRead()
+	if ok {
+		return &at, nil
+	}
+	return nil, nil
+}
+
+var _ irepository.ITokenRevocationRepository = &MockTokenRevocationRepository{}
+
+type MockTimeService struct {
+	now time.Time
+}
+
+func (m *MockTimeService) NowUTC() time.Time {
+	return m.now
+}
+
+func TestCached(t *testing.T) {
+	now := time.Now().UTC()
+	userId := uuid.New()
+
+	tests := []struct {
+		name string
+		// run performs lookups through the cache and reports whether the last one found a revocation.
+		run             func(cached *Cached, repository *MockTokenRevocationRepository, timeSvc *MockTimeService) bool
+		expectedRevoked bool
+		expectedLookups int
+	}{
+		{
+			name: "repeated token lookup is cached",
+			run: func(cached *Cached, repository *MockTokenRevocationRepository, timeSvc *MockTimeService) bool {
+				cached.IsTokenRevoked("token")
+				revoked, _ := cached.IsTokenRevoked("token")
+				return revoked
+			},
+			expectedLookups: 1,
+		},
+		{
+			name: "revocation through the cache takes effect immediately",
+			run: func(cached *Cached, repository *MockTokenRevocationRepository, timeSvc *MockTimeService) bool {
+				cached.IsTokenRevoked("token")
+				cached.RevokeToken("token", userId, now.Add(time.Hour))
+				revoked, _ := cached.IsTokenRevoked("token")
+				return revoked
+			},
+			expectedRevoked: true,
+			expectedLookups: 1,
+		},
+		{
+			name: "revocation elsewhere is seen once the lookup expires",
+			run: func(cached *Cached, repository *MockTokenRevocationRepository, timeSvc *MockTimeService) bool {
+				cached.IsTokenRevoked("token")
+				repository.RevokeToken("token", userId, now.Add(time.Hour))
+				timeSvc.now = now.Add(DefaultCacheTTL)
+				revoked, _ := cached.IsTokenRevoked("token")
+				return revoked
+			},
+			expectedRevoked: true,
+			expectedLookups: 2,
+		},
+		{
+			name: "revoking all tokens caches the new cutoff",
+			run: func(cached *Cached, repository *MockTokenRevocationRepository, timeSvc *MockTimeService) bool {
+				cached.RevokedBefore(userId)
+				cached.RevokeAllBefore(userId, now)
+				revokedBefore, _ := cached.RevokedBefore(userId)
+				return revokedBefore != nil && revokedBefore.Equal(now)
+			},
+			expectedRevoked: true,
+			expectedLookups: 1,
+		},
+		{
+			name: "revocation during a token lookup is not overwritten by it",
+			run: func(cached *Cached, repository *MockTokenRevocationRepository, timeSvc *MockTimeService) bool {
+				repository.duringLookup = func() { cached.RevokeToken("token", userId, now.Add(time.Hour)) }
+				cached.IsTokenRevoked("token")
+				revoked, _ := cached.IsTokenRevoked("token")
+				return revoked
+			},
+			expectedRevoked: true,
+			expectedLookups: 1,
+		},
+		{
+			name: "revoking all tokens during a cutoff lookup is not overwritten by it",
+			run: func(cached *Cached, repository *MockTokenRevocationRepository, timeSvc *MockTimeService) bool {
+				repository.duringLookup = func() { cached.RevokeAllBefore(userId, now) }
+				cached.RevokedBefore(userId)
+				revokedBefore, _ := cached.RevokedBefore(userId)
+				return revokedBefore != nil
+			},
+			expectedRevoked: true,
+			expectedLookups: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository := &MockTokenRevocationRepository{
+				revokedTokens: make(map[string]bool),
+				revokedBefore: make(map[uuid.UUID]time.Time),
+			}
+			timeSvc := &MockTimeService{now: now}
+			cached := NewCached(CachedConfig{Repository: repository, TimeService: timeSvc})
+
+			if revoked := tt.run(cached, repository, timeSvc); revoked != tt.expectedRevoked {
+				t.Errorf("expected revoked %v, got %v", tt.expectedRevoked, revoked)
+			}
+			if repository.lookups != tt.expectedLookups {
+				t.Errorf("expected %d repository lookups, got %d", tt.expectedLookups, repository.lookups)
+			}
+		})
+	}
+}
+
+func TestCached_Bounded(t *testing.T) {
+	repository := &MockTokenRevocationRepository{
+		revokedTokens: make(map[string]bool),
+		revokedBefore: make(map[uuid.UUID]time.Time),
+	}
+	cached := NewCached(CachedConfig{Repository: repository, TimeService: &MockTimeService{now: time.Now().UTC()}})
+
+	// None of the lookups expires, so the cache must drop live ones to stay bounded.
+	for i := 0; i < 2*maxCachedEntries; i++ {
+		cached.IsTokenRevoked(uuid.NewString())
+		cached.RevokedBefore(uuid.New())
+	}
+
+	if size := len(cached.tokens) + len(cached.cutoffs); size > maxCachedEntries {
+		t.Errorf("expected at most %d cached lookups, got %d", maxCachedEntries, size)
+	}
+}
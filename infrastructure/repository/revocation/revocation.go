@@ -0,0 +1,82 @@
+// Package revocationrepo provides the implementation of the ITokenRevocationRepository interface for storing revoked access tokens in a PostgreSQL database,
+// and a decorator caching its lookups in memory.
+package revocationrepo
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	"github.com/google/uuid"
+)
+
+// Repository implements the ITokenRevocationRepository interface for interacting with the revoked_tokens and session_revocations tables in the database.
+type Repository struct {
+	db *sql.DB
+}
+
+var _ irepository.ITokenRevocationRepository = &Repository{}
+
+// New creates a new instance of Repository with the given database connection.
+func New(db *sql.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// RevokeToken revokes the access token with the given ID. Revocations of tokens that have
+// since expired are deleted along the way, as expired tokens are rejected regardless.
+func (r *Repository) RevokeToken(tokenId string, userId uuid.UUID, expiresAt time.Time) error {
+	_, err := r.db.Exec(`
+		INSERT INTO revoked_tokens (jti, user_id, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (jti) DO NOTHING`, tokenId, userId, expiresAt)
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error revoking token: %v", err))
+	}
+
+	if _, err := r.db.Exec("DELETE FROM revoked_tokens WHERE expires_at < $1", time.Now().UTC()); err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error deleting expired token revocations: %v", err))
+	}
+	return nil
+}
+
+// IsTokenRevoked reports whether the access token with the given ID was revoked.
+func (r *Repository) IsTokenRevoked(tokenId string) (bool, error) {
+	var revoked bool
+	err := r.db.QueryRow("SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)", tokenId).Scan(&revoked)
+	if err != nil {
+		return false, errdmn.NewUnexpected(fmt.Sprintf("error checking token revocation: %v", err))
+	}
+	return revoked, nil
+}
+
+// RevokeAllBefore revokes every access token issued to the user before the given time. The
+// time only ever moves forward, so that an earlier revocation is never undone.
+func (r *Repository) RevokeAllBefore(userId uuid.UUID, at time.Time) error {
+	_, err := r.db.Exec(`
+		INSERT INTO session_revocations (user_id, revoked_before)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET revoked_before = GREATEST(session_revocations.revoked_before, EXCLUDED.revoked_before)`, userId, at)
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error revoking tokens of user: %v", err))
+	}
+	return nil
+}
+
+// RevokedBefore returns the time before which every access token issued to the user is
+// revoked, or nil if the user never revoked all tokens.
+func (r *Repository) RevokedBefore(userId uuid.UUID) (*time.Time, error) {
+	var revokedBefore time.Time
+	err := r.db.QueryRow("SELECT revoked_before FROM session_revocations WHERE user_id = $1", userId).Scan(&revokedBefore)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("error retrieving token revocation of user: %v", err))
+	}
+	return &revokedBefore, nil
+}
@@ -0,0 +1,202 @@
+// Package twofactorrepo provides the implementation of the ITwoFactorRepository and ITwoFactorChallengeRepository interfaces for storing second factors, their recovery codes and pending sign in challenges in a PostgreSQL database.
+package twofactorrepo
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	irepository "github.com/beka-birhanu/finance-go/application/common/interface/repository"
+	errdmn "github.com/beka-birhanu/finance-go/domain/error/common"
+	"github.com/google/uuid"
+)
+
+// Repository implements the ITwoFactorRepository and ITwoFactorChallengeRepository interfaces for interacting with the two_factor_secrets, two_factor_recovery_codes and two_factor_challenges tables in the database.
+type Repository struct {
+	db *sql.DB
+}
+
+var (
+	_ irepository.ITwoFactorRepository          = &Repository{}
+	_ irepository.ITwoFactorChallengeRepository = &Repository{}
+)
+
+// New creates a new instance of Repository with the given database connection.
+func New(db *sql.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// ByUser retrieves the second factor of the user, or nil if there is none.
+func (r *Repository) ByUser(userId uuid.UUID) (*irepository.TwoFactor, error) {
+	factor := &irepository.TwoFactor{UserID: userId}
+	var confirmedAt sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT secret, created_at, confirmed_at, last_used_step
+		FROM two_factor_secrets
+		WHERE user_id = $1`, userId).
+		Scan(&factor.Secret, &factor.CreatedAt, &confirmedAt, &factor.LastUsedStep)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("error retrieving second factor: %v", err))
+	}
+
+	if confirmedAt.Valid {
+		factor.ConfirmedAt = &confirmedAt.Time
+	}
+	return factor, nil
+}
+
+// Save inserts or replaces the second factor of the user.
+func (r *Repository) Save(factor *irepository.TwoFactor) error {
+	_, err := r.db.Exec(`
+		INSERT INTO two_factor_secrets (user_id, secret, created_at, confirmed_at, last_used_step)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE SET
+			secret = EXCLUDED.secret,
+			created_at = EXCLUDED.created_at,
+			confirmed_at = EXCLUDED.confirmed_at,
+			last_used_step = EXCLUDED.last_used_step`,
+		factor.UserID, factor.Secret, factor.CreatedAt, factor.ConfirmedAt, factor.LastUsedStep)
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error saving second factor: %v", err))
+	}
+	return nil
+}
+
+// Delete removes the second factor of the user along with their recovery codes.
+func (r *Repository) Delete(userId uuid.UUID) error {
+	return r.inTransaction("error deleting second factor", func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM two_factor_recovery_codes WHERE user_id = $1", userId); err != nil {
+			return err
+		}
+		_, err := tx.Exec("DELETE FROM two_factor_secrets WHERE user_id = $1", userId)
+		return err
+	})
+}
+
+// UseStep records the step of a code used by the user unless a code of that step or a later
+// one was used before. The check and the update are a single statement, so a code cannot be
+// used twice by concurrent requests.
+func (r *Repository) UseStep(userId uuid.UUID, step int64) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE two_factor_secrets
+		SET last_used_step = $2
+		WHERE user_id = $1 AND last_used_step < $2`, userId, step)
+	if err != nil {
+		return false, errdmn.NewUnexpected(fmt.Sprintf("error recording used code: %v", err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, errdmn.NewUnexpected(fmt.Sprintf("error recording used code: %v", err))
+	}
+	return affected > 0, nil
+}
+
+// ReplaceRecoveryCodes replaces the recovery codes of the user with the given hashes.
+func (r *Repository) ReplaceRecoveryCodes(userId uuid.UUID, codeHashes []string) error {
+	return r.inTransaction("error replacing recovery codes", func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM two_factor_recovery_codes WHERE user_id = $1", userId); err != nil {
+			return err
+		}
+		for _, codeHash := range codeHashes {
+			_, err := tx.Exec(`
+				INSERT INTO two_factor_recovery_codes (id, user_id, code_hash)
+				VALUES ($1, $2, $3)`, uuid.New(), userId, codeHash)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UseRecoveryCode marks the unused recovery code of the user with the given hash as used, and
+// reports whether there was such a code.
+func (r *Repository) UseRecoveryCode(userId uuid.UUID, codeHash string, at time.Time) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE two_factor_recovery_codes
+		SET used_at = $3
+		WHERE id = (
+			SELECT id FROM two_factor_recovery_codes
+			WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+			LIMIT 1
+		) AND used_at IS NULL`, userId, codeHash, at)
+	if err != nil {
+		return false, errdmn.NewUnexpected(fmt.Sprintf("error using recovery code: %v", err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, errdmn.NewUnexpected(fmt.Sprintf("error using recovery code: %v", err))
+	}
+	return affected > 0, nil
+}
+
+// SaveChallenge inserts a new challenge. Challenges that expired are deleted along the way.
+func (r *Repository) SaveChallenge(challenge *irepository.TwoFactorChallenge) error {
+	if _, err := r.db.Exec("DELETE FROM two_factor_challenges WHERE expires_at < $1", time.Now().UTC()); err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error deleting expired challenges: %v", err))
+	}
+
+	_, err := r.db.Exec(`
+		INSERT INTO two_factor_challenges (id, user_id, token_hash, expires_at, attempts)
+		VALUES ($1, $2, $3, $4, $5)`,
+		challenge.ID, challenge.UserID, challenge.TokenHash, challenge.ExpiresAt, challenge.Attempts)
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error saving challenge: %v", err))
+	}
+	return nil
+}
+
+// AttemptChallenge counts an attempt at the unexpired challenge with the given hash and
+// returns it, or returns nil if there is no such challenge or its attempts are used up.
+func (r *Repository) AttemptChallenge(tokenHash string, at time.Time, maxAttempts int) (*irepository.TwoFactorChallenge, error) {
+	challenge := &irepository.TwoFactorChallenge{TokenHash: tokenHash}
+	err := r.db.QueryRow(`
+		UPDATE two_factor_challenges
+		SET attempts = attempts + 1
+		WHERE token_hash = $1 AND expires_at > $2 AND attempts < $3
+		RETURNING id, user_id, expires_at, attempts`, tokenHash, at, maxAttempts).
+		Scan(&challenge.ID, &challenge.UserID, &challenge.ExpiresAt, &challenge.Attempts)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("error attempting challenge: %v", err))
+	}
+	return challenge, nil
+}
+
+// DeleteChallenge removes the challenge with the given ID.
+func (r *Repository) DeleteChallenge(id uuid.UUID) error {
+	if _, err := r.db.Exec("DELETE FROM two_factor_challenges WHERE id = $1", id); err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("error deleting challenge: %v", err))
+	}
+	return nil
+}
+
+// inTransaction runs fn in a transaction, committing it if fn succeeds and rolling it back
+// otherwise. Errors are reported as unexpected errors prefixed with the given message.
+func (r *Repository) inTransaction(message string, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("%s: %v", message, err))
+	}
+
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			log.Printf("error rolling back transaction: %v", rollbackErr)
+		}
+		return errdmn.NewUnexpected(fmt.Sprintf("%s: %v", message, err))
+	}
+	if err := tx.Commit(); err != nil {
+		return errdmn.NewUnexpected(fmt.Sprintf("%s: %v", message, err))
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
@@ -24,8 +25,15 @@ type Repository struct {
 	db *sql.DB
 }
 
-// Ensure UserRepository implements repository.IUserRepository.
-var _ irepository.IUserRepository = &Repository{}
+// Ensure UserRepository implements repository.IUserRepository and repository.IUserAdminRepository.
+var (
+	_ irepository.IUserRepository      = &Repository{}
+	_ irepository.IUserAdminRepository = &Repository{}
+)
+
+// columns are the columns of the users table a user is read from, in the order scanRowToUser
+// reads them.
+const columns = `id, username, password_hash, roles, disabled_at, created_at, updated_at`
 
 // New creates a new UserRepository with the given database connection.
 func New(db *sql.DB) *Repository {
@@ -99,7 +107,7 @@ func (u *Repository) Save(user *usermodel.User) error {
 //   - *usermodel.User: A pointer to the retrieved user model.
 //   - error: An error if the user is not found, otherwise nil.
 func (u *Repository) ById(id uuid.UUID) (*usermodel.User, error) {
-	row := u.db.QueryRow("SELECT id, username, password_hash, created_at, updated_at FROM users WHERE id = $1", id)
+	row := u.db.QueryRow("SELECT "+columns+" FROM users WHERE id = $1", id)
 
 	user, err := scanRowToUser(row)
 	if err != nil {
@@ -118,7 +126,7 @@ func (u *Repository) ById(id uuid.UUID) (*usermodel.User, error) {
 //   - *usermodel.User: A pointer to the retrieved user model.
 //   - error: An error if the user is not found, otherwise nil.
 func (u *Repository) ByUsername(username string) (*usermodel.User, error) {
-	row := u.db.QueryRow("SELECT id, username, password_hash, created_at, updated_at FROM users WHERE username = $1", username)
+	row := u.db.QueryRow("SELECT "+columns+" FROM users WHERE username = $1", username)
 
 	user, err := scanRowToUser(row)
 	if err != nil {
@@ -131,6 +139,73 @@ func (u *Repository) ByUsername(username string) (*usermodel.User, error) {
 	return user, nil
 }
 
+// List retrieves a page of the users whose username contains the search, ignoring case,
+// newest first.
+//
+// Returns:
+//   - []*usermodel.User: The users of the page.
+//   - error: An error if the users cannot be retrieved, otherwise nil.
+func (u *Repository) List(params irepository.UserListParams) ([]*usermodel.User, error) {
+	rows, err := u.db.Query(`
+		SELECT `+columns+`
+		FROM users
+		WHERE username ILIKE $1 ESCAPE '\'
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3`,
+		searchPattern(params.Search), params.Limit, params.Offset)
+	if err != nil {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("error listing users: %v", err))
+	}
+	defer rows.Close()
+
+	users := []*usermodel.User{}
+	for rows.Next() {
+		user, err := scanRowToUser(rows)
+		if err != nil {
+			return nil, errdmn.NewUnexpected(fmt.Sprintf("error scanning user: %v", err))
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("error iterating users: %v", err))
+	}
+	return users, nil
+}
+
+// Count returns the number of users whose username contains the search, ignoring case.
+func (u *Repository) Count(search string) (int, error) {
+	var count int
+	err := u.db.QueryRow(`SELECT COUNT(*) FROM users WHERE username ILIKE $1 ESCAPE '\'`, searchPattern(search)).Scan(&count)
+	if err != nil {
+		return 0, errdmn.NewUnexpected(fmt.Sprintf("error counting users: %v", err))
+	}
+	return count, nil
+}
+
+// Stats returns the usage statistics of the service since the given time.
+func (u *Repository) Stats(since time.Time) (*irepository.UsageStats, error) {
+	stats := &irepository.UsageStats{}
+	err := u.db.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM users),
+			(SELECT COUNT(*) FROM users WHERE disabled_at IS NOT NULL),
+			(SELECT COUNT(*) FROM users WHERE created_at >= $1),
+			(SELECT COUNT(DISTINCT user_id) FROM expenses WHERE created_at >= $1),
+			(SELECT COUNT(*) FROM expenses),
+			(SELECT COUNT(*) FROM expenses WHERE created_at >= $1)`, since).
+		Scan(&stats.Users, &stats.DisabledUsers, &stats.NewUsers, &stats.ActiveUsers, &stats.Expenses, &stats.NewExpenses)
+	if err != nil {
+		return nil, errdmn.NewUnexpected(fmt.Sprintf("error computing usage statistics: %v", err))
+	}
+	return stats, nil
+}
+
+// searchPattern returns the LIKE pattern matching usernames that contain the search, with
+// the LIKE wildcards of the search escaped so that it is matched literally.
+func searchPattern(search string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(search) + "%"
+}
+
 // upsertUser inserts a new user or updates an existing user in the database.
 // Returns a conflict error if the username already exists with a different ID.
 func upsertUser(ctx *sql.Tx, user *usermodel.User) error {
@@ -139,7 +214,7 @@ func upsertUser(ctx *sql.Tx, user *usermodel.User) error {
 	//    but a different ID already exists.
 	// 2. If such a user exists, the CTE returns the conflicting user's ID.
 	// 3. Attempt to insert a new user with the provided details (id, username, password_hash,
-	//    created_at, updated_at).
+	//    roles, disabled_at, created_at, updated_at).
 	// 4. On conflict with an existing user ID, update the user details with the new values.
 	// 5. The update only occurs if no conflicting username is found (i.e., the CTE is empty).
 	// 6. Return the ID of the inserted or updated user for further processing.
@@ -149,16 +224,19 @@ func upsertUser(ctx *sql.Tx, user *usermodel.User) error {
         WITH existing_user AS (
             SELECT id FROM users WHERE username = $1 AND id != $2
         )
-        INSERT INTO users (id, username, password_hash, created_at, updated_at)
-        VALUES ($2, $1, $3, $4, $5)
+        INSERT INTO users (id, username, password_hash, roles, disabled_at, created_at, updated_at)
+        VALUES ($2, $1, $3, $4, $5, $6, $7)
         ON CONFLICT (id) DO UPDATE SET
             username = EXCLUDED.username,
             password_hash = EXCLUDED.password_hash,
+            roles = EXCLUDED.roles,
+            disabled_at = EXCLUDED.disabled_at,
             created_at = EXCLUDED.created_at,
             updated_at = EXCLUDED.updated_at
         WHERE NOT EXISTS (SELECT 1 FROM existing_user)
         RETURNING id`,
-		user.Username(), user.ID(), user.PasswordHash(), user.CreatedAt(), user.UpdatedAt()).Scan(&userID)
+		user.Username(), user.ID(), user.PasswordHash(), strings.Join(user.Roles(), " "), user.DisabledAt(),
+		user.CreatedAt(), user.UpdatedAt()).Scan(&userID)
 
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
@@ -181,9 +259,10 @@ func upsertUser(ctx *sql.Tx, user *usermodel.User) error {
 func upsertExpenses(ctx *sql.Tx, expenses []expensemodel.Expense) error {
 	for _, expense := range expenses {
 		_, err := ctx.Exec(`
-            INSERT INTO expenses (id, description, amount, date, user_id, created_at, updated_at)
-            VALUES ($1, $2, $3, $4, $5, $6, $7)`,
-			expense.ID(), expense.Description(), expense.Amount(), expense.Date(), expense.UserID(), expense.CreatedAt(), expense.UpdatedAt())
+            INSERT INTO expenses (id, description, amount, date, category, account, user_id, created_at, updated_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			expense.ID(), expense.Description(), expensemodel.FormatCents(expense.AmountCents()), expense.Date(), expense.Category(), expense.Account(),
+			expense.UserID(), expense.CreatedAt(), expense.UpdatedAt())
 
 		if err != nil {
 			// Check if the error is a unique constraint violation (conflict)
@@ -197,27 +276,41 @@ func upsertExpenses(ctx *sql.Tx, expenses []expensemodel.Expense) error {
 	return nil
 }
 
-func scanRowToUser(row *sql.Row) (*usermodel.User, error) {
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanRowToUser reads a user from the columns of a row. Roles are stored separated by spaces.
+func scanRowToUser(row scanner) (*usermodel.User, error) {
 	var (
 		id           uuid.UUID
 		username     string
 		passwordHash string
+		roles        string
+		disabledAt   sql.NullTime
 		createdAt    time.Time
 		updatedAt    time.Time
 	)
 
-	err := row.Scan(&id, &username, &passwordHash, &createdAt, &updatedAt)
+	err := row.Scan(&id, &username, &passwordHash, &roles, &disabledAt, &createdAt, &updatedAt)
 	if err != nil {
 		return nil, err
 	}
 
-	user, err := usermodel.NewWithExistingHash(usermodel.ConfigForExistingHash{
+	config := usermodel.ConfigForExistingHash{
 		ID:           id,
 		Username:     username,
 		PasswordHash: passwordHash,
 		CreationTime: createdAt,
 		UpdatedAt:    updatedAt,
-	})
+		Roles:        strings.Fields(roles),
+	}
+	if disabledAt.Valid {
+		config.DisabledAt = &disabledAt.Time
+	}
+
+	user, err := usermodel.NewWithExistingHash(config)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,127 @@
+// Package totp provides time-based one-time passwords as specified by RFC 6238, using
+// HMAC-SHA1 as authenticator apps do by default.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	itotp "github.com/beka-birhanu/finance-go/application/common/interface/totp"
+)
+
+const (
+	// DefaultDigits is the number of digits of a code when none is configured.
+	DefaultDigits = 6
+
+	// DefaultPeriod is how long a code is valid when no period is configured.
+	DefaultPeriod = 30 * time.Second
+
+	// secretBytes is the number of random bytes of a secret, the 160 bits RFC 4226 recommends.
+	secretBytes = 20
+)
+
+// encoding is the base32 encoding of secrets, without padding as authenticator apps expect.
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Service implements the itotp.IService interface.
+type Service struct {
+	digits int
+	period time.Duration
+	skew   int64
+}
+
+// Ensure Service implements itotp.IService.
+var _ itotp.IService = &Service{}
+
+// Config holds the configuration for creating a new Service. Skew is the number of steps
+// before and after the current one whose codes are accepted as well, to allow for clock
+// drift between the server and the authenticator app.
+type Config struct {
+	Digits int
+	Period time.Duration
+	Skew   int
+}
+
+// New creates a new Service with the given configuration.
+func New(config Config) *Service {
+	digits := config.Digits
+	if digits <= 0 {
+		digits = DefaultDigits
+	}
+	period := config.Period
+	if period <= 0 {
+		period = DefaultPeriod
+	}
+
+	return &Service{
+		digits: digits,
+		period: period,
+		skew:   int64(config.Skew),
+	}
+}
+
+// GenerateSecret returns a new random secret, base32 encoded.
+func (s *Service) GenerateSecret() (string, error) {
+	secret := make([]byte, secretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(secret), nil
+}
+
+// URI returns the otpauth URI of the secret for the account of the issuer.
+func (s *Service) URI(secret string, issuer string, accountName string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(s.digits))
+	query.Set("period", fmt.Sprint(int64(s.period.Seconds())))
+
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(accountName)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// Verify reports whether the code is valid for the secret at the given time, within the
+// configured skew, and returns the step the code belongs to.
+func (s *Service) Verify(secret string, code string, at time.Time) (int64, bool) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != s.digits {
+		return 0, false
+	}
+
+	current := at.Unix() / int64(s.period.Seconds())
+	for step := current - s.skew; step <= current+s.skew; step++ {
+		if subtle.ConstantTimeCompare([]byte(s.code(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// code computes the code of a step, as specified by RFC 4226.
+func (s *Service) code(key []byte, step int64) string {
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulus := uint32(1)
+	for i := 0; i < s.digits; i++ {
+		modulus *= 10
+	}
+	return fmt.Sprintf("%0*d", s.digits, value%modulus)
+}
@@ -0,0 +1,96 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the secret of the test vectors of RFC 6238 for HMAC-SHA1, base32 encoded.
+var rfcSecret = encoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestService_Verify(t *testing.T) {
+	// Test vectors from RFC 6238, Appendix B.
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+	}
+
+	service := New(Config{Digits: 8})
+	for _, v := range vectors {
+		step, ok := service.Verify(rfcSecret, v.code, time.Unix(v.unix, 0))
+		if !ok {
+			t.Errorf("expected code %s to be valid at %d", v.code, v.unix)
+		}
+		if step != v.unix/30 {
+			t.Errorf("expected step %d, got %d", v.unix/30, step)
+		}
+	}
+}
+
+func TestService_VerifySkew(t *testing.T) {
+	at := time.Unix(1111111109, 0)
+	code := New(Config{Digits: 8}).code([]byte("12345678901234567890"), at.Unix()/30)
+
+	tests := []struct {
+		name        string
+		skew        int
+		offset      time.Duration
+		expectValid bool
+	}{
+		{name: "current step", expectValid: true},
+		{name: "previous step within the skew", skew: 1, offset: 30 * time.Second, expectValid: true},
+		{name: "next step within the skew", skew: 1, offset: -30 * time.Second, expectValid: true},
+		{name: "previous step without skew", offset: 30 * time.Second},
+		{name: "beyond the skew", skew: 1, offset: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := New(Config{Digits: 8, Skew: tt.skew})
+			if _, ok := service.Verify(rfcSecret, code, at.Add(tt.offset)); ok != tt.expectValid {
+				t.Errorf("expected valid %v, got %v", tt.expectValid, ok)
+			}
+		})
+	}
+}
+
+func TestService_GenerateSecretAndURI(t *testing.T) {
+	service := New(Config{})
+	secret, err := service.GenerateSecret()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now := time.Now()
+	code := service.code(mustDecode(t, secret), now.Unix()/30)
+	if _, ok := service.Verify(secret, code, now); !ok {
+		t.Error("expected a code of the generated secret to be valid")
+	}
+
+	uri, err := url.Parse(service.URI(secret, "Finance Go", "validUser"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || !strings.HasSuffix(uri.Path, "Finance Go:validUser") {
+		t.Errorf("unexpected URI %s", uri)
+	}
+	if uri.Query().Get("secret") != secret || uri.Query().Get("digits") != "6" || uri.Query().Get("period") != "30" {
+		t.Errorf("unexpected URI parameters %s", uri.RawQuery)
+	}
+}
+
+func mustDecode(t *testing.T, secret string) []byte {
+	key, err := encoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return key
+}